@@ -0,0 +1,527 @@
+// Package bundle exports a case to a single portable archive and imports it
+// into another SPECTRE database.
+//
+// A bundle is a zip file holding case.json (the case row, entities,
+// relationships, evidence metadata and analyses), the evidence files under
+// evidence/, and manifest.json listing the SHA-256 of every other entry. The
+// manifest may be signed with an ed25519 key; the signature is stored in
+// manifest.sig.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/custody"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+// FormatVersion is the bundle layout version written to the manifest.
+const FormatVersion = 1
+
+const (
+	manifestName  = "manifest.json"
+	signatureName = "manifest.sig"
+	dataName      = "case.json"
+)
+
+// ErrTampered is returned when a bundle's contents do not match its manifest
+// or its signature does not verify.
+var ErrTampered = errors.New("bundle failed integrity check")
+
+// Manifest describes a bundle's contents.
+type Manifest struct {
+	Format    int       `json:"format"`
+	Tool      string    `json:"tool"`
+	CaseID    string    `json:"case_id"`
+	CaseName  string    `json:"case_name"`
+	CreatedAt time.Time `json:"created_at"`
+	Files     []File    `json:"files"`
+	SignerKey string    `json:"signer_key,omitempty"` // base64 ed25519 public key
+}
+
+// File is a manifest entry.
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// caseData is the content of case.json. Evidence file paths point into the
+// bundle.
+type caseData struct {
+	Case          *core.Case           `json:"case"`
+	Entities      []*core.Entity       `json:"entities"`
+	Relationships []*core.Relationship `json:"relationships"`
+	Evidence      []*core.Evidence     `json:"evidence"`
+	Analyses      []*core.Analysis     `json:"analyses"`
+}
+
+// Export writes caseID to a bundle at out. If key is non-nil the manifest is
+// signed with it. Evidence files must still match their recorded hashes.
+func Export(caseID, out string, key ed25519.PrivateKey) (m *Manifest, err error) {
+	c, err := storage.GetCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("case not found: %s", caseID)
+	}
+
+	data := &caseData{Case: c}
+	if data.Entities, err = storage.ListEntitiesByCase(caseID); err != nil {
+		return nil, err
+	}
+	if data.Relationships, err = storage.ListRelationshipsByCase(caseID); err != nil {
+		return nil, err
+	}
+	if data.Analyses, err = storage.ListAnalysesByCase(caseID); err != nil {
+		return nil, err
+	}
+	evidence, err := storage.ListEvidenceByCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(out)
+		}
+	}()
+
+	zw := zip.NewWriter(f)
+	m = &Manifest{
+		Format:    FormatVersion,
+		Tool:      "spectre/" + core.Version,
+		CaseID:    c.ID,
+		CaseName:  c.Name,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	for _, ev := range evidence {
+		exported := *ev
+		if ev.FilePath != "" {
+			exported.FilePath = path.Join("evidence", ev.ID, filepath.Base(ev.FilePath))
+			entry, err := addFile(zw, exported.FilePath, ev.FilePath)
+			if err != nil {
+				return nil, err
+			}
+			if entry.SHA256 != ev.FileHash {
+				return nil, fmt.Errorf("evidence file %s no longer matches its recorded hash; run 'spectre evidence verify'", ev.FilePath)
+			}
+			m.Files = append(m.Files, entry)
+		}
+		data.Evidence = append(data.Evidence, &exported)
+	}
+
+	dataJSON, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal case: %w", err)
+	}
+	entry, err := addBytes(zw, dataName, dataJSON)
+	if err != nil {
+		return nil, err
+	}
+	m.Files = append(m.Files, entry)
+
+	if key != nil {
+		m.SignerKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	}
+	manifestJSON, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if _, err := addBytes(zw, manifestName, manifestJSON); err != nil {
+		return nil, err
+	}
+	if key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifestJSON))
+		if _, err := addBytes(zw, signatureName, []byte(sig)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	if err := custody.RecordExport(caseID, "bundle", out); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ImportOptions controls which bundles Import accepts.
+type ImportOptions struct {
+	// TrustedKeys, if set, restricts imports to bundles signed by one of
+	// these keys.
+	TrustedKeys []ed25519.PublicKey
+	// RequireSignature rejects unsigned bundles.
+	RequireSignature bool
+}
+
+// ImportResult describes an imported case. The signer's key travels in the
+// manifest, so a signature by an untrusted key does not show the bundle is
+// unmodified: anyone can re-sign a bundle with a key of their own.
+type ImportResult struct {
+	Case          *core.Case
+	Manifest      *Manifest
+	Signer        string // Fingerprint of the signing key, empty if unsigned
+	Trusted       bool   // Signer is one of ImportOptions.TrustedKeys
+	Entities      int
+	Relationships int
+	Evidence      int
+	Analyses      int
+}
+
+// Import validates the bundle at src and stores its case under fresh IDs.
+// Nothing is written unless the whole bundle verifies, and a failure while
+// storing it leaves nothing behind.
+func Import(src string, opts ImportOptions) (*ImportResult, error) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*zip.File)
+	for _, zf := range zr.File {
+		if !validName(zf.Name) {
+			return nil, fmt.Errorf("%w: invalid entry name %q", ErrTampered, zf.Name)
+		}
+		if _, dup := entries[zf.Name]; dup {
+			return nil, fmt.Errorf("%w: duplicate entry %q", ErrTampered, zf.Name)
+		}
+		entries[zf.Name] = zf
+	}
+
+	manifestJSON, err := readEntry(entries, manifestName)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(manifestJSON, &m); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %v", ErrTampered, err)
+	}
+	if m.Format != FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format %d (expected %d)", m.Format, FormatVersion)
+	}
+
+	res := &ImportResult{Manifest: &m}
+	if res.Signer, res.Trusted, err = checkSignature(entries, &m, manifestJSON, opts); err != nil {
+		return nil, err
+	}
+
+	// Every entry must be listed with a matching hash, and vice versa
+	listed := map[string]File{}
+	for _, mf := range m.Files {
+		zf, ok := entries[mf.Path]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is listed in the manifest but missing", ErrTampered, mf.Path)
+		}
+		sum, size, err := hashEntry(zf)
+		if err != nil {
+			return nil, err
+		}
+		if sum != mf.SHA256 || size != mf.Size {
+			return nil, fmt.Errorf("%w: %s does not match the manifest", ErrTampered, mf.Path)
+		}
+		listed[mf.Path] = mf
+	}
+	for name := range entries {
+		if _, ok := listed[name]; !ok && name != manifestName && name != signatureName {
+			return nil, fmt.Errorf("%w: %s is not listed in the manifest", ErrTampered, name)
+		}
+	}
+
+	if _, ok := listed[dataName]; !ok {
+		return nil, fmt.Errorf("%w: manifest does not cover %s", ErrTampered, dataName)
+	}
+	dataJSON, err := readEntry(entries, dataName)
+	if err != nil {
+		return nil, err
+	}
+	var data caseData
+	if err := json.Unmarshal(dataJSON, &data); err != nil {
+		return nil, fmt.Errorf("%w: invalid %s: %v", ErrTampered, dataName, err)
+	}
+	if err := data.validate(&m, listed); err != nil {
+		return nil, err
+	}
+
+	return res, data.store(res, entries, src)
+}
+
+// validate checks that case.json is consistent with itself and the manifest
+// before anything is written.
+func (d *caseData) validate(m *Manifest, listed map[string]File) error {
+	if d.Case == nil || d.Case.ID != m.CaseID {
+		return fmt.Errorf("%w: case does not match the manifest", ErrTampered)
+	}
+
+	entities := make(map[string]bool)
+	for _, e := range d.Entities {
+		entities[e.ID] = true
+	}
+	evidence := make(map[string]bool)
+	for _, ev := range d.Evidence {
+		evidence[ev.ID] = true
+		if ev.FilePath == "" {
+			continue
+		}
+		mf, ok := listed[ev.FilePath]
+		if !ok || !strings.HasPrefix(ev.FilePath, "evidence/") {
+			return fmt.Errorf("%w: evidence %s refers to an unlisted file", ErrTampered, ev.ID)
+		}
+		if mf.SHA256 != ev.FileHash {
+			return fmt.Errorf("%w: evidence %s hash differs from its file", ErrTampered, ev.ID)
+		}
+	}
+	for _, r := range d.Relationships {
+		if !entities[r.FromEntityID] || !entities[r.ToEntityID] {
+			return fmt.Errorf("%w: relationship %s refers to an unknown entity", ErrTampered, r.ID)
+		}
+		if r.EvidenceID != "" && !evidence[r.EvidenceID] {
+			return fmt.Errorf("%w: relationship %s refers to unknown evidence", ErrTampered, r.ID)
+		}
+	}
+	return nil
+}
+
+// store writes the case under new IDs and copies its evidence files into
+// custody.Root. The rows go in one transaction; if any write fails nothing
+// is kept and the copied files are removed.
+func (d *caseData) store(res *ImportResult, entries map[string]*zip.File, src string) error {
+	hash, err := custody.HashFile(src)
+	if err != nil {
+		return err
+	}
+
+	sourceID := d.Case.ID
+	c := *d.Case
+	c.ID = uuid.New().String()
+	dir := filepath.Join(custody.Root, c.ID)
+
+	err = storage.WithTx(func(tx *storage.Tx) error {
+		if err := tx.CreateCase(&c); err != nil {
+			return err
+		}
+
+		entityIDs := make(map[string]string)
+		for _, e := range d.Entities {
+			old := e.ID
+			e.ID, e.CaseID = uuid.New().String(), c.ID
+			if err := tx.CreateEntity(e); err != nil {
+				return err
+			}
+			entityIDs[old] = e.ID
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		evidenceIDs := make(map[string]string)
+		for _, ev := range d.Evidence {
+			old := ev.ID
+			ev.ID, ev.CaseID, ev.EntityID = uuid.New().String(), c.ID, entityIDs[ev.EntityID]
+			if ev.FilePath != "" {
+				dest := filepath.Join(dir, path.Base(ev.FilePath))
+				if _, err := os.Stat(dest); err == nil {
+					dest = filepath.Join(dir, ev.ID[:8]+"_"+path.Base(ev.FilePath))
+				}
+				if err := extract(entries[ev.FilePath], dest); err != nil {
+					return err
+				}
+				ev.FilePath = dest
+			}
+			if err := tx.CreateEvidence(ev); err != nil {
+				return err
+			}
+			evidenceIDs[old] = ev.ID
+		}
+
+		for _, r := range d.Relationships {
+			r.ID, r.CaseID = uuid.New().String(), c.ID
+			r.FromEntityID, r.ToEntityID = entityIDs[r.FromEntityID], entityIDs[r.ToEntityID]
+			r.EvidenceID = evidenceIDs[r.EvidenceID]
+			if err := tx.CreateRelationship(r); err != nil {
+				return err
+			}
+		}
+
+		for _, a := range d.Analyses {
+			a.ID, a.CaseID = uuid.New().String(), c.ID
+			if err := tx.SaveAnalysis(a); err != nil {
+				return err
+			}
+		}
+
+		details := map[string]string{"source_case": sourceID, "bundle": src}
+		if res.Signer != "" {
+			details["signer"] = res.Signer
+			details["signer_trusted"] = strconv.FormatBool(res.Trusted)
+		}
+		return tx.RecordCustody(c.ID, "", core.CustodyImported, hash, details)
+	})
+	if err != nil {
+		// The directory is named after the new case, so it holds only our copies
+		os.RemoveAll(dir)
+		return fmt.Errorf("failed to import case: %w", err)
+	}
+
+	res.Case = &c
+	res.Entities = len(d.Entities)
+	res.Evidence = len(d.Evidence)
+	res.Relationships = len(d.Relationships)
+	res.Analyses = len(d.Analyses)
+	return nil
+}
+
+// checkSignature verifies the manifest signature and returns the signer's
+// fingerprint and whether it is one of the trusted keys.
+func checkSignature(entries map[string]*zip.File, m *Manifest, manifestJSON []byte, opts ImportOptions) (string, bool, error) {
+	if _, signed := entries[signatureName]; !signed {
+		// A manifest naming a signer was signed; the signature was removed
+		if m.SignerKey != "" {
+			return "", false, fmt.Errorf("%w: manifest names a signer but the signature is missing", ErrTampered)
+		}
+		if opts.RequireSignature || len(opts.TrustedKeys) > 0 {
+			return "", false, fmt.Errorf("bundle is not signed")
+		}
+		return "", false, nil
+	}
+
+	raw, err := readEntry(entries, signatureName)
+	if err != nil {
+		return "", false, err
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return "", false, fmt.Errorf("%w: invalid signature encoding", ErrTampered)
+	}
+	pub, err := base64.StdEncoding.DecodeString(m.SignerKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return "", false, fmt.Errorf("%w: invalid signer key", ErrTampered)
+	}
+	if !ed25519.Verify(pub, manifestJSON, sig) {
+		return "", false, fmt.Errorf("%w: signature does not verify", ErrTampered)
+	}
+
+	signer := Fingerprint(pub)
+	for _, k := range opts.TrustedKeys {
+		if bytes.Equal(k, pub) {
+			return signer, true, nil
+		}
+	}
+	if len(opts.TrustedKeys) > 0 {
+		return "", false, fmt.Errorf("bundle is signed by untrusted key %s", signer)
+	}
+	return signer, false, nil
+}
+
+// validName rejects absolute paths and traversal in entry names.
+func validName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return false
+	}
+	return path.Clean(name) == name && name != ".." && !strings.HasPrefix(name, "../")
+}
+
+func addFile(zw *zip.Writer, name, src string) (File, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to read evidence file: %w", err)
+	}
+	defer in.Close()
+	return addReader(zw, name, in)
+}
+
+func addBytes(zw *zip.Writer, name string, data []byte) (File, error) {
+	return addReader(zw, name, bytes.NewReader(data))
+}
+
+func addReader(zw *zip.Writer, name string, r io.Reader) (File, error) {
+	w, err := zw.Create(name)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return File{Path: name, SHA256: hex.EncodeToString(h.Sum(nil)), Size: n}, nil
+}
+
+func readEntry(entries map[string]*zip.File, name string) ([]byte, error) {
+	zf, ok := entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is missing", ErrTampered, name)
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrTampered, name, err)
+	}
+	return data, nil
+}
+
+func hashEntry(zf *zip.File) (string, int64, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read %s: %w", zf.Name, err)
+	}
+	defer rc.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, rc)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s: %v", ErrTampered, zf.Name, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+func extract(zf *zip.File, dest string) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", zf.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write evidence file: %w", err)
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write evidence file: %w", err)
+	}
+	return out.Close()
+}
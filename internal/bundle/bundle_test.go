@@ -0,0 +1,226 @@
+package bundle
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/custody"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+func setupCase(t *testing.T) *core.Case {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB, oldRoot := storage.DB, custody.Root
+	storage.DB = db
+	custody.Root = t.TempDir()
+	t.Cleanup(func() {
+		storage.DB, custody.Root = oldDB, oldRoot
+		db.Close()
+	})
+	if err := storage.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &core.Case{Name: "Bundle"}
+	if err := storage.CreateCase(c); err != nil {
+		t.Fatal(err)
+	}
+	domain := &core.Entity{CaseID: c.ID, Type: core.EntityDomain, Value: "example.com"}
+	ip := &core.Entity{CaseID: c.ID, Type: core.EntityIP, Value: "192.0.2.1"}
+	storage.CreateEntity(domain)
+	storage.CreateEntity(ip)
+
+	dir := filepath.Join(custody.Root, c.ID)
+	os.MkdirAll(dir, 0755)
+	path := filepath.Join(dir, "dns_example.com.json")
+	os.WriteFile(path, []byte(`{"a": ["192.0.2.1"]}`), 0644)
+	hash, _ := custody.HashFile(path)
+	ev := &core.Evidence{CaseID: c.ID, EntityID: domain.ID, Collector: "dns", FilePath: path, FileHash: hash}
+	if err := storage.CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	storage.CreateRelationship(&core.Relationship{CaseID: c.ID, FromEntityID: domain.ID, ToEntityID: ip.ID, Type: "resolves_to", EvidenceID: ev.ID})
+	storage.SaveAnalysis(&core.Analysis{CaseID: c.ID, Findings: []string{"one host"}})
+	return c
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	c := setupCase(t)
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	out := filepath.Join(t.TempDir(), "case.zip")
+	m, err := Export(c.ID, out, priv)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if len(m.Files) != 2 || m.SignerKey == "" {
+		t.Errorf("Unexpected manifest: %+v", m)
+	}
+
+	res, err := Import(out, ImportOptions{TrustedKeys: []ed25519.PublicKey{pub}})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if res.Case.ID == c.ID || res.Case.Name != c.Name {
+		t.Errorf("Expected a renamed copy of the case, got %+v", res.Case)
+	}
+	if res.Entities != 2 || res.Relationships != 1 || res.Evidence != 1 || res.Analyses != 1 || res.Signer != Fingerprint(pub) || !res.Trusted {
+		t.Errorf("Unexpected import result: %+v", res)
+	}
+
+	rels, _ := storage.ListRelationshipsByCase(res.Case.ID)
+	evidence, _ := storage.ListEvidenceByCase(res.Case.ID)
+	if len(rels) != 1 || len(evidence) != 1 || rels[0].EvidenceID != evidence[0].ID {
+		t.Fatalf("Relationship was not remapped to the imported evidence: %+v", rels)
+	}
+	if from, _ := storage.GetEntity(rels[0].FromEntityID); from == nil || from.CaseID != res.Case.ID {
+		t.Errorf("Relationship still points at the source case's entities")
+	}
+
+	verification, err := custody.Verify(res.Case.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.OK() {
+		t.Errorf("Imported case does not verify: %+v", verification.Issues)
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := Import(out, ImportOptions{TrustedKeys: []ed25519.PublicKey{other}}); err == nil {
+		t.Error("Expected a bundle signed by an untrusted key to be refused")
+	}
+}
+
+func TestImportRefusesTamperedBundle(t *testing.T) {
+	c := setupCase(t)
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "case.zip")
+	if _, err := Export(c.ID, out, priv); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := filepath.Join(dir, "tampered.zip")
+	rewrite(t, out, tampered, func(name string, data []byte) []byte {
+		if filepath.Ext(name) == ".json" && name != manifestName && name != dataName {
+			return []byte(`{"a": ["203.0.113.66"]}`)
+		}
+		return data
+	})
+	if _, err := Import(tampered, ImportOptions{}); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected ErrTampered for a modified evidence file, got %v", err)
+	}
+
+	unsigned := filepath.Join(dir, "unsigned.zip")
+	rewrite(t, out, unsigned, func(name string, data []byte) []byte {
+		if name == signatureName {
+			return nil
+		}
+		return data
+	})
+	if _, err := Import(unsigned, ImportOptions{}); !errors.Is(err, ErrTampered) {
+		t.Errorf("Expected ErrTampered for a signed manifest without its signature, got %v", err)
+	}
+
+	cases, _ := storage.ListCases()
+	if len(cases) != 1 {
+		t.Errorf("Refused imports must not write anything, found %d cases", len(cases))
+	}
+}
+
+func TestImportRollsBackOnFailure(t *testing.T) {
+	c := setupCase(t)
+	out := filepath.Join(t.TempDir(), "case.zip")
+	if _, err := Export(c.ID, out, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail after the case, entities and evidence file have been written
+	if _, err := storage.DB.Exec(`CREATE TRIGGER links_down BEFORE INSERT ON relationships BEGIN SELECT RAISE(ABORT, 'disk I/O error'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(out, ImportOptions{}); err == nil {
+		t.Fatal("Expected the failed relationship insert to fail the import")
+	}
+
+	cases, _ := storage.ListCases()
+	if len(cases) != 1 {
+		t.Errorf("Expected a failed import to leave no case behind, found %d cases", len(cases))
+	}
+	var rows int
+	storage.DB.QueryRow(`SELECT (SELECT COUNT(*) FROM entities) + (SELECT COUNT(*) FROM evidence) + (SELECT COUNT(*) FROM custody_events WHERE case_id != ?)`, c.ID).Scan(&rows)
+	if rows != 2+1 {
+		t.Errorf("Expected only the source case's rows, found %d", rows)
+	}
+	if dirs, _ := os.ReadDir(custody.Root); len(dirs) != 1 {
+		t.Errorf("Expected the copied evidence files to be removed, found %d case directories", len(dirs))
+	}
+}
+
+func TestImportDoesNotTrustSelfSignedBundle(t *testing.T) {
+	c := setupCase(t)
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "case.zip")
+	if _, err := Export(c.ID, out, priv); err != nil {
+		t.Fatal(err)
+	}
+	res, err := Import(out, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if res.Signer == "" || res.Trusted {
+		t.Errorf("Expected an untrusted signer without --trust, got %q trusted=%v", res.Signer, res.Trusted)
+	}
+
+	unsigned := filepath.Join(dir, "unsigned.zip")
+	if _, err := Export(c.ID, unsigned, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(unsigned, ImportOptions{RequireSignature: true}); err == nil {
+		t.Error("Expected an unsigned bundle to be refused when a signature is required")
+	}
+}
+
+// rewrite copies a zip, passing each entry through edit; a nil result drops it.
+func rewrite(t *testing.T, src, dst string, edit func(name string, data []byte) []byte) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, zf := range zr.File {
+		rc, _ := zf.Open()
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if data = edit(zf.Name, data); data == nil {
+			continue
+		}
+		w, _ := zw.Create(zf.Name)
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var asnsCmd = &cobra.Command{
+	Use:   "asns",
+	Short: "Show which autonomous systems (hosting providers) a case's addresses sit on",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if caseID == "" {
+			ctxID, err := LoadContext()
+			if err == nil && ctxID != "" {
+				caseID = ctxID
+				fmt.Printf("Using current case: %s\n", caseID)
+			}
+		}
+
+		if caseID == "" {
+			return fmt.Errorf("case ID is required (use --case)")
+		}
+
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		clusters, err := storage.ASNClusters(caseID)
+		if err != nil {
+			return err
+		}
+
+		if len(clusters) == 0 {
+			fmt.Printf("No ASN data found for case %s (run the asn collector on its IPs)\n", caseID)
+			return nil
+		}
+
+		fmt.Printf("Autonomous systems for case %s:\n", caseID)
+		fmt.Println("--------------------------------------------------------------------------------")
+		for _, c := range clusters {
+			fmt.Printf("%-10s %-40s %d IPs, %d domains\n", c.ASN, c.Name, len(c.IPs), len(c.Domains))
+			for _, block := range c.Netblocks {
+				fmt.Printf("    netblock %s\n", block)
+			}
+			for _, ip := range c.IPs {
+				fmt.Printf("    ip       %s\n", ip)
+			}
+			for _, domain := range c.Domains {
+				fmt.Printf("    domain   %s\n", domain)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	asnsCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID (required)")
+	rootCmd.AddCommand(asnsCmd)
+}
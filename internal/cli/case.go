@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"crypto/ed25519"
 	"fmt"
 
+	"github.com/spectre/spectre/internal/bundle"
 	"github.com/spectre/spectre/internal/core"
 	"github.com/spectre/spectre/internal/storage"
 	"github.com/spf13/cobra"
@@ -42,7 +44,122 @@ var newCaseCmd = &cobra.Command{
 	},
 }
 
+var (
+	bundleOutput     string
+	bundleSignKey    string
+	bundleTrustKeys  []string
+	bundleRequireSig bool
+)
+
+var exportCaseCmd = &cobra.Command{
+	Use:   "export [case-id]",
+	Short: "Export a case and its evidence to a portable bundle",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		var key ed25519.PrivateKey
+		if bundleSignKey != "" {
+			k, err := bundle.LoadPrivateKey(bundleSignKey)
+			if err != nil {
+				return err
+			}
+			key = k
+		}
+
+		out := bundleOutput
+		if out == "" {
+			out = fmt.Sprintf("case_%s.zip", args[0])
+		}
+
+		m, err := bundle.Export(args[0], out, key)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Exported case '%s' to %s (%d files)\n", m.CaseName, out, len(m.Files))
+		if key != nil {
+			fmt.Printf("Signed with key %s\n", bundle.Fingerprint(key.Public().(ed25519.PublicKey)))
+		} else {
+			fmt.Println("Bundle is unsigned; use --sign-key to let recipients verify its origin.")
+		}
+		return nil
+	},
+}
+
+var importCaseCmd = &cobra.Command{
+	Use:   "import [bundle]",
+	Short: "Import a case bundle under new IDs",
+	Long: `Import verifies every file in the bundle against its manifest and, if the
+manifest is signed, the signature. Tampered bundles are refused. The case,
+its entities, relationships, evidence and analyses receive new IDs so a
+bundle can be imported into any database, including the one it came from.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		opts := bundle.ImportOptions{RequireSignature: bundleRequireSig}
+		for _, path := range bundleTrustKeys {
+			k, err := bundle.LoadPublicKey(path)
+			if err != nil {
+				return err
+			}
+			opts.TrustedKeys = append(opts.TrustedKeys, k)
+		}
+
+		res, err := bundle.Import(args[0], opts)
+		if err != nil {
+			return err
+		}
+
+		if err := SaveContext(res.Case.ID); err != nil {
+			fmt.Printf("Warning: failed to save context: %v\n", err)
+		}
+
+		signer := "unsigned"
+		switch {
+		case res.Trusted:
+			signer = "signed by trusted key " + res.Signer
+		case res.Signer != "":
+			signer = "signed by unverified key " + res.Signer
+		}
+		fmt.Printf("Imported case '%s' (ID: %s, %s)\n", res.Case.Name, res.Case.ID, signer)
+		if !res.Trusted {
+			fmt.Println("Warning: the bundle's origin is not verified; anyone can re-sign a modified bundle. Pass --trust <key.pub> with the sender's public key to verify it.")
+		}
+		fmt.Printf("  %d entities, %d relationships, %d evidence items, %d analyses\n", res.Entities, res.Relationships, res.Evidence, res.Analyses)
+		return nil
+	},
+}
+
+var keygenCaseCmd = &cobra.Command{
+	Use:   "keygen [path]",
+	Short: "Generate an ed25519 key pair for signing case bundles",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pub, err := bundle.GenerateKey(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote private key to %s and public key to %s.pub\n", args[0], args[0])
+		fmt.Printf("Fingerprint: %s\n", bundle.Fingerprint(pub))
+		return nil
+	},
+}
+
 func init() {
+	exportCaseCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Bundle path (default: case_<id>.zip)")
+	exportCaseCmd.Flags().StringVar(&bundleSignKey, "sign-key", "", "ed25519 private key used to sign the manifest")
+	importCaseCmd.Flags().StringSliceVar(&bundleTrustKeys, "trust", nil, "Only accept bundles signed by this public key (repeatable)")
+	importCaseCmd.Flags().BoolVar(&bundleRequireSig, "require-signature", false, "Refuse unsigned bundles")
+
 	caseCmd.AddCommand(newCaseCmd)
+	caseCmd.AddCommand(exportCaseCmd)
+	caseCmd.AddCommand(importCaseCmd)
+	caseCmd.AddCommand(keygenCaseCmd)
 	rootCmd.AddCommand(caseCmd)
 }
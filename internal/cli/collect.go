@@ -1,19 +1,37 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"sync"
+	"time"
 
 	"github.com/spectre/spectre/internal/collector"
+	_ "github.com/spectre/spectre/internal/collector/asn"    // Register ASN
+	_ "github.com/spectre/spectre/internal/collector/breach" // Register Breach
+	_ "github.com/spectre/spectre/internal/collector/ct"     // Register CT
 	_ "github.com/spectre/spectre/internal/collector/dns"    // Register DNS
+	_ "github.com/spectre/spectre/internal/collector/email"  // Register Email
+	_ "github.com/spectre/spectre/internal/collector/pdns"   // Register Reverse DNS
+	_ "github.com/spectre/spectre/internal/collector/rdap"   // Register RDAP
 	_ "github.com/spectre/spectre/internal/collector/whois"  // Register WHOIS
 	_ "github.com/spectre/spectre/internal/collector/github" // Register GitHub
 	_ "github.com/spectre/spectre/internal/collector/geo"    // Register GeoIP
 	_ "github.com/spectre/spectre/internal/collector/active" // Register Active Probes
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/jobs"
 	"github.com/spectre/spectre/internal/storage"
 	"github.com/spf13/cobra"
 )
 
+var (
+	collectTimeout time.Duration
+	targetType     string
+	detach         bool
+)
+
 var collectCmd = &cobra.Command{
 	Use:   "collect [collector|all] [target]",
 	Short: "Run a passive collector (or all) against a target",
@@ -39,11 +57,18 @@ var collectCmd = &cobra.Command{
 			return err
 		}
 
+		entityType := targetType
+		if entityType == "" {
+			entityType = core.InferEntityType(target)
+		}
+
 		var collectorsToRun []string
+		skippedActive := false
 		if collectorName == "all" {
-			for _, c := range collector.List() {
+			for _, c := range collector.Applicable(entityType) {
 				// Skip active collectors if not allowed
 				if c.IsActive() && !activeAllowed {
+					skippedActive = true
 					continue
 				}
 				collectorsToRun = append(collectorsToRun, c.Name())
@@ -51,45 +76,91 @@ var collectCmd = &cobra.Command{
 		} else {
 			collectorsToRun = []string{collectorName}
 		}
+		// A pool without job IDs would claim any queued job, other cases' included
+		if len(collectorsToRun) == 0 {
+			if skippedActive {
+				return fmt.Errorf("no applicable collectors for %s '%s' (active collectors need --active)", entityType, target)
+			}
+			return fmt.Errorf("no applicable collectors for %s '%s'", entityType, target)
+		}
 
-		var wg sync.WaitGroup
-		var printMu sync.Mutex
-
-		fmt.Printf("Starting collection against '%s' with %d collectors...\n", target, len(collectorsToRun))
+		// Ctrl-C cancels every running collector instead of killing the process
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
 
+		jobsCfg := jobs.LoadConfig()
+		var jobIDs []string
 		for _, name := range collectorsToRun {
-			wg.Add(1)
-			go func(cName string) {
-				defer wg.Done()
-
-				// Execute
-				evidenceList, err := collector.Run(cName, caseID, target, activeAllowed)
-				
-				printMu.Lock()
-				defer printMu.Unlock()
-
-				if err != nil {
-					fmt.Printf("[X] %s: Failed - %v\n", cName, err)
-					return
-				}
+			job, err := jobs.Enqueue(caseID, name, target, core.CollectOptions{
+				ActiveAllowed: activeAllowed,
+				EntityType:    entityType,
+				Timeout:       collectTimeout,
+			}, jobsCfg.MaxAttempts)
+			if err != nil {
+				return err
+			}
+			jobIDs = append(jobIDs, job.ID)
+		}
 
-				fmt.Printf("[+] %s: Completed (%d evidence items)\n", cName, len(evidenceList))
-
-				for _, ev := range evidenceList {
-					if err := storage.CreateEvidence(&ev); err != nil {
-						fmt.Printf("    - Failed to save evidence: %v\n", err)
-						continue
-					}
-					// fmt.Printf("    - Saved: %s\n", ev.FilePath) // Reduced verbosity for "fluidity"
-					
-					if err := storage.IngestEvidence(&ev); err != nil {
-						fmt.Printf("    - Warning: ingestion failed: %v\n", err)
-					}
-				}
-			}(name)
+		if detach {
+			fmt.Printf("Queued %d job(s) against %s '%s'. Run 'spectre jobs work' or 'spectre server' to execute them.\n", len(jobIDs), entityType, target)
+			for i, id := range jobIDs {
+				fmt.Printf("    %s  %s\n", id, collectorsToRun[i])
+			}
+			return nil
 		}
 
-		wg.Wait()
+		fmt.Printf("Starting collection against %s '%s' with %d collectors...\n", entityType, target, len(collectorsToRun))
+
+		// Run this command's jobs in-process, one worker per collector
+		var printMu sync.Mutex
+		jobsCfg.Workers = len(jobIDs)
+		pool := jobs.NewPool(jobsCfg, jobIDs...)
+		pool.OnProgress = func(job *core.Job, done, total int, message string) {
+			printMu.Lock()
+			defer printMu.Unlock()
+			if total > 0 {
+				fmt.Printf("    [%s] %d/%d %s\n", job.Collector, done, total, message)
+			} else {
+				fmt.Printf("    [%s] %s\n", job.Collector, message)
+			}
+		}
+		pool.OnFinish = func(job *core.Job) {
+			printMu.Lock()
+			defer printMu.Unlock()
+			switch job.Status {
+			case core.JobSucceeded:
+				fmt.Printf("[+] %s: Completed (%d evidence items)\n", job.Collector, job.EvidenceCount)
+			case core.JobQueued:
+				fmt.Printf("[~] %s: %s, retrying at %s\n", job.Collector, job.LastError, job.RunAfter.Local().Format("15:04:05"))
+			case core.JobFailed:
+				fmt.Printf("[X] %s: Failed - %s\n", job.Collector, job.LastError)
+			}
+		}
+
+		poolCtx, stopPool := context.WithCancel(ctx)
+		poolDone := make(chan struct{})
+		go func() {
+			defer close(poolDone)
+			if err := pool.Run(poolCtx); err != nil {
+				fmt.Printf("Worker pool error: %v\n", err)
+			}
+		}()
+
+		_, waitErr := jobs.Wait(ctx, jobIDs, 200*time.Millisecond)
+		stopPool()
+		<-poolDone
+
+		if ctx.Err() != nil {
+			for _, id := range jobIDs {
+				storage.CancelJob(id) // Already finished jobs are left as they are
+			}
+			fmt.Println("Collection interrupted.")
+			return nil
+		}
+		if waitErr != nil {
+			return waitErr
+		}
 		fmt.Println("Collection complete.")
 		return nil
 	},
@@ -97,5 +168,8 @@ var collectCmd = &cobra.Command{
 
 func init() {
 	collectCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID (required)")
+	collectCmd.Flags().StringVarP(&targetType, "type", "t", "", "Entity type of the target (domain, ip, email, username, url); inferred if omitted")
+	collectCmd.Flags().DurationVar(&collectTimeout, "timeout", 0, "Per-collector deadline (e.g. 30s, 5m); 0 disables")
+	collectCmd.Flags().BoolVar(&detach, "detach", false, "Queue the jobs and return without running them")
 	rootCmd.AddCommand(collectCmd)
 }
@@ -18,9 +18,21 @@ var consoleCmd = &cobra.Command{
 			return err
 		}
 
-		// Start Server in Background
+		// Jobs queued from the dashboard run in this process
+		stop := startJobWorkers()
+		defer stop()
+
+		// Start Server in Background. The dashboard link carries a token
+		// that is only valid while this console is running.
+		opts := server.LoadOptions()
+		token, err := server.NewSessionToken()
+		if err != nil {
+			return err
+		}
+		opts.SessionToken = token
+		tui.DashboardURL = opts.URL() + "/?token=" + opts.SessionToken
 		go func() {
-			if err := server.Start(8080); err != nil {
+			if err := server.Start(opts); err != nil {
 				fmt.Printf("Server error: %v\n", err)
 			}
 		}()
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var noBackup bool
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the SPECTRE database schema",
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations",
+	Long:  `Apply every pending schema migration. Unless --no-backup is given, a copy of the database is written to <path>.bak-<timestamp> first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.OpenDB(); err != nil {
+			return err
+		}
+
+		applied, err := storage.Migrate(!noBackup)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database schema is up to date.")
+			return nil
+		}
+
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	},
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending schema migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.OpenDB(); err != nil {
+			return err
+		}
+
+		migrations, err := storage.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		fmt.Printf("%-8s %-30s %s\n", "VERSION", "NAME", "APPLIED")
+		for _, m := range migrations {
+			applied := "pending"
+			if m.AppliedAt != nil {
+				applied = m.AppliedAt.Format("2006-01-02 15:04:05")
+			} else {
+				pending++
+			}
+			fmt.Printf("%-8s %-30s %s\n", fmt.Sprintf("%04d", m.Version), m.Name, applied)
+		}
+		fmt.Printf("\n%d migration(s), %d pending\n", len(migrations), pending)
+		return nil
+	},
+}
+
+func init() {
+	dbMigrateCmd.Flags().BoolVar(&noBackup, "no-backup", false, "Skip the pre-migration database backup")
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbStatusCmd)
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spectre/spectre/internal/custody"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var evidenceCmd = &cobra.Command{
+	Use:   "evidence",
+	Short: "Inspect evidence and its chain of custody",
+}
+
+var evidenceVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Rehash evidence files and check the custody chain",
+	Long: `Verify recomputes the SHA-256 of every evidence file and compares it with the
+stored evidence hash and the hash logged at collection time, then checks that
+the case's custody log is an unbroken hash chain. Without --case every case is
+verified. Exits non-zero if any case fails.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		ids := []string{caseID}
+		if caseID == "" {
+			cases, err := storage.ListCases()
+			if err != nil {
+				return err
+			}
+			ids = ids[:0]
+			for _, c := range cases {
+				ids = append(ids, c.ID)
+			}
+		}
+
+		failed := 0
+		for _, id := range ids {
+			res, err := custody.Verify(id)
+			if err != nil {
+				return fmt.Errorf("failed to verify case %s: %w", id, err)
+			}
+
+			status := "OK"
+			if !res.OK() {
+				status = "FAILED"
+				failed++
+			}
+			fmt.Printf("[%s] case %s: %d custody entries, %d files checked\n", status, id, res.Events, res.FilesChecked)
+			for _, is := range res.Issues {
+				fmt.Printf("    ERROR %-9s %s%s\n", is.Kind, is.Detail, pathSuffix(is.Path))
+			}
+			for _, is := range res.Warnings {
+				fmt.Printf("    WARN  %-9s %s%s\n", is.Kind, is.Detail, pathSuffix(is.Path))
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d cases failed verification", failed, len(ids))
+		}
+		fmt.Printf("\n%d cases verified.\n", len(ids))
+		return nil
+	},
+}
+
+var evidenceLogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the custody log of a case",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if caseID == "" {
+			ctxID, err := LoadContext()
+			if err == nil && ctxID != "" {
+				caseID = ctxID
+				fmt.Printf("Using current case: %s\n", caseID)
+			}
+		}
+
+		if caseID == "" {
+			return fmt.Errorf("case ID is required (use --case)")
+		}
+
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		events, err := storage.ListCustodyEvents(caseID)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			fmt.Printf("No custody events for case %s\n", caseID)
+			return nil
+		}
+
+		fmt.Printf("%-4s | %-19s | %-9s | %-12s | %-36s | %s\n", "SEQ", "TIME", "ACTION", "ACTOR", "EVIDENCE", "HASH")
+		fmt.Println("--------------------------------------------------------------------------------------------------------------------")
+		for _, e := range events {
+			fmt.Printf("%-4d | %-19s | %-9s | %-12s | %-36s | %s\n", e.Seq, e.Timestamp.Local().Format("2006-01-02 15:04:05"),
+				e.Action, e.Actor, e.EvidenceID, e.Hash[:16])
+		}
+		return nil
+	},
+}
+
+func pathSuffix(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return ""
+	}
+	return " (" + path + ")"
+}
+
+func init() {
+	evidenceVerifyCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID (default: all cases)")
+	evidenceLogCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID")
+	evidenceCmd.AddCommand(evidenceVerifyCmd)
+	evidenceCmd.AddCommand(evidenceLogCmd)
+	rootCmd.AddCommand(evidenceCmd)
+}
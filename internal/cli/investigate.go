@@ -1,21 +1,33 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/spectre/spectre/internal/analysis"
-	"github.com/spectre/spectre/internal/collector"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/pivot"
 	"github.com/spectre/spectre/internal/storage"
 	"github.com/spf13/cobra"
 )
 
+var (
+	pivotDepth   int
+	pivotMaxRuns int
+)
+
 var investigateCmd = &cobra.Command{
 	Use:   "investigate [target]",
 	Short: "Automated end-to-end investigation (One-Shot)",
-	Args:  cobra.ExactArgs(1),
+	Long: `Creates a case for the target and recursively pivots on every entity that
+collection discovers (IPs from DNS, emails from WHOIS, usernames from GitHub...)
+up to the configured depth and run budget. Active collectors only run with --active.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		target := args[0]
 		
@@ -39,21 +51,43 @@ var investigateCmd = &cobra.Command{
 		}
 		fmt.Printf("[+] Created Case: %s (ID: %s)\n", newCase.Name, caseID)
 
-		// 2. Run Collectors (Default set)
-		collectors := []string{"dns", "whois", "geo", "ports"}
-		fmt.Printf("[*] Running collectors: %v\n", collectors)
-		
-		for _, name := range collectors {
-			ev, err := collector.Run(name, caseID, target, true)
-			if err != nil {
-				fmt.Printf("    [!] %s failed: %v\n", name, err)
-				continue
-			}
-			fmt.Printf("    [+] %s: %d items\n", name, len(ev))
-			for _, e := range ev {
-				storage.CreateEvidence(&e)
-				storage.IngestEvidence(&e)
+		// 2. Seed entity and recursive pivot
+		seed, err := pivot.EnsureSeed(caseID, target)
+		if err != nil {
+			return err
+		}
+
+		cfg := pivot.LoadConfig()
+		if cmd.Flags().Changed("depth") {
+			cfg.MaxDepth = pivotDepth
+		}
+		if cmd.Flags().Changed("max-runs") {
+			cfg.MaxRuns = pivotMaxRuns
+		}
+		cfg.ActiveAllowed = activeAllowed
+
+		fmt.Printf("[*] Pivoting from %s '%s' (depth %d, budget %d runs)\n", seed.Type, seed.Value, cfg.MaxDepth, cfg.MaxRuns)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		engine := pivot.NewEngine(cfg)
+		engine.OnStep = func(s pivot.Step) {
+			indent := strings.Repeat("  ", s.Depth)
+			if s.Err != nil {
+				fmt.Printf("    %s[!] %s(%s) failed: %v\n", indent, s.Collector, s.Target, s.Err)
+				return
 			}
+			fmt.Printf("    %s[+] %s(%s): %d items, %d new entities\n", indent, s.Collector, s.Target, s.Evidence, len(s.NewEntities))
+		}
+
+		summary, err := engine.Run(ctx, caseID, seed)
+		if err != nil {
+			return fmt.Errorf("investigation interrupted: %w", err)
+		}
+		fmt.Printf("[*] Pivot complete: %d collector runs, %d new entities\n", summary.Runs, summary.NewEntities)
+		if summary.BudgetExhausted {
+			fmt.Println("[!] Run budget exhausted; increase --max-runs to expand further")
 		}
 
 		// 3. Analyze
@@ -83,5 +117,7 @@ var investigateCmd = &cobra.Command{
 }
 
 func init() {
+	investigateCmd.Flags().IntVar(&pivotDepth, "depth", 2, "Maximum pivot depth from the target (overrides pivot.max_depth)")
+	investigateCmd.Flags().IntVar(&pivotMaxRuns, "max-runs", 50, "Collector run budget for the case (overrides pivot.max_runs)")
 	rootCmd.AddCommand(investigateCmd)
 }
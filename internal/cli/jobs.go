@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/jobs"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	jobStatus  string
+	jobWorkers int
+)
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect and manage queued collection jobs",
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List collection jobs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		list, err := storage.ListJobs(caseID, jobStatus)
+		if err != nil {
+			return err
+		}
+
+		if len(list) == 0 {
+			fmt.Println("No jobs found.")
+			return nil
+		}
+
+		fmt.Printf("%-36s | %-10s | %-12s | %-30s | %-8s | %s\n", "ID", "STATUS", "COLLECTOR", "TARGET", "ATTEMPTS", "DETAIL")
+		fmt.Println("----------------------------------------------------------------------------------------------------------------------")
+		for _, j := range list {
+			detail := j.LastError
+			switch j.Status {
+			case core.JobSucceeded:
+				detail = fmt.Sprintf("%d evidence items", j.EvidenceCount)
+			case core.JobQueued:
+				if j.Attempts > 0 {
+					detail = fmt.Sprintf("retry at %s (%s)", j.RunAfter.Local().Format("15:04:05"), j.LastError)
+				}
+			}
+			fmt.Printf("%-36s | %-10s | %-12s | %-30s | %d/%-6d | %s\n", j.ID, j.Status, j.Collector, j.Target, j.Attempts, j.MaxAttempts, detail)
+		}
+
+		return nil
+	},
+}
+
+var jobsCancelCmd = &cobra.Command{
+	Use:   "cancel [job-id]",
+	Short: "Cancel a queued or running job",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		if err := storage.CancelJob(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Cancelled job %s\n", args[0])
+		return nil
+	},
+}
+
+var jobsRetryCmd = &cobra.Command{
+	Use:   "retry [job-id]",
+	Short: "Requeue a failed or cancelled job",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		if err := storage.RetryJob(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Requeued job %s\n", args[0])
+		return nil
+	},
+}
+
+var jobsWorkCmd = &cobra.Command{
+	Use:   "work",
+	Short: "Run a worker pool that executes queued jobs until interrupted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		cfg := jobs.LoadConfig()
+		if jobWorkers > 0 {
+			cfg.Workers = jobWorkers
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		pool := jobs.NewPool(cfg)
+		pool.OnFinish = func(job *core.Job) {
+			fmt.Printf("[%s] %s %s: %s\n", job.Status, job.Collector, job.Target, job.ID)
+		}
+
+		fmt.Printf("Processing jobs with %d workers (Ctrl-C to stop)...\n", cfg.Workers)
+		return pool.Run(ctx)
+	},
+}
+
+func init() {
+	jobsListCmd.Flags().StringVarP(&caseID, "case", "c", "", "Only show jobs for this case")
+	jobsListCmd.Flags().StringVar(&jobStatus, "status", "", "Only show jobs in this state (queued, running, succeeded, failed, cancelled)")
+	jobsWorkCmd.Flags().IntVarP(&jobWorkers, "workers", "w", 0, "Number of concurrent workers (defaults to jobs.workers)")
+
+	jobsCmd.AddCommand(jobsListCmd)
+	jobsCmd.AddCommand(jobsCancelCmd)
+	jobsCmd.AddCommand(jobsRetryCmd)
+	jobsCmd.AddCommand(jobsWorkCmd)
+	rootCmd.AddCommand(jobsCmd)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/collector/pdns"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pdnsStore  string
+	pdnsFormat string
+)
+
+var pdnsCmd = &cobra.Command{
+	Use:   "pdns",
+	Short: "Manage the local passive DNS store",
+}
+
+var pdnsImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import passive DNS observations (COF JSON lines, or CSV with a header row)",
+	Long: `Merge passive DNS observations from a sensor export into the local store.
+CSV input needs rrname, rrtype and rdata columns; time_first, time_last and
+count are optional. Observations already in the store have their time window
+widened and their counts added up.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := pdnsFormat
+		if format == "" {
+			format = pdns.FormatFor(args[0])
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", args[0], err)
+		}
+		defer f.Close()
+
+		store, err := pdns.OpenStore(pdnsStorePath(), true)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		n, err := store.Import(f, format)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d observations from %s into %s\n", n, args[0], store.Path())
+		return nil
+	},
+}
+
+var pdnsLookupCmd = &cobra.Command{
+	Use:   "lookup [ip]",
+	Short: "List the names seen resolving to an IP address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := pdns.OpenStore(pdnsStorePath(), false)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		records, err := store.ByIP(args[0])
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Printf("No names seen on %s.\n", args[0])
+			return nil
+		}
+
+		fmt.Printf("%-40s | %-5s | %-16s | %-16s | %-8s\n", "NAME", "TYPE", "FIRST SEEN", "LAST SEEN", "COUNT")
+		fmt.Println("--------------------------------------------------------------------------------------------------")
+		for _, r := range records {
+			fmt.Printf("%-40s | %-5s | %-16s | %-16s | %-8d\n", r.RRName, r.RRType, r.FirstSeen.Format("2006-01-02 15:04"), r.LastSeen.Format("2006-01-02 15:04"), r.Count)
+		}
+		return nil
+	},
+}
+
+func pdnsStorePath() string {
+	if pdnsStore != "" {
+		return pdnsStore
+	}
+	return pdns.StorePath()
+}
+
+func init() {
+	pdnsCmd.PersistentFlags().StringVar(&pdnsStore, "store", "", "Passive DNS store file (default collectors.reverse.store)")
+	pdnsImportCmd.Flags().StringVar(&pdnsFormat, "format", "", "Input format: csv or cof (default from the file extension)")
+	pdnsCmd.AddCommand(pdnsImportCmd)
+	pdnsCmd.AddCommand(pdnsLookupCmd)
+	rootCmd.AddCommand(pdnsCmd)
+}
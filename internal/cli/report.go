@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/spectre/spectre/internal/custody"
 	"github.com/spectre/spectre/internal/report"
 	"github.com/spectre/spectre/internal/storage"
 	"github.com/spf13/cobra"
@@ -37,6 +38,10 @@ var reportCmd = &cobra.Command{
 			return fmt.Errorf("failed to save report: %w", err)
 		}
 
+		if err := custody.RecordExport(caseID, "markdown", outputPath); err != nil {
+			fmt.Printf("Warning: failed to record export in custody log: %v\n", err)
+		}
+
 		fmt.Printf("Report successfully generated: %s\n", outputPath)
 		fmt.Println("\n--- PREVIEW ---")
 		
@@ -1,14 +1,22 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/jobs"
 	"github.com/spectre/spectre/internal/server"
 	"github.com/spectre/spectre/internal/storage"
 	"github.com/spf13/cobra"
 )
 
-var port int
+var (
+	port      int
+	bindAddr  string
+	enableTLS bool
+)
 
 var serverCmd = &cobra.Command{
 	Use:   "server",
@@ -18,12 +26,52 @@ var serverCmd = &cobra.Command{
 			return err
 		}
 
+		stop := startJobWorkers()
+		defer stop()
+
+		opts := server.LoadOptions()
+		if cmd.Flags().Changed("port") {
+			opts.Port = port
+		}
+		if cmd.Flags().Changed("bind") {
+			opts.Bind = bindAddr
+		}
+		if cmd.Flags().Changed("tls") {
+			opts.TLS = enableTLS
+		}
+		if !opts.AuthDisabled {
+			fmt.Println("API requests need a token: create one with 'spectre token create <name>'.")
+			fmt.Printf("Open the dashboard with %s/?token=<token>\n", opts.URL())
+		}
+
 		fmt.Printf("Launching Web Command Center...\n")
-		return server.Start(port)
+		return server.Start(opts)
 	},
 }
 
+// startJobWorkers runs the pool that executes the collection jobs queued
+// through the API, broadcasting each result to the dashboard. Every command
+// that serves the API needs one, or queued jobs never run.
+func startJobWorkers() (stop func()) {
+	pool := jobs.NewPool(jobs.LoadConfig())
+	pool.OnFinish = func(job *core.Job) {
+		server.Broadcast(map[string]interface{}{
+			"type": "job_updated",
+			"data": job,
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := pool.Run(ctx); err != nil {
+			log.Error().Err(err).Msg("Job worker pool stopped")
+		}
+	}()
+	return cancel
+}
+
 func init() {
 	serverCmd.Flags().IntVarP(&port, "port", "p", 8080, "Port to listen on")
+	serverCmd.Flags().StringVar(&bindAddr, "bind", "127.0.0.1", "Address to listen on (use 0.0.0.0 to expose the server)")
+	serverCmd.Flags().BoolVar(&enableTLS, "tls", false, "Serve HTTPS (a self-signed certificate is generated if none is configured)")
 	rootCmd.AddCommand(serverCmd)
 }
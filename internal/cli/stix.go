@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/custody"
+	"github.com/spectre/spectre/internal/stix"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var stixOutput string
+
+var stixCmd = &cobra.Command{
+	Use:   "stix",
+	Short: "Exchange case graphs as STIX 2.1 bundles",
+}
+
+var stixExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a case's entities and relationships as a STIX 2.1 bundle",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if caseID == "" {
+			ctxID, err := LoadContext()
+			if err == nil && ctxID != "" {
+				caseID = ctxID
+			}
+		}
+
+		if caseID == "" {
+			return fmt.Errorf("case ID is required (use --case)")
+		}
+
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		b, err := stix.ExportCase(caseID)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if stixOutput == "" {
+			fmt.Println(string(data))
+			return nil
+		}
+		if err := os.WriteFile(stixOutput, data, 0644); err != nil {
+			return fmt.Errorf("failed to save STIX bundle: %w", err)
+		}
+		if err := custody.RecordExport(caseID, "stix", stixOutput); err != nil {
+			fmt.Printf("Warning: failed to record export in custody log: %v\n", err)
+		}
+		fmt.Printf("Exported %d STIX objects to %s\n", len(b.Objects), stixOutput)
+		return nil
+	},
+}
+
+var stixImportCmd = &cobra.Command{
+	Use:   "import [bundle.json]",
+	Short: "Import observables and relationships from a STIX 2.1 bundle into a case",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if caseID == "" {
+			ctxID, err := LoadContext()
+			if err == nil && ctxID != "" {
+				caseID = ctxID
+				fmt.Printf("Using current case: %s\n", caseID)
+			}
+		}
+
+		if caseID == "" {
+			return fmt.Errorf("case ID is required (use --case)")
+		}
+
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		b, err := stix.ReadBundle(f)
+		if err != nil {
+			return err
+		}
+		res, err := stix.ImportBundle(caseID, b)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Imported %d entities and %d relationships (%d objects skipped)\n", res.Entities, res.Relationships, res.Skipped)
+		return nil
+	},
+}
+
+func init() {
+	stixExportCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID")
+	stixExportCmd.Flags().StringVarP(&stixOutput, "output", "o", "", "Write the bundle to a file instead of stdout")
+	stixImportCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID to import into")
+	stixCmd.AddCommand(stixExportCmd)
+	stixCmd.AddCommand(stixImportCmd)
+	rootCmd.AddCommand(stixCmd)
+}
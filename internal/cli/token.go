@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spectre/spectre/internal/server"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for the web server",
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Generate a new API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		secret, t, err := server.GenerateToken(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created token '%s' (ID: %s)\n\n", t.Name, t.ID)
+		fmt.Printf("    %s\n\n", secret)
+		fmt.Println("Store it now; it cannot be shown again.")
+		fmt.Println("Use it as 'Authorization: Bearer <token>' or open the dashboard with /?token=<token>.")
+		return nil
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List API tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		tokens, err := storage.ListAPITokens()
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			fmt.Println("No API tokens. Create one with 'spectre token create <name>'.")
+			return nil
+		}
+
+		fmt.Printf("%-36s | %-20s | %-12s | %-16s | %-16s\n", "ID", "NAME", "PREFIX", "LAST USED", "STATUS")
+		fmt.Println("--------------------------------------------------------------------------------------------------------------")
+		for _, t := range tokens {
+			lastUsed := "never"
+			if t.LastUsedAt != nil {
+				lastUsed = t.LastUsedAt.Format("2006-01-02 15:04")
+			}
+			status := "active"
+			if t.RevokedAt != nil {
+				status = "revoked " + t.RevokedAt.Format("2006-01-02")
+			}
+			fmt.Printf("%-36s | %-20s | %-12s | %-16s | %-16s\n", t.ID, t.Name, t.Prefix, lastUsed, status)
+		}
+		return nil
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke [id|prefix]",
+	Short: "Revoke an API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		if err := storage.RevokeAPIToken(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked token %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+	rootCmd.AddCommand(tokenCmd)
+}
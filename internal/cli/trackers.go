@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var trackersCmd = &cobra.Command{
+	Use:   "trackers",
+	Short: "Show clusters of sites sharing analytics or ad tracker IDs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if caseID == "" {
+			ctxID, err := LoadContext()
+			if err == nil && ctxID != "" {
+				caseID = ctxID
+				fmt.Printf("Using current case: %s\n", caseID)
+			}
+		}
+
+		if caseID == "" {
+			return fmt.Errorf("case ID is required (use --case)")
+		}
+
+		if err := storage.InitDB(); err != nil {
+			return err
+		}
+
+		clusters, err := storage.TrackerClusters(caseID)
+		if err != nil {
+			return err
+		}
+
+		if len(clusters) == 0 {
+			fmt.Printf("No shared tracker IDs found for case %s\n", caseID)
+			return nil
+		}
+
+		fmt.Printf("Shared-tracker clusters for case %s:\n", caseID)
+		fmt.Println("--------------------------------------------------------------------------------")
+		for i, c := range clusters {
+			fmt.Printf("[%d] %d sites via %s\n", i+1, len(c.Sites), strings.Join(c.Trackers, ", "))
+			for _, site := range c.Sites {
+				fmt.Printf("    %s\n", site)
+			}
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	trackersCmd.Flags().StringVarP(&caseID, "case", "c", "", "Case ID (required)")
+	rootCmd.AddCommand(trackersCmd)
+}
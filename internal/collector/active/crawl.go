@@ -0,0 +1,403 @@
+package active
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+// robotsAgent is the product token matched against robots.txt groups.
+const robotsAgent = "spectre"
+
+type CrawlCollector struct{}
+
+func init() {
+	collector.Register(&CrawlCollector{})
+}
+
+func (c *CrawlCollector) Name() string {
+	return "crawl"
+}
+
+func (c *CrawlCollector) Description() string {
+	return "Active web crawl extracting links, emails, phones, social profiles, trackers and JS endpoints (WARC evidence)"
+}
+
+func (c *CrawlCollector) IsActive() bool {
+	return true
+}
+
+func (c *CrawlCollector) Accepts() []string {
+	return []string{core.EntityDomain, core.EntityURL}
+}
+
+func (c *CrawlCollector) Produces() []string {
+	return []string{core.EntityDomain, core.EntityEmail, core.EntityPhone, core.EntityAccount, core.EntityURL}
+}
+
+func (c *CrawlCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// crawlItem is a queued URL. Scripts are fetched for endpoint extraction
+// whatever their depth.
+type crawlItem struct {
+	url    *neturl.URL
+	depth  int
+	script bool
+}
+
+// crawler holds the state of one crawl.
+type crawler struct {
+	client    *http.Client
+	warc      *warcWriter
+	scope     string // registrable host the crawl stays on
+	subdomain bool   // whether subdomains of scope are in scope
+	robots    map[string]*robotsRules
+	useRobots bool
+	maxBody   int64
+	result    *core.CrawlResult
+
+	domains, emails, phones, endpoints map[string]bool
+	social                             map[string]core.SocialLink
+	trackers                           map[string]core.TrackerID
+}
+
+func (c *CrawlCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	maxDepth, maxPages := 2, 50
+	if viper.IsSet("collectors.crawl.max_depth") {
+		maxDepth = viper.GetInt("collectors.crawl.max_depth")
+	}
+	if n := viper.GetInt("collectors.crawl.max_pages"); n > 0 {
+		maxPages = n
+	}
+	maxBody := int64(viper.GetInt("collectors.crawl.max_body"))
+	if maxBody <= 0 {
+		maxBody = 1 << 20
+	}
+
+	raw := target
+	if !strings.HasPrefix(raw, "http://") && !strings.HasPrefix(raw, "https://") {
+		raw = "https://" + target
+	}
+	start, err := neturl.Parse(raw)
+	if err != nil || start.Hostname() == "" {
+		return nil, fmt.Errorf("invalid crawl target %q", target)
+	}
+	if start.Path == "" {
+		start.Path = "/"
+	}
+
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+	safeTarget := strings.NewReplacer("://", "_", "/", "_", ":", "_", "\\", "_").Replace(target)
+	stamp := time.Now().Unix()
+	warcPath := filepath.Join(storageDir, fmt.Sprintf("crawl_%s_%d.warc.gz", safeTarget, stamp))
+	warcFile, err := os.Create(warcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create WARC file: %w", err)
+	}
+	defer warcFile.Close()
+
+	client := netclient.NewClient()
+	// Redirect targets are queued like links so each response is archived
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	cr := &crawler{
+		client:    client,
+		warc:      &warcWriter{w: warcFile},
+		scope:     strings.TrimPrefix(strings.ToLower(start.Hostname()), "www."),
+		subdomain: !viper.IsSet("collectors.crawl.include_subdomains") || viper.GetBool("collectors.crawl.include_subdomains"),
+		robots:    make(map[string]*robotsRules),
+		useRobots: !viper.IsSet("collectors.crawl.respect_robots") || viper.GetBool("collectors.crawl.respect_robots"),
+		maxBody:   maxBody,
+		result:    &core.CrawlResult{Target: target, StartURL: start.String(), MaxDepth: maxDepth, MaxPages: maxPages},
+		domains:   make(map[string]bool),
+		emails:    make(map[string]bool),
+		phones:    make(map[string]bool),
+		endpoints: make(map[string]bool),
+		social:    make(map[string]core.SocialLink),
+		trackers:  make(map[string]core.TrackerID),
+	}
+	robotsNote := "obey"
+	if !cr.useRobots {
+		robotsNote = "ignore"
+	}
+	if err := cr.warc.warcinfo(map[string]string{"software": "spectre crawl", "format": "WARC File Format 1.1", "robots": robotsNote, "description": "crawl of " + target}); err != nil {
+		return nil, fmt.Errorf("failed to write WARC file: %w", err)
+	}
+
+	queue := []crawlItem{{url: start}}
+	visited := map[string]bool{start.String(): true}
+	enqueue := func(u *neturl.URL, depth int, script bool) {
+		if !visited[u.String()] {
+			visited[u.String()] = true
+			queue = append(queue, crawlItem{url: u, depth: depth, script: script})
+		}
+	}
+
+	for len(queue) > 0 && len(cr.result.Pages) < maxPages {
+		if ctx.Err() != nil {
+			break
+		}
+		item := queue[0]
+		queue = queue[1:]
+
+		if !cr.allowed(ctx, item.url) {
+			cr.result.Disallowed = append(cr.result.Disallowed, item.url.String())
+			continue
+		}
+		page, content, location, body := cr.fetch(ctx, item)
+		if page == nil {
+			// Without an explicit scheme, fall back to plain HTTP like the http collector
+			if item.url == start && start.Scheme == "https" && !strings.HasPrefix(target, "https://") && ctx.Err() == nil {
+				plain := *start
+				plain.Scheme = "http"
+				start = &plain
+				cr.result.StartURL = start.String()
+				enqueue(start, 0, false)
+			}
+			continue
+		}
+		cr.result.Pages = append(cr.result.Pages, *page)
+
+		for _, t := range core.ExtractTrackers(body) {
+			if _, ok := cr.trackers[t.Type+t.ID]; !ok {
+				t.Page = page.URL
+				cr.trackers[t.Type+t.ID] = t
+			}
+		}
+		if location != nil && cr.inScope(location) {
+			enqueue(location, item.depth, item.script)
+		}
+		if content == nil {
+			if item.script || strings.Contains(page.ContentType, "javascript") {
+				cr.addEndpoints(extractEndpoints(body, item.url))
+			}
+			continue
+		}
+
+		for _, e := range append(content.emails, extractEmails(content.text)...) {
+			cr.emails[e] = true
+		}
+		for _, p := range append(content.phones, extractPhones(content.text)...) {
+			cr.phones[p] = true
+		}
+		for _, js := range content.inline {
+			cr.addEndpoints(extractEndpoints(js, item.url))
+		}
+		for _, s := range content.scripts {
+			if cr.inScope(s) {
+				enqueue(s, item.depth, true)
+			}
+		}
+		for _, link := range content.links {
+			if cr.inScope(link) {
+				if item.depth < maxDepth {
+					enqueue(link, item.depth+1, false)
+				}
+				continue
+			}
+			if sl, ok := socialLink(link); ok {
+				cr.social[sl.URL] = sl
+			}
+			if host := strings.ToLower(link.Hostname()); host != "" && net.ParseIP(host) == nil {
+				cr.domains[host] = true
+			}
+		}
+	}
+
+	if err := warcFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write WARC file: %w", err)
+	}
+	if len(cr.result.Pages) == 0 {
+		os.Remove(warcPath)
+		return nil, fmt.Errorf("crawl of %s fetched no pages", target)
+	}
+	cr.finish(warcPath)
+
+	data, err := json.MarshalIndent(cr.result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	filePath := filepath.Join(storageDir, fmt.Sprintf("crawl_%s_%d.json", safeTarget, stamp))
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "crawl",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"target":   target,
+			"pages":    len(cr.result.Pages),
+			"emails":   len(cr.result.Emails),
+			"trackers": len(cr.result.Trackers),
+			"warc":     warcPath,
+		},
+		RawData: cr.result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// inScope reports whether u is on the crawled host (or a subdomain of it
+// when include_subdomains is set).
+func (cr *crawler) inScope(u *neturl.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return host == cr.scope || (cr.subdomain && strings.HasSuffix(host, "."+cr.scope))
+}
+
+// allowed checks the ethics scope and, when enabled, robots.txt for u.
+func (cr *crawler) allowed(ctx context.Context, u *neturl.URL) bool {
+	if ok, _ := ethics.IsAllowed(u.Hostname()); !ok {
+		return false
+	}
+	if !cr.useRobots {
+		return true
+	}
+	origin := u.Scheme + "://" + u.Host
+	rules, ok := cr.robots[origin]
+	if !ok {
+		// A missing or unreadable robots.txt allows everything
+		if body, err := cr.fetchRaw(ctx, origin+"/robots.txt"); err == nil {
+			rules = parseRobots(body, robotsAgent)
+		}
+		cr.robots[origin] = rules
+	}
+	return rules.allowed(u.EscapedPath())
+}
+
+// fetchRaw GETs u, archives the exchange and returns the body of a 200.
+func (cr *crawler) fetchRaw(ctx context.Context, u string) (string, error) {
+	if err := ethics.Wait(ctx, "crawl"); err != nil {
+		return "", err
+	}
+	resp, err := get(ctx, cr.client, u)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, truncated, err := readCapped(resp.Body, cr.maxBody)
+	if err != nil {
+		return "", err
+	}
+	if err := cr.warc.exchange(resp, body, truncated); err != nil {
+		return "", fmt.Errorf("failed to write WARC file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	return string(body), nil
+}
+
+// fetch retrieves one queued URL. HTML pages are parsed into content; the
+// redirect target, if any, is returned as location.
+func (cr *crawler) fetch(ctx context.Context, item crawlItem) (page *core.CrawledPage, content *pageContent, location *neturl.URL, body string) {
+	if err := ethics.Wait(ctx, "crawl"); err != nil {
+		return nil, nil, nil, ""
+	}
+	resp, err := get(ctx, cr.client, item.url.String())
+	if err != nil {
+		return nil, nil, nil, ""
+	}
+	defer resp.Body.Close()
+	data, truncated, err := readCapped(resp.Body, cr.maxBody)
+	if err != nil {
+		return nil, nil, nil, ""
+	}
+	cr.warc.exchange(resp, data, truncated)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	page = &core.CrawledPage{URL: item.url.String(), StatusCode: resp.StatusCode, ContentType: mediaType, Depth: item.depth, Size: len(data)}
+	if loc := resp.Header.Get("Location"); loc != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if u, err := item.url.Parse(loc); err == nil {
+			u.Fragment = ""
+			location = u
+		}
+	}
+	body = string(data)
+	if !item.script && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		content = parsePage(item.url, body)
+		page.Title = content.title
+	}
+	return page, content, location, body
+}
+
+func (cr *crawler) addEndpoints(endpoints []string) {
+	for _, e := range endpoints {
+		cr.endpoints[e] = true
+	}
+}
+
+// finish copies the collected sets into the result in a stable order and
+// hashes the WARC file.
+func (cr *crawler) finish(warcPath string) {
+	cr.result.Domains = sortedKeys(cr.domains)
+	cr.result.Emails = sortedKeys(cr.emails)
+	cr.result.Phones = sortedKeys(cr.phones)
+	cr.result.Endpoints = sortedKeys(cr.endpoints)
+	for _, k := range sortedKeys(cr.social) {
+		cr.result.Social = append(cr.result.Social, cr.social[k])
+	}
+	for _, k := range sortedKeys(cr.trackers) {
+		cr.result.Trackers = append(cr.result.Trackers, cr.trackers[k])
+	}
+
+	cr.result.WARC = warcPath
+	if data, err := os.ReadFile(warcPath); err == nil {
+		sum := sha256.Sum256(data)
+		cr.result.WARCSHA256 = hex.EncodeToString(sum[:])
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// readCapped reads at most max bytes and reports whether there was more.
+func readCapped(r io.Reader, max int64) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil && len(data) == 0 {
+		return nil, false, err
+	}
+	if int64(len(data)) > max {
+		return data[:max], true, nil
+	}
+	return data, false, nil
+}
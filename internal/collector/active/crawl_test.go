@@ -0,0 +1,190 @@
+package active
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spf13/viper"
+)
+
+func TestCrawlCollector_Collect(t *testing.T) {
+	ethics.SetBlacklist(nil)
+	ethics.SetLimit("crawl", 10000)
+	t.Cleanup(func() { ethics.SetBlacklist([]string{".gov", ".mil", "localhost", "127.0.0.1"}) })
+	viper.Set("collectors.crawl.max_depth", 1)
+	defer viper.Set("collectors.crawl.max_depth", nil)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Acme</title>
+<script src="/static/app.js"></script>
+<script>gtag('config', 'G-ABC123XYZ'); var api = "/api/v1/users";</script>
+</head><body>
+<a href="/about">About</a> <a href="/private/admin">Admin</a> <a href="/old">Old</a>
+<a href="https://partner.example.org/deal">Partner</a>
+<a href="https://twitter.com/acmecorp">Twitter</a> <a href="https://twitter.com/intent/tweet?text=hi">Share</a>
+<a href="mailto:Sales@Acme.test?subject=hi">Mail us</a>
+<img src="logo@2x.png">
+</body></html>`)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/contact", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<p>Call +44 20 7946 0958 or write to press@acme.test</p><a href="/deeper">Too deep</a>`)
+	})
+	mux.HandleFunc("/contact", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="tel:+1-202-555-0143">Phone</a><script>(function(){ var s = 'UA-1234567-1'; })()</script>`)
+	})
+	mux.HandleFunc("/static/app.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/javascript")
+		fmt.Fprint(w, `fetch("/api/v2/orders?limit=10"); img.src = "/img/x.png"; const ext = "https://api.partner.example.org/graphql";`)
+	})
+	mux.HandleFunc("/private/admin", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("robots.txt disallowed path was fetched")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	caseID := "test_case_crawl"
+	defer os.RemoveAll(filepath.Join("evidence_storage", caseID))
+
+	evidence, err := (&CrawlCollector{}).CollectContext(t.Context(), caseID, server.URL, core.CollectOptions{})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	result := evidence[0].RawData.(*core.CrawlResult)
+
+	fetched := make(map[string]core.CrawledPage)
+	for _, p := range result.Pages {
+		fetched[strings.TrimPrefix(p.URL, server.URL)] = p
+	}
+	for _, path := range []string{"/", "/about", "/old", "/contact", "/static/app.js"} {
+		if _, ok := fetched[path]; !ok {
+			t.Errorf("Expected %s to be crawled, got %v", path, result.Pages)
+		}
+	}
+	if _, ok := fetched["/deeper"]; ok {
+		t.Error("Expected /deeper to be beyond max_depth")
+	}
+	if fetched["/"].Title != "Acme" || fetched["/old"].StatusCode != 301 {
+		t.Errorf("Unexpected page records: %+v %+v", fetched["/"], fetched["/old"])
+	}
+	if len(result.Disallowed) != 1 || !strings.HasSuffix(result.Disallowed[0], "/private/admin") {
+		t.Errorf("Expected /private/admin to be disallowed, got %v", result.Disallowed)
+	}
+
+	check := func(name string, got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+	check("domains", result.Domains, "partner.example.org", "twitter.com")
+	check("emails", result.Emails, "press@acme.test", "sales@acme.test")
+	check("phones", result.Phones, "+12025550143", "+442079460958")
+	check("endpoints", result.Endpoints, server.URL+"/api/v1/users", server.URL+"/api/v2/orders?limit=10", "https://api.partner.example.org/graphql")
+
+	if len(result.Social) != 1 || result.Social[0].Username != "acmecorp" || result.Social[0].Platform != "Twitter" {
+		t.Errorf("Expected one Twitter profile, got %+v", result.Social)
+	}
+	trackers := make(map[string]string)
+	for _, tr := range result.Trackers {
+		trackers[tr.Type] = tr.ID
+	}
+	if trackers["google_analytics_4"] != "G-ABC123XYZ" || trackers["google_analytics"] != "UA-1234567-1" {
+		t.Errorf("Unexpected trackers: %+v", result.Trackers)
+	}
+
+	// The WARC holds every exchange, robots.txt included
+	f, err := os.Open(result.WARC)
+	if err != nil {
+		t.Fatalf("WARC file missing: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	warc, _ := io.ReadAll(gz)
+	if n := strings.Count(string(warc), "WARC-Type: response"); n != len(result.Pages)+1 {
+		t.Errorf("Expected %d response records, got %d", len(result.Pages)+1, n)
+	}
+	if !strings.Contains(string(warc), "WARC-Target-URI: "+server.URL+"/robots.txt") || !strings.HasPrefix(string(warc), "WARC/1.1\r\nWARC-Type: warcinfo") {
+		t.Error("Expected a warcinfo record and the robots.txt exchange in the WARC")
+	}
+	if result.WARCSHA256 == "" {
+		t.Error("Expected the WARC file to be hashed")
+	}
+}
+
+func TestRobots(t *testing.T) {
+	rules := parseRobots(`
+User-agent: Googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /search
+Disallow: /*.pdf$
+Allow: /search/about
+# comment
+`, robotsAgent)
+	for path, want := range map[string]bool{
+		"/":               true,
+		"/search?q=x":     false,
+		"/search/about":   true,
+		"/files/a.pdf":    false,
+		"/files/a.pdf?v1": true,
+	} {
+		if got := rules.allowed(path); got != want {
+			t.Errorf("allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	specific := parseRobots("User-agent: *\nDisallow: /\n\nUser-agent: Spectre\nDisallow: /tmp\n", robotsAgent)
+	if !specific.allowed("/") || specific.allowed("/tmp/x") {
+		t.Error("Expected the group naming us to replace the * group")
+	}
+}
+
+func TestSocialLink(t *testing.T) {
+	tests := map[string]string{
+		"https://www.linkedin.com/in/jane-doe/":      "LinkedIn:jane-doe",
+		"https://www.youtube.com/@acme":              "YouTube:acme",
+		"https://github.com/acme/widgets":            "GitHub:acme",
+		"https://www.facebook.com/sharer.php?u=x":    "",
+		"https://www.linkedin.com/shareArticle?url=": "",
+		"https://example.com/acme":                   "",
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		sl, ok := socialLink(u)
+		got := ""
+		if ok {
+			got = sl.Platform + ":" + sl.Username
+		}
+		if got != want {
+			t.Errorf("socialLink(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
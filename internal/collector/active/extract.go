@@ -0,0 +1,269 @@
+package active
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spectre/spectre/internal/core"
+	"golang.org/x/net/html"
+)
+
+// pageContent is what the crawler takes from one HTML page.
+type pageContent struct {
+	title   string
+	links   []*url.URL // a, area, iframe, frame and form targets
+	scripts []*url.URL // external script sources
+	inline  []string   // inline script bodies
+	text    string     // visible text
+	emails  []string   // from mailto: links
+	phones  []string   // from tel: links
+}
+
+// parsePage tokenizes an HTML document and resolves its links against base.
+func parsePage(base *url.URL, body string) *pageContent {
+	pc := &pageContent{}
+	var text strings.Builder
+	resolve := func(ref string) *url.URL {
+		u, err := base.Parse(strings.TrimSpace(ref))
+		if err != nil {
+			return nil
+		}
+		u.Fragment = ""
+		return u
+	}
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	var inScript, inStyle, inTitle bool
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			pc.text = text.String()
+			return pc
+		case html.TextToken:
+			data := string(z.Text())
+			switch {
+			case inScript:
+				pc.inline = append(pc.inline, data)
+			case inTitle:
+				pc.title = strings.TrimSpace(data)
+			case !inStyle:
+				text.WriteString(data)
+				text.WriteByte(' ')
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script":
+				inScript = false
+			case "style":
+				inStyle = false
+			case "title":
+				inTitle = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			attrs := make(map[string]string)
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[string(k)] = string(v)
+			}
+			if tt == html.StartTagToken {
+				switch tag {
+				case "script":
+					inScript = attrs["src"] == ""
+				case "style":
+					inStyle = true
+				case "title":
+					inTitle = true
+				}
+			}
+
+			var ref string
+			switch tag {
+			case "a", "area":
+				ref = attrs["href"]
+			case "iframe", "frame":
+				ref = attrs["src"]
+			case "form":
+				ref = attrs["action"]
+			case "script":
+				if u := resolve(attrs["src"]); attrs["src"] != "" && u != nil {
+					pc.scripts = append(pc.scripts, u)
+				}
+			}
+			lower := strings.ToLower(ref)
+			switch {
+			case ref == "":
+			case strings.HasPrefix(lower, "mailto:"):
+				addr, _, _ := strings.Cut(ref[len("mailto:"):], "?")
+				if addr, err := url.PathUnescape(addr); err == nil {
+					pc.emails = append(pc.emails, extractEmails(addr)...)
+				}
+			case strings.HasPrefix(lower, "tel:"):
+				if phone, ok := normalizePhone(ref[len("tel:"):]); ok {
+					pc.phones = append(pc.phones, phone)
+				}
+			default:
+				if u := resolve(ref); u != nil && (u.Scheme == "http" || u.Scheme == "https") {
+					pc.links = append(pc.links, u)
+				}
+			}
+		}
+	}
+}
+
+var (
+	emailRe = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9](?:[a-zA-Z0-9\-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9\-]*[a-zA-Z0-9])?)*\.[a-zA-Z]{2,24}`)
+	// Only international numbers are taken from free text; anything looser
+	// matches dates, prices and order numbers.
+	phoneRe = regexp.MustCompile(`\+\d[\d\s().\-]{6,20}\d`)
+)
+
+// notEmailSuffixes are file extensions that look like TLDs in retina asset
+// names such as "logo@2x.png".
+var notEmailSuffixes = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".css", ".js"}
+
+// extractEmails finds email addresses in s, lowercased and deduplicated.
+func extractEmails(s string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range emailRe.FindAllString(s, -1) {
+		m = strings.ToLower(strings.Trim(m, "."))
+		skip := seen[m]
+		for _, suffix := range notEmailSuffixes {
+			if strings.HasSuffix(m, suffix) {
+				skip = true
+			}
+		}
+		if !skip {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// extractPhones finds international phone numbers in visible text.
+func extractPhones(text string) []string {
+	var out []string
+	for _, m := range phoneRe.FindAllString(text, -1) {
+		if phone, ok := normalizePhone(m); ok {
+			out = append(out, phone)
+		}
+	}
+	return out
+}
+
+// normalizePhone keeps the digits of a number and its leading +. Numbers
+// outside the E.164 length range are rejected.
+func normalizePhone(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	if strings.HasPrefix(s, "+") {
+		b.WriteByte('+')
+	}
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			digits++
+		}
+	}
+	return b.String(), digits >= 7 && digits <= 15
+}
+
+// socialPlatforms maps a host to its platform name and the path prefixes
+// that are not profiles.
+var socialPlatforms = map[string]struct {
+	name    string
+	ignored []string
+}{
+	"twitter.com":   {"Twitter", []string{"intent", "share", "home", "search", "hashtag", "i"}},
+	"x.com":         {"Twitter", []string{"intent", "share", "home", "search", "hashtag", "i"}},
+	"facebook.com":  {"Facebook", []string{"sharer", "sharer.php", "share.php", "dialog", "plugins", "tr", "login.php"}},
+	"instagram.com": {"Instagram", []string{"p", "explore", "reel"}},
+	"linkedin.com":  {"LinkedIn", []string{"shareArticle", "sharing", "feed"}},
+	"github.com":    {"GitHub", []string{"login", "features", "about", "pricing", "sponsors"}},
+	"youtube.com":   {"YouTube", []string{"watch", "embed", "results", "playlist"}},
+	"tiktok.com":    {"TikTok", []string{"tag", "music"}},
+	"t.me":          {"Telegram", []string{"share", "joinchat"}},
+	"reddit.com":    {"Reddit", []string{"submit", "search"}},
+	"pinterest.com": {"Pinterest", []string{"pin"}},
+	"medium.com":    {"Medium", []string{"m", "tag"}},
+}
+
+// socialLink recognises a link to a profile on a social platform.
+func socialLink(u *url.URL) (core.SocialLink, bool) {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	platform, ok := socialPlatforms[host]
+	if !ok {
+		return core.SocialLink{}, false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if segments[0] == "" {
+		return core.SocialLink{}, false
+	}
+	for _, ignored := range platform.ignored {
+		if strings.EqualFold(segments[0], ignored) {
+			return core.SocialLink{}, false
+		}
+	}
+
+	username := segments[0]
+	switch {
+	case host == "linkedin.com" && len(segments) > 1 && (segments[0] == "in" || segments[0] == "company"):
+		username = segments[1]
+	case host == "youtube.com" && len(segments) > 1 && (segments[0] == "channel" || segments[0] == "c" || segments[0] == "user"):
+		username = segments[1]
+	case host == "reddit.com" && len(segments) > 1 && (segments[0] == "user" || segments[0] == "u"):
+		username = segments[1]
+	case host == "linkedin.com", host == "youtube.com" && !strings.HasPrefix(username, "@"), host == "reddit.com":
+		return core.SocialLink{}, false
+	}
+	username = strings.TrimPrefix(username, "@")
+
+	canonical := url.URL{Scheme: "https", Host: host, Path: u.Path}
+	return core.SocialLink{Platform: platform.name, URL: strings.TrimSuffix(canonical.String(), "/"), Username: username}, true
+}
+
+// endpointRe matches quoted absolute URLs and root-relative paths in
+// JavaScript, in the spirit of LinkFinder.
+var endpointRe = regexp.MustCompile("[\"'`]((?:https?://[a-zA-Z0-9.\\-]+(?::\\d+)?)?/[a-zA-Z0-9_\\-.~%/{}:$]*(?:\\?[^\"'`\\s<>]*)?)[\"'`]")
+
+// staticExtensions are skipped when collecting endpoints.
+var staticExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true,
+	".css": true, ".woff": true, ".woff2": true, ".ttf": true, ".eot": true, ".map": true,
+}
+
+// extractEndpoints finds URLs and API paths referenced from JavaScript and
+// resolves them against base. Bare "/" and static assets are ignored.
+func extractEndpoints(js string, base *url.URL) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range endpointRe.FindAllStringSubmatch(js, -1) {
+		ref := m[1]
+		if strings.HasPrefix(ref, "//") || len(strings.Trim(ref, "/")) < 2 {
+			continue
+		}
+		u, err := base.Parse(ref)
+		if err != nil || staticExtensions[strings.ToLower(path.Ext(u.Path))] {
+			continue
+		}
+		if u.Path == "" || u.Path == "/" {
+			continue
+		}
+		s := u.String()
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
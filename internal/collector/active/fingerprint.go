@@ -0,0 +1,367 @@
+package active
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+//go:embed technologies/technologies.json
+var defaultTechnologies []byte
+
+// pattern is one signature regex with its Wappalyzer-style modifiers
+// ("regex\;version:\1\;confidence:50").
+type pattern struct {
+	re         *regexp.Regexp
+	version    string
+	confidence int
+}
+
+// signature describes how to recognise one technology. The JSON form follows
+// Wappalyzer's: cats, headers, cookies, html, scriptSrc, meta, url and
+// implies, plus favicon for mmh3 icon hashes.
+type signature struct {
+	Cats      []string          `json:"cats"`
+	Headers   map[string]string `json:"headers"`
+	Cookies   map[string]string `json:"cookies"` // keys match cookie names by prefix
+	HTML      []string          `json:"html"`
+	ScriptSrc []string          `json:"scriptSrc"`
+	Meta      map[string]string `json:"meta"`
+	URL       []string          `json:"url"`
+	Favicon   []int32           `json:"favicon"`
+	Implies   []string          `json:"implies"`
+
+	headers   map[string]*pattern
+	cookies   map[string]*pattern
+	html      []*pattern
+	scriptSrc []*pattern
+	meta      map[string]*pattern
+	url       []*pattern
+}
+
+// Fingerprints is a compiled technology signature database.
+type Fingerprints struct {
+	techs map[string]*signature
+	names []string // sorted, for deterministic output
+}
+
+// LoadFingerprints reads a signature database from path, or the bundled one
+// when path is empty.
+func LoadFingerprints(path string) (*Fingerprints, error) {
+	data := defaultTechnologies
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read technology signatures: %w", err)
+		}
+	}
+	return ParseFingerprints(data)
+}
+
+// ParseFingerprints decodes and compiles a signature database.
+func ParseFingerprints(data []byte) (*Fingerprints, error) {
+	var techs map[string]*signature
+	if err := json.Unmarshal(data, &techs); err != nil {
+		return nil, fmt.Errorf("failed to parse technology signatures: %w", err)
+	}
+
+	fp := &Fingerprints{techs: techs}
+	for name, sig := range techs {
+		fp.names = append(fp.names, name)
+		var err error
+		compileMap := func(in map[string]string, lower bool) map[string]*pattern {
+			out := make(map[string]*pattern, len(in))
+			for k, v := range in {
+				p, perr := compilePattern(v)
+				if perr != nil && err == nil {
+					err = perr
+				}
+				if lower {
+					k = strings.ToLower(k)
+				}
+				out[k] = p
+			}
+			return out
+		}
+		compileList := func(in []string) []*pattern {
+			var out []*pattern
+			for _, v := range in {
+				p, perr := compilePattern(v)
+				if perr != nil && err == nil {
+					err = perr
+				}
+				out = append(out, p)
+			}
+			return out
+		}
+		sig.headers = compileMap(sig.Headers, true)
+		sig.cookies = compileMap(sig.Cookies, false)
+		sig.meta = compileMap(sig.Meta, true)
+		sig.html = compileList(sig.HTML)
+		sig.scriptSrc = compileList(sig.ScriptSrc)
+		sig.url = compileList(sig.URL)
+		if err != nil {
+			return nil, fmt.Errorf("technology %s: %w", name, err)
+		}
+	}
+	sort.Strings(fp.names)
+	return fp, nil
+}
+
+func compilePattern(s string) (*pattern, error) {
+	parts := strings.Split(s, `\;`)
+	p := &pattern{confidence: 100}
+	for _, mod := range parts[1:] {
+		switch {
+		case strings.HasPrefix(mod, "version:"):
+			p.version = strings.TrimPrefix(mod, "version:")
+		case strings.HasPrefix(mod, "confidence:"):
+			c, err := strconv.Atoi(strings.TrimPrefix(mod, "confidence:"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid confidence in %q", s)
+			}
+			p.confidence = c
+		}
+	}
+	re, err := regexp.Compile("(?i)" + parts[0])
+	if err != nil {
+		return nil, err
+	}
+	p.re = re
+	return p, nil
+}
+
+// match reports whether p matches s and the version it extracts.
+func (p *pattern) match(s string) (bool, string) {
+	groups := p.re.FindStringSubmatch(s)
+	if groups == nil {
+		return false, ""
+	}
+	version := p.version
+	for i := len(groups) - 1; i >= 1; i-- {
+		version = strings.ReplaceAll(version, `\`+strconv.Itoa(i), groups[i])
+	}
+	return true, strings.TrimSpace(version)
+}
+
+// Page is what fingerprints are matched against.
+type Page struct {
+	URL         string
+	Headers     map[string]string // canonical names
+	Cookies     map[string]string // name -> value
+	HTML        string
+	ScriptSrc   []string
+	Meta        map[string]string // lowercase name -> content
+	FaviconMMH3 *int32
+}
+
+var (
+	scriptSrcRe = regexp.MustCompile(`(?is)<script[^>]+src\s*=\s*["']?([^"'\s>]+)`)
+	metaRe      = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaNameRe  = regexp.MustCompile(`(?is)\b(?:name|property)\s*=\s*["']([^"']+)["']`)
+	metaValueRe = regexp.MustCompile(`(?is)\bcontent\s*=\s*["']([^"']*)["']`)
+)
+
+// ParseHTML extracts script sources and meta tags from body.
+func ParseHTML(body string) (scripts []string, meta map[string]string) {
+	for _, m := range scriptSrcRe.FindAllStringSubmatch(body, -1) {
+		scripts = append(scripts, m[1])
+	}
+	meta = make(map[string]string)
+	for _, tag := range metaRe.FindAllString(body, -1) {
+		name := metaNameRe.FindStringSubmatch(tag)
+		content := metaValueRe.FindStringSubmatch(tag)
+		if name != nil && content != nil {
+			meta[strings.ToLower(name[1])] = content[1]
+		}
+	}
+	return scripts, meta
+}
+
+// Detect returns the technologies page matches, including implied ones.
+func (fp *Fingerprints) Detect(page *Page) []core.Technology {
+	found := make(map[string]*core.Technology)
+	add := func(name, version, matched string, confidence int) {
+		t, ok := found[name]
+		if !ok {
+			t = &core.Technology{Name: name, Categories: fp.techs[name].Cats}
+			found[name] = t
+		}
+		t.Confidence += confidence
+		if t.Confidence > 100 {
+			t.Confidence = 100
+		}
+		if len(version) > len(t.Version) {
+			t.Version = version
+		}
+		if matched != "" {
+			t.Matched = append(t.Matched, matched)
+		}
+	}
+
+	headers := make(map[string]string, len(page.Headers))
+	for k, v := range page.Headers {
+		headers[strings.ToLower(k)] = v
+	}
+
+	for _, name := range fp.names {
+		sig := fp.techs[name]
+		for header, p := range sig.headers {
+			if v, ok := headers[header]; ok {
+				if hit, version := p.match(v); hit {
+					add(name, version, "header:"+header, p.confidence)
+				}
+			}
+		}
+		for cookie, p := range sig.cookies {
+			for n, v := range page.Cookies {
+				if strings.HasPrefix(n, cookie) {
+					if hit, version := p.match(v); hit {
+						add(name, version, "cookie:"+n, p.confidence)
+						break
+					}
+				}
+			}
+		}
+		for metaName, p := range sig.meta {
+			if v, ok := page.Meta[metaName]; ok {
+				if hit, version := p.match(v); hit {
+					add(name, version, "meta:"+metaName, p.confidence)
+				}
+			}
+		}
+		for _, p := range sig.html {
+			if hit, version := p.match(page.HTML); hit {
+				add(name, version, "html", p.confidence)
+				break
+			}
+		}
+		for _, p := range sig.scriptSrc {
+			hit := false
+			for _, src := range page.ScriptSrc {
+				var version string
+				if hit, version = p.match(src); hit {
+					add(name, version, "script:"+src, p.confidence)
+					break
+				}
+			}
+			if hit {
+				break
+			}
+		}
+		for _, p := range sig.url {
+			if hit, version := p.match(page.URL); hit {
+				add(name, version, "url", p.confidence)
+				break
+			}
+		}
+		if page.FaviconMMH3 != nil {
+			for _, h := range sig.Favicon {
+				if h == *page.FaviconMMH3 {
+					add(name, "", "favicon", 100)
+					break
+				}
+			}
+		}
+	}
+
+	// Implied technologies inherit the confidence of what implies them
+	for changed := true; changed; {
+		changed = false
+		for _, name := range fp.names {
+			t, ok := found[name]
+			if !ok {
+				continue
+			}
+			for _, implied := range fp.techs[name].Implies {
+				target, confidence := implied, t.Confidence
+				if i := strings.Index(implied, `\;confidence:`); i >= 0 {
+					target = implied[:i]
+					confidence, _ = strconv.Atoi(implied[i+len(`\;confidence:`):])
+				}
+				if _, known := fp.techs[target]; !known {
+					continue
+				}
+				if _, ok := found[target]; !ok {
+					add(target, "", "implied:"+name, confidence)
+					changed = true
+				}
+			}
+		}
+	}
+
+	out := make([]core.Technology, 0, len(found))
+	for _, name := range fp.names {
+		if t, ok := found[name]; ok {
+			out = append(out, *t)
+		}
+	}
+	return out
+}
+
+// FaviconHash computes the Shodan-style favicon hash: MurmurHash3 (x86,
+// 32-bit, seed 0) over the base64 encoding with a newline every 76
+// characters, as Python's base64.encodebytes produces.
+func FaviconHash(data []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76])
+		b.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded)
+	b.WriteByte('\n')
+	return int32(murmur3([]byte(b.String()), 0))
+}
+
+// murmur3 is MurmurHash3_x86_32.
+func murmur3(data []byte, seed uint32) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	h := seed
+	n := len(data) / 4
+	for i := 0; i < n; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	tail := data[n*4:]
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -1,19 +1,28 @@
 package active
 
 import (
+	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"html"
+	"io"
+	"net/http"
+	neturl "net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/spectre/spectre/internal/collector"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
 	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
 )
 
 type HTTPCollector struct{}
@@ -27,55 +36,128 @@ func (c *HTTPCollector) Name() string {
 }
 
 func (c *HTTPCollector) Description() string {
-	return "Active HTTP service discovery (Headers, Title)"
+	return "Active HTTP service discovery (redirects, headers, cookies, favicon hash, technology fingerprints)"
 }
 
 func (c *HTTPCollector) IsActive() bool {
 	return true
 }
 
+func (c *HTTPCollector) Accepts() []string {
+	return []string{core.EntityDomain, core.EntityIP, core.EntityURL}
+}
+
+func (c *HTTPCollector) Produces() []string {
+	return []string{core.EntityTechnology}
+}
+
 func (c *HTTPCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// securityHeaders are reported when missing from the final response.
+var securityHeaders = []string{"Strict-Transport-Security", "Content-Security-Policy", "X-Frame-Options", "X-Content-Type-Options", "Referrer-Policy", "Permissions-Policy"}
+
+var (
+	titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	iconRe  = regexp.MustCompile(`(?is)<link\s[^>]*rel\s*=\s*["'][^"']*icon[^"']*["'][^>]*>`)
+	hrefRe  = regexp.MustCompile(`(?is)\bhref\s*=\s*["']([^"']+)["']`)
+)
+
+func (c *HTTPCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	maxBody := int64(viper.GetInt("collectors.http.max_body"))
+	if maxBody <= 0 {
+		maxBody = 2 << 20
+	}
+
+	client := netclient.NewClient()
+	// Redirects are followed by hand so each hop is recorded and scoped
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
 	// Try HTTPS first
 	url := target
 	if !strings.HasPrefix(url, "http") {
 		url = "https://" + target
 	}
 
-	client := netclient.NewClient()
-
-	resp, err := client.Get(url)
+	result := &core.HTTPResult{Target: target}
+	resp, cookies, err := fetch(ctx, client, url, result)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("http request aborted: %w", ctx.Err())
+		}
+		if strings.HasPrefix(target, "http") {
+			return nil, fmt.Errorf("http request failed: %w", err)
+		}
 		// Fallback to HTTP
 		url = "http://" + target
-		resp, err = client.Get(url)
+		result = &core.HTTPResult{Target: target}
+		resp, cookies, err = fetch(ctx, client, url, result)
 		if err != nil {
 			return nil, fmt.Errorf("http request failed: %w", err)
 		}
 	}
 	defer resp.Body.Close()
 
-	results := make(map[string]interface{})
-	results["url"] = url
-	results["status_code"] = resp.StatusCode
-	
-	headers := make(map[string]string)
+	result.URL = resp.Request.URL.String()
+	result.StatusCode = resp.StatusCode
+	result.Headers = make(map[string]string)
 	for k, v := range resp.Header {
-		headers[k] = strings.Join(v, ", ")
+		result.Headers[k] = strings.Join(v, ", ")
+	}
+	for _, h := range securityHeaders {
+		if resp.Header.Get(h) == "" {
+			result.MissingHeaders = append(result.MissingHeaders, h)
+		}
 	}
-	results["headers"] = headers
+
+	cookieValues := make(map[string]string)
+	for _, ck := range cookies {
+		cookieValues[ck.Name] = ck.Value
+		rec := core.HTTPCookie{Name: ck.Name, Domain: ck.Domain, Path: ck.Path, Secure: ck.Secure, HttpOnly: ck.HttpOnly, SameSite: sameSiteName(ck.SameSite)}
+		result.Cookies = append(result.Cookies, rec)
+		if (!ck.Secure && resp.Request.URL.Scheme == "https") || !ck.HttpOnly {
+			result.InsecureCookies = append(result.InsecureCookies, ck.Name)
+		}
+	}
+
+	bodyBytes, truncated, err := readCapped(resp.Body, maxBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	result.BodyTruncated = truncated
+	bodySum := sha256.Sum256(bodyBytes)
+	result.BodySize = len(bodyBytes)
+	result.BodySHA256 = hex.EncodeToString(bodySum[:])
+	if utf8.Valid(bodyBytes) {
+		result.Body = string(bodyBytes)
+	} else {
+		result.BodyBase64 = base64.StdEncoding.EncodeToString(bodyBytes)
+	}
+	body := string(bodyBytes)
 
 	// Extract Title
-	bodyBytes := make([]byte, 4096) // Read first 4KB
-	n, _ := resp.Body.Read(bodyBytes)
-	body := string(bodyBytes[:n])
-	
-	re := regexp.MustCompile(`(?i)<title>(.*?)</title>`)
-	match := re.FindStringSubmatch(body)
-	if len(match) > 1 {
-		results["title"] = match[1]
+	if match := titleRe.FindStringSubmatch(body); len(match) > 1 {
+		result.Title = strings.TrimSpace(html.UnescapeString(match[1]))
+	}
+
+	if !viper.IsSet("collectors.http.favicon") || viper.GetBool("collectors.http.favicon") {
+		result.Favicon = fetchFavicon(ctx, client, resp.Request.URL, body)
 	}
 
-	data, err := json.MarshalIndent(results, "", "  ")
+	fp, err := LoadFingerprints(viper.GetString("collectors.http.signatures"))
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{URL: result.URL, Headers: result.Headers, Cookies: cookieValues, HTML: body}
+	page.ScriptSrc, page.Meta = ParseHTML(body)
+	if result.Favicon != nil {
+		page.FaviconMMH3 = &result.Favicon.MMH3
+	}
+	result.Technologies = fp.Detect(page)
+	result.Trackers = core.ExtractTrackers(body)
+
+	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +168,7 @@ func (c *HTTPCollector) Collect(caseID string, target string) ([]core.Evidence,
 		return nil, err
 	}
 
-	fileName := fmt.Sprintf("http_%s_%d.json", target, time.Now().Unix())
+	fileName := fmt.Sprintf("http_%s_%d.json", strings.NewReplacer("://", "_", "/", "_", ":", "_").Replace(target), time.Now().Unix())
 	filePath := filepath.Join(storageDir, fileName)
 	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return nil, err
@@ -96,18 +178,129 @@ func (c *HTTPCollector) Collect(caseID string, target string) ([]core.Evidence,
 	hash := sha256.Sum256(data)
 	hashStr := hex.EncodeToString(hash[:])
 
+	techs := make([]string, len(result.Technologies))
+	for i, t := range result.Technologies {
+		techs[i] = t.Name
+	}
+	metadata := map[string]interface{}{
+		"target":       target,
+		"url":          result.URL,
+		"status_code":  result.StatusCode,
+		"server":       result.Headers["Server"],
+		"technologies": techs,
+	}
+	if result.Title != "" {
+		metadata["title"] = result.Title
+	}
+
 	evidence := core.Evidence{
 		CaseID:      caseID,
 		Collector:   "http",
 		FilePath:    filePath,
 		FileHash:    hashStr,
 		CollectedAt: time.Now(),
-		Metadata: map[string]interface{}{
-			"target": target,
-			"server": headers["Server"],
-			"title":  results["title"],
-		},
+		Metadata:    metadata,
+		RawData:     result,
 	}
 
 	return []core.Evidence{evidence}, nil
 }
+
+// fetch GETs url and follows up to collectors.http.max_redirects redirects,
+// recording each hop in result. A redirect leaving the ethics scope is not
+// followed. It returns the final response and every cookie set on the way.
+func fetch(ctx context.Context, client *http.Client, url string, result *core.HTTPResult) (*http.Response, []*http.Cookie, error) {
+	maxRedirects := 10
+	if viper.IsSet("collectors.http.max_redirects") {
+		maxRedirects = viper.GetInt("collectors.http.max_redirects")
+	}
+
+	var cookies []*http.Cookie
+	for hop := 0; ; hop++ {
+		resp, err := get(ctx, client, url)
+		if err != nil {
+			return nil, nil, err
+		}
+		cookies = append(cookies, resp.Cookies()...)
+
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" || hop >= maxRedirects {
+			return resp, cookies, nil
+		}
+		next, err := resp.Request.URL.Parse(location)
+		if err != nil {
+			return resp, cookies, nil
+		}
+		result.Redirects = append(result.Redirects, core.HTTPHop{URL: url, StatusCode: resp.StatusCode, Location: next.String()})
+		if ok, _ := ethics.IsAllowed(next.Hostname()); !ok {
+			return resp, cookies, nil
+		}
+		if err := ethics.Wait(ctx, "http"); err != nil {
+			resp.Body.Close()
+			return nil, nil, err
+		}
+		resp.Body.Close()
+		url = next.String()
+	}
+}
+
+// fetchFavicon downloads the icon named by the page's <link rel="icon">,
+// or /favicon.ico, and hashes it. Failures just mean no favicon.
+func fetchFavicon(ctx context.Context, client *http.Client, base *neturl.URL, body string) *core.Favicon {
+	iconURL, _ := base.Parse("/favicon.ico")
+	if tag := iconRe.FindString(body); tag != "" {
+		if href := hrefRe.FindStringSubmatch(tag); href != nil {
+			if u, err := base.Parse(html.UnescapeString(href[1])); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+				iconURL = u
+			}
+		}
+	}
+	if ok, _ := ethics.IsAllowed(iconURL.Hostname()); !ok {
+		return nil
+	}
+	if err := ethics.Wait(ctx, "http"); err != nil {
+		return nil
+	}
+
+	resp, err := get(ctx, client, iconURL.String())
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil || resp.StatusCode != http.StatusOK || len(data) == 0 || strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return &core.Favicon{URL: iconURL.String(), Size: len(data), MMH3: FaviconHash(data), SHA256: hex.EncodeToString(sum[:])}
+}
+
+func sameSiteName(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+// get issues a GET request bound to ctx.
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	return client.Do(req)
+}
+
+// userAgent is sent with every request; some sites serve bots differently.
+func userAgent() string {
+	if ua := viper.GetString("collectors.http.user_agent"); ua != "" {
+		return ua
+	}
+	return "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"
+}
@@ -9,6 +9,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
 )
 
 func TestHTTPCollector_Collect(t *testing.T) {
@@ -68,3 +71,123 @@ func TestHTTPCollector_Collect(t *testing.T) {
 		t.Errorf("Expected status 200, got %v", result["status_code"])
 	}
 }
+
+func TestHTTPCollector_RedirectsAndTechnologies(t *testing.T) {
+	ethics.SetBlacklist(nil)
+	ethics.SetLimit("http", 10000)
+	t.Cleanup(func() { ethics.SetBlacklist([]string{".gov", ".mil", "localhost", "127.0.0.1"}) })
+
+	icon := []byte("\x00\x00\x01\x00fake icon bytes")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/blog/", http.StatusMovedPermanently)
+		case "/blog/":
+			w.Header().Set("Server", "nginx/1.25.3")
+			w.Header().Set("X-Frame-Options", "DENY")
+			http.SetCookie(w, &http.Cookie{Name: "wordpress_test_cookie", Value: "WP Cookie check"})
+			fmt.Fprint(w, `<html><head><title>Blog &amp; News</title>
+<meta name="generator" content="WordPress 6.4.2">
+<link rel="shortcut icon" href="/static/icon.ico">
+<script src="/wp-includes/js/jquery/jquery.min.js?ver=3.7.1"></script>
+<script>fbq('init', '123456789012345');</script>
+</head><body>Hello</body></html>`)
+		case "/static/icon.ico":
+			w.Write(icon)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	caseID := "test_case_http_tech"
+	defer os.RemoveAll(filepath.Join("evidence_storage", caseID))
+
+	evidence, err := (&HTTPCollector{}).Collect(caseID, strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	result := evidence[0].RawData.(*core.HTTPResult)
+
+	if len(result.Redirects) != 1 || result.Redirects[0].StatusCode != 301 || !strings.HasSuffix(result.Redirects[0].Location, "/blog/") {
+		t.Errorf("Expected one 301 hop to /blog/, got %+v", result.Redirects)
+	}
+	if !strings.HasSuffix(result.URL, "/blog/") || result.Title != "Blog & News" {
+		t.Errorf("Expected the final page to be recorded, got %s %q", result.URL, result.Title)
+	}
+	if len(result.Cookies) != 1 || len(result.InsecureCookies) != 1 {
+		t.Errorf("Expected the WordPress cookie to be flagged as missing HttpOnly, got %+v", result.Cookies)
+	}
+	for _, h := range result.MissingHeaders {
+		if h == "X-Frame-Options" {
+			t.Error("X-Frame-Options was set and should not be reported missing")
+		}
+	}
+	if result.Favicon == nil || result.Favicon.MMH3 != FaviconHash(icon) || result.Favicon.Size != len(icon) {
+		t.Errorf("Expected the linked favicon to be hashed, got %+v", result.Favicon)
+	}
+
+	if len(result.Trackers) != 1 || result.Trackers[0].Value() != "facebook_pixel:123456789012345" {
+		t.Errorf("Expected the Facebook Pixel ID, got %+v", result.Trackers)
+	}
+
+	techs := make(map[string]core.Technology)
+	for _, tech := range result.Technologies {
+		techs[tech.Name] = tech
+	}
+	for name, version := range map[string]string{"Nginx": "1.25.3", "WordPress": "6.4.2", "jQuery": "3.7.1", "PHP": "", "MySQL": ""} {
+		tech, ok := techs[name]
+		if !ok || tech.Version != version {
+			t.Errorf("Expected %s %q, got %+v (found %v)", name, version, tech, ok)
+		}
+	}
+}
+
+func TestFingerprints_Detect(t *testing.T) {
+	fp, err := ParseFingerprints([]byte(`{
+		"Shop": {"cats": ["Ecommerce"], "headers": {"X-Shop": "^v([\\d.]+)\\;version:\\1"}, "implies": ["Lang\\;confidence:50"]},
+		"Lang": {"cats": ["Programming languages"]},
+		"Guess": {"html": ["powered by guess\\;confidence:40"], "favicon": [1234]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hash int32 = 1234
+	techs := fp.Detect(&Page{Headers: map[string]string{"X-Shop": "v2.1"}, HTML: "Powered by Guess", FaviconMMH3: &hash})
+	got := make(map[string]core.Technology)
+	for _, tech := range techs {
+		got[tech.Name] = tech
+	}
+	if got["Shop"].Version != "2.1" || got["Shop"].Confidence != 100 {
+		t.Errorf("Shop: %+v", got["Shop"])
+	}
+	if got["Lang"].Confidence != 50 {
+		t.Errorf("Expected Lang to be implied at 50, got %+v", got["Lang"])
+	}
+	if got["Guess"].Confidence != 100 {
+		t.Errorf("Expected html and favicon confidence to add up, got %+v", got["Guess"])
+	}
+
+	if _, err := LoadFingerprints(""); err != nil {
+		t.Errorf("Bundled signatures are invalid: %v", err)
+	}
+}
+
+func TestMurmur3(t *testing.T) {
+	tests := []struct {
+		in   string
+		seed uint32
+		want uint32
+	}{
+		{"", 0, 0},
+		{"", 1, 0x514e28b7},
+		{"hello", 0, 613153351},
+		{"The quick brown fox jumps over the lazy dog", 0, 0x2e4ff723},
+	}
+	for _, tt := range tests {
+		if got := murmur3([]byte(tt.in), tt.seed); got != tt.want {
+			t.Errorf("murmur3(%q, %d) = %#x, want %#x", tt.in, tt.seed, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package active
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -8,12 +9,20 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spectre/spectre/internal/collector"
+	dnscollector "github.com/spectre/spectre/internal/collector/dns"
 	"github.com/spectre/spectre/internal/core"
 	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spectre/spectre/internal/http"
 	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 type PortCollector struct{}
@@ -27,45 +36,182 @@ func (c *PortCollector) Name() string {
 }
 
 func (c *PortCollector) Description() string {
-	return "Active TCP port scanner for common services"
+	return "Active TCP/UDP port scanner with banner grabbing and service detection"
 }
 
 func (c *PortCollector) IsActive() bool {
 	return true
 }
 
+func (c *PortCollector) Accepts() []string {
+	return []string{core.EntityIP, core.EntityDomain, core.EntityNetblock}
+}
+
+func (c *PortCollector) Produces() []string {
+	return []string{core.EntityService}
+}
+
 func (c *PortCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext scans target, which may be an IP, a domain (every address
+// it resolves to) or a CIDR block. Params may set "ports" (e.g.
+// "22,80,8000-8100", overriding the mode) and "udp" ("true" adds the UDP
+// probes).
+func (c *PortCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
 	mode := viper.GetString("collectors.ports.mode")
 	var ports []int
 
-	switch mode {
-	case "top-100":
+	spec := opts.Params["ports"]
+	if spec == "" {
+		spec = viper.GetString("collectors.ports.ports")
+	}
+	switch {
+	case spec != "":
+		mode = "range"
+		var err error
+		if ports, err = ParsePorts(spec); err != nil {
+			return nil, err
+		}
+	case mode == "top-100":
 		ports = getTop100Ports()
-	case "custom":
+	case mode == "custom":
 		ports = viper.GetIntSlice("collectors.ports.custom_ports")
 	default:
 		// Default list (common ports)
 		ports = []int{20, 21, 22, 23, 25, 53, 80, 110, 111, 135, 139, 143, 443, 445, 993, 995, 1723, 3306, 3389, 5432, 5900, 8080, 8443}
 	}
 
-	results := make(map[int]string)
+	var udpPorts []int
+	udp := viper.GetBool("collectors.ports.udp")
+	if v, ok := opts.Params["udp"]; ok {
+		udp, _ = strconv.ParseBool(v)
+	}
+	if udp && viper.GetBool("ghost_mode") {
+		log.Warn().Msg("Ghost mode enabled: UDP probes cannot go through the proxy and are skipped")
+		udp = false
+	}
+	if udp {
+		udpPorts = viper.GetIntSlice("collectors.ports.udp_ports")
+		if len(udpPorts) == 0 {
+			udpPorts = defaultUDPPorts
+		}
+	}
 
-	for _, port := range ports {
-		// Apply rate limit
-		if err := ethics.Wait("ports"); err != nil {
-			// If context is cancelled, we should probably stop
-			return nil, err
+	hosts, err := scanHosts(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	result := &core.PortScanResult{Target: target, Mode: mode, TCPPorts: len(ports), UDPPorts: len(udpPorts), Open: []core.OpenPort{}}
+	for _, h := range hosts {
+		// A CIDR block or a domain's addresses may reach outside the scope
+		if ok, _ := ethics.IsAllowed(h); !ok {
+			result.Skipped = append(result.Skipped, h)
+			continue
 		}
+		result.Hosts = append(result.Hosts, h)
+	}
+	if len(result.Hosts) == 0 {
+		return nil, fmt.Errorf("no hosts in scope for %s", target)
+	}
+
+	db, err := LoadProbeDB(viper.GetString("collectors.ports.probes"))
+	if err != nil {
+		return nil, err
+	}
+	s := &scanner{
+		db:            db,
+		dial:          netclient.DialContext,
+		wait:          func(ctx context.Context) error { return ethics.Wait(ctx, "ports") },
+		timeout:       durationSetting("collectors.ports.timeout", time.Second),
+		bannerTimeout: durationSetting("collectors.ports.banner_timeout", 2*time.Second),
+	}
 
-		address := fmt.Sprintf("%s:%d", target, port)
-		conn, err := net.DialTimeout("tcp", address, 1*time.Second)
-		if err == nil {
-			results[port] = "open"
-			conn.Close()
+	type job struct {
+		host     string
+		port     int
+		protocol string
+	}
+	total := len(result.Hosts) * (len(ports) + len(udpPorts))
+	jobs := make(chan job)
+	found := make(chan core.OpenPort)
+
+	workers := viper.GetInt("collectors.ports.workers")
+	if workers <= 0 {
+		workers = 50
+	}
+	if workers > total {
+		workers = total
+	}
+
+	var wg sync.WaitGroup
+	var progress sync.Mutex
+	done := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				var open *core.OpenPort
+				if j.protocol == "udp" {
+					open = s.scanUDP(ctx, j.host, j.port)
+				} else {
+					open = s.scanTCP(ctx, j.host, j.port)
+				}
+				if open != nil {
+					found <- *open
+				}
+				progress.Lock()
+				done++
+				opts.Report(done, total, fmt.Sprintf("scanned %s %d/%s", j.host, j.port, j.protocol))
+				progress.Unlock()
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, h := range result.Hosts {
+			for _, p := range ports {
+				select {
+				case jobs <- job{h, p, "tcp"}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			for _, p := range udpPorts {
+				select {
+				case jobs <- job{h, p, "udp"}:
+				case <-ctx.Done():
+					return
+				}
+			}
 		}
+	}()
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+	for open := range found {
+		result.Open = append(result.Open, open)
+	}
+
+	// A cancelled context aborts the scan
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	sort.Slice(result.Open, func(i, j int) bool {
+		a, b := result.Open[i], result.Open[j]
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.Port < b.Port
+	})
 
-	data, err := json.MarshalIndent(results, "", "  ")
+	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +222,7 @@ func (c *PortCollector) Collect(caseID string, target string) ([]core.Evidence,
 		return nil, err
 	}
 
-	fileName := fmt.Sprintf("ports_%s_%d.json", target, time.Now().Unix())
+	fileName := fmt.Sprintf("ports_%s_%d.json", strings.ReplaceAll(target, "/", "_"), time.Now().Unix())
 	filePath := filepath.Join(storageDir, fileName)
 	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return nil, err
@@ -94,14 +240,114 @@ func (c *PortCollector) Collect(caseID string, target string) ([]core.Evidence,
 		CollectedAt: time.Now(),
 		Metadata: map[string]interface{}{
 			"target": target,
-			"count":  len(results),
+			"count":  len(result.Open),
+			"hosts":  len(result.Hosts),
 			"mode":   mode,
 		},
+		RawData: result,
 	}
 
 	return []core.Evidence{evidence}, nil
 }
 
+// defaultUDPPorts are the ports with a UDP probe in the bundled database.
+var defaultUDPPorts = []int{53, 123, 137, 161, 1900}
+
+// ParsePorts parses a port list such as "22,80,8000-8100". Duplicates are
+// dropped; order is kept.
+func ParsePorts(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			lo, hi = part[:i], part[i+1:]
+		}
+		start, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		end, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || start < 1 || end > 65535 || start > end {
+			return nil, fmt.Errorf("invalid port range %q", part)
+		}
+		for p := start; p <= end; p++ {
+			if !seen[p] {
+				seen[p] = true
+				ports = append(ports, p)
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports in %q", spec)
+	}
+	return ports, nil
+}
+
+// scanHosts expands target into the hosts to scan. CIDR blocks are capped
+// by collectors.ports.max_hosts; domains are resolved unless ghost mode
+// sends the connections (and so the lookups) through the proxy.
+func scanHosts(ctx context.Context, target string) ([]string, error) {
+	target = strings.TrimSpace(target)
+	if _, network, err := net.ParseCIDR(target); err == nil {
+		maxHosts := viper.GetInt("collectors.ports.max_hosts")
+		if maxHosts <= 0 {
+			maxHosts = 256
+		}
+		ones, bits := network.Mask.Size()
+		if bits-ones > 30 || 1<<(bits-ones) > maxHosts+2 {
+			return nil, fmt.Errorf("%s has more than %d hosts (collectors.ports.max_hosts)", target, maxHosts)
+		}
+		var hosts []string
+		for ip := network.IP.Mask(network.Mask); network.Contains(ip); ip = nextIP(ip) {
+			hosts = append(hosts, ip.String())
+		}
+		// Skip the network and broadcast addresses of IPv4 blocks larger than /31
+		if bits == 32 && bits-ones > 1 {
+			hosts = hosts[1 : len(hosts)-1]
+		}
+		return hosts, nil
+	}
+	if net.ParseIP(target) != nil || viper.GetBool("ghost_mode") {
+		return []string{target}, nil
+	}
+
+	resolver := dnscollector.NewResolver()
+	var hosts []string
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		ans := resolver.Query(ctx, target, qtype)
+		for _, rec := range ans.Records {
+			if rec.Type == "A" || rec.Type == "AAAA" {
+				hosts = append(hosts, rec.Value)
+			}
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("%s did not resolve to any address", target)
+	}
+	return hosts, nil
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+func durationSetting(key string, def time.Duration) time.Duration {
+	if d := viper.GetDuration(key); d > 0 {
+		return d
+	}
+	return def
+}
+
 func getTop100Ports() []int {
 	return []int{
 		20, 21, 22, 23, 25, 53, 80, 81, 88, 110, 111, 113, 119, 135, 137, 138, 139, 143, 161, 179,
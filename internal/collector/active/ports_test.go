@@ -1,42 +1,79 @@
 package active
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spectre/spectre/internal/http"
 	"github.com/spf13/viper"
 )
 
-func TestPortCollector_Collect(t *testing.T) {
-	// Start a listener on a random port
+// allowLoopback lifts the default scope block on 127.0.0.1 for one test.
+func allowLoopback(t *testing.T) {
+	ethics.SetBlacklist(nil)
+	ethics.SetLimit("ports", 10000)
+	t.Cleanup(func() { ethics.SetBlacklist([]string{".gov", ".mil", "localhost", "127.0.0.1"}) })
+}
+
+// serveBanner accepts connections and writes banner to each.
+func serveBanner(t *testing.T, banner string) int {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		t.Fatalf("Failed to start listener: %v", err)
 	}
-	defer listener.Close()
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestPortCollector_Collect(t *testing.T) {
+	allowLoopback(t)
+	viper.Set("collectors.ports.banner_timeout", "300ms")
+	defer viper.Set("collectors.ports.banner_timeout", nil)
 
-	// Get the port
-	addr := listener.Addr().(*net.TCPAddr)
-	port := addr.Port
+	sshPort := serveBanner(t, "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n")
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.3")
+	}))
+	defer web.Close()
+	webPort := web.Listener.Addr().(*net.TCPAddr).Port
 
-	// Configure Viper
-	viper.Set("collectors.ports.mode", "custom")
-	viper.Set("collectors.ports.custom_ports", []int{port, port + 1}) // Scan open port and one closed port
+	// A port that was just closed stays closed
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
 
 	collector := &PortCollector{}
 	caseID := "test_case_ports"
-	target := "127.0.0.1"
 
 	// Cleanup
 	defer os.RemoveAll(filepath.Join("evidence_storage", caseID))
 	os.RemoveAll(filepath.Join("evidence_storage", caseID))
 
-	// Run Collect
-	evidence, err := collector.Collect(caseID, target)
+	spec := fmt.Sprintf("%d,%d,%d", sshPort, webPort, closedPort)
+	evidence, err := collector.CollectContext(context.Background(), caseID, "127.0.0.1", core.CollectOptions{Params: map[string]string{"ports": spec}})
 	if err != nil {
 		t.Fatalf("Collect failed: %v", err)
 	}
@@ -46,8 +83,8 @@ func TestPortCollector_Collect(t *testing.T) {
 	}
 
 	ev := evidence[0]
-	if ev.Collector != "ports" {
-		t.Errorf("Expected collector 'ports', got '%s'", ev.Collector)
+	if ev.Collector != "ports" || ev.Metadata["mode"] != "range" {
+		t.Errorf("Unexpected evidence: %s %v", ev.Collector, ev.Metadata)
 	}
 
 	// Verify File Content
@@ -55,19 +92,143 @@ func TestPortCollector_Collect(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to read evidence file: %v", err)
 	}
-
-	var results map[string]string // Key is stringified int in JSON
-	if err := json.Unmarshal(content, &results); err != nil {
+	var result core.PortScanResult
+	if err := json.Unmarshal(content, &result); err != nil {
 		t.Fatalf("Failed to parse evidence JSON: %v", err)
 	}
+	if result.TCPPorts != 3 || len(result.Open) != 2 {
+		t.Fatalf("Expected 2 of 3 ports open, got %+v", result)
+	}
 
-	// Verify open port found
-	if status, ok := results[fmt.Sprintf("%d", port)]; !ok || status != "open" {
-		t.Errorf("Expected port %d to be open, got %v", port, results[fmt.Sprintf("%d", port)])
+	byPort := make(map[int]core.OpenPort)
+	for _, p := range result.Open {
+		byPort[p.Port] = p
+	}
+	if ssh := byPort[sshPort]; ssh.Service != "ssh" || ssh.Product != "OpenSSH" || ssh.Version != "9.6p1" || ssh.Method != "NULL" {
+		t.Errorf("Expected OpenSSH 9.6p1 from the banner, got %+v", ssh)
+	}
+	if web := byPort[webPort]; web.Service != "http" || web.Product != "nginx" || web.Version != "1.25.3" || web.Method != "GetRequest" {
+		t.Errorf("Expected nginx 1.25.3 from the fallback HTTP probe, got %+v", web)
+	}
+	if _, ok := byPort[closedPort]; ok {
+		t.Errorf("Expected port %d to be closed/missing", closedPort)
+	}
+}
+
+// CIDR targets infer the netblock type; the registry must still route them
+// to the scanner.
+func TestPortCollector_RunCIDR(t *testing.T) {
+	allowLoopback(t)
+	sshPort := serveBanner(t, "SSH-2.0-OpenSSH_9.6p1\r\n")
+
+	caseID := "test_case_ports_cidr"
+	defer os.RemoveAll(filepath.Join("evidence_storage", caseID))
+
+	opts := core.CollectOptions{ActiveAllowed: true, Params: map[string]string{"ports": strconv.Itoa(sshPort)}}
+	evidence, err := collector.Run(context.Background(), "ports", caseID, "127.0.0.1/32", opts)
+	if err != nil {
+		t.Fatalf("Run on a CIDR target failed: %v", err)
+	}
+	result := evidence[0].RawData.(*core.PortScanResult)
+	if len(result.Open) != 1 || result.Open[0].Port != sshPort {
+		t.Errorf("Expected port %d open on the block, got %+v", sshPort, result.Open)
+	}
+}
+
+func TestScanner_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
 	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if string(buf[:n]) == "\x00ping" {
+				pc.WriteTo([]byte("pong v2.1"), addr)
+			}
+		}
+	}()
+	port := pc.LocalAddr().(*net.UDPAddr).Port
 
-	// Verify closed port not found (or closed if logic changes, but current logic only adds "open" ports)
-	if _, ok := results[fmt.Sprintf("%d", port+1)]; ok {
-		t.Errorf("Expected port %d to be closed/missing, but it was in results", port+1)
+	db, err := ParseProbeDB([]byte(`{
+		"probes": [{"name": "Ping", "protocol": "udp", "payload": "\\x00ping", "ports": [` + strconv.Itoa(port) + `], "service": "pingd"}],
+		"matches": [{"probe": "Ping", "pattern": "^pong v([\\d.]+)", "service": "pingd", "version": "$1"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &scanner{db: db, dial: netclient.DialContext, wait: func(context.Context) error { return nil }, timeout: time.Second, bannerTimeout: 300 * time.Millisecond}
+
+	open := s.scanUDP(context.Background(), "127.0.0.1", port)
+	if open == nil || open.Service != "pingd" || open.Version != "2.1" || open.Protocol != "udp" {
+		t.Errorf("Expected pingd 2.1 over UDP, got %+v", open)
+	}
+	if s.scanUDP(context.Background(), "127.0.0.1", port+1) != nil {
+		t.Error("Expected a port without a probe to be skipped")
+	}
+}
+
+func TestProbeDB_Identify(t *testing.T) {
+	db, err := LoadProbeDB("")
+	if err != nil {
+		t.Fatalf("Bundled probe database is invalid: %v", err)
+	}
+
+	tests := []struct {
+		probe, response           string
+		service, product, version string
+	}{
+		{"NULL", "220 mail.example.com ESMTP Postfix (Ubuntu)\r\n", "smtp", "Postfix smtpd", ""},
+		{"NULL", "220 (vsFTPd 3.0.5)\r\n", "ftp", "vsftpd", "3.0.5"},
+		{"NULL", "J\x00\x00\x00\x0a8.0.36-0ubuntu0.22.04.1\x00\x08\x00\x00\x00", "mysql", "MySQL", "8.0.36-0ubuntu0.22.04.1"},
+		{"NULL", "n\x00\x00\x00\x0a5.5.5-10.11.6-MariaDB-0+deb12u1\x00", "mysql", "MariaDB", "10.11.6"},
+		{"RedisInfo", "$3500\r\n# Server\r\nredis_version:7.2.4\r\n", "redis", "Redis", "7.2.4"},
+		{"GetRequest", "HTTP/1.1 200 OK\r\nDate: x\r\nServer: Apache/2.4.58 (Debian)\r\n\r\n", "http", "Apache httpd", "2.4.58"},
+		{"GetRequest", "HTTP/1.1 404 Not Found\r\nServer: gunicorn\r\n\r\n", "http", "gunicorn", ""},
+		{"PostgresSSLRequest", "N", "postgresql", "PostgreSQL", ""},
+	}
+	for _, tt := range tests {
+		var got core.OpenPort
+		if !db.identify(tt.probe, []byte(tt.response), &got) {
+			t.Errorf("%q: no match", tt.response)
+			continue
+		}
+		if got.Service != tt.service || got.Product != tt.product || got.Version != tt.version {
+			t.Errorf("%q: got %s/%s/%s, want %s/%s/%s", tt.response, got.Service, got.Product, got.Version, tt.service, tt.product, tt.version)
+		}
+	}
+
+	if probes := db.probesFor("tcp", 6379); len(probes) != 1 || probes[0].Name != "RedisInfo" {
+		t.Errorf("Expected the Redis probe for 6379, got %v", probes)
+	}
+	if probes := db.probesFor("tcp", 31337); len(probes) != 1 || probes[0].Name != "GetRequest" {
+		t.Errorf("Expected the HTTP fallback for an unknown port, got %v", probes)
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	ports, err := ParsePorts("22, 80-82,80,443")
+	if err != nil || fmt.Sprint(ports) != "[22 80 81 82 443]" {
+		t.Errorf("ParsePorts = %v, %v", ports, err)
+	}
+	for _, bad := range []string{"0", "70000", "90-80", "http", ""} {
+		if _, err := ParsePorts(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestScanHosts_CIDR(t *testing.T) {
+	hosts, err := scanHosts(context.Background(), "192.0.2.0/29")
+	if err != nil || len(hosts) != 6 || hosts[0] != "192.0.2.1" || hosts[5] != "192.0.2.6" {
+		t.Errorf("Expected the 6 usable hosts of a /29, got %v, %v", hosts, err)
+	}
+	if _, err := scanHosts(context.Background(), "10.0.0.0/8"); err == nil || !strings.Contains(err.Error(), "max_hosts") {
+		t.Errorf("Expected a /8 to exceed max_hosts, got %v", err)
 	}
 }
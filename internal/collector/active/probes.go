@@ -0,0 +1,366 @@
+package active
+
+import (
+	"context"
+	"crypto/tls"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+//go:embed probes/services.json
+var defaultProbeDB []byte
+
+// maxBanner bounds how much of a response is read and kept.
+const maxBanner = 2048
+
+// Probe is a payload sent to a port to provoke an identifying response,
+// modelled on nmap-service-probes. The NULL probe sends nothing and only
+// waits for a banner.
+type Probe struct {
+	Name     string `json:"name"`
+	Protocol string `json:"protocol"`
+	TLS      bool   `json:"tls,omitempty"`      // handshake before sending the payload
+	Payload  string `json:"payload,omitempty"`  // \r, \n, \t, \\ and \xHH escapes
+	Ports    []int  `json:"ports,omitempty"`    // ports the probe is tried on
+	Fallback bool   `json:"fallback,omitempty"` // also try it on ports no other probe claims
+	Service  string `json:"service,omitempty"`  // assumed for any response (UDP)
+
+	payload []byte
+}
+
+// Match identifies a service from a response. Product, Version and Info may
+// reference pattern groups as $1, $2, ...
+type Match struct {
+	Probe   string `json:"probe,omitempty"` // empty matches responses to any probe
+	Pattern string `json:"pattern"`
+	Service string `json:"service"`
+	Product string `json:"product,omitempty"`
+	Version string `json:"version,omitempty"`
+	Info    string `json:"info,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// ProbeDB is a set of probes and the matches applied to their responses.
+type ProbeDB struct {
+	Probes  []*Probe `json:"probes"`
+	Matches []*Match `json:"matches"`
+}
+
+// LoadProbeDB reads a probe database from path, or the bundled one when
+// path is empty.
+func LoadProbeDB(path string) (*ProbeDB, error) {
+	data := defaultProbeDB
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read probe database: %w", err)
+		}
+	}
+	return ParseProbeDB(data)
+}
+
+// ParseProbeDB decodes a probe database and compiles its patterns.
+func ParseProbeDB(data []byte) (*ProbeDB, error) {
+	var db ProbeDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("failed to parse probe database: %w", err)
+	}
+	for _, p := range db.Probes {
+		payload, err := unescape(p.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("probe %s: %w", p.Name, err)
+		}
+		p.payload = payload
+	}
+	for _, m := range db.Matches {
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("match for %s: %w", m.Service, err)
+		}
+		m.re = re
+	}
+	return &db, nil
+}
+
+// probesFor lists the probes to try on an open port, in database order:
+// those claiming the port, or the fallback probes if none do.
+func (db *ProbeDB) probesFor(protocol string, port int) []*Probe {
+	var claimed, fallback []*Probe
+	for _, p := range db.Probes {
+		if p.Protocol != protocol || p.Name == "NULL" {
+			continue
+		}
+		for _, pp := range p.Ports {
+			if pp == port {
+				claimed = append(claimed, p)
+				break
+			}
+		}
+		if p.Fallback {
+			fallback = append(fallback, p)
+		}
+	}
+	if len(claimed) > 0 {
+		return claimed
+	}
+	return fallback
+}
+
+// identify applies the matches for probe to a response and fills in the
+// service fields of result. It reports whether one matched.
+func (db *ProbeDB) identify(probe string, response []byte, result *core.OpenPort) bool {
+	for _, m := range db.Matches {
+		if m.Probe != "" && m.Probe != probe {
+			continue
+		}
+		groups := m.re.FindSubmatchIndex(response)
+		if groups == nil {
+			continue
+		}
+		expand := func(template string) string {
+			if template == "" {
+				return ""
+			}
+			out := m.re.Expand(nil, []byte(template), response, groups)
+			return strings.TrimSpace(printable(out))
+		}
+		result.Service = m.Service
+		result.Product = expand(m.Product)
+		result.Version = expand(m.Version)
+		result.Info = expand(m.Info)
+		result.Method = probe
+		return true
+	}
+	return false
+}
+
+// scanner holds what the workers share.
+type scanner struct {
+	db            *ProbeDB
+	dial          func(ctx context.Context, network, address string) (net.Conn, error)
+	wait          func(ctx context.Context) error
+	timeout       time.Duration // connect timeout
+	bannerTimeout time.Duration // how long to wait for a response
+}
+
+// scanTCP connects to host:port and, when it is open, identifies the
+// service. It returns nil for closed or filtered ports.
+func (s *scanner) scanTCP(ctx context.Context, host string, port int) *core.OpenPort {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := s.connect(ctx, "tcp", address)
+	if err != nil {
+		return nil
+	}
+	result := &core.OpenPort{Host: host, Port: port, Protocol: "tcp"}
+
+	// NULL probe: many services greet first
+	banner := s.read(ctx, conn)
+	conn.Close()
+	if len(banner) > 0 {
+		result.Banner = printable(banner)
+		if s.db.identify("NULL", banner, result) {
+			return result
+		}
+	}
+
+	for _, p := range s.db.probesFor("tcp", port) {
+		response, err := s.send(ctx, "tcp", address, host, p)
+		if err != nil || len(response) == 0 {
+			continue
+		}
+		if result.Banner == "" {
+			result.Banner = printable(response)
+		}
+		if s.db.identify(p.Name, response, result) {
+			if p.TLS {
+				result.TLS = true
+				if result.Service == "http" {
+					result.Service = "https"
+				} else {
+					result.Service = "ssl/" + result.Service
+				}
+			}
+			return result
+		}
+	}
+
+	result.Service, result.Method = wellKnownService(port, "tcp"), "port"
+	return result
+}
+
+// scanUDP sends each UDP probe claiming the port. Only ports that answer
+// are reported; silence is indistinguishable from filtering.
+func (s *scanner) scanUDP(ctx context.Context, host string, port int) *core.OpenPort {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	for _, p := range s.db.probesFor("udp", port) {
+		response, err := s.send(ctx, "udp", address, host, p)
+		if err != nil || len(response) == 0 {
+			continue
+		}
+		result := &core.OpenPort{Host: host, Port: port, Protocol: "udp", Banner: printable(response)}
+		if !s.db.identify(p.Name, response, result) {
+			result.Service, result.Method = p.Service, p.Name
+		}
+		return result
+	}
+	return nil
+}
+
+// send opens a fresh connection, sends the probe payload and returns the
+// response.
+func (s *scanner) send(ctx context.Context, network, address, host string, p *Probe) ([]byte, error) {
+	conn, err := s.connect(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if p.TLS {
+		serverName := host
+		if net.ParseIP(host) != nil {
+			serverName = ""
+		}
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		hsCtx, cancel := context.WithTimeout(ctx, s.bannerTimeout)
+		err := tlsConn.HandshakeContext(hsCtx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(s.bannerTimeout))
+	if _, err := conn.Write(p.payload); err != nil {
+		return nil, err
+	}
+	response := s.read(ctx, conn)
+	if network == "udp" && len(response) == 0 {
+		return nil, errors.New("no response")
+	}
+	return response, nil
+}
+
+func (s *scanner) connect(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.dial(dialCtx, network, address)
+}
+
+// read collects up to maxBanner bytes until the peer goes quiet.
+func (s *scanner) read(ctx context.Context, conn net.Conn) []byte {
+	deadline := time.Now().Add(s.bannerTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	buf := make([]byte, maxBanner)
+	n := 0
+	for n < len(buf) {
+		m, err := conn.Read(buf[n:])
+		n += m
+		if err != nil {
+			break
+		}
+		if n > 0 {
+			// Something arrived; give the rest a moment
+			conn.SetReadDeadline(time.Now().Add(s.bannerTimeout / 4))
+		}
+	}
+	return buf[:n]
+}
+
+// printable renders a response for the evidence file, escaping bytes that
+// are not printable ASCII.
+func printable(b []byte) string {
+	if len(b) > maxBanner {
+		b = b[:maxBanner]
+	}
+	var sb strings.Builder
+	for _, c := range b {
+		switch {
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\\':
+			sb.WriteString(`\\`)
+		case c >= 0x20 && c < 0x7f:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, `\x%02x`, c)
+		}
+	}
+	return sb.String()
+}
+
+// unescape decodes the escapes allowed in probe payloads.
+func unescape(s string) ([]byte, error) {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			out = append(out, s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return nil, fmt.Errorf("trailing backslash in payload")
+		}
+		i++
+		switch s[i] {
+		case 'r':
+			out = append(out, '\r')
+		case 'n':
+			out = append(out, '\n')
+		case 't':
+			out = append(out, '\t')
+		case '\\':
+			out = append(out, '\\')
+		case 'x':
+			if i+3 > len(s) {
+				return nil, fmt.Errorf("short \\x escape in payload")
+			}
+			v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid \\x escape in payload: %w", err)
+			}
+			out = append(out, byte(v))
+			i += 2
+		default:
+			return nil, fmt.Errorf("unknown escape \\%c in payload", s[i])
+		}
+	}
+	return out, nil
+}
+
+// wellKnownServices names services by port when no probe identified them.
+var wellKnownServices = map[string]string{
+	"tcp/21": "ftp", "tcp/22": "ssh", "tcp/23": "telnet", "tcp/25": "smtp", "tcp/53": "domain",
+	"tcp/80": "http", "tcp/88": "kerberos", "tcp/110": "pop3", "tcp/111": "rpcbind", "tcp/135": "msrpc",
+	"tcp/139": "netbios-ssn", "tcp/143": "imap", "tcp/389": "ldap", "tcp/443": "https", "tcp/445": "microsoft-ds",
+	"tcp/465": "smtps", "tcp/587": "submission", "tcp/636": "ldaps", "tcp/873": "rsync", "tcp/993": "imaps",
+	"tcp/995": "pop3s", "tcp/1433": "ms-sql-s", "tcp/1521": "oracle", "tcp/1723": "pptp", "tcp/2049": "nfs",
+	"tcp/3306": "mysql", "tcp/3389": "ms-wbt-server", "tcp/5432": "postgresql", "tcp/5900": "vnc",
+	"tcp/6379": "redis", "tcp/8080": "http-proxy", "tcp/8443": "https-alt", "tcp/9200": "elasticsearch",
+	"tcp/11211": "memcached", "tcp/27017": "mongodb",
+	"udp/53": "domain", "udp/123": "ntp", "udp/137": "netbios-ns", "udp/161": "snmp", "udp/500": "isakmp",
+	"udp/1900": "upnp", "udp/5353": "mdns",
+}
+
+func wellKnownService(port int, protocol string) string {
+	return wellKnownServices[protocol+"/"+strconv.Itoa(port)]
+}
@@ -0,0 +1,123 @@
+package active
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// robotsRules holds the Allow and Disallow lines of the robots.txt group
+// that applies to us.
+type robotsRules struct {
+	allow    []*regexp.Regexp
+	disallow []*regexp.Regexp
+	lengths  map[*regexp.Regexp]int // pattern length, for longest-match
+}
+
+// parseRobots reads robots.txt and keeps the group for agent, or the "*"
+// group when there is no specific one (RFC 9309).
+func parseRobots(body, agent string) *robotsRules {
+	type group struct {
+		agents []string
+		rules  [][2]string // {"allow"|"disallow", path}
+	}
+	var groups []*group
+	var cur *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				cur = &group{}
+				groups = append(groups, cur)
+				inAgents = true
+			}
+			cur.agents = append(cur.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if cur != nil {
+				cur.rules = append(cur.rules, [2]string{key, value})
+			}
+		}
+	}
+
+	rules := &robotsRules{lengths: make(map[*regexp.Regexp]int)}
+	agent = strings.ToLower(agent)
+	var matched []*group
+	for _, want := range []string{agent, "*"} {
+		for _, g := range groups {
+			for _, a := range g.agents {
+				if a == want {
+					matched = append(matched, g)
+					break
+				}
+			}
+		}
+		if len(matched) > 0 {
+			break
+		}
+	}
+	for _, g := range matched {
+		for _, r := range g.rules {
+			if r[1] == "" {
+				continue // "Disallow:" allows everything
+			}
+			re := robotsPattern(r[1])
+			rules.lengths[re] = len(r[1])
+			if r[0] == "allow" {
+				rules.allow = append(rules.allow, re)
+			} else {
+				rules.disallow = append(rules.disallow, re)
+			}
+		}
+	}
+	return rules
+}
+
+// robotsPattern turns a path rule with * and $ wildcards into a regexp
+// anchored at the start of the path.
+func robotsPattern(rule string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i, r := range rule {
+		switch {
+		case r == '*':
+			b.WriteString(".*")
+		case r == '$' && i == len(rule)-1:
+			b.WriteString("$")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return regexp.MustCompile(b.String())
+}
+
+// allowed applies the longest matching rule to path; Allow wins ties.
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best, allow := -1, true
+	for _, re := range r.allow {
+		if re.MatchString(path) && r.lengths[re] > best {
+			best, allow = r.lengths[re], true
+		}
+	}
+	for _, re := range r.disallow {
+		if re.MatchString(path) && r.lengths[re] > best {
+			best, allow = r.lengths[re], false
+		}
+	}
+	return allow
+}
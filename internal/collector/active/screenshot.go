@@ -35,12 +35,25 @@ func (c *ScreenshotCollector) IsActive() bool {
 	return true
 }
 
+func (c *ScreenshotCollector) Accepts() []string {
+	return []string{core.EntityDomain, core.EntityIP, core.EntityURL}
+}
+
+func (c *ScreenshotCollector) Produces() []string {
+	return nil // Enriches the target entity in place
+}
+
 func (c *ScreenshotCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
-	if err := ethics.Wait("screenshot"); err != nil {
+	if err := ethics.Wait(context.Background(), "screenshot"); err != nil {
 		return nil, err
 	}
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+// CollectContext captures the screenshot; cancelling ctx tears down the
+// headless browser. Rate limiting is applied by collector.Run.
+func (c *ScreenshotCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("headless", true),
 		chromedp.Flag("disable-gpu", true),
 	)
@@ -57,18 +70,18 @@ func (c *ScreenshotCollector) Collect(caseID string, target string) ([]core.Evid
 	}
 
 	if proxy != "" {
-		opts = append(opts, chromedp.ProxyServer(proxy))
+		allocOpts = append(allocOpts, chromedp.ProxyServer(proxy))
 	}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, allocOpts...)
 	defer cancel()
 
 	// Setup context
-	ctx, cancel := chromedp.NewContext(allocCtx)
+	browserCtx, cancel := chromedp.NewContext(allocCtx)
 	defer cancel()
 
 	// Timeout
-	ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+	browserCtx, cancel = context.WithTimeout(browserCtx, 30*time.Second)
 	defer cancel()
 
 	url := target
@@ -78,13 +91,15 @@ func (c *ScreenshotCollector) Collect(caseID string, target string) ([]core.Evid
 	var buf []byte
 
 	// Run tasks
-	err := chromedp.Run(ctx,
+	opts.Report(0, 1, "navigating to "+url)
+	err := chromedp.Run(browserCtx,
 		chromedp.Navigate(url),
 		chromedp.FullScreenshot(&buf, 90),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("screenshot failed: %w", err)
 	}
+	opts.Report(1, 1, "screenshot captured")
 
 	// Store file
 	storageDir := filepath.Join("evidence_storage", caseID)
@@ -1,6 +1,7 @@
 package active
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -64,6 +65,14 @@ func (c *SocialCollector) IsActive() bool {
 	return true
 }
 
+func (c *SocialCollector) Accepts() []string {
+	return []string{core.EntityUsername}
+}
+
+func (c *SocialCollector) Produces() []string {
+	return []string{core.EntityAccount}
+}
+
 type SiteResult struct {
 	Site   string `json:"site"`
 	URL    string `json:"url"`
@@ -71,10 +80,15 @@ type SiteResult struct {
 }
 
 func (c *SocialCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+func (c *SocialCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
 	// Target is assumed to be the username
 	username := target
 	
 	var results []SiteResult
+	var checked int
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 5) // Limit concurrency
@@ -88,14 +102,14 @@ func (c *SocialCollector) Collect(caseID string, target string) ([]core.Evidence
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			if err := ethics.Wait("social"); err != nil {
+			if err := ethics.Wait(ctx, "social"); err != nil {
 				return
 			}
 
 			checkURL := fmt.Sprintf(urlTmpl, username)
 			status := "error"
 
-			req, err := http.NewRequest("GET", checkURL, nil)
+			req, err := http.NewRequestWithContext(ctx, "GET", checkURL, nil)
 			if err == nil {
 				req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
 				resp, err := client.Do(req)
@@ -113,20 +127,26 @@ func (c *SocialCollector) Collect(caseID string, target string) ([]core.Evidence
                 }
 			}
 
+			mu.Lock()
+			defer mu.Unlock()
 			if status == "found" {
-				mu.Lock()
 				results = append(results, SiteResult{
 					Site:   site,
 					URL:    checkURL,
 					Status: status,
 				})
-				mu.Unlock()
 			}
+			checked++
+			opts.Report(checked, len(c.Sites), fmt.Sprintf("%s: %s", site, status))
 		}(site, urlTmpl)
 	}
 
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if len(results) == 0 {
 		return nil, nil // No evidence found
 	}
@@ -0,0 +1,272 @@
+package active
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+type TLSCollector struct{}
+
+func init() {
+	collector.Register(&TLSCollector{})
+}
+
+func (c *TLSCollector) Name() string {
+	return "tls"
+}
+
+func (c *TLSCollector) Description() string {
+	return "TLS certificate chain, negotiated parameters and JA3S/JARM-style fingerprints"
+}
+
+func (c *TLSCollector) IsActive() bool {
+	return true
+}
+
+func (c *TLSCollector) Accepts() []string {
+	return []string{core.EntityDomain, core.EntityIP, core.EntityURL}
+}
+
+func (c *TLSCollector) Produces() []string {
+	return []string{core.EntityCertificate, core.EntityDomain, core.EntityIP}
+}
+
+func (c *TLSCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext handshakes with target (host, host:port or URL). Params
+// may set "port" and "sni"; "jarm" set to "false" skips the extra probes.
+func (c *TLSCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	host, port, err := tlsTarget(target, opts)
+	if err != nil {
+		return nil, err
+	}
+	sni := opts.Params["sni"]
+	if sni == "" && net.ParseIP(host) == nil {
+		sni = host
+	}
+	timeout := 10 * time.Second
+	if v := viper.GetDuration("collectors.tls.timeout"); v > 0 {
+		timeout = v
+	}
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	result := &core.TLSResult{Target: target, Host: host, Port: port, SNI: sni}
+	if err := handshake(ctx, address, sni, timeout, result); err != nil {
+		return nil, fmt.Errorf("tls handshake with %s failed: %w", address, err)
+	}
+
+	// Fingerprints come from raw hellos since crypto/tls hides the
+	// ServerHello extensions. JA3S reuses the reply to the first JARM probe.
+	jarm := !viper.IsSet("collectors.tls.jarm") || viper.GetBool("collectors.tls.jarm")
+	if v, ok := opts.Params["jarm"]; ok {
+		jarm, _ = strconv.ParseBool(v)
+	}
+	probes := jarmProbes[:1]
+	if jarm {
+		probes = jarmProbes
+	}
+	hellos := make([]*serverHello, len(jarmProbes))
+	for i, p := range probes {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		hellos[i], _ = probeServer(ctx, netclient.DialContext, address, sni, p, timeout)
+	}
+	if hellos[0] != nil {
+		result.JA3SString, result.JA3S = JA3S(hellos[0])
+	}
+	if jarm {
+		result.JARM = JARM(hellos)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("tls_%s_%d_%d.json", host, port, time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	metadata := map[string]interface{}{
+		"target":  target,
+		"version": result.Version,
+		"cipher":  result.CipherSuite,
+		"trusted": result.VerifyError == "",
+	}
+	if len(result.Chain) > 0 {
+		metadata["serial"] = result.Chain[0].Serial
+		metadata["not_after"] = result.Chain[0].NotAfter
+	}
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "tls",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata:    metadata,
+		RawData:     result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// handshake completes a normal TLS handshake, fills in the negotiated
+// parameters and chain, and verifies the chain without failing on it.
+func handshake(ctx context.Context, address, sni string, timeout time.Duration, result *core.TLSResult) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	raw, err := netclient.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	defer raw.Close()
+	// Through a proxy the remote address is the proxy's own
+	if tcp, ok := raw.RemoteAddr().(*net.TCPAddr); ok && netclient.DialsDirect() {
+		result.Address = tcp.IP.String()
+	} else if host, _, _ := net.SplitHostPort(address); net.ParseIP(host) != nil {
+		result.Address = host
+	}
+
+	conn := tls.Client(raw, &tls.Config{
+		ServerName:         sni,
+		InsecureSkipVerify: true, // verified below so untrusted chains are still recorded
+		NextProtos:         []string{"h2", "http/1.1"},
+		MinVersion:         tls.VersionTLS10,
+	})
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	result.Version = tls.VersionName(state.Version)
+	result.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	result.ALPN = state.NegotiatedProtocol
+	for _, cert := range state.PeerCertificates {
+		result.Chain = append(result.Chain, certificateInfo(cert))
+	}
+
+	if len(state.PeerCertificates) > 0 {
+		intermediates := x509.NewCertPool()
+		for _, cert := range state.PeerCertificates[1:] {
+			intermediates.AddCert(cert)
+		}
+		name := sni
+		if name == "" {
+			name = result.Address
+		}
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{DNSName: name, Intermediates: intermediates})
+		if err != nil {
+			result.VerifyError = err.Error()
+		}
+	}
+	return nil
+}
+
+func certificateInfo(cert *x509.Certificate) core.Certificate {
+	sum := sha256.Sum256(cert.Raw)
+	info := core.Certificate{
+		Serial:             core.NormalizeSerial(cert.SerialNumber.Text(16)),
+		Issuer:             cert.Issuer.String(),
+		Subject:            cert.Subject.String(),
+		CommonName:         strings.ToLower(cert.Subject.CommonName),
+		NotBefore:          cert.NotBefore.UTC(),
+		NotAfter:           cert.NotAfter.UTC(),
+		SHA256:             hex.EncodeToString(sum[:]),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		PublicKey:          publicKeyName(cert.PublicKey),
+		IsCA:               cert.IsCA,
+		SelfSigned:         cert.CheckSignatureFrom(cert) == nil,
+	}
+	for _, name := range cert.DNSNames {
+		info.SANs = append(info.SANs, strings.ToLower(name))
+	}
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	return info
+}
+
+func publicKeyName(key interface{}) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + strings.ReplaceAll(k.Curve.Params().Name, "-", "")
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+	return "unknown"
+}
+
+// tlsTarget splits target into host and port. URLs use their port (443
+// for https); otherwise the "port" param, collectors.tls.port, then 443.
+func tlsTarget(target string, opts core.CollectOptions) (string, int, error) {
+	port := 443
+	if v := viper.GetInt("collectors.tls.port"); v > 0 {
+		port = v
+	}
+	if v := opts.Params["port"]; v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 1 || p > 65535 {
+			return "", 0, fmt.Errorf("invalid port %q", v)
+		}
+		port = p
+	}
+
+	host := strings.TrimSpace(target)
+	if u, err := url.Parse(host); err == nil && u.Host != "" && (u.Scheme == "https" || u.Scheme == "http") {
+		host = u.Hostname()
+		if p, err := strconv.Atoi(u.Port()); err == nil {
+			port = p
+		}
+	} else if h, p, err := net.SplitHostPort(host); err == nil {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return "", 0, fmt.Errorf("invalid port in %q", target)
+		}
+		host, port = h, n
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", 0, fmt.Errorf("no host in target %q", target)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,131 @@
+package active
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/http"
+)
+
+func TestTLSCollector_Collect(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.EnableHTTP2 = true
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // refused probes are expected
+	var conns atomic.Int32
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	target := strings.TrimPrefix(server.URL, "https://")
+	caseID := "test_case_tls"
+	defer os.RemoveAll(filepath.Join("evidence_storage", caseID))
+
+	evidence, err := (&TLSCollector{}).CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	res := evidence[0].RawData.(*core.TLSResult)
+
+	if res.Host != "127.0.0.1" || res.Address != "127.0.0.1" || res.Port == 443 {
+		t.Errorf("Unexpected host/address/port: %s %s %d", res.Host, res.Address, res.Port)
+	}
+	if res.Version != "TLS 1.3" || res.CipherSuite == "" || res.ALPN != "h2" {
+		t.Errorf("Unexpected negotiated parameters: %s %s %q", res.Version, res.CipherSuite, res.ALPN)
+	}
+	if len(res.Chain) == 0 {
+		t.Fatal("Expected the presented chain")
+	}
+	leaf := res.Chain[0]
+	if leaf.Serial == "" || len(leaf.SHA256) != 64 || leaf.PublicKey == "unknown" {
+		t.Errorf("Unexpected leaf certificate: %+v", leaf)
+	}
+	if !containsName(leaf.SANs, "example.com") || !containsName(leaf.SANs, "127.0.0.1") {
+		t.Errorf("Expected the test certificate SANs, got %v", leaf.SANs)
+	}
+	if res.VerifyError == "" {
+		t.Error("The httptest certificate is not publicly trusted; expected a verify error")
+	}
+
+	if len(res.JA3S) != 32 || !strings.HasPrefix(res.JA3SString, "771,") {
+		t.Errorf("Unexpected JA3S: %q (%s)", res.JA3S, res.JA3SString)
+	}
+	if len(res.JARM) != 62 || res.JARM == strings.Repeat("0", 62) {
+		t.Errorf("Unexpected JARM: %q", res.JARM)
+	}
+	// Probe 4 offers at most TLS 1.1, which Go servers refuse
+	if res.JARM[12:15] != "000" {
+		t.Errorf("Expected the TLS 1.1 probe to be refused, got %q", res.JARM[12:15])
+	}
+	// One handshake plus the JARM probes; JA3S needs no connection of its own
+	if got, want := int(conns.Load()), 1+len(jarmProbes); got != want {
+		t.Errorf("Expected %d connections, got %d", want, got)
+	}
+}
+
+func TestTLSProbe_Deterministic(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	var fps []string
+	for i := 0; i < 2; i++ {
+		hellos := make([]*serverHello, len(jarmProbes))
+		for j, p := range jarmProbes {
+			hellos[j], _ = probeServer(context.Background(), netclient.DialContext, address, "example.com", p, 5*time.Second)
+		}
+		fps = append(fps, JARM(hellos))
+	}
+	if fps[0] != fps[1] {
+		t.Errorf("Expected a stable fingerprint, got %s and %s", fps[0], fps[1])
+	}
+}
+
+func TestTLSTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		params map[string]string
+		host   string
+		port   int
+	}{
+		{"example.com", nil, "example.com", 443},
+		{"Example.com.", map[string]string{"port": "8443"}, "example.com", 8443},
+		{"192.0.2.1:993", nil, "192.0.2.1", 993},
+		{"https://example.com:9443/login", nil, "example.com", 9443},
+		{"[2001:db8::1]:443", nil, "2001:db8::1", 443},
+	}
+	for _, tt := range tests {
+		host, port, err := tlsTarget(tt.target, core.CollectOptions{Params: tt.params})
+		if err != nil || host != tt.host || port != tt.port {
+			t.Errorf("tlsTarget(%q) = %s, %d, %v; want %s, %d", tt.target, host, port, err, tt.host, tt.port)
+		}
+	}
+	if _, _, err := tlsTarget("example.com", core.CollectOptions{Params: map[string]string{"port": "99999"}}); err == nil {
+		t.Error("Expected an invalid port to be rejected")
+	}
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,324 @@
+package active
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS record and handshake constants used by the raw probes.
+const (
+	recordHandshake   = 0x16
+	recordAlert       = 0x15
+	typeClientHello   = 0x01
+	typeServerHello   = 0x02
+	extServerName     = 0x0000
+	extSupportedGroup = 0x000a
+	extPointFormats   = 0x000b
+	extSigAlgs        = 0x000d
+	extALPN           = 0x0010
+	extSupportedVers  = 0x002b
+	extPSKModes       = 0x002d
+	extKeyShare       = 0x0033
+	maxServerHello    = 64 << 10
+)
+
+// probeCiphers is the cipher list the probes offer, in "forward" order.
+var probeCiphers = []uint16{
+	0x1301, 0x1302, 0x1303, // TLS 1.3
+	0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, // ECDHE AEAD
+	0xc009, 0xc013, 0xc00a, 0xc014, // ECDHE CBC
+	0x009c, 0x009d, 0x002f, 0x0035, 0x000a, // RSA
+}
+
+// tlsProbe describes one ClientHello variant.
+type tlsProbe struct {
+	version uint16 // highest version offered
+	reverse bool   // offer ciphers in reverse order
+	alpn    []string
+	tls13   bool // send supported_versions and key_share
+}
+
+// jarmProbes are the ClientHellos behind the JARM-style fingerprint. Like
+// JARM they vary version, cipher order and ALPN, but the exact hellos differ,
+// so the hashes are not comparable with published JARM values.
+var jarmProbes = []tlsProbe{
+	{version: 0x0303, alpn: []string{"h2", "http/1.1"}},
+	{version: 0x0303, reverse: true, alpn: []string{"h2", "http/1.1"}},
+	{version: 0x0303},
+	{version: 0x0303, alpn: []string{"http/1.1"}},
+	{version: 0x0302, alpn: []string{"http/1.1"}},
+	{version: 0x0303, tls13: true, alpn: []string{"h2", "http/1.1"}},
+	{version: 0x0303, tls13: true, reverse: true, alpn: []string{"h2", "http/1.1"}},
+	{version: 0x0303, tls13: true},
+}
+
+// serverHello holds the ServerHello fields fingerprints are built from.
+type serverHello struct {
+	Version    uint16 // negotiated version (supported_versions when present)
+	Legacy     uint16 // version field of the ServerHello itself
+	Cipher     uint16
+	Extensions []uint16
+	ALPN       string
+}
+
+// probeServer sends the ClientHello described by p and parses the reply.
+// A nil hello with a nil error means the server refused the handshake.
+func probeServer(ctx context.Context, dial func(context.Context, string, string) (net.Conn, error), address, sni string, p tlsProbe, timeout time.Duration) (*serverHello, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := dial(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	hello, err := buildClientHello(p, sni)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(hello); err != nil {
+		return nil, err
+	}
+	return readServerHello(conn)
+}
+
+// buildClientHello encodes a ClientHello record for p.
+func buildClientHello(p tlsProbe, sni string) ([]byte, error) {
+	random := make([]byte, 32+32+32) // random, session ID, x25519 key share
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	ciphers := make([]uint16, 0, len(probeCiphers))
+	for _, c := range probeCiphers {
+		if c>>8 == 0x13 && !p.tls13 {
+			continue
+		}
+		ciphers = append(ciphers, c)
+	}
+	if p.reverse {
+		for i, j := 0, len(ciphers)-1; i < j; i, j = i+1, j-1 {
+			ciphers[i], ciphers[j] = ciphers[j], ciphers[i]
+		}
+	}
+
+	var ext []byte
+	if sni != "" && net.ParseIP(sni) == nil {
+		name := u16(len(sni)+3, append([]byte{0}, u16(len(sni), []byte(sni))...))
+		ext = append(ext, extension(extServerName, name)...)
+	}
+	ext = append(ext, extension(extSupportedGroup, u16list([]uint16{0x001d, 0x0017, 0x0018}))...)
+	ext = append(ext, extension(extPointFormats, []byte{1, 0})...)
+	ext = append(ext, extension(extSigAlgs, u16list([]uint16{0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601, 0x0201}))...)
+	if len(p.alpn) > 0 {
+		var list []byte
+		for _, proto := range p.alpn {
+			list = append(list, byte(len(proto)))
+			list = append(list, proto...)
+		}
+		ext = append(ext, extension(extALPN, u16(len(list), list))...)
+	}
+	if p.tls13 {
+		ext = append(ext, extension(extSupportedVers, []byte{4, 0x03, 0x04, 0x03, 0x03})...)
+		ext = append(ext, extension(extPSKModes, []byte{1, 1})...)
+		share := append([]byte{0x00, 0x1d}, u16(32, random[64:96])...)
+		ext = append(ext, extension(extKeyShare, u16(len(share), share))...)
+	}
+
+	body := make([]byte, 0, 512)
+	body = binary.BigEndian.AppendUint16(body, p.version)
+	body = append(body, random[:32]...)
+	body = append(body, 32)
+	body = append(body, random[32:64]...)
+	body = append(body, u16list(ciphers)...)
+	body = append(body, 1, 0) // null compression
+	body = append(body, u16(len(ext), ext)...)
+
+	handshake := append([]byte{typeClientHello, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}, body...)
+	record := []byte{recordHandshake, 0x03, 0x01}
+	return append(record, u16(len(handshake), handshake)...), nil
+}
+
+// readServerHello reads handshake records until the ServerHello is
+// complete. Alerts and closed connections mean the probe was refused.
+func readServerHello(r io.Reader) (*serverHello, error) {
+	var handshake []byte
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if len(handshake) == 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		switch header[0] {
+		case recordAlert:
+			return nil, nil
+		case recordHandshake:
+			handshake = append(handshake, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected TLS record type %d", header[0])
+		}
+		if len(handshake) > maxServerHello {
+			return nil, fmt.Errorf("ServerHello too large")
+		}
+		if len(handshake) >= 4 {
+			if handshake[0] != typeServerHello {
+				return nil, fmt.Errorf("unexpected handshake message %d", handshake[0])
+			}
+			msgLen := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+			if len(handshake) >= 4+msgLen {
+				return parseServerHello(handshake[4 : 4+msgLen])
+			}
+		}
+	}
+}
+
+func parseServerHello(b []byte) (*serverHello, error) {
+	errShort := fmt.Errorf("truncated ServerHello")
+	if len(b) < 2+32+1 {
+		return nil, errShort
+	}
+	h := &serverHello{Legacy: binary.BigEndian.Uint16(b)}
+	h.Version = h.Legacy
+	b = b[34:]
+	sidLen := int(b[0])
+	if len(b) < 1+sidLen+3 {
+		return nil, errShort
+	}
+	b = b[1+sidLen:]
+	h.Cipher = binary.BigEndian.Uint16(b)
+	b = b[3:] // cipher and compression method
+	if len(b) < 2 {
+		return h, nil // no extensions
+	}
+	extLen := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	if len(b) < extLen {
+		return nil, errShort
+	}
+	b = b[:extLen]
+	for len(b) >= 4 {
+		typ := binary.BigEndian.Uint16(b)
+		n := int(binary.BigEndian.Uint16(b[2:]))
+		if len(b) < 4+n {
+			return nil, errShort
+		}
+		data := b[4 : 4+n]
+		h.Extensions = append(h.Extensions, typ)
+		switch typ {
+		case extSupportedVers:
+			if n == 2 {
+				h.Version = binary.BigEndian.Uint16(data)
+			}
+		case extALPN:
+			if n >= 3 && int(data[2]) <= n-3 {
+				h.ALPN = string(data[3 : 3+int(data[2])])
+			}
+		}
+		b = b[4+n:]
+	}
+	return h, nil
+}
+
+// JA3S returns the JA3S string ("version,cipher,ext-ext") of h and its MD5.
+func JA3S(h *serverHello) (string, string) {
+	exts := make([]string, len(h.Extensions))
+	for i, e := range h.Extensions {
+		exts[i] = strconv.Itoa(int(e))
+	}
+	s := fmt.Sprintf("%d,%d,%s", h.Legacy, h.Cipher, strings.Join(exts, "-"))
+	sum := md5.Sum([]byte(s))
+	return s, hex.EncodeToString(sum[:])
+}
+
+// JARM combines the answers to jarmProbes into a 62 character fingerprint
+// laid out like JARM: per probe the chosen cipher's index and a version
+// letter, then a truncated SHA-256 over ALPN and extension choices. Refused
+// probes contribute "000". All refusals yield all zeros.
+func JARM(hellos []*serverHello) string {
+	var raw strings.Builder
+	var rest strings.Builder
+	answered := false
+	for _, h := range hellos {
+		if h == nil {
+			raw.WriteString("000")
+			continue
+		}
+		answered = true
+		index := 0
+		for i, c := range probeCiphers {
+			if c == h.Cipher {
+				index = i + 1
+				break
+			}
+		}
+		fmt.Fprintf(&raw, "%02x%c", index, versionLetter(h.Version))
+		exts := make([]string, len(h.Extensions))
+		for i, e := range h.Extensions {
+			exts[i] = fmt.Sprintf("%04x", e)
+		}
+		rest.WriteString(h.ALPN + "-" + strings.Join(exts, "-") + "|")
+	}
+	if !answered {
+		return strings.Repeat("0", 62)
+	}
+	sum := sha256.Sum256([]byte(rest.String()))
+	fp := raw.String() + hex.EncodeToString(sum[:])
+	if len(fp) > 62 {
+		fp = fp[:62]
+	}
+	return fp + strings.Repeat("0", 62-len(fp))
+}
+
+func versionLetter(v uint16) byte {
+	switch v {
+	case 0x0304:
+		return 'd'
+	case 0x0303:
+		return 'c'
+	case 0x0302:
+		return 'b'
+	case 0x0301:
+		return 'a'
+	}
+	return '0'
+}
+
+func extension(typ uint16, data []byte) []byte {
+	out := binary.BigEndian.AppendUint16(nil, typ)
+	return append(out, u16(len(data), data)...)
+}
+
+// u16 prefixes data with a two byte length.
+func u16(n int, data []byte) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(n)), data...)
+}
+
+func u16list(values []uint16) []byte {
+	out := make([]byte, 0, len(values)*2)
+	for _, v := range values {
+		out = binary.BigEndian.AppendUint16(out, v)
+	}
+	return u16(len(out), out)
+}
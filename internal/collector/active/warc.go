@@ -0,0 +1,88 @@
+package active
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// warcWriter writes WARC/1.1 records, each compressed as its own gzip
+// member so standard tools can seek to any record of the .warc.gz.
+type warcWriter struct {
+	w io.Writer
+}
+
+// warcinfo writes the leading record describing the crawl.
+func (ww *warcWriter) warcinfo(fields map[string]string) error {
+	var block bytes.Buffer
+	for _, k := range []string{"software", "format", "robots", "description"} {
+		if v, ok := fields[k]; ok {
+			fmt.Fprintf(&block, "%s: %s\r\n", k, v)
+		}
+	}
+	return ww.record("warcinfo", "", "application/warc-fields", block.Bytes(), nil)
+}
+
+// exchange writes a request record and the response record for it. body
+// is what was read of the response; truncated marks it as cut short.
+func (ww *warcWriter) exchange(resp *http.Response, body []byte, truncated bool) error {
+	var req bytes.Buffer
+	fmt.Fprintf(&req, "%s %s HTTP/1.1\r\nHost: %s\r\n", resp.Request.Method, resp.Request.URL.RequestURI(), resp.Request.URL.Host)
+	resp.Request.Header.Write(&req)
+	req.WriteString("\r\n")
+	uri := resp.Request.URL.String()
+	if err := ww.record("request", uri, "application/http;msgtype=request", req.Bytes(), nil); err != nil {
+		return err
+	}
+
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "HTTP/%d.%d %s\r\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
+	resp.Header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(body)
+
+	extra := map[string]string{"WARC-Payload-Digest": warcDigest(body)}
+	if truncated {
+		extra["WARC-Truncated"] = "length"
+	}
+	return ww.record("response", uri, "application/http;msgtype=response", block.Bytes(), extra)
+}
+
+func (ww *warcWriter) record(warcType, uri, contentType string, block []byte, extra map[string]string) error {
+	var head bytes.Buffer
+	head.WriteString("WARC/1.1\r\n")
+	fmt.Fprintf(&head, "WARC-Type: %s\r\n", warcType)
+	fmt.Fprintf(&head, "WARC-Record-ID: <urn:uuid:%s>\r\n", uuid.New())
+	fmt.Fprintf(&head, "WARC-Date: %s\r\n", time.Now().UTC().Format(time.RFC3339))
+	if uri != "" {
+		fmt.Fprintf(&head, "WARC-Target-URI: %s\r\n", uri)
+	}
+	// Keys are few and fixed; write them in a stable order
+	for _, k := range []string{"WARC-Payload-Digest", "WARC-Truncated"} {
+		if v, ok := extra[k]; ok {
+			fmt.Fprintf(&head, "%s: %s\r\n", k, v)
+		}
+	}
+	fmt.Fprintf(&head, "WARC-Block-Digest: %s\r\n", warcDigest(block))
+	fmt.Fprintf(&head, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&head, "Content-Length: %d\r\n\r\n", len(block))
+
+	gz := gzip.NewWriter(ww.w)
+	gz.Write(head.Bytes())
+	gz.Write(block)
+	gz.Write([]byte("\r\n\r\n"))
+	return gz.Close()
+}
+
+// warcDigest is the conventional base32 SHA-1 digest used by WARC tools.
+func warcDigest(b []byte) string {
+	sum := sha1.Sum(b)
+	return "sha1:" + base32.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,143 @@
+package asn
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/collector/rdap"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+)
+
+type ASNCollector struct{}
+
+func init() {
+	collector.Register(&ASNCollector{})
+}
+
+func (a *ASNCollector) Name() string {
+	return "asn"
+}
+
+func (a *ASNCollector) Description() string {
+	return "ASN, announced prefix and RIR for IP addresses from offline pfx2as / ip2asn datasets"
+}
+
+func (a *ASNCollector) IsActive() bool {
+	return false
+}
+
+func (a *ASNCollector) Accepts() []string {
+	return []string{core.EntityIP}
+}
+
+func (a *ASNCollector) Produces() []string {
+	return []string{core.EntityASN, core.EntityNetblock}
+}
+
+func (a *ASNCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return a.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext finds the most specific prefix announced for target in
+// the datasets listed in collectors.asn.datasets. Params may set "dataset"
+// to search a single file instead.
+func (a *ASNCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	paths := viper.GetStringSlice("collectors.asn.datasets")
+	if v := opts.Params["dataset"]; v != "" {
+		paths = []string{v}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no ASN datasets configured (collectors.asn.datasets)")
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(target))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q: %w", target, err)
+	}
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return nil, fmt.Errorf("%s is not a publicly routed address", addr)
+	}
+
+	table, err := loadCached(paths)
+	if err != nil {
+		return nil, err
+	}
+	prefix, r, ok := table.Lookup(addr)
+	if !ok {
+		return nil, fmt.Errorf("no announced prefix covers %s", addr)
+	}
+
+	// The registries come from the RDAP bootstrap the rdap collector uses
+	bootstrap, err := rdap.LoadBootstrap(viper.GetString("collectors.rdap.bootstrap_dir"))
+	if err != nil {
+		return nil, err
+	}
+	result := &core.ASNResult{
+		IP:      addr.String(),
+		Prefix:  prefix.String(),
+		RIR:     rdap.RIR(bootstrap.IP(addr)),
+		Dataset: r.dataset,
+	}
+	for _, n := range r.origins {
+		names := table.names[n]
+		result.Origins = append(result.Origins, core.ASNInfo{
+			Number:  n,
+			Name:    names.name,
+			Country: names.country,
+			RIR:     rdap.RIR(bootstrap.ASN(n)),
+		})
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("asn_%s_%d.json", strings.ReplaceAll(result.IP, ":", "_"), time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	metadata := map[string]interface{}{
+		"target": target,
+		"prefix": result.Prefix,
+	}
+	if len(result.Origins) > 0 {
+		metadata["asn"] = result.Origins[0].Value()
+		metadata["as_name"] = result.Origins[0].Name
+	}
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "asn",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata:    metadata,
+		RawData:     result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
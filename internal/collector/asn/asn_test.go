@@ -0,0 +1,112 @@
+package asn
+
+import (
+	"compress/gzip"
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+)
+
+// gzipCopy writes a gzipped copy of a testdata file and returns its path.
+func gzipCopy(t *testing.T, name string) string {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name+".gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write(data)
+	gz.Close()
+	f.Close()
+	return path
+}
+
+func TestASNCollector(t *testing.T) {
+	pfx2as, _ := filepath.Abs(filepath.Join("testdata", "sample.pfx2as"))
+	viper.Set("collectors.asn.datasets", []string{pfx2as, gzipCopy(t, "sample.ip2asn.tsv")})
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		viper.Set("collectors.asn.datasets", nil)
+	})
+
+	tests := []struct {
+		ip      string
+		prefix  string
+		origins []core.ASNInfo
+		rir     string
+	}{
+		// pfx2as is listed first, so its origin wins over ip2asn's
+		{"192.0.2.10", "192.0.2.0/24", []core.ASNInfo{{Number: 64496}}, "ARIN"},
+		// more specific prefix
+		{"198.51.100.200", "198.51.100.128/25", []core.ASNInfo{{Number: 64499}}, "ARIN"},
+		// multi-origin prefix, named from ip2asn
+		{"198.51.101.1", "198.51.100.0/22", []core.ASNInfo{{Number: 64497, Name: "EXAMPLE-HOSTING", Country: "US"}, {Number: 64498}}, "ARIN"},
+		{"2001:db8::1", "2001:db8::/32", []core.ASNInfo{{Number: 64500, Name: "EXAMPLE-V6", Country: "DE"}}, "APNIC"},
+	}
+	for _, tt := range tests {
+		evs, err := (&ASNCollector{}).Collect("case-asn", tt.ip)
+		if err != nil {
+			t.Errorf("Collect(%s) failed: %v", tt.ip, err)
+			continue
+		}
+		res := evs[0].RawData.(*core.ASNResult)
+		if res.Prefix != tt.prefix || !reflect.DeepEqual(res.Origins, tt.origins) || res.RIR != tt.rir {
+			t.Errorf("Collect(%s) = %+v, want prefix %s origins %+v rir %q", tt.ip, res, tt.prefix, tt.origins, tt.rir)
+		}
+	}
+
+	for _, ip := range []string{"203.0.113.5", "10.0.0.1", "not-an-ip"} {
+		if _, err := (&ASNCollector{}).Collect("case-asn", ip); err == nil {
+			t.Errorf("Collect(%s): expected an error", ip)
+		}
+	}
+
+	// A single dataset through Params
+	evs, err := (&ASNCollector{}).CollectContext(context.Background(), "case-asn", "192.0.2.10", core.CollectOptions{
+		Params: map[string]string{"dataset": filepath.Join(cwd, "testdata", "sample.ip2asn.tsv")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evs[0].Metadata["asn"] != "AS64510" || evs[0].Metadata["as_name"] != "OTHER-AS" {
+		t.Errorf("unexpected metadata %v", evs[0].Metadata)
+	}
+}
+
+func TestASNCollector_NoDatasets(t *testing.T) {
+	if _, err := (&ASNCollector{}).Collect("case-asn", "192.0.2.10"); err == nil || !strings.Contains(err.Error(), "collectors.asn.datasets") {
+		t.Errorf("expected a configuration error, got %v", err)
+	}
+}
+
+func TestLoadTable_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.tsv")
+	os.WriteFile(path, []byte("192.0.2.0\t24\t64496\nprefix,length,asn\n"), 0644)
+	if _, err := LoadTable([]string{path}); err == nil || !strings.Contains(err.Error(), "bad.tsv:2") {
+		t.Errorf("expected an error naming the line, got %v", err)
+	}
+}
+
+func TestTableLookup_Unmapped(t *testing.T) {
+	table, err := LoadTable([]string{filepath.Join("testdata", "sample.pfx2as")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix, _, ok := table.Lookup(netip.MustParseAddr("::ffff:192.0.2.1"))
+	if !ok || prefix.String() != "192.0.2.0/24" {
+		t.Errorf("expected IPv4-mapped address to match 192.0.2.0/24, got %v", prefix)
+	}
+}
@@ -0,0 +1,196 @@
+package asn
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spectre/spectre/internal/collector/rdap"
+)
+
+// route is an announced prefix and the AS numbers originating it.
+type route struct {
+	origins []uint32
+	dataset string
+}
+
+type asName struct {
+	name, country string
+}
+
+// Table maps announced prefixes to their origin ASes. It reads two line
+// formats, detected per line, so dumps can be mixed freely:
+//
+//	pfx2as (CAIDA RouteViews): prefix, length, origins ("13335", or
+//	"4739_4826" for multi-origin prefixes, "1,2" for AS sets)
+//	ip2asn (iptoasn.com): range start, range end, AS number, country, AS name
+//
+// Fields are tab-separated and files may be gzip-compressed.
+type Table struct {
+	routes map[netip.Prefix]*route
+	names  map[uint32]asName
+}
+
+// LoadTable reads the datasets in order. When two datasets announce the
+// same prefix the first one listed wins; AS names come from whichever
+// dataset has them.
+func LoadTable(paths []string) (*Table, error) {
+	t := &Table{routes: make(map[netip.Prefix]*route), names: make(map[uint32]asName)}
+	for _, path := range paths {
+		if err := t.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *Table) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open ASN dataset: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if magic, _ := r.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to read ASN dataset %s: %w", path, err)
+		}
+		defer gz.Close()
+		return t.read(gz, filepath.Base(path))
+	}
+	return t.read(r, filepath.Base(path))
+}
+
+func (t *Table) read(r io.Reader, dataset string) error {
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := t.parseLine(line, dataset); err != nil {
+			return fmt.Errorf("%s:%d: %w", dataset, n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ASN dataset %s: %w", dataset, err)
+	}
+	return nil
+}
+
+func (t *Table) parseLine(line, dataset string) error {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 3 {
+		return fmt.Errorf("expected pfx2as or ip2asn fields, got %q", line)
+	}
+
+	start, err := netip.ParseAddr(fields[0])
+	if err != nil {
+		return fmt.Errorf("invalid address %q", fields[0])
+	}
+
+	// pfx2as: the second field is a prefix length
+	if bits, err := strconv.Atoi(fields[1]); err == nil {
+		prefix, err := start.Prefix(bits)
+		if err != nil {
+			return err
+		}
+		var origins []uint32
+		for _, s := range strings.FieldsFunc(fields[2], func(r rune) bool { return r == '_' || r == ',' }) {
+			n, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid AS number %q", s)
+			}
+			origins = append(origins, uint32(n))
+		}
+		t.add([]netip.Prefix{prefix}, &route{origins: origins, dataset: dataset})
+		return nil
+	}
+
+	// ip2asn: a range; AS 0 marks unrouted space
+	end, err := netip.ParseAddr(fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid address %q", fields[1])
+	}
+	n, err := strconv.ParseUint(fields[2], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid AS number %q", fields[2])
+	}
+	if n == 0 {
+		return nil
+	}
+	var info asName
+	if len(fields) > 3 && fields[3] != "None" {
+		info.country = fields[3]
+	}
+	if len(fields) > 4 {
+		info.name = fields[4]
+	}
+	if _, ok := t.names[uint32(n)]; !ok && (info.name != "" || info.country != "") {
+		t.names[uint32(n)] = info
+	}
+	t.add(rdap.RangeToPrefixes(start, end), &route{origins: []uint32{uint32(n)}, dataset: dataset})
+	return nil
+}
+
+func (t *Table) add(prefixes []netip.Prefix, r *route) {
+	for _, p := range prefixes {
+		p = p.Masked()
+		if _, ok := t.routes[p]; !ok {
+			t.routes[p] = r
+		}
+	}
+}
+
+// Lookup returns the most specific announced prefix containing addr.
+func (t *Table) Lookup(addr netip.Addr) (netip.Prefix, *route, bool) {
+	addr = addr.Unmap()
+	for bits := addr.BitLen(); bits >= 0; bits-- {
+		p, _ := addr.Prefix(bits)
+		if r, ok := t.routes[p]; ok {
+			return p, r, true
+		}
+	}
+	return netip.Prefix{}, nil, false
+}
+
+var (
+	cacheMu  sync.Mutex
+	cacheKey string
+	cached   *Table
+)
+
+// loadCached returns the table for paths, reading the files again only
+// when the list or one of the files changes. Full dumps take a moment to
+// load and a pivot run looks up many addresses.
+func loadCached(paths []string) (*Table, error) {
+	var key strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ASN dataset: %w", err)
+		}
+		fmt.Fprintf(&key, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if cached != nil && cacheKey == key.String() {
+		return cached, nil
+	}
+	t, err := LoadTable(paths)
+	if err != nil {
+		return nil, err
+	}
+	cached, cacheKey = t, key.String()
+	return t, nil
+}
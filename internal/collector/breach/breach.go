@@ -0,0 +1,125 @@
+package breach
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+)
+
+// DefaultStore is the breach store used when collectors.breach.store is unset.
+const DefaultStore = "breaches.db"
+
+type BreachCollector struct{}
+
+func init() {
+	collector.Register(&BreachCollector{})
+}
+
+func (b *BreachCollector) Name() string {
+	return "breach"
+}
+
+func (b *BreachCollector) Description() string {
+	return "Offline breach-corpus lookup for emails and usernames (local store, nothing leaves the machine)"
+}
+
+func (b *BreachCollector) IsActive() bool {
+	return false
+}
+
+func (b *BreachCollector) Accepts() []string {
+	return []string{core.EntityEmail, core.EntityUsername}
+}
+
+func (b *BreachCollector) Produces() []string {
+	return []string{core.EntityBreach}
+}
+
+func (b *BreachCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return b.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext looks target up in the local breach store. Params may set
+// "store" to search a different store file.
+func (b *BreachCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	path := StorePath()
+	if v := opts.Params["store"]; v != "" {
+		path = v
+	}
+	store, err := OpenStore(path, false)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	identifier := normalize(target)
+	kind := core.EntityUsername
+	if strings.Contains(identifier, "@") {
+		kind = core.EntityEmail
+	}
+	exposures, err := store.Lookup(kind, identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &core.BreachResult{
+		Target:    identifier,
+		Kind:      kind,
+		Store:     path,
+		Exposures: exposures,
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("breach_%s_%d.json", strings.NewReplacer("/", "_", "\\", "_").Replace(identifier), time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "breach",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"target":   target,
+			"kind":     kind,
+			"breaches": len(exposures),
+		},
+		RawData: result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// StorePath returns the configured breach store file.
+func StorePath() string {
+	if path := viper.GetString("collectors.breach.store"); path != "" {
+		return path
+	}
+	return DefaultStore
+}
@@ -0,0 +1,117 @@
+package breach
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+const testCSV = `Email,Username,Password,Phone Number,Last IP
+Jane.Doe@Example.com,jdoe,hunter2-secret,,203.0.113.7
+bob@example.com,,correct-horse,+15551234567,
+,orphan,tr0ub4dor,,
+`
+
+const testJSONL = `{"email": "jane.doe@example.com", "password_hash": "$2y$10$abcdefghijklmnopqrstuv", "dob": "1990-01-01"}
+{"login": "jdoe", "pass": "letmein-secret", "id": 42}
+
+{"email": null, "username": "nobody"}
+`
+
+func newStore(t *testing.T) (*Store, string) {
+	path := filepath.Join(t.TempDir(), "breaches.db")
+	store, err := OpenStore(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if n, err := store.Import(strings.NewReader(testCSV), FormatCSV, Breach{Name: "acme-2019", Title: "Acme Forum", Date: "2019-03-01"}); err != nil || n != 3 {
+		t.Fatalf("CSV import = %d, %v", n, err)
+	}
+	if n, err := store.Import(strings.NewReader(testJSONL), FormatJSONL, Breach{Name: "shop-2021", Date: "2021-07-15"}); err != nil || n != 3 {
+		t.Fatalf("JSONL import = %d, %v", n, err)
+	}
+	return store, path
+}
+
+func TestStore_ImportAndLookup(t *testing.T) {
+	store, path := newStore(t)
+
+	got, err := store.Lookup(core.EntityEmail, "JANE.DOE@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Breach != "shop-2021" || got[1].Breach != "acme-2019" {
+		t.Fatalf("expected both breaches newest first, got %+v", got)
+	}
+	if want := []string{"date_of_birth", "email", "password_hash"}; !reflect.DeepEqual(got[0].Fields, want) {
+		t.Errorf("shop-2021 fields = %v, want %v", got[0].Fields, want)
+	}
+	if want := []string{"email", "ip_address", "password", "username"}; !reflect.DeepEqual(got[1].Fields, want) {
+		t.Errorf("acme-2019 fields = %v, want %v", got[1].Fields, want)
+	}
+	if got[1].Title != "Acme Forum" || got[1].Date != "2019-03-01" {
+		t.Errorf("unexpected breach details %+v", got[1])
+	}
+
+	users, _ := store.Lookup(core.EntityUsername, "jdoe")
+	if len(users) != 2 {
+		t.Errorf("expected jdoe in both breaches, got %+v", users)
+	}
+	if none, _ := store.Lookup(core.EntityEmail, "nobody@example.com"); len(none) != 0 {
+		t.Errorf("expected no exposures, got %+v", none)
+	}
+
+	breaches, _ := store.Breaches()
+	if len(breaches) != 2 || breaches[0].Records != 3 {
+		t.Errorf("unexpected breach list %+v", breaches)
+	}
+
+	// Nothing but identifiers and field names may reach the disk
+	store.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2-secret", "correct-horse", "letmein-secret", "tr0ub4dor", "abcdefghijklmnopqrstuv", "203.0.113.7", "1990-01-01"} {
+		if bytes.Contains(data, []byte(secret)) {
+			t.Errorf("breach store contains %q", secret)
+		}
+	}
+}
+
+func TestBreachCollector_Collect(t *testing.T) {
+	_, path := newStore(t)
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(cwd)
+
+	opts := core.CollectOptions{Params: map[string]string{"store": path}}
+	evs, err := (&BreachCollector{}).CollectContext(context.Background(), "case-breach", "jdoe", opts)
+	if err != nil {
+		t.Fatalf("CollectContext failed: %v", err)
+	}
+	res := evs[0].RawData.(*core.BreachResult)
+	if res.Kind != core.EntityUsername || len(res.Exposures) != 2 {
+		t.Errorf("unexpected result %+v", res)
+	}
+	data, _ := os.ReadFile(evs[0].FilePath)
+	if bytes.Contains(data, []byte("hunter2")) || bytes.Contains(data, []byte("letmein")) {
+		t.Error("evidence contains a password")
+	}
+
+	opts.Params["store"] = filepath.Join(t.TempDir(), "missing.db")
+	if _, err := (&BreachCollector{}).CollectContext(context.Background(), "case-breach", "jdoe", opts); err == nil {
+		t.Error("expected an error for a missing store")
+	}
+	if _, err := os.Stat(opts.Params["store"]); !os.IsNotExist(err) {
+		t.Error("lookup created a store file")
+	}
+}
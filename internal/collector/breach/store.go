@@ -0,0 +1,366 @@
+package breach
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Import formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// storeSchema keeps one row per identifier, breach and field name. The
+// primary key doubles as the lookup index.
+const storeSchema = `
+CREATE TABLE IF NOT EXISTS breaches (
+	name TEXT PRIMARY KEY,
+	title TEXT,
+	breach_date TEXT,
+	imported_at DATETIME NOT NULL,
+	records INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS exposures (
+	identifier TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	breach TEXT NOT NULL REFERENCES breaches(name),
+	field TEXT NOT NULL,
+	PRIMARY KEY (identifier, kind, breach, field)
+) WITHOUT ROWID;
+`
+
+// fieldAliases maps common column names onto one field name per data class.
+var fieldAliases = map[string]string{
+	"e_mail": "email", "mail": "email", "email_address": "email",
+	"user": "username", "user_name": "username", "login": "username", "handle": "username", "nick": "username",
+	"pass": "password", "pwd": "password", "passwd": "password", "plaintext": "password",
+	"hash": "password_hash", "passhash": "password_hash", "password_hashed": "password_hash",
+	"ip": "ip_address", "ipaddress": "ip_address", "last_ip": "ip_address",
+	"phone_number": "phone", "mobile": "phone", "tel": "phone",
+	"dob": "date_of_birth", "birthdate": "date_of_birth", "birthday": "date_of_birth",
+}
+
+// Breach describes one imported breach.
+type Breach struct {
+	Name       string    `json:"name"`
+	Title      string    `json:"title,omitempty"`
+	Date       string    `json:"date,omitempty"`
+	ImportedAt time.Time `json:"imported_at"`
+	Records    int       `json:"records"`
+}
+
+// Store is a local SQLite index of breach compilations. Only identifiers
+// (emails and usernames) and the names of the fields present in each record
+// are stored; passwords and every other value are dropped on import.
+type Store struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenStore opens the store at path, creating it when create is set.
+// Without create a missing store is an error, so lookups never leave an
+// empty database behind.
+func OpenStore(path string, create bool) (*Store, error) {
+	dsn := "file:" + filepath.ToSlash(path)
+	if !create {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("breach store %s not found (import one with 'spectre breach import'): %w", path, err)
+		}
+		dsn += "?mode=ro"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breach store: %w", err)
+	}
+	if create {
+		if _, err := db.Exec(storeSchema); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialize breach store: %w", err)
+		}
+	}
+	return &Store{db: db, path: path}, nil
+}
+
+// Path returns the file backing the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// FormatFor guesses the import format from a file name.
+func FormatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONL
+	default:
+		return FormatCSV
+	}
+}
+
+// Import reads records from r and indexes every email and username found
+// under b.Name, returning the number of records indexed. CSV input needs a
+// header row; JSON lines input is one object per line. Importing the same
+// breach again adds to it.
+func (s *Store) Import(r io.Reader, format string, b Breach) (int, error) {
+	if b.Name == "" {
+		return 0, fmt.Errorf("breach name is required")
+	}
+	if b.ImportedAt.IsZero() {
+		b.ImportedAt = time.Now().UTC()
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO breaches (name, title, breach_date, imported_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			title = COALESCE(NULLIF(excluded.title, ''), title),
+			breach_date = COALESCE(NULLIF(excluded.breach_date, ''), breach_date),
+			imported_at = excluded.imported_at`,
+		b.Name, b.Title, b.Date, b.ImportedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record breach: %w", err)
+	}
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO exposures (identifier, kind, breach, field) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare import: %w", err)
+	}
+	defer stmt.Close()
+
+	count := 0
+	add := func(rec map[string]string) error {
+		ids := identifiers(rec)
+		if len(ids) == 0 {
+			return nil
+		}
+		var fields []string
+		for f, v := range rec {
+			if v != "" {
+				fields = append(fields, f)
+			}
+		}
+		for kind, id := range ids {
+			for _, f := range fields {
+				if _, err := stmt.Exec(id, kind, b.Name, f); err != nil {
+					return fmt.Errorf("failed to index record: %w", err)
+				}
+			}
+		}
+		count++
+		return nil
+	}
+
+	switch format {
+	case FormatCSV:
+		err = readCSV(r, add)
+	case FormatJSONL:
+		err = readJSONL(r, add)
+	default:
+		err = fmt.Errorf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`UPDATE breaches SET records = records + ? WHERE name = ?`, count, b.Name); err != nil {
+		return 0, fmt.Errorf("failed to record breach: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return count, nil
+}
+
+// Lookup returns the breaches identifier appears in as the given kind
+// (core.EntityEmail or core.EntityUsername), newest breach first.
+func (s *Store) Lookup(kind, identifier string) ([]core.BreachExposure, error) {
+	query := `SELECT b.name, COALESCE(b.title, ''), COALESCE(b.breach_date, ''), b.imported_at, e.field
+	          FROM exposures e JOIN breaches b ON b.name = e.breach
+	          WHERE e.identifier = ? AND e.kind = ?`
+	rows, err := s.db.Query(query, normalize(identifier), kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query breach store: %w", err)
+	}
+	defer rows.Close()
+
+	byName := make(map[string]*core.BreachExposure)
+	for rows.Next() {
+		var e core.BreachExposure
+		var field string
+		if err := rows.Scan(&e.Breach, &e.Title, &e.Date, &e.ImportedAt, &field); err != nil {
+			return nil, fmt.Errorf("failed to scan exposure: %w", err)
+		}
+		if byName[e.Breach] == nil {
+			byName[e.Breach] = &e
+		}
+		byName[e.Breach].Fields = append(byName[e.Breach].Fields, field)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query breach store: %w", err)
+	}
+
+	exposures := make([]core.BreachExposure, 0, len(byName))
+	for _, e := range byName {
+		sort.Strings(e.Fields)
+		exposures = append(exposures, *e)
+	}
+	sort.Slice(exposures, func(i, j int) bool {
+		if exposures[i].Date != exposures[j].Date {
+			return exposures[i].Date > exposures[j].Date
+		}
+		return exposures[i].Breach < exposures[j].Breach
+	})
+	return exposures, nil
+}
+
+// Breaches lists the imported breaches by name.
+func (s *Store) Breaches() ([]Breach, error) {
+	rows, err := s.db.Query(`SELECT name, COALESCE(title, ''), COALESCE(breach_date, ''), imported_at, records FROM breaches ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list breaches: %w", err)
+	}
+	defer rows.Close()
+
+	var breaches []Breach
+	for rows.Next() {
+		var b Breach
+		if err := rows.Scan(&b.Name, &b.Title, &b.Date, &b.ImportedAt, &b.Records); err != nil {
+			return nil, fmt.Errorf("failed to scan breach: %w", err)
+		}
+		breaches = append(breaches, b)
+	}
+	return breaches, rows.Err()
+}
+
+// identifiers picks the email and username out of a normalized record.
+func identifiers(rec map[string]string) map[string]string {
+	ids := make(map[string]string)
+	if email := normalize(rec["email"]); strings.Contains(email, "@") {
+		ids[core.EntityEmail] = email
+	}
+	if user := normalize(rec["username"]); user != "" {
+		if strings.Contains(user, "@") {
+			// Sites that log in by email often label the column "login"
+			ids[core.EntityEmail] = user
+		} else {
+			ids[core.EntityUsername] = user
+		}
+	}
+	return ids
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func fieldName(column string) string {
+	f := strings.ToLower(strings.TrimSpace(column))
+	f = strings.NewReplacer(" ", "_", "-", "_").Replace(f)
+	if alias, ok := fieldAliases[f]; ok {
+		return alias
+	}
+	return f
+}
+
+// readCSV streams a CSV file with a header row. Values are trimmed and
+// only survive long enough to pick out identifiers.
+func readCSV(r io.Reader, add func(map[string]string) error) error {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = fieldName(strings.TrimPrefix(h, "\ufeff"))
+	}
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+		rec := make(map[string]string, len(row))
+		for i, v := range row {
+			if i < len(columns) && columns[i] != "" {
+				if v = strings.TrimSpace(v); v != "" {
+					rec[columns[i]] = v
+				}
+			}
+		}
+		if err := add(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// readJSONL streams one JSON object per line. Numbers are kept as text;
+// other non-string values only count towards the fields present.
+func readJSONL(r io.Reader, add func(map[string]string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return fmt.Errorf("failed to parse JSON line %d: %w", line, err)
+		}
+		rec := make(map[string]string, len(obj))
+		for k, v := range obj {
+			f := fieldName(k)
+			switch v := v.(type) {
+			case nil:
+			case string:
+				if v = strings.TrimSpace(v); v != "" {
+					rec[f] = v
+				}
+			case float64:
+				rec[f] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				if f != "email" && f != "username" {
+					rec[f] = "present"
+				}
+			}
+		}
+		if err := add(rec); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package ct
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+// DefaultEndpoint is the public crt.sh instance.
+const DefaultEndpoint = "https://crt.sh/"
+
+// maxResponseBytes bounds a single API response; popular domains return
+// tens of megabytes from crt.sh.
+const maxResponseBytes = 256 << 20
+
+// Entry is one row in crt.sh's JSON output, which is also the format of
+// offline dumps.
+type Entry struct {
+	ID             int64  `json:"id"`
+	IssuerName     string `json:"issuer_name"`
+	CommonName     string `json:"common_name"`
+	NameValue      string `json:"name_value"`
+	SerialNumber   string `json:"serial_number"`
+	NotBefore      string `json:"not_before"`
+	NotAfter       string `json:"not_after"`
+	EntryTimestamp string `json:"entry_timestamp"`
+}
+
+type CTCollector struct{}
+
+func init() {
+	collector.Register(&CTCollector{})
+}
+
+func (c *CTCollector) Name() string {
+	return "ct"
+}
+
+func (c *CTCollector) Description() string {
+	return "Certificate Transparency search (crt.sh-compatible API or offline dumps)"
+}
+
+func (c *CTCollector) IsActive() bool {
+	return false
+}
+
+func (c *CTCollector) Accepts() []string {
+	return []string{core.EntityDomain}
+}
+
+func (c *CTCollector) Produces() []string {
+	return []string{core.EntityCertificate, core.EntityDomain}
+}
+
+func (c *CTCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext finds certificates for target and its subdomains. Params
+// may set "dump" (a crt.sh JSON or JSON Lines file to search instead of the
+// API), "endpoint" and "subdomains" ("false" matches the exact name only).
+func (c *CTCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	domain := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(target), "."))
+	subdomains := true
+	if v, ok := opts.Params["subdomains"]; ok {
+		subdomains, _ = strconv.ParseBool(v)
+	} else if viper.IsSet("collectors.ct.include_subdomains") {
+		subdomains = viper.GetBool("collectors.ct.include_subdomains")
+	}
+
+	var entries []Entry
+	var source string
+	var err error
+	if dump := param(opts, "dump", "collectors.ct.dump"); dump != "" {
+		source = dump
+		entries, err = ReadDump(dump)
+		if err != nil {
+			return nil, err
+		}
+		entries = filterEntries(entries, domain, subdomains)
+	} else {
+		source = param(opts, "endpoint", "collectors.ct.endpoint")
+		if source == "" {
+			source = DefaultEndpoint
+		}
+		entries, err = search(ctx, source, domain, subdomains)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &core.CTResult{
+		Domain:       domain,
+		Source:       source,
+		Entries:      len(entries),
+		Certificates: Certificates(entries),
+	}
+	if viper.GetBool("collectors.ct.exclude_expired") {
+		now := time.Now()
+		kept := result.Certificates[:0]
+		for _, cert := range result.Certificates {
+			if cert.NotAfter.IsZero() || cert.NotAfter.After(now) {
+				kept = append(kept, cert)
+			}
+		}
+		result.Certificates = kept
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("ct_%s_%d.json", domain, time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "ct",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"target":       domain,
+			"source":       source,
+			"certificates": len(result.Certificates),
+		},
+		RawData: result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// search queries a crt.sh-compatible endpoint for the exact name and, when
+// subdomains is set, for "%.<domain>".
+func search(ctx context.Context, endpoint, domain string, subdomains bool) ([]Entry, error) {
+	queries := []string{domain}
+	if subdomains {
+		queries = append(queries, "%."+domain)
+	}
+
+	client := netclient.NewClient()
+	client.Timeout = 2 * time.Minute // crt.sh is slow for large domains
+	if v := viper.GetDuration("collectors.ct.timeout"); v > 0 {
+		client.Timeout = v
+	}
+
+	var all []Entry
+	for i, q := range queries {
+		if i > 0 {
+			if err := ethics.Wait(ctx, "ct"); err != nil {
+				return nil, err
+			}
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CT endpoint %q: %w", endpoint, err)
+		}
+		values := u.Query()
+		values.Set("q", q)
+		values.Set("output", "json")
+		u.RawQuery = values.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("CT search failed: %w", err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CT response: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("CT endpoint returned %d for %q", resp.StatusCode, q)
+		}
+		if len(body) > maxResponseBytes {
+			return nil, fmt.Errorf("CT response for %q exceeds %d bytes", q, maxResponseBytes)
+		}
+
+		entries, err := parseEntries(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CT response: %w", err)
+		}
+		all = append(all, entries...)
+	}
+	return all, nil
+}
+
+// ReadDump loads an offline CT dump: either a JSON array in crt.sh's output
+// format or one such object per line.
+func ReadDump(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CT dump: %w", err)
+	}
+	entries, err := parseEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CT dump %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func parseEntries(data []byte) ([]Entry, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		var entries []Entry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+		return entries, nil
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(text, &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// filterEntries keeps dump entries naming domain (or, with subdomains, any
+// name below it).
+func filterEntries(entries []Entry, domain string, subdomains bool) []Entry {
+	var out []Entry
+	for _, e := range entries {
+		for _, name := range append(splitNames(e.NameValue), strings.ToLower(e.CommonName)) {
+			name = strings.TrimPrefix(name, "*.")
+			if name == domain || (subdomains && strings.HasSuffix(name, "."+domain)) {
+				out = append(out, e)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Certificates merges entries into one certificate per issuer and serial;
+// CT logs hold both the precertificate and the final certificate.
+func Certificates(entries []Entry) []core.Certificate {
+	index := make(map[string]int)
+	var certs []core.Certificate
+	for _, e := range entries {
+		serial := core.NormalizeSerial(e.SerialNumber)
+		if serial == "" {
+			continue
+		}
+		key := e.IssuerName + "|" + serial
+		i, ok := index[key]
+		if !ok {
+			i = len(certs)
+			index[key] = i
+			certs = append(certs, core.Certificate{
+				Serial:     serial,
+				Issuer:     e.IssuerName,
+				CommonName: strings.ToLower(e.CommonName),
+				NotBefore:  parseTime(e.NotBefore),
+				NotAfter:   parseTime(e.NotAfter),
+			})
+		}
+		cert := &certs[i]
+		if e.ID != 0 && !containsID(cert.LogIDs, e.ID) {
+			cert.LogIDs = append(cert.LogIDs, e.ID)
+		}
+		if logged := parseTime(e.EntryTimestamp); !logged.IsZero() && (cert.LoggedAt.IsZero() || logged.Before(cert.LoggedAt)) {
+			cert.LoggedAt = logged
+		}
+		for _, name := range splitNames(e.NameValue) {
+			if !containsName(cert.SANs, name) {
+				cert.SANs = append(cert.SANs, name)
+			}
+		}
+	}
+
+	for i := range certs {
+		sort.Strings(certs[i].SANs)
+	}
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].NotBefore.After(certs[j].NotBefore) })
+	return certs
+}
+
+// splitNames splits crt.sh's newline separated name_value.
+func splitNames(v string) []string {
+	var out []string
+	for _, name := range strings.Split(v, "\n") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			out = append(out, strings.TrimSuffix(name, "."))
+		}
+	}
+	return out
+}
+
+// parseTime accepts crt.sh timestamps, which have no zone and optional
+// fractional seconds, as well as RFC 3339.
+func parseTime(v string) time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", time.RFC3339Nano, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
+
+func param(opts core.CollectOptions, name, key string) string {
+	if v := opts.Params[name]; v != "" {
+		return v
+	}
+	return viper.GetString(key)
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsName(names []string, name string) bool {
+	for _, v := range names {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
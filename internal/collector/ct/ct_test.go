@@ -0,0 +1,101 @@
+package ct
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+)
+
+func collect(t *testing.T, params map[string]string) *core.CTResult {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ev, err := (&CTCollector{}).CollectContext(context.Background(), "case-ct", "example.com", core.CollectOptions{Params: params})
+	if err != nil {
+		t.Fatalf("CollectContext failed: %v", err)
+	}
+	return ev[0].RawData.(*core.CTResult)
+}
+
+func TestCT_Dump(t *testing.T) {
+	dump, _ := filepath.Abs("testdata/dump.json")
+	res := collect(t, map[string]string{"dump": dump})
+
+	if res.Entries != 3 {
+		t.Errorf("Expected 3 matching entries (example.org filtered out), got %d", res.Entries)
+	}
+	if len(res.Certificates) != 2 {
+		t.Fatalf("Expected precertificate and certificate to merge into 2 certificates, got %+v", res.Certificates)
+	}
+
+	le := res.Certificates[0]
+	if le.Serial != "4a1b2c3d4e5f6" || le.Issuer != "C=US, O=Let's Encrypt, CN=R3" {
+		t.Errorf("Unexpected newest certificate: %+v", le)
+	}
+	if len(le.LogIDs) != 2 || le.LoggedAt.Format("15:04:05") != "09:59:00" {
+		t.Errorf("Expected both log entries and the earliest timestamp, got %v at %v", le.LogIDs, le.LoggedAt)
+	}
+	if len(le.SANs) != 2 || le.SANs[0] != "example.com" || le.SANs[1] != "www.example.com" {
+		t.Errorf("Unexpected SANs: %v", le.SANs)
+	}
+	if res.Certificates[1].SANs[0] != "*.example.com" {
+		t.Errorf("Expected the wildcard SAN to be kept, got %v", res.Certificates[1].SANs)
+	}
+}
+
+func TestCT_Endpoint(t *testing.T) {
+	ethics.SetLimit("ct", 1000)
+
+	var mu sync.Mutex
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Query().Get("q"))
+		mu.Unlock()
+		if r.URL.Query().Get("output") != "json" {
+			http.Error(w, "json only", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("q") == "example.com" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"id": 1, "issuer_name": "CN=Test CA", "common_name": "api.example.com", "name_value": "api.example.com\n192.0.2.1", "serial_number": "AB:CD", "not_before": "2024-01-01T00:00:00", "not_after": "2024-02-01T00:00:00"}]`))
+	}))
+	defer srv.Close()
+
+	res := collect(t, map[string]string{"endpoint": srv.URL})
+	if len(queries) != 2 || queries[0] != "example.com" || queries[1] != "%.example.com" {
+		t.Errorf("Expected exact and subdomain queries, got %v", queries)
+	}
+	if len(res.Certificates) != 1 || res.Certificates[0].Serial != "abcd" {
+		t.Fatalf("Expected one certificate with a normalized serial, got %+v", res.Certificates)
+	}
+
+	queries = nil
+	collect(t, map[string]string{"endpoint": srv.URL, "subdomains": "false"})
+	if len(queries) != 1 {
+		t.Errorf("Expected only the exact query, got %v", queries)
+	}
+}
+
+func TestCT_EndpointError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	_, err := (&CTCollector{}).CollectContext(context.Background(), "case-ct", "example.com", core.CollectOptions{Params: map[string]string{"endpoint": srv.URL}})
+	if err == nil {
+		t.Fatal("Expected an error for a non-200 response")
+	}
+}
@@ -0,0 +1,85 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// maxTransferRecords bounds how much of a zone is read from one server.
+const maxTransferRecords = 100000
+
+// Transfer requests a full zone transfer (AXFR) of zone from server over
+// TCP and returns its records. Most servers refuse; the error then carries
+// the RCODE.
+func Transfer(ctx context.Context, server, zone string, timeout time.Duration) ([]core.DNSRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	qname, err := dnsmessage.NewName(fqdn(zone))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zone %q: %w", zone, err)
+	}
+	id := uint16(rand.Intn(1 << 16))
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id})
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{Name: qname, Type: dnsmessage.TypeAXFR, Class: dnsmessage.ClassINET})
+	query, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, err
+	}
+
+	// The zone is streamed over one or more messages, starting and ending
+	// with its SOA record (RFC 5936 section 2.2).
+	var records []core.DNSRecord
+	soas := 0
+	for soas < 2 {
+		resp, err := readTCPMessage(conn)
+		if err != nil {
+			if len(records) == 0 {
+				return nil, err
+			}
+			return records, fmt.Errorf("zone transfer ended early: %w", err)
+		}
+		msg, err := parseResponse(resp, id)
+		if err != nil {
+			return records, err
+		}
+		if msg.RCode != dnsmessage.RCodeSuccess {
+			return nil, fmt.Errorf("transfer refused (%s)", strings.TrimPrefix(msg.RCode.String(), "RCode"))
+		}
+		if len(msg.Answers) == 0 {
+			return records, fmt.Errorf("empty zone transfer message")
+		}
+
+		for _, rec := range convertRecords(msg.Answers) {
+			if rec.Type == "SOA" {
+				soas++
+			}
+			records = append(records, rec)
+		}
+		if len(records) > maxTransferRecords {
+			return records, fmt.Errorf("zone exceeds %d records; transfer truncated", maxTransferRecords)
+		}
+	}
+	return records, nil
+}
@@ -1,19 +1,37 @@
 package dns
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spectre/spectre/internal/collector"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
+// Default DKIM selectors and SRV services probed when none are configured.
+var (
+	DefaultDKIMSelectors = []string{"default", "google", "selector1", "selector2", "k1", "mail", "dkim", "s1", "s2", "smtp"}
+	DefaultSRVServices   = []string{"_sip._tcp", "_sips._tcp", "_xmpp-client._tcp", "_xmpp-server._tcp",
+		"_autodiscover._tcp", "_ldap._tcp", "_kerberos._tcp", "_caldav._tcp", "_carddav._tcp",
+		"_imaps._tcp", "_submission._tcp", "_matrix._tcp"}
+)
+
+// apexTypes are queried for the target domain itself.
+var apexTypes = []dnsmessage.Type{
+	dnsmessage.TypeA, dnsmessage.TypeAAAA, dnsmessage.TypeCNAME, dnsmessage.TypeMX, dnsmessage.TypeNS,
+	dnsmessage.TypeTXT, dnsmessage.TypeSOA, TypeCAA,
+}
+
 type DNSCollector struct{}
 
 func init() {
@@ -25,33 +43,71 @@ func (d *DNSCollector) Name() string {
 }
 
 func (d *DNSCollector) Description() string {
-	return "Passive DNS lookup for A, MX, and NS records"
+	return "DNS records (A, AAAA, CNAME, MX, NS, TXT, SOA, CAA, SRV, DMARC, DKIM) from configured resolvers"
 }
 
 func (d *DNSCollector) IsActive() bool {
 	return false
 }
 
+func (d *DNSCollector) Accepts() []string {
+	return []string{core.EntityDomain}
+}
+
+func (d *DNSCollector) Produces() []string {
+	return []string{core.EntityDomain, core.EntityIP, core.EntityEmail}
+}
+
 func (d *DNSCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
-	results := make(map[string][]string)
+	return d.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext queries the target's records directly. Params may override
+// "dkim_selectors" and "srv_services" with comma-separated lists.
+func (d *DNSCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(target)), ".")
+	resolver := NewResolver()
 
-	// A Records
-	ips, _ := net.LookupHost(target)
-	results["A"] = ips
+	type query struct {
+		name  string
+		qtype dnsmessage.Type
+	}
+	var queries []query
+	for _, t := range apexTypes {
+		queries = append(queries, query{domain, t})
+	}
+	queries = append(queries, query{"_dmarc." + domain, dnsmessage.TypeTXT})
+	for _, svc := range listSetting(opts, "srv_services", DefaultSRVServices) {
+		queries = append(queries, query{svc + "." + domain, dnsmessage.TypeSRV})
+	}
+	for _, sel := range listSetting(opts, "dkim_selectors", DefaultDKIMSelectors) {
+		queries = append(queries, query{sel + "._domainkey." + domain, dnsmessage.TypeTXT})
+	}
 
-	// MX Records
-	mxs, _ := net.LookupMX(target)
-	for _, mx := range mxs {
-		results["MX"] = append(results["MX"], mx.Host)
+	result := &core.DNSResult{Domain: domain}
+	failed := 0
+	for i, q := range queries {
+		if err := ethics.Wait(ctx, "dns"); err != nil {
+			return nil, err
+		}
+		ans := resolver.Query(ctx, q.name, q.qtype)
+		if ans.Error != "" {
+			failed++
+		}
+		result.Answers = append(result.Answers, ans)
+		opts.Report(i+1, len(queries), fmt.Sprintf("%s %s", ans.Type, q.name))
 	}
 
-	// NS Records
-	nss, _ := net.LookupNS(target)
-	for _, ns := range nss {
-		results["NS"] = append(results["NS"], ns.Host)
+	// Lookup errors are tolerated per query, but a cancelled run or one
+	// where no resolver answered must not be stored as an empty evidence item.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if failed == len(queries) {
+		return nil, fmt.Errorf("no DNS server answered: %s", result.Answers[0].Error)
 	}
 
-	data, err := json.MarshalIndent(results, "", "  ")
+	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +118,7 @@ func (d *DNSCollector) Collect(caseID string, target string) ([]core.Evidence, e
 		return nil, err
 	}
 
-	fileName := fmt.Sprintf("dns_%s_%d.json", target, time.Now().Unix())
+	fileName := fmt.Sprintf("dns_%s_%d.json", domain, time.Now().Unix())
 	filePath := filepath.Join(storageDir, fileName)
 	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return nil, err
@@ -79,11 +135,31 @@ func (d *DNSCollector) Collect(caseID string, target string) ([]core.Evidence, e
 		FileHash:    hashStr,
 		CollectedAt: time.Now(),
 		Metadata: map[string]interface{}{
-			"target": target,
-			"types":  []string{"A", "MX", "NS"},
+			"target":    domain,
+			"transport": resolver.Transport,
+			"servers":   resolver.Servers,
+			"queries":   len(queries),
 		},
-		RawData: results,
+		RawData: result,
 	}
 
 	return []core.Evidence{evidence}, nil
 }
+
+// listSetting reads a comma-separated Params override, then the
+// collectors.dns config key, then the default.
+func listSetting(opts core.CollectOptions, key string, def []string) []string {
+	if v := opts.Params[key]; v != "" {
+		var out []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	if v := viper.GetStringSlice("collectors.dns." + key); len(v) > 0 {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,387 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/core"
+	netclient "github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Transports supported by Resolver.
+const (
+	TransportUDP = "udp" // Falls back to TCP for truncated answers
+	TransportTCP = "tcp"
+	TransportDoH = "doh" // RFC 8484, through the configured HTTP proxy
+)
+
+// TypeCAA is not defined by dnsmessage; CAA answers are decoded from the raw
+// record data.
+const TypeCAA dnsmessage.Type = 257
+
+// Default resolvers used when none are configured.
+var (
+	DefaultServers    = []string{"1.1.1.1:53", "8.8.8.8:53"}
+	DefaultDoHServers = []string{"https://cloudflare-dns.com/dns-query", "https://dns.google/dns-query"}
+)
+
+// Resolver sends queries straight to the configured servers instead of the
+// operating system's resolver.
+type Resolver struct {
+	Servers    []string // host:port for udp/tcp, URLs for doh
+	Transport  string
+	Timeout    time.Duration
+	HTTPClient *http.Client // Used for DoH
+}
+
+// NewResolver builds a resolver from collectors.dns. In ghost mode plain
+// DNS would bypass the proxy, so queries are sent over DoH instead.
+func NewResolver() *Resolver {
+	r := &Resolver{
+		Transport: strings.ToLower(viper.GetString("collectors.dns.transport")),
+		Timeout:   viper.GetDuration("collectors.dns.timeout"),
+	}
+	if r.Transport == "" {
+		r.Transport = TransportUDP
+	}
+	if r.Timeout <= 0 {
+		r.Timeout = 5 * time.Second
+	}
+	if viper.GetBool("ghost_mode") && r.Transport != TransportDoH {
+		log.Warn().Str("transport", r.Transport).Msg("Ghost mode enabled: sending DNS queries over DoH through the proxy")
+		r.Transport = TransportDoH
+	}
+
+	if r.Transport == TransportDoH {
+		r.Servers = viper.GetStringSlice("collectors.dns.doh_servers")
+		if len(r.Servers) == 0 {
+			r.Servers = DefaultDoHServers
+		}
+		r.HTTPClient = netclient.NewClient()
+	} else {
+		for _, s := range viper.GetStringSlice("collectors.dns.servers") {
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			r.Servers = append(r.Servers, s)
+		}
+		if len(r.Servers) == 0 {
+			r.Servers = DefaultServers
+		}
+	}
+	return r
+}
+
+// Query asks each server in turn until one responds. A response with a
+// non-success RCODE (such as NXDOMAIN) is still an answer; the error is only
+// set when no server could be reached.
+func (r *Resolver) Query(ctx context.Context, name string, qtype dnsmessage.Type) *core.DNSAnswer {
+	ans := &core.DNSAnswer{Query: name, Type: TypeName(qtype), Transport: r.Transport}
+
+	var lastErr error
+	for _, server := range r.Servers {
+		msg, transport, err := r.exchange(ctx, server, name, qtype)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		ans.Server, ans.Transport = server, transport
+		ans.RCode = strings.TrimPrefix(msg.RCode.String(), "RCode")
+		ans.Records = convertRecords(msg.Answers)
+		return ans
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no DNS servers configured")
+	}
+	ans.Error = lastErr.Error()
+	return ans
+}
+
+func (r *Resolver) exchange(ctx context.Context, server, name string, qtype dnsmessage.Type) (*dnsmessage.Message, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	id := uint16(rand.Intn(1 << 16))
+	if r.Transport == TransportDoH {
+		id = 0 // RFC 8484 section 4.1: use ID 0 for cache friendliness
+	}
+	query, err := buildQuery(id, name, qtype)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var resp []byte
+	transport := r.Transport
+	switch r.Transport {
+	case TransportDoH:
+		resp, err = r.exchangeDoH(ctx, server, query)
+	case TransportTCP:
+		resp, err = exchangeTCP(ctx, server, query)
+	default:
+		resp, err = exchangeUDP(ctx, server, query)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	msg, err := parseResponse(resp, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if msg.Truncated && transport == TransportUDP {
+		if resp, err = exchangeTCP(ctx, server, query); err != nil {
+			return nil, "", err
+		}
+		if msg, err = parseResponse(resp, id); err != nil {
+			return nil, "", err
+		}
+		transport = TransportTCP
+	}
+	return msg, transport, nil
+}
+
+func buildQuery(id uint16, name string, qtype dnsmessage.Type) ([]byte, error) {
+	qname, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNS name %q: %w", name, err)
+	}
+
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+
+	// Advertise a larger UDP payload so TXT-heavy answers are not truncated
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+func parseResponse(resp []byte, id uint16) (*dnsmessage.Message, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		return nil, fmt.Errorf("invalid DNS response: %w", err)
+	}
+	if !msg.Response || msg.ID != id {
+		return nil, fmt.Errorf("DNS response does not match the query")
+	}
+	return &msg, nil
+}
+
+func exchangeUDP(ctx context.Context, server string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func exchangeTCP(ctx context.Context, server string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, err
+	}
+	return readTCPMessage(conn)
+}
+
+// writeTCPMessage and readTCPMessage frame messages with the two-byte
+// length prefix DNS uses over TCP (RFC 1035 section 4.2.2).
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	framed := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(framed, uint16(len(msg)))
+	copy(framed[2:], msg)
+	_, err := w.Write(framed)
+	return err
+}
+
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (r *Resolver) exchangeDoH(ctx context.Context, server string, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH server returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 65535))
+}
+
+// convertRecords renders answer records, skipping types SPECTRE does not
+// record (such as DNSSEC signatures).
+func convertRecords(resources []dnsmessage.Resource) []core.DNSRecord {
+	var out []core.DNSRecord
+	for _, res := range resources {
+		rec := core.DNSRecord{
+			Name: strings.TrimSuffix(res.Header.Name.String(), "."),
+			Type: TypeName(res.Header.Type),
+			TTL:  res.Header.TTL,
+		}
+
+		switch body := res.Body.(type) {
+		case *dnsmessage.AResource:
+			rec.Value = net.IP(body.A[:]).String()
+		case *dnsmessage.AAAAResource:
+			rec.Value = net.IP(body.AAAA[:]).String()
+		case *dnsmessage.CNAMEResource:
+			rec.Value = trimDot(body.CNAME)
+		case *dnsmessage.NSResource:
+			rec.Value = trimDot(body.NS)
+		case *dnsmessage.PTRResource:
+			rec.Value = trimDot(body.PTR)
+		case *dnsmessage.MXResource:
+			rec.Value, rec.Preference = trimDot(body.MX), body.Pref
+		case *dnsmessage.TXTResource:
+			rec.Value = strings.Join(body.TXT, "")
+		case *dnsmessage.SRVResource:
+			rec.Value, rec.Priority, rec.Weight, rec.Port = trimDot(body.Target), body.Priority, body.Weight, body.Port
+		case *dnsmessage.SOAResource:
+			rec.Value = fmt.Sprintf("%s %s %d %d %d %d %d", trimDot(body.NS), trimDot(body.MBox),
+				body.Serial, body.Refresh, body.Retry, body.Expire, body.MinTTL)
+		case *dnsmessage.UnknownResource:
+			if res.Header.Type != TypeCAA {
+				rec.Value = hex.EncodeToString(body.Data)
+				break
+			}
+			flags, tag, value, ok := parseCAA(body.Data)
+			if !ok {
+				continue
+			}
+			rec.Tag = tag
+			rec.Value = fmt.Sprintf("%d %s %q", flags, tag, value)
+		default:
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// parseCAA decodes CAA record data (RFC 8659 section 4.1).
+func parseCAA(data []byte) (flags uint8, tag, value string, ok bool) {
+	if len(data) < 2 {
+		return 0, "", "", false
+	}
+	tagLen := int(data[1])
+	if len(data) < 2+tagLen {
+		return 0, "", "", false
+	}
+	return data[0], string(data[2 : 2+tagLen]), string(data[2+tagLen:]), true
+}
+
+// TypeName returns the mnemonic of a record type ("A", "CAA", ...).
+func TypeName(t dnsmessage.Type) string {
+	if t == TypeCAA {
+		return "CAA"
+	}
+	name := t.String()
+	if strings.HasPrefix(name, "Type") {
+		return strings.TrimPrefix(name, "Type")
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func trimDot(n dnsmessage.Name) string {
+	return strings.TrimSuffix(n.String(), ".")
+}
+
+// ReverseName returns the PTR query name for addr, under in-addr.arpa for
+// IPv4 and ip6.arpa (one label per nibble) for IPv6.
+func ReverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	b := addr.AsSlice()
+	var labels []string
+	if addr.Is4() {
+		for i := len(b) - 1; i >= 0; i-- {
+			labels = append(labels, strconv.Itoa(int(b[i])))
+		}
+		return strings.Join(labels, ".") + ".in-addr.arpa"
+	}
+	const hexDigits = "0123456789abcdef"
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, string(hexDigits[b[i]&0xf]), string(hexDigits[b[i]>>4]))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa"
+}
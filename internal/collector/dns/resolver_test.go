@@ -0,0 +1,175 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// answer builds a response to query with the given answers.
+func answer(t *testing.T, query []byte, truncated bool, add func(b *dnsmessage.Builder, q dnsmessage.Question)) []byte {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := p.Question()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Truncated: truncated, RecursionAvailable: true})
+	b.StartQuestions()
+	b.Question(q)
+	b.StartAnswers()
+	if !truncated {
+		add(&b, q)
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func records(b *dnsmessage.Builder, q dnsmessage.Question) {
+	h := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 300}
+	switch q.Type {
+	case dnsmessage.TypeA:
+		b.AResource(h, dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	case dnsmessage.TypeTXT:
+		b.TXTResource(h, dnsmessage.TXTResource{TXT: []string{"v=spf1 include:_spf.example.net ", "-all"}})
+	case TypeCAA:
+		h.Type = TypeCAA
+		b.UnknownResource(h, dnsmessage.UnknownResource{Type: TypeCAA, Data: append([]byte{0, 5}, "issueletsencrypt.org"...)})
+	}
+}
+
+// serveDNS answers UDP queries with truncated responses, forcing a retry over
+// TCP on the same port.
+func serveDNS(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		t.Skipf("cannot listen on matching TCP port: %v", err)
+	}
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			q := buf[:n]
+			var p dnsmessage.Parser
+			p.Start(q)
+			question, _ := p.Question()
+			pc.WriteTo(answer(t, q, question.Type == dnsmessage.TypeTXT, records), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			q, err := readTCPMessage(conn)
+			if err == nil {
+				writeTCPMessage(conn, answer(t, q, false, records))
+			}
+			conn.Close()
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestResolver_UDPWithTCPFallback(t *testing.T) {
+	r := &Resolver{Servers: []string{serveDNS(t)}, Transport: TransportUDP, Timeout: 2 * time.Second}
+
+	ans := r.Query(context.Background(), "example.com", dnsmessage.TypeA)
+	if ans.Error != "" || len(ans.Records) != 1 {
+		t.Fatalf("Unexpected A answer: %+v", ans)
+	}
+	rec := ans.Records[0]
+	if rec.Value != "192.0.2.1" || rec.TTL != 300 || rec.Name != "example.com" || ans.Transport != TransportUDP {
+		t.Errorf("Unexpected A record: %+v via %s", rec, ans.Transport)
+	}
+
+	ans = r.Query(context.Background(), "example.com", dnsmessage.TypeTXT)
+	if ans.Transport != TransportTCP || len(ans.Records) != 1 {
+		t.Fatalf("Expected a truncated UDP answer to be retried over TCP, got %+v", ans)
+	}
+	if ans.Records[0].Value != "v=spf1 include:_spf.example.net -all" {
+		t.Errorf("TXT strings not joined: %q", ans.Records[0].Value)
+	}
+
+	ans = r.Query(context.Background(), "example.com", TypeCAA)
+	if len(ans.Records) != 1 || ans.Records[0].Tag != "issue" || ans.Records[0].Value != `0 issue "letsencrypt.org"` {
+		t.Errorf("Unexpected CAA answer: %+v", ans.Records)
+	}
+}
+
+func TestResolver_FailsOverAndReportsErrors(t *testing.T) {
+	dead, _ := net.ListenPacket("udp", "127.0.0.1:0")
+	deadAddr := dead.LocalAddr().String()
+	dead.Close()
+
+	r := &Resolver{Servers: []string{deadAddr, serveDNS(t)}, Transport: TransportUDP, Timeout: 500 * time.Millisecond}
+	if ans := r.Query(context.Background(), "example.com", dnsmessage.TypeA); ans.Error != "" || ans.Server == deadAddr {
+		t.Errorf("Expected the second server to answer, got %+v", ans)
+	}
+
+	r.Servers = []string{deadAddr}
+	if ans := r.Query(context.Background(), "example.com", dnsmessage.TypeA); ans.Error == "" {
+		t.Error("Expected an error when no server answers")
+	}
+}
+
+func TestResolver_DoH(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		q, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		io.Copy(w, bytes.NewReader(answer(t, q, false, records)))
+	}))
+	defer srv.Close()
+
+	r := &Resolver{Servers: []string{srv.URL}, Transport: TransportDoH, Timeout: 2 * time.Second, HTTPClient: srv.Client()}
+	ans := r.Query(context.Background(), "example.com", dnsmessage.TypeA)
+	if ans.Error != "" || len(ans.Records) != 1 || ans.Server != srv.URL {
+		t.Errorf("Unexpected DoH answer: %+v", ans)
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	tests := map[string]string{
+		"192.0.2.10":         "10.2.0.192.in-addr.arpa",
+		"::ffff:192.0.2.10":  "10.2.0.192.in-addr.arpa",
+		"2001:db8::567:89ab": "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+	}
+	for ip, want := range tests {
+		if got := ReverseName(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("ReverseName(%s) = %s, want %s", ip, got, want)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+//go:embed wordlists/subdomains.txt
+var defaultWordlist string
+
+// Subdomain discovery sources.
+const (
+	SourceBruteforce  = "bruteforce"
+	SourceAXFR        = "axfr"
+	SourcePermutation = "permutation"
+)
+
+// permutationAffixes are combined with discovered labels ("api" gives
+// "api-dev", "dev-api", "api2", ...).
+var permutationAffixes = []string{"dev", "test", "stage", "staging", "prod", "qa", "uat", "old", "new", "int", "internal", "beta", "admin", "v2"}
+
+// axfrPort is the port zone transfers are requested on.
+var axfrPort = "53"
+
+type SubdomainCollector struct{}
+
+func init() {
+	collector.Register(&SubdomainCollector{})
+}
+
+func (s *SubdomainCollector) Name() string {
+	return "subdomains"
+}
+
+func (s *SubdomainCollector) Description() string {
+	return "Subdomain enumeration (wordlist brute force, wildcard detection, AXFR attempts, permutations)"
+}
+
+// IsActive is true because zone transfers are requested from the target's
+// own nameservers.
+func (s *SubdomainCollector) IsActive() bool {
+	return true
+}
+
+func (s *SubdomainCollector) Accepts() []string {
+	return []string{core.EntityDomain}
+}
+
+func (s *SubdomainCollector) Produces() []string {
+	return []string{core.EntityDomain, core.EntityIP}
+}
+
+func (s *SubdomainCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return s.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext enumerates subdomains of target. Params may set "wordlist"
+// (a file path), "axfr" and "permutations" ("false" disables them).
+func (s *SubdomainCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(target)), ".")
+	e := &enumerator{
+		ctx:      ctx,
+		domain:   domain,
+		resolver: NewResolver(),
+		workers:  viper.GetInt("collectors.subdomains.workers"),
+		tried:    make(map[string]bool),
+		found:    make(map[string]*core.Subdomain),
+		result:   &core.SubdomainResult{Domain: domain},
+	}
+	if e.workers <= 0 {
+		e.workers = 10
+	}
+
+	words, err := loadWordlist(setting(opts, "wordlist", viper.GetString("collectors.subdomains.wordlist")))
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Report(0, 0, "checking for wildcard DNS")
+	if err := e.detectWildcard(); err != nil {
+		return nil, err
+	}
+
+	// Plain DNS to the target's nameservers would bypass the ghost-mode proxy
+	if enabled(opts, "axfr", "collectors.subdomains.axfr") {
+		if viper.GetBool("ghost_mode") {
+			log.Warn().Str("domain", domain).Msg("Ghost mode enabled: skipping zone transfer attempts")
+		} else {
+			opts.Report(0, 0, "attempting zone transfers")
+			if err := e.zoneTransfers(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var candidates []string
+	for _, w := range words {
+		candidates = append(candidates, w+"."+domain)
+	}
+	if err := e.bruteforce(candidates, SourceBruteforce, opts); err != nil {
+		return nil, err
+	}
+
+	if enabled(opts, "permutations", "collectors.subdomains.permutations") {
+		limit := viper.GetInt("collectors.subdomains.max_permutations")
+		if limit <= 0 {
+			limit = 300
+		}
+		if err := e.bruteforce(e.permutations(limit), SourcePermutation, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	result := e.result
+	result.Tried = len(e.tried)
+	for _, sub := range e.found {
+		result.Subdomains = append(result.Subdomains, *sub)
+	}
+	sort.Slice(result.Subdomains, func(i, j int) bool { return result.Subdomains[i].Name < result.Subdomains[j].Name })
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("subdomains_%s_%d.json", domain, time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "subdomains",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"target":   domain,
+			"found":    len(result.Subdomains),
+			"tried":    result.Tried,
+			"wildcard": result.Wildcard,
+		},
+		RawData: result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+type enumerator struct {
+	ctx      context.Context
+	domain   string
+	resolver *Resolver
+	workers  int
+
+	mu       sync.Mutex
+	tried    map[string]bool
+	found    map[string]*core.Subdomain
+	wildcard map[string]bool // addresses and CNAME targets of the wildcard
+	result   *core.SubdomainResult
+}
+
+// lookup resolves name's A records, honouring the collector's rate limit.
+func (e *enumerator) lookup(name string) (addrs []string, cname string, err error) {
+	if err := ethics.Wait(e.ctx, "subdomains"); err != nil {
+		return nil, "", err
+	}
+	ans := e.resolver.Query(e.ctx, name, dnsmessage.TypeA)
+	if ans.Error != "" || ans.RCode != "Success" {
+		return nil, "", nil
+	}
+	for _, rec := range ans.Records {
+		switch rec.Type {
+		case "A":
+			addrs = append(addrs, rec.Value)
+		case "CNAME":
+			if cname == "" {
+				cname = rec.Value
+			}
+		}
+	}
+	return addrs, cname, nil
+}
+
+// detectWildcard resolves random labels; any answer means the zone has a
+// wildcard record whose addresses or CNAME target must be filtered from
+// brute-force hits.
+func (e *enumerator) detectWildcard() error {
+	e.wildcard = make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		buf := make([]byte, 6)
+		rand.Read(buf)
+		addrs, cname, err := e.lookup("spectre-" + hex.EncodeToString(buf) + "." + e.domain)
+		if err != nil {
+			return err
+		}
+		for _, a := range addrs {
+			if !e.wildcard[a] {
+				e.wildcard[a] = true
+				e.result.WildcardIPs = append(e.result.WildcardIPs, a)
+			}
+		}
+		if cname = strings.ToLower(cname); cname != "" && !e.wildcard[cname] {
+			e.wildcard[cname] = true
+			e.result.WildcardCNAMEs = append(e.result.WildcardCNAMEs, cname)
+		}
+		if len(addrs) > 0 || cname != "" {
+			e.result.Wildcard = true
+		}
+	}
+	sort.Strings(e.result.WildcardIPs)
+	sort.Strings(e.result.WildcardCNAMEs)
+	return nil
+}
+
+// isWildcard reports whether a hit is the wildcard answering: its CNAME is
+// the wildcard's, or it has no CNAME and only wildcard addresses.
+func (e *enumerator) isWildcard(addrs []string, cname string) bool {
+	if !e.result.Wildcard {
+		return false
+	}
+	if cname != "" {
+		return e.wildcard[strings.ToLower(cname)]
+	}
+	if len(addrs) == 0 {
+		return false
+	}
+	for _, a := range addrs {
+		if !e.wildcard[a] {
+			return false
+		}
+	}
+	return true
+}
+
+// zoneTransfers tries AXFR against every address of every nameserver.
+func (e *enumerator) zoneTransfers() error {
+	if err := ethics.Wait(e.ctx, "subdomains"); err != nil {
+		return err
+	}
+	nsAnswer := e.resolver.Query(e.ctx, e.domain, dnsmessage.TypeNS)
+	timeout := e.resolver.Timeout * 4
+
+	for _, ns := range nsAnswer.Records {
+		if ns.Type != "NS" {
+			continue
+		}
+		addrs, _, err := e.lookup(ns.Value)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			if err := ethics.Wait(e.ctx, "subdomains"); err != nil {
+				return err
+			}
+			attempt := core.ZoneTransfer{Nameserver: ns.Value, Address: addr}
+			if ok, err := ethics.IsAllowed(addr); !ok {
+				attempt.Error = err.Error()
+				e.result.ZoneTransfers = append(e.result.ZoneTransfers, attempt)
+				continue
+			}
+			records, err := Transfer(e.ctx, net.JoinHostPort(addr, axfrPort), e.domain, timeout)
+			if err != nil {
+				attempt.Error = err.Error()
+			}
+			attempt.Records = len(records)
+			attempt.Success = err == nil && len(records) > 0
+			e.result.ZoneTransfers = append(e.result.ZoneTransfers, attempt)
+			e.addTransferred(records)
+		}
+	}
+	return e.ctx.Err()
+}
+
+func (e *enumerator) addTransferred(records []core.DNSRecord) {
+	for _, rec := range records {
+		name := strings.ToLower(rec.Name)
+		if name == e.domain || !strings.HasSuffix(name, "."+e.domain) || strings.HasPrefix(name, "*.") {
+			continue
+		}
+		e.tried[name] = true
+		sub := e.found[name]
+		if sub == nil {
+			sub = &core.Subdomain{Name: name, Source: SourceAXFR}
+			e.found[name] = sub
+		}
+		switch rec.Type {
+		case "A", "AAAA":
+			sub.Addresses = append(sub.Addresses, rec.Value)
+		case "CNAME":
+			sub.CNAME = rec.Value
+		}
+	}
+}
+
+// bruteforce resolves the candidates not tried yet with a pool of workers.
+func (e *enumerator) bruteforce(candidates []string, source string, opts core.CollectOptions) error {
+	var todo []string
+	for _, name := range candidates {
+		if !e.tried[name] {
+			e.tried[name] = true
+			todo = append(todo, name)
+		}
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var firstErr error
+	done := 0
+	for i := 0; i < e.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				addrs, cname, err := e.lookup(name)
+
+				e.mu.Lock()
+				done++
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if err == nil && (len(addrs) > 0 || cname != "") && !e.isWildcard(addrs, cname) {
+					e.found[name] = &core.Subdomain{Name: name, Addresses: addrs, CNAME: cname, Source: source}
+				}
+				n := done
+				e.mu.Unlock()
+				if n%25 == 0 || n == len(todo) {
+					opts.Report(n, len(todo), fmt.Sprintf("%s: %d/%d", source, n, len(todo)))
+				}
+			}
+		}()
+	}
+
+	for _, name := range todo {
+		if e.ctx.Err() != nil {
+			break
+		}
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return e.ctx.Err()
+}
+
+// permutations derives new candidates from the labels found so far.
+func (e *enumerator) permutations(limit int) []string {
+	labels := make(map[string]bool)
+	for name := range e.found {
+		label := strings.TrimSuffix(name, "."+e.domain)
+		if !strings.Contains(label, ".") {
+			labels[label] = true
+		}
+	}
+	sorted := make([]string, 0, len(labels))
+	for l := range labels {
+		sorted = append(sorted, l)
+	}
+	sort.Strings(sorted)
+
+	var out []string
+	add := func(label string) {
+		name := label + "." + e.domain
+		if len(out) < limit && !e.tried[name] && !labels[label] {
+			out = append(out, name)
+		}
+	}
+	for _, label := range sorted {
+		for _, affix := range permutationAffixes {
+			add(label + "-" + affix)
+			add(affix + "-" + label)
+			add(affix + "." + label)
+		}
+		for i := 1; i <= 3; i++ {
+			add(label + strconv.Itoa(i))
+		}
+	}
+	return out
+}
+
+// loadWordlist reads labels from path, or the bundled list if path is empty.
+func loadWordlist(path string) ([]string, error) {
+	content := defaultWordlist
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read wordlist: %w", err)
+		}
+		content = string(data)
+	}
+
+	var words []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		w := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if w == "" || strings.HasPrefix(w, "#") || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words, scanner.Err()
+}
+
+func setting(opts core.CollectOptions, key, def string) string {
+	if v := opts.Params[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+// enabled reads a boolean Params override, then the config key (default on).
+func enabled(opts core.CollectOptions, param, key string) bool {
+	if v, ok := opts.Params[param]; ok {
+		b, err := strconv.ParseBool(v)
+		return err != nil || b
+	}
+	if !viper.IsSet(key) {
+		return true
+	}
+	return viper.GetBool(key)
+}
@@ -0,0 +1,247 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// zone is a fake authoritative zone. Names map to an A address, or in
+// cnames to a CNAME target outside the zone; wildcard (an address) or
+// wildcardCNAME, if set, answers every other name.
+type zone struct {
+	origin        string
+	hosts         map[string]string
+	cnames        map[string]string
+	wildcard      string
+	wildcardCNAME string
+}
+
+func (z *zone) respond(b *dnsmessage.Builder, q dnsmessage.Question) {
+	name := strings.TrimSuffix(q.Name.String(), ".")
+	h := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	switch q.Type {
+	case dnsmessage.TypeNS:
+		if name == z.origin {
+			b.NSResource(h, dnsmessage.NSResource{NS: dnsmessage.MustNewName("ns1." + z.origin + ".")})
+		}
+	case dnsmessage.TypeA:
+		target, isAlias := z.cnames[name]
+		if _, ok := z.hosts[name]; !ok && !isAlias && z.wildcardCNAME != "" && strings.HasSuffix(name, "."+z.origin) {
+			target, isAlias = z.wildcardCNAME, true
+		}
+		if isAlias {
+			b.CNAMEResource(h, dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(target + ".")})
+			return
+		}
+		addr, ok := z.hosts[name]
+		if name == "ns1."+z.origin {
+			addr, ok = "127.0.0.1", true
+		}
+		if !ok && z.wildcard != "" && strings.HasSuffix(name, "."+z.origin) {
+			addr, ok = z.wildcard, true
+		}
+		if ok {
+			b.AResource(h, dnsmessage.AResource{A: [4]byte(net.ParseIP(addr).To4())})
+		}
+	}
+}
+
+// transfer writes the zone as an AXFR response: SOA, hosts, SOA.
+func (z *zone) transfer(b *dnsmessage.Builder, q dnsmessage.Question) {
+	soa := func() {
+		b.SOAResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}, dnsmessage.SOAResource{
+			NS: dnsmessage.MustNewName("ns1." + z.origin + "."), MBox: dnsmessage.MustNewName("hostmaster." + z.origin + "."), Serial: 1})
+	}
+	soa()
+	for name, addr := range z.hosts {
+		h := dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name + "."), Class: dnsmessage.ClassINET, TTL: 60}
+		b.AResource(h, dnsmessage.AResource{A: [4]byte(net.ParseIP(addr).To4())})
+	}
+	soa()
+}
+
+// serveZone serves z over UDP and TCP on one port. AXFR is only answered
+// when allowAXFR is set, otherwise it is refused.
+func serveZone(t *testing.T, z *zone, allowAXFR bool) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		t.Skipf("cannot listen on matching TCP port: %v", err)
+	}
+	t.Cleanup(func() {
+		pc.Close()
+		ln.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(answer(t, buf[:n], false, z.respond), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			q, err := readTCPMessage(conn)
+			if err == nil {
+				var p dnsmessage.Parser
+				h, _ := p.Start(q)
+				question, _ := p.Question()
+				if question.Type != dnsmessage.TypeAXFR {
+					writeTCPMessage(conn, answer(t, q, false, z.respond))
+				} else if allowAXFR {
+					writeTCPMessage(conn, answer(t, q, false, z.transfer))
+				} else {
+					b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RCode: dnsmessage.RCodeRefused})
+					msg, _ := b.Finish()
+					writeTCPMessage(conn, msg)
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func runSubdomains(t *testing.T, server string, words ...string) *core.SubdomainResult {
+	_, port, _ := net.SplitHostPort(server)
+	oldPort := axfrPort
+	axfrPort = port
+	viper.Set("collectors.dns.servers", []string{server})
+	ethics.SetLimit("subdomains", 10000)
+	ethics.SetBlacklist(nil)
+	t.Cleanup(func() {
+		axfrPort = oldPort
+		viper.Set("collectors.dns.servers", nil)
+		ethics.SetBlacklist([]string{".gov", ".mil", "localhost", "127.0.0.1"})
+	})
+
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "words.txt")
+	os.WriteFile(wordlist, []byte(strings.Join(words, "\n")), 0644)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ev, err := (&SubdomainCollector{}).CollectContext(context.Background(), "case-sub", "example.test", core.CollectOptions{Params: map[string]string{"wordlist": wordlist}})
+	if err != nil {
+		t.Fatalf("CollectContext failed: %v", err)
+	}
+	return ev[0].RawData.(*core.SubdomainResult)
+}
+
+func names(res *core.SubdomainResult) map[string]string {
+	out := make(map[string]string)
+	for _, s := range res.Subdomains {
+		out[s.Name] = s.Source
+	}
+	return out
+}
+
+func TestSubdomains_BruteforceAXFRAndPermutations(t *testing.T) {
+	z := &zone{origin: "example.test", hosts: map[string]string{
+		"www.example.test":     "192.0.2.1",
+		"api.example.test":     "192.0.2.2",
+		"api-dev.example.test": "192.0.2.3",
+		"vault.example.test":   "192.0.2.4",
+	}}
+	res := runSubdomains(t, serveZone(t, z, true), "www", "api", "nothere")
+
+	got := names(res)
+	want := map[string]string{
+		"www.example.test":     SourceAXFR,
+		"api.example.test":     SourceAXFR,
+		"api-dev.example.test": SourceAXFR,
+		"vault.example.test":   SourceAXFR,
+	}
+	for name, source := range want {
+		if got[name] != source {
+			t.Errorf("Expected %s from %s, got %q", name, source, got[name])
+		}
+	}
+	if len(res.ZoneTransfers) != 1 || !res.ZoneTransfers[0].Success {
+		t.Errorf("Expected a successful zone transfer, got %+v", res.ZoneTransfers)
+	}
+	if res.Wildcard {
+		t.Error("Zone has no wildcard")
+	}
+}
+
+func TestSubdomains_RefusedTransferAndWildcard(t *testing.T) {
+	z := &zone{origin: "example.test", wildcard: "192.0.2.99", hosts: map[string]string{
+		"www.example.test":     "192.0.2.1",
+		"api.example.test":     "192.0.2.2",
+		"api-dev.example.test": "192.0.2.3",
+	}}
+	res := runSubdomains(t, serveZone(t, z, false), "www", "api", "nothere", "mail")
+
+	if !res.Wildcard || len(res.WildcardIPs) != 1 || res.WildcardIPs[0] != "192.0.2.99" {
+		t.Fatalf("Expected wildcard 192.0.2.99 to be detected, got %+v", res)
+	}
+	if len(res.ZoneTransfers) != 1 || res.ZoneTransfers[0].Success || res.ZoneTransfers[0].Error == "" {
+		t.Errorf("Expected a refused zone transfer, got %+v", res.ZoneTransfers)
+	}
+
+	got := names(res)
+	if got["www.example.test"] != SourceBruteforce || got["api.example.test"] != SourceBruteforce {
+		t.Errorf("Expected brute-force hits, got %v", got)
+	}
+	if got["api-dev.example.test"] != SourcePermutation {
+		t.Errorf("Expected api-dev to be found by permutation, got %v", got)
+	}
+	if _, ok := got["mail.example.test"]; ok {
+		t.Error("Wildcard answers must not be reported as subdomains")
+	}
+}
+
+func TestSubdomains_CNAMEWithWildcard(t *testing.T) {
+	// A CNAME-only subdomain is real even though its answer has no addresses
+	z := &zone{origin: "example.test", wildcard: "192.0.2.99",
+		hosts:  map[string]string{"www.example.test": "192.0.2.1"},
+		cnames: map[string]string{"shop.example.test": "shops.provider.invalid"},
+	}
+	got := names(runSubdomains(t, serveZone(t, z, false), "www", "shop", "nothere"))
+	if got["shop.example.test"] != SourceBruteforce || got["www.example.test"] != SourceBruteforce {
+		t.Errorf("Expected www and the CNAME-only shop, got %v", got)
+	}
+	if _, ok := got["nothere.example.test"]; ok {
+		t.Error("Wildcard answers must not be reported as subdomains")
+	}
+
+	// A wildcard CNAME filters hits aliased to the same target only
+	z = &zone{origin: "example.test", wildcardCNAME: "parking.provider.invalid",
+		hosts:  map[string]string{"www.example.test": "192.0.2.1"},
+		cnames: map[string]string{"shop.example.test": "shops.provider.invalid"},
+	}
+	res := runSubdomains(t, serveZone(t, z, false), "www", "shop", "nothere")
+	if !res.Wildcard || len(res.WildcardCNAMEs) != 1 || res.WildcardCNAMEs[0] != "parking.provider.invalid" {
+		t.Fatalf("Expected the wildcard CNAME to be detected, got %+v", res)
+	}
+	got = names(res)
+	if got["shop.example.test"] != SourceBruteforce || got["www.example.test"] != SourceBruteforce {
+		t.Errorf("Expected www and shop, got %v", got)
+	}
+	if _, ok := got["nothere.example.test"]; ok {
+		t.Error("Wildcard CNAME answers must not be reported as subdomains")
+	}
+}
@@ -0,0 +1,379 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/collector"
+	dnscollector "github.com/spectre/spectre/internal/collector/dns"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/net/idna"
+)
+
+//go:embed lists/disposable.txt
+var disposableList string
+
+//go:embed lists/freemail.txt
+var freemailList string
+
+// DefaultGravatarEndpoint serves public profiles as <endpoint><md5>.json.
+const DefaultGravatarEndpoint = "https://en.gravatar.com/"
+
+// roleAccounts are local parts of shared mailboxes. They say nothing about
+// a person, so no usernames are derived from them.
+var roleAccounts = map[string]bool{
+	"abuse": true, "admin": true, "administrator": true, "billing": true, "careers": true, "contact": true,
+	"help": true, "hello": true, "hostmaster": true, "hr": true, "info": true, "jobs": true, "marketing": true,
+	"no-reply": true, "noreply": true, "office": true, "postmaster": true, "press": true, "privacy": true,
+	"sales": true, "security": true, "support": true, "team": true, "webmaster": true,
+}
+
+type EmailCollector struct{}
+
+func init() {
+	collector.Register(&EmailCollector{})
+}
+
+func (e *EmailCollector) Name() string {
+	return "email"
+}
+
+func (e *EmailCollector) Description() string {
+	return "Email intelligence (syntax, MX/SPF, disposable/free-mail, Gravatar, username candidates; SMTP RCPT check with --active)"
+}
+
+// IsActive is false: only the optional SMTP check touches the target's
+// infrastructure, and it runs only when active probing is allowed.
+func (e *EmailCollector) IsActive() bool {
+	return false
+}
+
+func (e *EmailCollector) Accepts() []string {
+	return []string{core.EntityEmail}
+}
+
+func (e *EmailCollector) Produces() []string {
+	return []string{core.EntityDomain, core.EntityUsername, core.EntityAccount}
+}
+
+func (e *EmailCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return e.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+func (e *EmailCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	result := &core.EmailResult{Email: strings.ToLower(strings.TrimSpace(target))}
+
+	local, domain, err := ParseAddress(result.Email)
+	if err != nil {
+		result.SyntaxError = err.Error()
+	} else {
+		result.Valid = true
+		result.LocalPart, result.Domain = local, domain
+		result.Email = local + "@" + domain
+
+		disposable, err := loadDomainList(disposableList, viper.GetString("collectors.email.disposable_list"))
+		if err != nil {
+			return nil, err
+		}
+		free, err := loadDomainList(freemailList, viper.GetString("collectors.email.freemail_list"))
+		if err != nil {
+			return nil, err
+		}
+		result.Disposable = listed(disposable, domain)
+		result.FreeMail = listed(free, domain)
+		result.Role = roleAccounts[strings.SplitN(local, "+", 2)[0]]
+
+		lookupMail(ctx, dnscollector.NewResolver(), result)
+
+		if opts.ActiveAllowed && (!viper.IsSet("collectors.email.smtp_verify") || viper.GetBool("collectors.email.smtp_verify")) && result.AcceptsMail {
+			result.SMTP = verifySMTP(ctx, mailHosts(result), result.Email, domain)
+		}
+
+		sum := md5.Sum([]byte(result.Email))
+		result.GravatarMD5 = hex.EncodeToString(sum[:])
+		if !viper.IsSet("collectors.email.gravatar") || viper.GetBool("collectors.email.gravatar") {
+			profile, err := lookupGravatar(ctx, netclient.NewClient(), result.GravatarMD5)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, fmt.Errorf("email lookup aborted: %w", ctx.Err())
+				}
+				log.Warn().Err(err).Str("email", result.Email).Msg("Gravatar lookup failed")
+			}
+			result.Gravatar = profile
+		}
+
+		if !result.Role {
+			result.Usernames = CandidateUsernames(local)
+		}
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("email_%s_%d.json", strings.NewReplacer("/", "_", "\\", "_").Replace(result.Email), time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	metadata := map[string]interface{}{
+		"target":     target,
+		"valid":      result.Valid,
+		"disposable": result.Disposable,
+		"free_mail":  result.FreeMail,
+		"gravatar":   result.Gravatar != nil,
+	}
+	if result.SMTP != nil {
+		metadata["smtp"] = result.SMTP.Verdict
+	}
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "email",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata:    metadata,
+		RawData:     result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// ParseAddress checks that s is a bare addr-spec (RFC 5321 limits) and
+// returns its local part and ASCII domain.
+func ParseAddress(s string) (local, domain string, err error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid address: %w", err)
+	}
+	if addr.Name != "" || addr.Address != s {
+		return "", "", fmt.Errorf("invalid address: expected a bare address, not %q", s)
+	}
+	at := strings.LastIndexByte(s, '@')
+	local, domain = s[:at], s[at+1:]
+	if len(local) > 64 {
+		return "", "", fmt.Errorf("invalid address: local part longer than 64 characters")
+	}
+	domain, err = idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid domain: %w", err)
+	}
+	if !strings.Contains(domain, ".") {
+		return "", "", fmt.Errorf("invalid domain: %q has no top-level domain", domain)
+	}
+	if len(local)+1+len(domain) > 254 {
+		return "", "", fmt.Errorf("invalid address: longer than 254 characters")
+	}
+	return local, domain, nil
+}
+
+// lookupMail records the domain's MX records (falling back to an address
+// record, as RFC 5321 does) and SPF policy.
+func lookupMail(ctx context.Context, r *dnscollector.Resolver, result *core.EmailResult) {
+	ans := r.Query(ctx, result.Domain, dnsmessage.TypeMX)
+	for _, rec := range ans.Records {
+		if rec.Type == "MX" {
+			result.MX = append(result.MX, rec)
+		}
+	}
+	sort.SliceStable(result.MX, func(i, j int) bool { return result.MX[i].Preference < result.MX[j].Preference })
+
+	switch {
+	case len(result.MX) == 1 && result.MX[0].Value == "":
+		// Null MX (RFC 7505): the domain explicitly accepts no mail
+		result.AcceptsMail = false
+	case len(result.MX) > 0:
+		result.AcceptsMail = true
+	default:
+		for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			if a := r.Query(ctx, result.Domain, qtype); len(a.Records) > 0 {
+				result.AcceptsMail = true
+				break
+			}
+		}
+	}
+
+	for _, rec := range r.Query(ctx, result.Domain, dnsmessage.TypeTXT).Records {
+		if rec.Type == "TXT" && strings.HasPrefix(strings.ToLower(rec.Value), "v=spf1") {
+			result.SPF = rec.Value
+			break
+		}
+	}
+}
+
+// mailHosts lists the exchangers to try in preference order, or the domain
+// itself when it has no MX records.
+func mailHosts(result *core.EmailResult) []string {
+	var hosts []string
+	for _, mx := range result.MX {
+		if mx.Value != "" {
+			hosts = append(hosts, mx.Value)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = []string{result.Domain}
+	}
+	return hosts
+}
+
+// lookupGravatar fetches the public profile for an email hash. A missing
+// profile is not an error.
+func lookupGravatar(ctx context.Context, client *http.Client, hash string) (*core.GravatarProfile, error) {
+	endpoint := viper.GetString("collectors.email.gravatar_endpoint")
+	if endpoint == "" {
+		endpoint = DefaultGravatarEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(endpoint, "/")+"/"+hash+".json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "spectre")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("gravatar lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gravatar lookup failed: %s", resp.Status)
+	}
+
+	var body struct {
+		Entry []struct {
+			ProfileURL        string `json:"profileUrl"`
+			PreferredUsername string `json:"preferredUsername"`
+			ThumbnailURL      string `json:"thumbnailUrl"`
+			DisplayName       string `json:"displayName"`
+			CurrentLocation   string `json:"currentLocation"`
+			Accounts          []struct {
+				Domain   string `json:"domain"`
+				Username string `json:"username"`
+				URL      string `json:"url"`
+			} `json:"accounts"`
+		} `json:"entry"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse gravatar profile: %w", err)
+	}
+	if len(body.Entry) == 0 {
+		return nil, nil
+	}
+	entry := body.Entry[0]
+	profile := &core.GravatarProfile{
+		ProfileURL:  entry.ProfileURL,
+		Username:    entry.PreferredUsername,
+		DisplayName: entry.DisplayName,
+		Location:    entry.CurrentLocation,
+		AvatarURL:   entry.ThumbnailURL,
+	}
+	for _, a := range entry.Accounts {
+		profile.Accounts = append(profile.Accounts, core.GravatarAccount{Domain: a.Domain, Username: a.Username, URL: a.URL})
+	}
+	return profile, nil
+}
+
+// CandidateUsernames derives likely handles from an email local part:
+// "jane.doe+news" gives jane.doe, janedoe, jane_doe, jane-doe, jdoe and
+// janed. Candidates shorter than three characters or all digits are
+// dropped.
+func CandidateUsernames(local string) []string {
+	local = strings.ToLower(strings.SplitN(local, "+", 2)[0])
+	parts := strings.FieldsFunc(local, func(r rune) bool { return r == '.' || r == '_' || r == '-' })
+
+	var out []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if len(s) < 3 || strings.Trim(s, "0123456789") == "" || seen[s] {
+			return
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+
+	add(local)
+	if len(parts) > 1 {
+		for _, sep := range []string{"", ".", "_", "-"} {
+			add(strings.Join(parts, sep))
+		}
+		first, last := parts[0], parts[len(parts)-1]
+		add(first[:1] + last)
+		add(first + last[:1])
+		add(last + first)
+	}
+	// Trailing digits are often a birth year or a counter
+	if trimmed := strings.TrimRight(local, "0123456789"); trimmed != local {
+		add(trimmed)
+	}
+	return out
+}
+
+// loadDomainList parses a bundled list plus an optional extra file. Blank
+// lines and # comments are ignored.
+func loadDomainList(bundled, extraPath string) (map[string]bool, error) {
+	list := make(map[string]bool)
+	read := func(s string) {
+		scanner := bufio.NewScanner(strings.NewReader(s))
+		for scanner.Scan() {
+			line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			if line != "" && !strings.HasPrefix(line, "#") {
+				list[line] = true
+			}
+		}
+	}
+	read(bundled)
+	if extraPath != "" {
+		data, err := os.ReadFile(extraPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read domain list: %w", err)
+		}
+		read(string(data))
+	}
+	return list, nil
+}
+
+// listed reports whether domain or one of its parents is in list.
+func listed(list map[string]bool, domain string) bool {
+	for d := domain; d != ""; {
+		if list[d] {
+			return true
+		}
+		_, rest, ok := strings.Cut(d, ".")
+		if !ok {
+			break
+		}
+		d = rest
+	}
+	return false
+}
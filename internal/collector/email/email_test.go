@@ -0,0 +1,284 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers MX and TXT queries for example.com over UDP. The
+// exchanger is localhost so the SMTP check reaches the fake mail server.
+func serveDNS(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+			if q.Name.String() == "example.com." {
+				switch q.Type {
+				case dnsmessage.TypeMX:
+					b.MXResource(rh, dnsmessage.MXResource{Pref: 20, MX: dnsmessage.MustNewName("backup.invalid.")})
+					b.MXResource(rh, dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("localhost.")})
+				case dnsmessage.TypeTXT:
+					b.TXTResource(rh, dnsmessage.TXTResource{TXT: []string{"google-site-verification=abc"}})
+					b.TXTResource(rh, dnsmessage.TXTResource{TXT: []string{"v=spf1 mx -all"}})
+				}
+			}
+			msg, _ := b.Finish()
+			pc.WriteTo(msg, addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+// serveSMTP accepts RCPT TO only for the given mailboxes, or for anyone
+// when catchAll is set.
+func serveSMTP(t *testing.T, catchAll bool, mailboxes ...string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				fmt.Fprint(conn, "220 mx.example.com ESMTP\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					cmd := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"), strings.HasPrefix(cmd, "MAIL FROM"):
+						fmt.Fprint(conn, "250 OK\r\n")
+					case strings.HasPrefix(cmd, "RCPT TO"):
+						known := catchAll
+						for _, m := range mailboxes {
+							if strings.Contains(cmd, strings.ToUpper("<"+m+">")) {
+								known = true
+							}
+						}
+						if known {
+							fmt.Fprint(conn, "250 OK\r\n")
+						} else {
+							fmt.Fprint(conn, "550 5.1.1 No such user\r\n")
+						}
+					case strings.HasPrefix(cmd, "QUIT"):
+						fmt.Fprint(conn, "221 Bye\r\n")
+						return
+					default:
+						fmt.Fprint(conn, "502 Not implemented\r\n")
+					}
+				}
+			}(conn)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func setup(t *testing.T, smtpServerPort string) {
+	gravatar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// md5("jane.doe@example.com")
+		if r.URL.Path != "/0cba00ca3da1b283a57287bcceb17e35.json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"entry":[{"profileUrl":"https://gravatar.com/janedoe","preferredUsername":"janedoe",
+			"displayName":"Jane Doe","accounts":[{"domain":"github.com","username":"jdoe","url":"https://github.com/jdoe"}]}]}`)
+	}))
+	t.Cleanup(gravatar.Close)
+
+	oldPort := smtpPort
+	smtpPort = smtpServerPort
+	viper.Set("collectors.dns.servers", []string{serveDNS(t)})
+	viper.Set("collectors.email.gravatar_endpoint", gravatar.URL)
+	ethics.SetLimit("email", 10000)
+	ethics.SetBlacklist(nil)
+
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		smtpPort = oldPort
+		viper.Set("collectors.dns.servers", nil)
+		viper.Set("collectors.email.gravatar_endpoint", nil)
+		ethics.SetBlacklist([]string{".gov", ".mil", "localhost", "127.0.0.1"})
+	})
+}
+
+func collect(t *testing.T, target string, active bool) *core.EmailResult {
+	evs, err := (&EmailCollector{}).CollectContext(context.Background(), "case-email", target, core.CollectOptions{ActiveAllowed: active})
+	if err != nil {
+		t.Fatalf("CollectContext failed: %v", err)
+	}
+	if len(evs) != 1 {
+		t.Fatalf("expected 1 evidence, got %d", len(evs))
+	}
+	if _, err := os.Stat(evs[0].FilePath); err != nil {
+		t.Fatalf("evidence file missing: %v", err)
+	}
+	return evs[0].RawData.(*core.EmailResult)
+}
+
+func TestEmailCollector_Collect(t *testing.T) {
+	setup(t, serveSMTP(t, false, "jane.doe@example.com"))
+
+	res := collect(t, "Jane.Doe@Example.com", true)
+	if !res.Valid || res.LocalPart != "jane.doe" || res.Domain != "example.com" {
+		t.Fatalf("unexpected parse: %+v", res)
+	}
+	if len(res.MX) != 2 || res.MX[0].Value != "localhost" || res.MX[1].Value != "backup.invalid" {
+		t.Errorf("expected MX sorted by preference, got %+v", res.MX)
+	}
+	if !res.AcceptsMail || res.SPF != "v=spf1 mx -all" {
+		t.Errorf("expected mail acceptance and SPF, got accepts=%v spf=%q", res.AcceptsMail, res.SPF)
+	}
+	if res.SMTP == nil || res.SMTP.Verdict != core.SMTPDeliverable || res.SMTP.Server != "localhost" {
+		t.Errorf("expected deliverable verdict from localhost, got %+v", res.SMTP)
+	}
+	if res.GravatarMD5 != "0cba00ca3da1b283a57287bcceb17e35" {
+		t.Errorf("unexpected gravatar hash %s", res.GravatarMD5)
+	}
+	if res.Gravatar == nil || res.Gravatar.Username != "janedoe" || len(res.Gravatar.Accounts) != 1 {
+		t.Errorf("expected gravatar profile, got %+v", res.Gravatar)
+	}
+	if len(res.Usernames) == 0 || res.Usernames[0] != "jane.doe" {
+		t.Errorf("expected username candidates, got %v", res.Usernames)
+	}
+
+	// Unknown mailbox, no Gravatar profile
+	res = collect(t, "nobody@example.com", true)
+	if res.SMTP == nil || res.SMTP.Verdict != core.SMTPUndeliverable || res.SMTP.Code != 550 {
+		t.Errorf("expected undeliverable verdict, got %+v", res.SMTP)
+	}
+	if res.Gravatar != nil {
+		t.Errorf("expected no gravatar profile, got %+v", res.Gravatar)
+	}
+
+	// Passive runs never talk SMTP
+	res = collect(t, "jane.doe@example.com", false)
+	if res.SMTP != nil {
+		t.Errorf("expected no SMTP check without active mode, got %+v", res.SMTP)
+	}
+
+	res = collect(t, "not an email", false)
+	if res.Valid || res.SyntaxError == "" {
+		t.Errorf("expected syntax error, got %+v", res)
+	}
+}
+
+func TestEmailCollector_CatchAll(t *testing.T) {
+	setup(t, serveSMTP(t, true))
+
+	res := collect(t, "anyone@example.com", true)
+	if res.SMTP == nil || res.SMTP.Verdict != core.SMTPCatchAll {
+		t.Errorf("expected catch-all verdict, got %+v", res.SMTP)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in            string
+		local, domain string
+		ok            bool
+	}{
+		{"jane@example.com", "jane", "example.com", true},
+		{"jane+tag@example.com", "jane+tag", "example.com", true},
+		{"jane@bücher.de", "jane", "xn--bcher-kva.de", true},
+		{"Jane <jane@example.com>", "", "", false},
+		{"jane@localhost", "", "", false},
+		{"jane.example.com", "", "", false},
+		{strings.Repeat("a", 65) + "@example.com", "", "", false},
+	}
+	for _, tt := range tests {
+		local, domain, err := ParseAddress(tt.in)
+		if (err == nil) != tt.ok || local != tt.local || domain != tt.domain {
+			t.Errorf("ParseAddress(%q) = %q, %q, %v", tt.in, local, domain, err)
+		}
+	}
+}
+
+func TestCandidateUsernames(t *testing.T) {
+	got := CandidateUsernames("Jane.Doe+news")
+	want := []string{"jane.doe", "janedoe", "jane_doe", "jane-doe", "jdoe", "janed", "doejane"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = CandidateUsernames("jdoe1987")
+	want = []string{"jdoe1987", "jdoe"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := CandidateUsernames("12345"); len(got) != 0 {
+		t.Errorf("expected no candidates for digits, got %v", got)
+	}
+}
+
+func TestDomainLists(t *testing.T) {
+	extra := filepath.Join(t.TempDir(), "extra.txt")
+	os.WriteFile(extra, []byte("# local additions\nburner.example\n"), 0644)
+
+	list, err := loadDomainList(disposableList, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"mailinator.com", "burner.example", "mx.burner.example"} {
+		if !listed(list, d) {
+			t.Errorf("expected %s to be listed as disposable", d)
+		}
+	}
+	if listed(list, "example.com") {
+		t.Error("example.com should not be listed")
+	}
+
+	free, _ := loadDomainList(freemailList, "")
+	if !listed(free, "gmail.com") || listed(free, "example.com") {
+		t.Error("unexpected free-mail classification")
+	}
+}
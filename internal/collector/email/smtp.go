@@ -0,0 +1,122 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+// smtpPort is a variable so tests can point the check at a local server.
+var smtpPort = "25"
+
+// verifySMTP asks the domain's exchangers whether they would accept mail
+// for addr, stopping after RCPT TO. A second RCPT for a random mailbox
+// tells a real "yes" apart from a server that accepts everything.
+func verifySMTP(ctx context.Context, hosts []string, addr, domain string) *core.SMTPCheck {
+	timeout := viper.GetDuration("collectors.email.smtp_timeout")
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	helo := viper.GetString("collectors.email.helo")
+	if helo == "" {
+		helo = "localhost"
+	}
+	from := viper.GetString("collectors.email.mail_from")
+
+	check := &core.SMTPCheck{Verdict: core.SMTPUnknown}
+	for i, host := range hosts {
+		if i == 2 {
+			break // the backups rarely know more than the primaries
+		}
+		if ok, _ := ethics.IsAllowed(host); !ok {
+			check.Error = "mail server outside the ethics scope"
+			continue
+		}
+		if err := ethics.Wait(ctx, "email"); err != nil {
+			check.Error = err.Error()
+			return check
+		}
+
+		check = rcpt(ctx, host, helo, from, addr, domain, timeout)
+		if check.Verdict != core.SMTPUnknown {
+			return check
+		}
+	}
+	return check
+}
+
+// rcpt runs one SMTP conversation up to RCPT TO and quits.
+func rcpt(ctx context.Context, host, helo, from, addr, domain string, timeout time.Duration) *core.SMTPCheck {
+	check := &core.SMTPCheck{Server: host, Verdict: core.SMTPUnknown}
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := netclient.DialContext(dialCtx, "tcp", net.JoinHostPort(host, smtpPort))
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		check.Error = err.Error()
+		return check
+	}
+	defer c.Close()
+
+	if err := c.Hello(helo); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	if from == "" {
+		from = "postmaster@" + helo
+	}
+	if err := c.Mail(from); err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	err = c.Rcpt(addr)
+	check.Code, check.Message = replyCode(err)
+	switch {
+	case err == nil:
+		check.Code = 250
+		check.Verdict = core.SMTPDeliverable
+		if c.Rcpt(randomMailbox(domain)) == nil {
+			check.Verdict = core.SMTPCatchAll
+		}
+	case check.Code >= 500:
+		check.Verdict = core.SMTPUndeliverable
+	default:
+		// 4xx is usually greylisting; anything else is inconclusive
+		check.Error = err.Error()
+	}
+	c.Quit()
+	return check
+}
+
+func replyCode(err error) (int, string) {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code, tpErr.Msg
+	}
+	return 0, ""
+}
+
+func randomMailbox(domain string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "spectre-" + hex.EncodeToString(b) + "@" + domain
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -22,6 +23,8 @@ type PluginMetadata struct {
 	Command     string   `yaml:"command"`
 	Args        []string `yaml:"args"`
 	IsActive    bool     `yaml:"is_active"`
+	Accepts     []string `yaml:"accepts"`  // Entity types the plugin takes (empty = any)
+	Produces    []string `yaml:"produces"` // Entity types the plugin reports
 }
 
 // ExternalCollector implements the core.Collector interface for external scripts.
@@ -42,15 +45,31 @@ func (e *ExternalCollector) IsActive() bool {
 	return e.metadata.IsActive
 }
 
+func (e *ExternalCollector) Accepts() []string {
+	return e.metadata.Accepts
+}
+
+func (e *ExternalCollector) Produces() []string {
+	return e.metadata.Produces
+}
+
 // Collect executes the external plugin and captures its output.
 func (e *ExternalCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return e.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext executes the plugin, killing the process if ctx is cancelled.
+func (e *ExternalCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
 	// Prepare command
-	args := append(e.metadata.Args, target)
-	cmd := exec.Command(e.metadata.Command, args...)
+	args := append(append([]string{}, e.metadata.Args...), target)
+	cmd := exec.CommandContext(ctx, e.metadata.Command, args...)
 	cmd.Dir = e.path
 
 	// Run and capture output
 	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("plugin '%s' aborted: %w", e.metadata.Name, ctx.Err())
+	}
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return nil, fmt.Errorf("plugin execution failed: %s", string(exitErr.Stderr))
@@ -1,10 +1,16 @@
 package geo
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+
+	"github.com/spf13/viper"
 )
 
 func TestGeoIPCollector_Collect(t *testing.T) {
@@ -19,10 +25,12 @@ func TestGeoIPCollector_Collect(t *testing.T) {
 		"lon":         -77.4874,
 		"isp":         "Google LLC",
 		"query":       "8.8.8.8",
+		"mobile":      false,
 	}
+	body, _ := json.Marshal(mockResponse)
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		json.NewEncoder(w).Encode(mockResponse)
+		w.Write(body)
 	}))
 	defer server.Close()
 
@@ -46,4 +54,31 @@ func TestGeoIPCollector_Collect(t *testing.T) {
 	if ev.Metadata["city"] != "Ashburn" {
 		t.Errorf("Expected city Ashburn, got %v", ev.Metadata["city"])
 	}
+
+	// The evidence is the response as received, fields we don't parse included
+	stored, err := os.ReadFile(ev.FilePath)
+	if err != nil {
+		t.Fatalf("Evidence file missing: %v", err)
+	}
+	if !bytes.Equal(stored, body) {
+		t.Errorf("Expected the raw response as evidence, got %s", stored)
+	}
+	if sum := sha256.Sum256(body); ev.FileHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected the hash of the raw response, got %s", ev.FileHash)
+	}
+}
+
+func TestNewProvider_GhostMode(t *testing.T) {
+	viper.Set("ghost_mode", true)
+	defer viper.Set("ghost_mode", nil)
+
+	if _, err := NewProvider(""); err == nil {
+		t.Error("expected ip-api to be refused in ghost mode")
+	}
+
+	viper.Set("collectors.geo.ghost_allow_ip_api", true)
+	defer viper.Set("collectors.geo.ghost_allow_ip_api", nil)
+	if p, err := NewProvider(""); err != nil || p.Name() != ProviderIPAPI {
+		t.Errorf("expected ip-api after opting in, got %v, %v", p, err)
+	}
 }
@@ -1,19 +1,19 @@
 package geo
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spectre/spectre/internal/collector"
 	"github.com/spectre/spectre/internal/core"
-	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
 )
 
 type GeoIPCollector struct {
@@ -31,44 +31,48 @@ func (c *GeoIPCollector) Name() string {
 }
 
 func (c *GeoIPCollector) Description() string {
-	return "Enrich IP addresses with geolocation data via ip-api.com"
+	return "Enrich IP addresses with geolocation data (offline MaxMind/DB-IP databases or ip-api.com)"
 }
 
 func (c *GeoIPCollector) IsActive() bool {
 	return false
 }
 
-func (c *GeoIPCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
-	client := netclient.NewClient()
+func (c *GeoIPCollector) Accepts() []string {
+	return []string{core.EntityIP}
+}
 
-	// API Request
-	url := c.BaseURL + target
-	if c.BaseURL == "" {
-		url = "http://ip-api.com/json/" + target
-	}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("geoip request failed: %w", err)
-	}
-	defer resp.Body.Close()
+func (c *GeoIPCollector) Produces() []string {
+	return nil // Enriches the target entity in place
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("geoip api returned status: %d", resp.StatusCode)
-	}
+// Local reports whether lookups use the offline databases, which need no
+// rate limit.
+func (c *GeoIPCollector) Local() bool {
+	return viper.GetString("collectors.geo.provider") == ProviderMMDB
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *GeoIPCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext looks target up with the provider selected by
+// collectors.geo.provider.
+func (c *GeoIPCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	provider, err := NewProvider(c.BaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
-
-	// Parse for metadata extraction
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse json: %w", err)
+	loc, err := provider.Lookup(ctx, target)
+	if err != nil {
+		return nil, err
 	}
 
-	if result["status"] == "fail" {
-		return nil, fmt.Errorf("geoip api error: %v", result["message"])
+	body := loc.Raw
+	if body == nil {
+		if body, err = json.MarshalIndent(loc, "", "  "); err != nil {
+			return nil, err
+		}
 	}
 
 	// Store Evidence File
@@ -77,7 +81,7 @@ func (c *GeoIPCollector) Collect(caseID string, target string) ([]core.Evidence,
 		return nil, err
 	}
 
-	fileName := fmt.Sprintf("geo_%s_%d.json", target, time.Now().Unix())
+	fileName := fmt.Sprintf("geo_%s_%d.json", strings.ReplaceAll(target, ":", "_"), time.Now().Unix())
 	filePath := filepath.Join(storageDir, fileName)
 	if err := os.WriteFile(filePath, body, 0644); err != nil {
 		return nil, err
@@ -94,12 +98,13 @@ func (c *GeoIPCollector) Collect(caseID string, target string) ([]core.Evidence,
 		FileHash:    hashStr,
 		CollectedAt: time.Now(),
 		Metadata: map[string]interface{}{
-			"target":  target,
-			"country": result["countryCode"], // US, DE
-			"city":    result["city"],
-			"isp":     result["isp"],
-			"lat":     result["lat"],
-			"lon":     result["lon"],
+			"target":   target,
+			"country":  loc.CountryCode, // US, DE
+			"city":     loc.City,
+			"isp":      loc.ISP,
+			"lat":      loc.Lat,
+			"lon":      loc.Lon,
+			"provider": loc.Provider,
 		},
 	}
 
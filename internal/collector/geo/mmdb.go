@@ -0,0 +1,161 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+)
+
+// cityRecord is the part of the GeoIP2/GeoLite2 City schema Spectre uses.
+// DB-IP's City databases share the layout.
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+		TimeZone  string   `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+}
+
+// asnRecord covers the GeoLite2/DB-IP ASN schema and the extra fields of
+// the commercial ISP databases.
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	ASOrg        string `maxminddb:"autonomous_system_organization"`
+	ISP          string `maxminddb:"isp"`
+	Organization string `maxminddb:"organization"`
+}
+
+// MMDBProvider reads MaxMind DB files: a City database for the location
+// and an ASN (or ISP) database for the network owner. Either may be
+// omitted.
+type MMDBProvider struct {
+	city, asn *maxminddb.Reader
+}
+
+var (
+	readersMu sync.Mutex
+	readers   = make(map[string]*maxminddb.Reader)
+)
+
+// openReader opens path once per process; the files are memory-mapped
+// and shared by every lookup.
+func openReader(path string) (*maxminddb.Reader, error) {
+	readersMu.Lock()
+	defer readersMu.Unlock()
+	if r, ok := readers[path]; ok {
+		return r, nil
+	}
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	readers[path] = r
+	return r, nil
+}
+
+// OpenMMDB opens the City and ASN databases; at least one is required.
+func OpenMMDB(cityPath, asnPath string) (*MMDBProvider, error) {
+	if cityPath == "" && asnPath == "" {
+		return nil, fmt.Errorf("no GeoIP databases configured (collectors.geo.city_db, collectors.geo.asn_db)")
+	}
+	p := &MMDBProvider{}
+	var err error
+	if cityPath != "" {
+		if p.city, err = openReader(cityPath); err != nil {
+			return nil, err
+		}
+	}
+	if asnPath != "" {
+		if p.asn, err = openReader(asnPath); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *MMDBProvider) Name() string {
+	return ProviderMMDB
+}
+
+func (p *MMDBProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q: %w", ip, err)
+	}
+	addr = addr.Unmap()
+
+	loc := &Location{Query: addr.String(), Provider: ProviderMMDB}
+	found := false
+
+	if p.city != nil {
+		var rec cityRecord
+		res := p.city.Lookup(addr)
+		if err := res.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP city record: %w", err)
+		}
+		if res.Found() {
+			found = true
+			loc.Country = name(rec.Country.Names)
+			loc.CountryCode = rec.Country.ISOCode
+			if len(rec.Subdivisions) > 0 {
+				loc.Region = rec.Subdivisions[0].ISOCode
+				loc.RegionName = name(rec.Subdivisions[0].Names)
+			}
+			loc.City = name(rec.City.Names)
+			loc.Zip = rec.Postal.Code
+			loc.Timezone = rec.Location.TimeZone
+			if rec.Location.Latitude != nil && rec.Location.Longitude != nil {
+				loc.Lat, loc.Lon = *rec.Location.Latitude, *rec.Location.Longitude
+			}
+		}
+	}
+
+	if p.asn != nil {
+		var rec asnRecord
+		res := p.asn.Lookup(addr)
+		if err := res.Decode(&rec); err != nil {
+			return nil, fmt.Errorf("failed to read GeoIP ASN record: %w", err)
+		}
+		if res.Found() {
+			found = true
+			loc.ISP, loc.Org = rec.ISP, rec.Organization
+			if loc.ISP == "" {
+				loc.ISP = rec.ASOrg
+			}
+			if loc.Org == "" {
+				loc.Org = rec.ASOrg
+			}
+			if rec.Number != 0 {
+				loc.AS = strings.TrimSpace(fmt.Sprintf("AS%d %s", rec.Number, rec.ASOrg))
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no GeoIP data for %s", addr)
+	}
+	return loc, nil
+}
+
+// name picks the English name from a localized name map.
+func name(names map[string]string) string {
+	return names["en"]
+}
@@ -0,0 +1,154 @@
+package geo
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spf13/viper"
+)
+
+// writeMMDB builds a small MaxMind DB with one record per network.
+func writeMMDB(t *testing.T, dbType string, records map[string]mmdbtype.Map) string {
+	t.Helper()
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: dbType, IncludeReservedNetworks: true, RecordSize: 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cidr, rec := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Insert(network, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), dbType+".mmdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := tree.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testDatabases(t *testing.T) (city, asn string) {
+	city = writeMMDB(t, "GeoLite2-City", map[string]mmdbtype.Map{
+		"192.0.2.0/24": {
+			"city":    mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Ashburn")}},
+			"country": mmdbtype.Map{"iso_code": mmdbtype.String("US"), "names": mmdbtype.Map{"en": mmdbtype.String("United States")}},
+			"subdivisions": mmdbtype.Slice{
+				mmdbtype.Map{"iso_code": mmdbtype.String("VA"), "names": mmdbtype.Map{"en": mmdbtype.String("Virginia")}},
+			},
+			"location": mmdbtype.Map{
+				"latitude":  mmdbtype.Float64(39.0438),
+				"longitude": mmdbtype.Float64(-77.4874),
+				"time_zone": mmdbtype.String("America/New_York"),
+			},
+			"postal": mmdbtype.Map{"code": mmdbtype.String("20149")},
+		},
+	})
+	asn = writeMMDB(t, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"192.0.2.0/24": {
+			"autonomous_system_number":       mmdbtype.Uint32(64496),
+			"autonomous_system_organization": mmdbtype.String("Example Hosting LLC"),
+		},
+		"198.51.100.0/24": {
+			"autonomous_system_number":       mmdbtype.Uint32(64497),
+			"autonomous_system_organization": mmdbtype.String("Other Net"),
+		},
+	})
+	return city, asn
+}
+
+func TestMMDBProvider(t *testing.T) {
+	city, asn := testDatabases(t)
+	p, err := OpenMMDB(city, asn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := p.Lookup(context.Background(), "192.0.2.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Location{
+		Query: "192.0.2.10", Country: "United States", CountryCode: "US", Region: "VA", RegionName: "Virginia",
+		City: "Ashburn", Zip: "20149", Lat: 39.0438, Lon: -77.4874, Timezone: "America/New_York",
+		ISP: "Example Hosting LLC", Org: "Example Hosting LLC", AS: "AS64496 Example Hosting LLC", Provider: ProviderMMDB,
+	}
+	if !reflect.DeepEqual(*loc, want) {
+		t.Errorf("Lookup = %+v\nwant %+v", *loc, want)
+	}
+
+	// Only the ASN database knows this one
+	loc, err = p.Lookup(context.Background(), "198.51.100.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.City != "" || loc.Lat != 0 || loc.AS != "AS64497 Other Net" {
+		t.Errorf("unexpected partial record %+v", loc)
+	}
+
+	if _, err := p.Lookup(context.Background(), "203.0.113.1"); err == nil {
+		t.Error("expected an error for an address in neither database")
+	}
+	if _, err := OpenMMDB("", ""); err == nil {
+		t.Error("expected an error without databases")
+	}
+}
+
+func TestGeoIPCollector_MMDB(t *testing.T) {
+	city, asn := testDatabases(t)
+	viper.Set("collectors.geo.provider", ProviderMMDB)
+	viper.Set("collectors.geo.city_db", city)
+	viper.Set("collectors.geo.asn_db", asn)
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		for _, k := range []string{"provider", "city_db", "asn_db"} {
+			viper.Set("collectors.geo."+k, nil)
+		}
+	})
+
+	// BaseURL points nowhere: the lookup must not touch the network
+	c := &GeoIPCollector{BaseURL: "http://127.0.0.1:1/"}
+	evs, err := c.Collect("test-case", "192.0.2.10")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	md := evs[0].Metadata
+	if md["country"] != "US" || md["city"] != "Ashburn" || md["isp"] != "Example Hosting LLC" || md["lat"] != 39.0438 || md["lon"] != -77.4874 || md["provider"] != ProviderMMDB {
+		t.Errorf("unexpected metadata %v", md)
+	}
+
+	// Offline lookups skip the rate limit meant for ip-api
+	ethics.SetLimit("geo", 0.001)
+	defer ethics.SetLimit("geo", 0.75)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if _, err := collector.Run(ctx, "geo", "test-case", "192.0.2.10", core.CollectOptions{}); err != nil {
+			t.Fatalf("run %d was throttled: %v", i, err)
+		}
+	}
+
+	viper.Set("collectors.geo.provider", "nope")
+	if _, err := c.Collect("test-case", "192.0.2.10"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
@@ -0,0 +1,125 @@
+package geo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+// Location is what a provider knows about an address. The JSON layout is
+// ip-api.com's, so evidence looks the same whichever provider produced it.
+type Location struct {
+	Query       string  `json:"query"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"countryCode"`
+	Region      string  `json:"region"`
+	RegionName  string  `json:"regionName"`
+	City        string  `json:"city"`
+	Zip         string  `json:"zip"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Timezone    string  `json:"timezone"`
+	ISP         string  `json:"isp"`
+	Org         string  `json:"org"`
+	AS          string  `json:"as"` // "AS15169 Google LLC"
+	Provider    string  `json:"provider"`
+
+	// Raw is the response as the provider sent it, stored as the evidence
+	// file so the custody hash covers the original bytes. Local databases
+	// leave it empty.
+	Raw []byte `json:"-"`
+}
+
+// Provider looks up the location of an IP address.
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, ip string) (*Location, error)
+}
+
+// Provider names accepted by collectors.geo.provider.
+const (
+	ProviderIPAPI = "ip-api"
+	ProviderMMDB  = "mmdb"
+)
+
+// NewProvider returns the provider selected by collectors.geo.provider.
+// ip-api is the default; mmdb reads the databases at collectors.geo.city_db
+// and collectors.geo.asn_db and never leaves the machine. In ghost mode
+// ip-api is refused unless collectors.geo.ghost_allow_ip_api opts in, since
+// the address would still cross the Tor exit in plaintext.
+func NewProvider(baseURL string) (Provider, error) {
+	switch name := viper.GetString("collectors.geo.provider"); name {
+	case "", ProviderIPAPI:
+		if viper.GetBool("ghost_mode") {
+			if !viper.GetBool("collectors.geo.ghost_allow_ip_api") {
+				return nil, fmt.Errorf("ghost mode: the ip-api provider sends addresses over plain HTTP; use the %q provider or set collectors.geo.ghost_allow_ip_api", ProviderMMDB)
+			}
+			log.Warn().Msg("Ghost mode enabled: ip-api lookups go over plain HTTP through the proxy")
+		}
+		return &ipAPIProvider{BaseURL: baseURL}, nil
+	case ProviderMMDB:
+		return OpenMMDB(viper.GetString("collectors.geo.city_db"), viper.GetString("collectors.geo.asn_db"))
+	default:
+		return nil, fmt.Errorf("unknown geo provider %q (use %q or %q)", name, ProviderIPAPI, ProviderMMDB)
+	}
+}
+
+// ipAPIProvider queries the free ip-api.com JSON endpoint, which is only
+// served over plain HTTP.
+type ipAPIProvider struct {
+	BaseURL string
+}
+
+func (p *ipAPIProvider) Name() string {
+	return ProviderIPAPI
+}
+
+func (p *ipAPIProvider) Lookup(ctx context.Context, ip string) (*Location, error) {
+	client := netclient.NewClient()
+
+	url := p.BaseURL + ip
+	if p.BaseURL == "" {
+		url = "http://ip-api.com/json/" + ip
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build geoip request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("geoip request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geoip api returned status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var result struct {
+		Location
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+	if result.Status == "fail" {
+		return nil, fmt.Errorf("geoip api error: %v", result.Message)
+	}
+
+	loc := result.Location
+	loc.Provider = ProviderIPAPI
+	loc.Raw = body
+	return &loc, nil
+}
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -36,13 +37,25 @@ func (g *GitHubCollector) IsActive() bool {
 	return false
 }
 
+func (g *GitHubCollector) Accepts() []string {
+	return []string{core.EntityDomain, core.EntityEmail, core.EntityUsername}
+}
+
+func (g *GitHubCollector) Produces() []string {
+	return []string{core.EntityUsername, core.EntityRepo}
+}
+
 func (g *GitHubCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return g.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+func (g *GitHubCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
 	apiKey := config.GetAPIKey("github")
 	client := netclient.NewClient()
 	
 	// Search repositories
 	url := fmt.Sprintf("https://api.github.com/search/repositories?q=%s", target)
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	if apiKey != "" {
 		req.Header.Set("Authorization", "token "+apiKey)
@@ -0,0 +1,211 @@
+package pdns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const testCSV = `Query,QType,Answer,First_Seen,Last_Seen,Hits
+www.Example.test.,A,192.0.2.10,2023-01-01,2023-02-01,3
+mail.example.test,A,192.0.2.10,1672531200,1680307200,1
+other.test,A,192.0.2.20,2023-01-01,2023-01-01,1
+example.test,MX,mail.example.test.,2023-01-01,2023-01-01,1
+broken.test,A,not-an-ip,2023-01-01,2023-01-01,1
+`
+
+const testCOF = `{"rrname": "www.example.test", "rrtype": "A", "rdata": "192.0.2.10", "time_first": 1640995200, "time_last": 1700000000, "count": 2}
+{"rrname": "shop.test", "rrtype": "A", "rdata": ["192.0.2.10", "192.0.2.11"], "time_first": 1690000000, "time_last": 1690000000}
+`
+
+func newStore(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "pdns.db")
+	store, err := OpenStore(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if n, err := store.Import(strings.NewReader(testCSV), FormatCSV); err != nil || n != 4 {
+		t.Fatalf("CSV import = %d, %v", n, err)
+	}
+	if n, err := store.Import(strings.NewReader(testCOF), FormatCOF); err != nil || n != 3 {
+		t.Fatalf("COF import = %d, %v", n, err)
+	}
+	return path
+}
+
+func TestStore_ImportAndByIP(t *testing.T) {
+	store, err := OpenStore(newStore(t), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	got, err := store.ByIP("192.0.2.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range got {
+		names = append(names, r.RRName)
+	}
+	if strings.Join(names, ",") != "www.example.test,shop.test,mail.example.test" {
+		t.Fatalf("unexpected names, most recent first: %v", names)
+	}
+
+	// Sightings of the same answer are merged
+	www := got[0]
+	if www.Count != 5 || !www.FirstSeen.Equal(time.Unix(1640995200, 0)) || !www.LastSeen.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("unexpected merged record %+v", www)
+	}
+	if other, _ := store.ByIP("::ffff:192.0.2.20"); len(other) != 1 || other[0].RRName != "other.test" {
+		t.Errorf("expected mapped address to match, got %+v", other)
+	}
+	if _, err := store.ByIP("bogus"); err == nil {
+		t.Error("expected an error for an invalid address")
+	}
+
+	if _, err := OpenStore(filepath.Join(t.TempDir(), "missing.db"), false); err == nil {
+		t.Error("expected an error for a missing store")
+	}
+}
+
+func TestCOFProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "analyst" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/pdns/query/192.0.2.10" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `{"rrname": "a.test", "rrtype": "A", "rdata": "192.0.2.10", "time_first": 1690000000, "time_last": 1690000000, "count": 4}`)
+		fmt.Fprintln(w, `{"rrname": "b.test", "rrtype": "A", "rdata": "192.0.2.99", "time_first": 1690000000, "time_last": 1690000000}`)
+		fmt.Fprintln(w, `{"rrname": "10.2.0.192.in-addr.arpa", "rrtype": "PTR", "rdata": "a.test", "time_first": 1690000000, "time_last": 1690000000}`)
+	}))
+	defer srv.Close()
+
+	p, err := newCOFProvider(ProviderConfig{Name: "circl", URL: srv.URL + "/pdns/query/{ip}", Username: "analyst", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.ByIP(context.Background(), "192.0.2.10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].RRName != "a.test" || got[0].Count != 4 {
+		t.Errorf("unexpected records %+v", got)
+	}
+	if none, err := p.ByIP(context.Background(), "192.0.2.77"); err != nil || len(none) != 0 {
+		t.Errorf("expected no records for an unknown address, got %+v, %v", none, err)
+	}
+
+	p, _ = newCOFProvider(ProviderConfig{Name: "circl", URL: srv.URL + "/pdns/query/{ip}"})
+	if _, err := p.ByIP(context.Background(), "192.0.2.10"); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
+
+// serveDNS answers the PTR query for 192.0.2.10 over UDP.
+func serveDNS(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true})
+			b.StartQuestions()
+			b.Question(q)
+			b.StartAnswers()
+			if q.Type == dnsmessage.TypePTR && q.Name.String() == "10.2.0.192.in-addr.arpa." {
+				rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+				b.PTRResource(rh, dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("Host-10.Provider.test.")})
+			}
+			msg, _ := b.Finish()
+			pc.WriteTo(msg, addr)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestReverseCollector_Collect(t *testing.T) {
+	path := newStore(t)
+	viper.Set("collectors.dns.servers", []string{serveDNS(t)})
+	viper.Set("collectors.reverse.providers", []map[string]interface{}{{"name": "sensors", "type": "sqlite", "path": path}})
+	viper.Set("collectors.reverse.max_domains", 2)
+
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		viper.Set("collectors.dns.servers", nil)
+		viper.Set("collectors.reverse.providers", nil)
+		viper.Set("collectors.reverse.max_domains", nil)
+	})
+
+	evs, err := (&ReverseCollector{}).CollectContext(context.Background(), "case-reverse", "192.0.2.10", core.CollectOptions{})
+	if err != nil {
+		t.Fatalf("CollectContext failed: %v", err)
+	}
+	if _, err := os.Stat(evs[0].FilePath); err != nil {
+		t.Fatalf("evidence file missing: %v", err)
+	}
+	res := evs[0].RawData.(*core.ReverseDNSResult)
+	if len(res.PTR) != 1 || res.PTR[0] != "host-10.provider.test" {
+		t.Errorf("unexpected PTR names %v (%s)", res.PTR, res.PTRError)
+	}
+	if len(res.Resolutions) != 2 || !res.Truncated || res.Resolutions[0].RRName != "www.example.test" || res.Resolutions[0].Source != "sensors" {
+		t.Errorf("expected the two most recent names, got %+v", res)
+	}
+
+	// Without PTR or a store, nothing can answer
+	viper.Set("collectors.reverse.providers", []map[string]interface{}{{"name": "gone", "path": filepath.Join(t.TempDir(), "missing.db")}})
+	opts := core.CollectOptions{Params: map[string]string{"ptr": "false"}}
+	if _, err := (&ReverseCollector{}).CollectContext(context.Background(), "case-reverse", "192.0.2.10", opts); err == nil {
+		t.Error("expected an error when every source failed")
+	}
+	if _, err := (&ReverseCollector{}).CollectContext(context.Background(), "case-reverse", "example.test", opts); err == nil {
+		t.Error("expected an error for a non-IP target")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	byName := make(map[string]*core.PDNSRecord)
+	merge(byName, core.PDNSRecord{RRName: "a.test", FirstSeen: time.Unix(200, 0), LastSeen: time.Unix(300, 0), Count: 1, Source: "local"})
+	merge(byName, core.PDNSRecord{RRName: "a.test", FirstSeen: time.Unix(100, 0), LastSeen: time.Unix(250, 0), Count: 2, Source: "circl"})
+
+	got := byName["a.test"]
+	if got.Count != 3 || got.FirstSeen.Unix() != 100 || got.LastSeen.Unix() != 300 || got.Source != "local,circl" {
+		t.Errorf("unexpected merged record %+v", got)
+	}
+}
@@ -0,0 +1,197 @@
+package pdns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+// DefaultStore is the passive DNS store used when collectors.reverse.store
+// is unset.
+const DefaultStore = "pdns.db"
+
+// maxProviderResponse bounds a single provider reply.
+const maxProviderResponse = 32 << 20
+
+// Provider answers passive DNS queries.
+type Provider interface {
+	Name() string
+	// ByIP returns the names seen resolving to ip.
+	ByIP(ctx context.Context, ip string) ([]core.PDNSRecord, error)
+}
+
+// ProviderConfig is one entry of collectors.reverse.providers.
+type ProviderConfig struct {
+	Name    string            `mapstructure:"name"`
+	Type    string            `mapstructure:"type"` // registered provider type: "sqlite" or "cof"
+	Timeout time.Duration     `mapstructure:"timeout"`
+	Headers map[string]string `mapstructure:"headers"`
+
+	// sqlite: the local store file.
+	Path string `mapstructure:"path"`
+
+	// cof: URL template with {ip} and {api_key} answering in the Passive
+	// DNS Common Output Format, such as CIRCL's pDNS service, with optional
+	// HTTP basic auth.
+	URL      string `mapstructure:"url"`
+	APIKey   string `mapstructure:"api_key"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// ProviderFactory builds a provider from its configuration.
+type ProviderFactory func(cfg ProviderConfig) (Provider, error)
+
+var (
+	providerMu    sync.RWMutex
+	providerTypes = map[string]ProviderFactory{"sqlite": newStoreProvider, "cof": newCOFProvider}
+)
+
+// RegisterProviderType makes a provider type available to configuration.
+func RegisterProviderType(name string, factory ProviderFactory) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerTypes[name] = factory
+}
+
+// StorePath returns the configured local passive DNS store file.
+func StorePath() string {
+	if path := viper.GetString("collectors.reverse.store"); path != "" {
+		return path
+	}
+	return DefaultStore
+}
+
+// LoadProviders builds the providers configured in
+// collectors.reverse.providers. Without any, the local store is used when
+// it exists.
+func LoadProviders() ([]Provider, error) {
+	var configs []ProviderConfig
+	if err := viper.UnmarshalKey("collectors.reverse.providers", &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse passive DNS providers: %w", err)
+	}
+	if len(configs) == 0 {
+		if _, err := os.Stat(StorePath()); err != nil {
+			return nil, nil
+		}
+		configs = []ProviderConfig{{Name: "local", Type: "sqlite"}}
+	}
+
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	var providers []Provider
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("provider%d", i+1)
+		}
+		if cfg.Type == "" {
+			cfg.Type = "sqlite"
+		}
+		factory, ok := providerTypes[cfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("passive DNS provider %s: unknown type %q", cfg.Name, cfg.Type)
+		}
+		p, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("passive DNS provider %s: %w", cfg.Name, err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// storeProvider reads a local Store. The file is opened per lookup so an
+// import running alongside an investigation is picked up.
+type storeProvider struct {
+	name, path string
+}
+
+func newStoreProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.Path == "" {
+		cfg.Path = StorePath()
+	}
+	return &storeProvider{name: cfg.Name, path: cfg.Path}, nil
+}
+
+func (p *storeProvider) Name() string {
+	return p.name
+}
+
+func (p *storeProvider) ByIP(ctx context.Context, ip string) ([]core.PDNSRecord, error) {
+	store, err := OpenStore(p.path, false)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	return store.ByIP(ip)
+}
+
+// cofProvider queries an HTTP service answering in COF.
+type cofProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+}
+
+func newCOFProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("needs url")
+	}
+	client := netclient.NewClient()
+	if cfg.Timeout > 0 {
+		client.Timeout = cfg.Timeout
+	}
+	return &cofProvider{cfg: cfg, client: client}, nil
+}
+
+func (p *cofProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *cofProvider) ByIP(ctx context.Context, ip string) ([]core.PDNSRecord, error) {
+	u := strings.NewReplacer("{ip}", url.PathEscape(ip), "{api_key}", url.QueryEscape(p.cfg.APIKey)).Replace(p.cfg.URL)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/x-ndjson, application/json")
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, strings.ReplaceAll(v, "{api_key}", p.cfg.APIKey))
+	}
+	if p.cfg.Username != "" {
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("passive DNS query failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("passive DNS query failed: %s", resp.Status)
+	}
+
+	var records []core.PDNSRecord
+	err = ReadCOF(io.LimitReader(resp.Body, maxProviderResponse), func(r core.PDNSRecord) error {
+		if (r.RRType == "A" || r.RRType == "AAAA") && r.RData == ip {
+			records = append(records, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
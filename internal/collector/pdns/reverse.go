@@ -0,0 +1,203 @@
+package pdns
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/collector/dns"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultMaxDomains caps the names kept per address; shared hosting and
+// CDN addresses can carry hundreds of thousands.
+const DefaultMaxDomains = 500
+
+type ReverseCollector struct{}
+
+func init() {
+	collector.Register(&ReverseCollector{})
+}
+
+func (r *ReverseCollector) Name() string {
+	return "reverse"
+}
+
+func (r *ReverseCollector) Description() string {
+	return "Reverse DNS (PTR) and passive DNS: other domains seen on an IP address"
+}
+
+func (r *ReverseCollector) IsActive() bool {
+	return false
+}
+
+func (r *ReverseCollector) Accepts() []string {
+	return []string{core.EntityIP}
+}
+
+func (r *ReverseCollector) Produces() []string {
+	return []string{core.EntityDomain}
+}
+
+func (r *ReverseCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return r.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext looks up the PTR names of target and asks every
+// configured passive DNS provider which names have resolved to it. Params
+// may set "ptr" to "false" to skip the PTR query.
+func (r *ReverseCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(target))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q: %w", target, err)
+	}
+	addr = addr.Unmap()
+	result := &core.ReverseDNSResult{IP: addr.String()}
+
+	ptr := !viper.IsSet("collectors.reverse.ptr") || viper.GetBool("collectors.reverse.ptr")
+	if v := opts.Params["ptr"]; v != "" {
+		ptr = v == "true"
+	}
+	if ptr {
+		ans := dns.NewResolver().Query(ctx, dns.ReverseName(addr), dnsmessage.TypePTR)
+		if ans.Error != "" {
+			result.PTRError = ans.Error
+		}
+		for _, rec := range ans.Records {
+			if name := strings.ToLower(rec.Value); rec.Type == "PTR" && !contains(result.PTR, name) {
+				result.PTR = append(result.PTR, name)
+			}
+		}
+	}
+
+	providers, err := LoadProviders()
+	if err != nil {
+		return nil, err
+	}
+	if !ptr && len(providers) == 0 {
+		return nil, fmt.Errorf("no passive DNS provider configured (collectors.reverse.providers) and PTR lookups disabled")
+	}
+
+	byName := make(map[string]*core.PDNSRecord)
+	for _, p := range providers {
+		result.Providers = append(result.Providers, p.Name())
+		records, err := p.ByIP(ctx, result.IP)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("passive DNS lookup aborted: %w", ctx.Err())
+			}
+			log.Warn().Err(err).Str("provider", p.Name()).Msg("Passive DNS lookup failed")
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", p.Name(), err))
+			continue
+		}
+		for _, rec := range records {
+			rec.Source = p.Name()
+			merge(byName, rec)
+		}
+	}
+	// Fail only when no source answered at all
+	if (!ptr || result.PTRError != "") && len(result.Errors) == len(result.Providers) {
+		failures := result.Errors
+		if result.PTRError != "" {
+			failures = append([]string{"PTR: " + result.PTRError}, failures...)
+		}
+		return nil, fmt.Errorf("reverse lookup failed: %s", strings.Join(failures, "; "))
+	}
+
+	for _, rec := range byName {
+		result.Resolutions = append(result.Resolutions, *rec)
+	}
+	sort.Slice(result.Resolutions, func(i, j int) bool {
+		a, b := result.Resolutions[i], result.Resolutions[j]
+		if !a.LastSeen.Equal(b.LastSeen) {
+			return a.LastSeen.After(b.LastSeen)
+		}
+		return a.RRName < b.RRName
+	})
+	limit := viper.GetInt("collectors.reverse.max_domains")
+	if limit <= 0 {
+		limit = DefaultMaxDomains
+	}
+	if len(result.Resolutions) > limit {
+		result.Resolutions = result.Resolutions[:limit]
+		result.Truncated = true
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("reverse_%s_%d.json", strings.ReplaceAll(result.IP, ":", "_"), time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "reverse",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"target":  target,
+			"ptr":     strings.Join(result.PTR, ", "),
+			"domains": len(result.Resolutions),
+		},
+		RawData: result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// merge folds rec into the record already kept for its name, widening the
+// time window and adding up the counts across providers.
+func merge(byName map[string]*core.PDNSRecord, rec core.PDNSRecord) {
+	prev, ok := byName[rec.RRName]
+	if !ok {
+		byName[rec.RRName] = &rec
+		return
+	}
+	if !rec.FirstSeen.IsZero() && (prev.FirstSeen.IsZero() || rec.FirstSeen.Before(prev.FirstSeen)) {
+		prev.FirstSeen = rec.FirstSeen
+	}
+	if rec.LastSeen.After(prev.LastSeen) {
+		prev.LastSeen = rec.LastSeen
+	}
+	prev.Count += rec.Count
+	if !contains(strings.Split(prev.Source, ","), rec.Source) {
+		prev.Source += "," + rec.Source
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,353 @@
+package pdns
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Import formats.
+const (
+	FormatCSV = "csv"
+	FormatCOF = "cof" // Passive DNS Common Output Format, one JSON object per line
+)
+
+// storeSchema keeps one row per name, type and answer; repeated sightings
+// widen the time window and add to the count.
+const storeSchema = `
+CREATE TABLE IF NOT EXISTS observations (
+	rrname TEXT NOT NULL,
+	rrtype TEXT NOT NULL,
+	rdata TEXT NOT NULL,
+	first_seen DATETIME NOT NULL,
+	last_seen DATETIME NOT NULL,
+	count INTEGER NOT NULL DEFAULT 1,
+	PRIMARY KEY (rrname, rrtype, rdata)
+) WITHOUT ROWID;
+CREATE INDEX IF NOT EXISTS idx_observations_rdata ON observations(rdata, rrtype);
+`
+
+// columnAliases maps the column names of common sensor exports onto the
+// COF field names.
+var columnAliases = map[string]string{
+	"name": "rrname", "query": "rrname", "qname": "rrname", "domain": "rrname",
+	"type": "rrtype", "qtype": "rrtype",
+	"answer": "rdata", "value": "rdata", "ip": "rdata", "address": "rdata",
+	"first_seen": "time_first", "firstseen": "time_first", "first": "time_first",
+	"last_seen": "time_last", "lastseen": "time_last", "last": "time_last",
+	"hits": "count",
+}
+
+// Store is a local SQLite passive DNS database, filled from our own sensor
+// exports.
+type Store struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenStore opens the store at path, creating it when create is set.
+// Without create a missing store is an error, so lookups never leave an
+// empty database behind.
+func OpenStore(path string, create bool) (*Store, error) {
+	dsn := "file:" + filepath.ToSlash(path)
+	if !create {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("passive DNS store %s not found (import one with 'spectre pdns import'): %w", path, err)
+		}
+		dsn += "?mode=ro"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open passive DNS store: %w", err)
+	}
+	if create {
+		if _, err := db.Exec(storeSchema); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialize passive DNS store: %w", err)
+		}
+	}
+	return &Store{db: db, path: path}, nil
+}
+
+// Path returns the file backing the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// FormatFor guesses the import format from a file name.
+func FormatFor(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson", ".json":
+		return FormatCOF
+	default:
+		return FormatCSV
+	}
+}
+
+// Import reads observations from r and merges them into the store,
+// returning the number read. CSV input needs a header row naming at least
+// rrname, rrtype and rdata; COF input may hold several answers per line.
+func (s *Store) Import(r io.Reader, format string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO observations (rrname, rrtype, rdata, first_seen, last_seen, count) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(rrname, rrtype, rdata) DO UPDATE SET
+			first_seen = MIN(first_seen, excluded.first_seen),
+			last_seen = MAX(last_seen, excluded.last_seen),
+			count = count + excluded.count`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare import: %w", err)
+	}
+	defer stmt.Close()
+
+	count := 0
+	add := func(rec core.PDNSRecord) error {
+		if _, err := stmt.Exec(rec.RRName, rec.RRType, rec.RData, rec.FirstSeen.UTC(), rec.LastSeen.UTC(), rec.Count); err != nil {
+			return fmt.Errorf("failed to store observation: %w", err)
+		}
+		count++
+		return nil
+	}
+
+	switch format {
+	case FormatCSV:
+		err = readCSV(r, add)
+	case FormatCOF:
+		err = ReadCOF(r, add)
+	default:
+		err = fmt.Errorf("unsupported import format %q", format)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return count, nil
+}
+
+// ByIP returns the A/AAAA observations answering with ip, most recently
+// seen first.
+func (s *Store) ByIP(ip string) ([]core.PDNSRecord, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q: %w", ip, err)
+	}
+	rows, err := s.db.Query(`SELECT rrname, rrtype, rdata, first_seen, last_seen, count FROM observations
+		WHERE rdata = ? AND rrtype IN ('A', 'AAAA') ORDER BY last_seen DESC, rrname`, addr.Unmap().String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query passive DNS store: %w", err)
+	}
+	defer rows.Close()
+
+	var records []core.PDNSRecord
+	for rows.Next() {
+		var r core.PDNSRecord
+		if err := rows.Scan(&r.RRName, &r.RRType, &r.RData, &r.FirstSeen, &r.LastSeen, &r.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan observation: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// normalizeRecord canonicalizes names and addresses so the same sighting
+// from different sensors lands on one row. It reports false for records
+// missing a name, type or answer.
+func normalizeRecord(r *core.PDNSRecord) bool {
+	r.RRName = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(r.RRName), "."))
+	r.RRType = strings.ToUpper(strings.TrimSpace(r.RRType))
+	r.RData = strings.TrimSuffix(strings.TrimSpace(r.RData), ".")
+	if r.RRName == "" || r.RRType == "" || r.RData == "" {
+		return false
+	}
+	switch r.RRType {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(r.RData)
+		if err != nil {
+			return false
+		}
+		r.RData = addr.Unmap().String()
+	default:
+		r.RData = strings.ToLower(r.RData)
+	}
+	if r.LastSeen.IsZero() {
+		r.LastSeen = r.FirstSeen
+	}
+	if r.FirstSeen.IsZero() {
+		r.FirstSeen = r.LastSeen
+	}
+	if r.Count <= 0 {
+		r.Count = 1
+	}
+	return true
+}
+
+// parseTime reads Unix seconds, RFC 3339 or "2006-01-02 15:04:05".
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// readCSV streams a CSV export with a header row.
+func readCSV(r io.Reader, add func(core.PDNSRecord) error) error {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"rrname", "rrtype", "rdata"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("CSV header has no %s column", required)
+		}
+	}
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV: %w", err)
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+
+		rec := core.PDNSRecord{RRName: get("rrname"), RRType: get("rrtype"), RData: get("rdata")}
+		if rec.FirstSeen, err = parseTime(get("time_first")); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.LastSeen, err = parseTime(get("time_last")); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if c := get("count"); c != "" {
+			if rec.Count, err = strconv.ParseInt(strings.TrimSpace(c), 10, 64); err != nil {
+				return fmt.Errorf("line %d: invalid count %q", line, c)
+			}
+		}
+		if !normalizeRecord(&rec) {
+			continue
+		}
+		if err := add(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// cofRecord is one line of the Passive DNS Common Output Format
+// (draft-dulaunoy-dnsop-passive-dns-cof). rdata may be a string or a list,
+// and times are Unix seconds.
+type cofRecord struct {
+	RRName    string          `json:"rrname"`
+	RRType    string          `json:"rrtype"`
+	RData     json.RawMessage `json:"rdata"`
+	TimeFirst int64           `json:"time_first"`
+	TimeLast  int64           `json:"time_last"`
+	Count     int64           `json:"count"`
+}
+
+// ReadCOF streams COF JSON lines. A reply that is a single JSON array of
+// COF objects is accepted too.
+func ReadCOF(r io.Reader, add func(core.PDNSRecord) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	emit := func(c cofRecord) error {
+		var answers []string
+		if err := json.Unmarshal(c.RData, &answers); err != nil {
+			var one string
+			if err := json.Unmarshal(c.RData, &one); err != nil {
+				return nil
+			}
+			answers = []string{one}
+		}
+		for _, a := range answers {
+			rec := core.PDNSRecord{RRName: c.RRName, RRType: c.RRType, RData: a, Count: c.Count}
+			if c.TimeFirst > 0 {
+				rec.FirstSeen = time.Unix(c.TimeFirst, 0).UTC()
+			}
+			if c.TimeLast > 0 {
+				rec.LastSeen = time.Unix(c.TimeLast, 0).UTC()
+			}
+			if !normalizeRecord(&rec) {
+				continue
+			}
+			if err := add(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for n := 1; ; n++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read COF record %d: %w", n, err)
+		}
+
+		var records []cofRecord
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+			if err := json.Unmarshal(raw, &records); err != nil {
+				return fmt.Errorf("failed to read COF record %d: %w", n, err)
+			}
+		} else {
+			var c cofRecord
+			if err := json.Unmarshal(raw, &c); err != nil {
+				return fmt.Errorf("failed to read COF record %d: %w", n, err)
+			}
+			records = []cofRecord{c}
+		}
+		for _, c := range records {
+			if err := emit(c); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package rdap
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// bundled holds a hand-maintained subset of the IANA RDAP bootstrap
+// registry (https://data.iana.org/rdap/): common gTLDs and the main RIR
+// blocks. Anything it misses, most ccTLDs and newer gTLDs included,
+// goes to collectors.rdap.fallback unless bootstrap_dir has the real files.
+//
+//go:embed bootstrap/*.json
+var bundled embed.FS
+
+// bootstrapFiles are the registry files, named as IANA publishes them.
+var bootstrapFiles = []string{"dns.json", "ipv4.json", "ipv6.json", "asn.json"}
+
+// registryFile is the layout shared by all bootstrap files (RFC 9224):
+// each service pairs a list of entries with the base URLs serving them.
+type registryFile struct {
+	Services [][][]string `json:"services"`
+}
+
+type prefixService struct {
+	prefix netip.Prefix
+	urls   []string
+}
+
+type asnService struct {
+	first, last uint32
+	urls        []string
+}
+
+// Bootstrap maps domains, addresses and AS numbers to the RDAP servers
+// responsible for them.
+type Bootstrap struct {
+	tlds     map[string][]string
+	prefixes []prefixService
+	asns     []asnService
+}
+
+// LoadBootstrap reads the registry files from dir, using the bundled copy
+// for any file dir does not have. An empty dir uses the bundled files only.
+func LoadBootstrap(dir string) (*Bootstrap, error) {
+	b := &Bootstrap{tlds: make(map[string][]string)}
+	for _, name := range bootstrapFiles {
+		var data []byte
+		var err error
+		if dir != "" {
+			data, err = os.ReadFile(filepath.Join(dir, name))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read RDAP bootstrap: %w", err)
+			}
+		}
+		if data == nil {
+			if data, err = bundled.ReadFile("bootstrap/" + name); err != nil {
+				return nil, fmt.Errorf("failed to read bundled RDAP bootstrap: %w", err)
+			}
+		}
+
+		var file registryFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse RDAP bootstrap %s: %w", name, err)
+		}
+		for _, service := range file.Services {
+			if len(service) != 2 {
+				continue
+			}
+			entries, urls := service[0], preferHTTPS(service[1])
+			for _, entry := range entries {
+				if err := b.add(name, entry, urls); err != nil {
+					return nil, fmt.Errorf("invalid entry %q in RDAP bootstrap %s: %w", entry, name, err)
+				}
+			}
+		}
+	}
+	return b, nil
+}
+
+func (b *Bootstrap) add(file, entry string, urls []string) error {
+	switch file {
+	case "dns.json":
+		b.tlds[strings.ToLower(strings.Trim(entry, "."))] = urls
+	case "asn.json":
+		first, last, _ := strings.Cut(entry, "-")
+		if last == "" {
+			last = first
+		}
+		lo, err := strconv.ParseUint(first, 10, 32)
+		if err != nil {
+			return err
+		}
+		hi, err := strconv.ParseUint(last, 10, 32)
+		if err != nil {
+			return err
+		}
+		b.asns = append(b.asns, asnService{uint32(lo), uint32(hi), urls})
+	default:
+		p, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return err
+		}
+		b.prefixes = append(b.prefixes, prefixService{p.Masked(), urls})
+	}
+	return nil
+}
+
+// Domain returns the servers for name, matching the longest registered
+// label suffix (so "example.co.uk" prefers "co.uk" over "uk").
+func (b *Bootstrap) Domain(name string) []string {
+	labels := strings.Split(strings.ToLower(strings.Trim(name, ".")), ".")
+	for i := range labels {
+		if urls, ok := b.tlds[strings.Join(labels[i:], ".")]; ok {
+			return urls
+		}
+	}
+	return nil
+}
+
+// IP returns the servers for the most specific prefix containing addr.
+func (b *Bootstrap) IP(addr netip.Addr) []string {
+	addr = addr.Unmap()
+	var best *prefixService
+	for i, s := range b.prefixes {
+		if s.prefix.Contains(addr) && (best == nil || s.prefix.Bits() > best.prefix.Bits()) {
+			best = &b.prefixes[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.urls
+}
+
+// ASN returns the servers for AS number n.
+func (b *Bootstrap) ASN(n uint32) []string {
+	for _, s := range b.asns {
+		if n >= s.first && n <= s.last {
+			return s.urls
+		}
+	}
+	return nil
+}
+
+// rirHosts maps the RDAP servers of the five regional registries to their
+// names.
+var rirHosts = map[string]string{
+	"rdap.afrinic.net": "AFRINIC",
+	"rdap.apnic.net":   "APNIC",
+	"rdap.arin.net":    "ARIN",
+	"rdap.lacnic.net":  "LACNIC",
+	"rdap.db.ripe.net": "RIPE NCC",
+}
+
+// RIR names the regional registry whose RDAP server is listed first in
+// urls, or returns "" for any other server.
+func RIR(urls []string) string {
+	if len(urls) == 0 {
+		return ""
+	}
+	u, err := url.Parse(urls[0])
+	if err != nil {
+		return ""
+	}
+	return rirHosts[u.Hostname()]
+}
+
+// preferHTTPS orders HTTPS base URLs first; registries often list both.
+func preferHTTPS(urls []string) []string {
+	var secure, plain []string
+	for _, u := range urls {
+		if strings.HasPrefix(u, "https://") {
+			secure = append(secure, u)
+		} else {
+			plain = append(plain, u)
+		}
+	}
+	return append(secure, plain...)
+}
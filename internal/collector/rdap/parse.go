@@ -0,0 +1,276 @@
+package rdap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// object is the subset of an RDAP response (RFC 9083) that Spectre keeps.
+type object struct {
+	ObjectClassName string   `json:"objectClassName"`
+	Handle          string   `json:"handle"`
+	LDHName         string   `json:"ldhName"`
+	Name            string   `json:"name"`
+	Status          []string `json:"status"`
+	Events          []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities    []entity `json:"entities"`
+	Nameservers []struct {
+		LDHName string `json:"ldhName"`
+	} `json:"nameservers"`
+
+	StartAddress string `json:"startAddress"`
+	EndAddress   string `json:"endAddress"`
+	Type         string `json:"type"`
+	Country      string `json:"country"`
+	ParentHandle string `json:"parentHandle"`
+	CIDRs        []struct {
+		V4Prefix string `json:"v4prefix"`
+		V6Prefix string `json:"v6prefix"`
+		Length   int    `json:"length"`
+	} `json:"cidr0_cidrs"`
+
+	StartAutnum uint32 `json:"startAutnum"`
+	EndAutnum   uint32 `json:"endAutnum"`
+}
+
+type entity struct {
+	Handle     string            `json:"handle"`
+	Roles      []string          `json:"roles"`
+	VCardArray []json.RawMessage `json:"vcardArray"`
+	PublicIDs  []struct {
+		Type       string `json:"type"`
+		Identifier string `json:"identifier"`
+	} `json:"publicIds"`
+	Remarks []struct {
+		Title string `json:"title"`
+	} `json:"remarks"`
+	Entities []entity `json:"entities"`
+}
+
+// redactionMarkers appear in contact details withheld by the registry.
+var redactionMarkers = []string{"redacted", "privacy", "withheld", "data protected", "not disclosed"}
+
+// parseResponse decodes an RDAP reply into a result for query.
+func parseResponse(query, url string, raw []byte) (*core.RDAPResult, error) {
+	var obj object
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse RDAP response: %w", err)
+	}
+
+	result := &core.RDAPResult{
+		Query:        query,
+		ObjectClass:  obj.ObjectClassName,
+		URL:          url,
+		Handle:       obj.Handle,
+		Name:         strings.ToLower(obj.LDHName),
+		Status:       obj.Status,
+		StartAddress: obj.StartAddress,
+		EndAddress:   obj.EndAddress,
+		Country:      obj.Country,
+		NetworkType:  obj.Type,
+		ParentHandle: obj.ParentHandle,
+		StartAutnum:  obj.StartAutnum,
+		EndAutnum:    obj.EndAutnum,
+		Raw:          raw,
+	}
+	if result.Name == "" {
+		result.Name = obj.Name
+	}
+	for _, e := range obj.Events {
+		if t, err := time.Parse(time.RFC3339, e.Date); err == nil {
+			result.Events = append(result.Events, core.RDAPEvent{Action: e.Action, Date: t.UTC()})
+		}
+	}
+	for _, ns := range obj.Nameservers {
+		if name := strings.ToLower(strings.TrimSuffix(ns.LDHName, ".")); name != "" {
+			result.NameServers = append(result.NameServers, name)
+		}
+	}
+
+	for _, c := range obj.CIDRs {
+		prefix := c.V4Prefix
+		if prefix == "" {
+			prefix = c.V6Prefix
+		}
+		if prefix != "" {
+			result.CIDRs = append(result.CIDRs, fmt.Sprintf("%s/%d", prefix, c.Length))
+		}
+	}
+	if len(result.CIDRs) == 0 && obj.StartAddress != "" {
+		start, err1 := netip.ParseAddr(obj.StartAddress)
+		end, err2 := netip.ParseAddr(obj.EndAddress)
+		if err1 == nil && err2 == nil {
+			for _, p := range RangeToPrefixes(start, end) {
+				result.CIDRs = append(result.CIDRs, p.String())
+			}
+		}
+	}
+
+	var walk func([]entity)
+	walk = func(entities []entity) {
+		for _, e := range entities {
+			c := contact(e)
+			if hasRole(c.Roles, "registrar") && result.Registrar == "" {
+				result.Registrar = c.Organization
+				if result.Registrar == "" {
+					result.Registrar = c.Name
+				}
+				for _, id := range e.PublicIDs {
+					if strings.EqualFold(id.Type, "IANA Registrar ID") {
+						result.RegistrarID = id.Identifier
+					}
+				}
+			}
+			result.Contacts = append(result.Contacts, c)
+			walk(e.Entities)
+		}
+	}
+	walk(obj.Entities)
+	return result, nil
+}
+
+// contact reads an entity's jCard (RFC 7095): each property is
+// [name, parameters, type, value...].
+func contact(e entity) core.RDAPContact {
+	c := core.RDAPContact{Handle: e.Handle, Roles: e.Roles}
+	if len(e.VCardArray) == 2 {
+		var props [][]json.RawMessage
+		json.Unmarshal(e.VCardArray[1], &props)
+		for _, p := range props {
+			if len(p) < 4 {
+				continue
+			}
+			var name string
+			var params map[string]interface{}
+			json.Unmarshal(p[0], &name)
+			json.Unmarshal(p[1], &params)
+			value := jcardText(p[3])
+			switch strings.ToLower(name) {
+			case "fn":
+				c.Name = value
+			case "org":
+				c.Organization = value
+			case "kind":
+				c.Kind = value
+			case "email":
+				if value != "" {
+					c.Emails = append(c.Emails, strings.ToLower(value))
+				}
+			case "tel":
+				if phone := normalizePhone(value); phone != "" {
+					c.Phones = append(c.Phones, phone)
+				}
+			case "adr":
+				if cc, ok := params["cc"].(string); ok {
+					c.Country = strings.ToUpper(cc)
+				}
+			}
+		}
+	}
+	if c.Organization == "" && c.Kind == "org" {
+		c.Organization = c.Name
+	}
+
+	fields := []string{c.Name, c.Organization}
+	fields = append(fields, c.Emails...)
+	for _, r := range e.Remarks {
+		fields = append(fields, r.Title)
+	}
+	for _, f := range fields {
+		lower := strings.ToLower(f)
+		for _, marker := range redactionMarkers {
+			if strings.Contains(lower, marker) {
+				c.Redacted = true
+			}
+		}
+	}
+	return c
+}
+
+// jcardText flattens a jCard value, which may be a string or a list of
+// components, into text.
+func jcardText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var parts []interface{}
+	if json.Unmarshal(raw, &parts) == nil {
+		var out []string
+		for _, p := range parts {
+			if s, ok := p.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return strings.Join(out, " ")
+	}
+	return ""
+}
+
+// normalizePhone reduces "tel:+1.703-555-0100;ext=12" to "+17035550100".
+func normalizePhone(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "tel:")
+	if i := strings.IndexAny(s, ";xX"); i >= 0 {
+		s = s[:i]
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	if len(strings.TrimPrefix(b.String(), "+")) < 5 {
+		return ""
+	}
+	return b.String()
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if strings.EqualFold(r, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// RangeToPrefixes splits an address range into the fewest CIDR prefixes,
+// e.g. 10.0.0.0-10.0.2.255 into 10.0.0.0/23 and 10.0.2.0/24.
+func RangeToPrefixes(start, end netip.Addr) []netip.Prefix {
+	if start.Is4() != end.Is4() || end.Less(start) {
+		return nil
+	}
+	var prefixes []netip.Prefix
+	for start.IsValid() && !end.Less(start) {
+		bits := start.BitLen()
+		// Widen while the prefix stays aligned on start and ends within range
+		for bits > 0 {
+			p := netip.PrefixFrom(start, bits-1).Masked()
+			if p.Addr() != start || end.Less(lastAddr(p)) {
+				break
+			}
+			bits--
+		}
+		p := netip.PrefixFrom(start, bits)
+		prefixes = append(prefixes, p)
+		start = lastAddr(p).Next()
+	}
+	return prefixes
+}
+
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
@@ -0,0 +1,225 @@
+package rdap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+	"golang.org/x/net/idna"
+)
+
+// DefaultFallback redirects to the authoritative server for any query; it
+// is used when the bootstrap registry has no entry.
+const DefaultFallback = "https://rdap.org/"
+
+// maxResponseBytes bounds a single RDAP reply.
+const maxResponseBytes = 8 << 20
+
+type RDAPCollector struct{}
+
+func init() {
+	collector.Register(&RDAPCollector{})
+}
+
+func (r *RDAPCollector) Name() string {
+	return "rdap"
+}
+
+func (r *RDAPCollector) Description() string {
+	return "Structured registration data (RDAP) for domains, IP networks and AS numbers"
+}
+
+func (r *RDAPCollector) IsActive() bool {
+	return false
+}
+
+func (r *RDAPCollector) Accepts() []string {
+	return []string{core.EntityDomain, core.EntityIP, core.EntityNetblock, core.EntityASN}
+}
+
+func (r *RDAPCollector) Produces() []string {
+	return []string{core.EntityOrganization, core.EntityEmail, core.EntityPhone, core.EntityNetblock, core.EntityDomain}
+}
+
+func (r *RDAPCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return r.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext looks target up on the RDAP server the bootstrap registry
+// names for it. Params may set "server" to query a specific base URL.
+func (r *RDAPCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	bootstrap, err := LoadBootstrap(viper.GetString("collectors.rdap.bootstrap_dir"))
+	if err != nil {
+		return nil, err
+	}
+
+	query, path, servers, err := resolve(bootstrap, target, opts.EntityType)
+	if err != nil {
+		return nil, err
+	}
+	if s := opts.Params["server"]; s != "" {
+		servers = []string{s}
+	}
+	if len(servers) == 0 {
+		fallback := DefaultFallback
+		if viper.IsSet("collectors.rdap.fallback") {
+			fallback = viper.GetString("collectors.rdap.fallback")
+		}
+		if fallback == "" {
+			return nil, fmt.Errorf("no RDAP server known for %s", query)
+		}
+		servers = []string{fallback}
+	}
+
+	client := netclient.NewClient()
+	if timeout := viper.GetDuration("collectors.rdap.timeout"); timeout > 0 {
+		client.Timeout = timeout
+	}
+	var raw []byte
+	var url string
+	for _, base := range servers {
+		url = strings.TrimSuffix(base, "/") + "/" + path
+		raw, err = fetch(ctx, client, url)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := parseResponse(query, url, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	safe := strings.NewReplacer("/", "_", ":", "_").Replace(query)
+	fileName := fmt.Sprintf("rdap_%s_%d.json", safe, time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	metadata := map[string]interface{}{
+		"target":       target,
+		"object_class": result.ObjectClass,
+		"handle":       result.Handle,
+		"url":          url,
+	}
+	if result.Registrar != "" {
+		metadata["registrar"] = result.Registrar
+	}
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "rdap",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata:    metadata,
+		RawData:     result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
+
+// resolve normalizes target and returns the RDAP path to query with the
+// servers responsible for it.
+func resolve(b *Bootstrap, target, entityType string) (query, path string, servers []string, err error) {
+	target = strings.TrimSpace(target)
+	if entityType == "" {
+		entityType = core.InferEntityType(target)
+	}
+
+	switch entityType {
+	case core.EntityIP:
+		if host, _, err := net.SplitHostPort(target); err == nil {
+			target = host
+		}
+		addr, err := netip.ParseAddr(target)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid IP address %q: %w", target, err)
+		}
+		addr = addr.Unmap()
+		return addr.String(), "ip/" + addr.String(), b.IP(addr), nil
+	case core.EntityNetblock:
+		prefix, err := netip.ParsePrefix(target)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid prefix %q: %w", target, err)
+		}
+		prefix = prefix.Masked()
+		return prefix.String(), "ip/" + prefix.String(), b.IP(prefix.Addr()), nil
+	case core.EntityASN:
+		n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(target), "AS"), 10, 32)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid AS number %q", target)
+		}
+		return fmt.Sprintf("AS%d", n), fmt.Sprintf("autnum/%d", n), b.ASN(uint32(n)), nil
+	case core.EntityDomain:
+		name, err := idna.Lookup.ToASCII(strings.TrimSuffix(target, "."))
+		if err != nil {
+			return "", "", nil, fmt.Errorf("invalid domain %q: %w", target, err)
+		}
+		return name, "domain/" + name, b.Domain(name), nil
+	default:
+		return "", "", nil, fmt.Errorf("rdap does not accept %s targets", entityType)
+	}
+}
+
+// fetch GETs an RDAP URL; the client follows the redirects registries use
+// to hand queries to each other.
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+	req.Header.Set("User-Agent", "spectre")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rdap query failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("rdap object not found: %s", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rdap query failed: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RDAP response: %w", err)
+	}
+	return data, nil
+}
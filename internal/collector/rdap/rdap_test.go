@@ -0,0 +1,210 @@
+package rdap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+)
+
+const domainResponse = `{
+  "objectClassName": "domain", "handle": "2336799_DOMAIN_COM-VRSN", "ldhName": "EXAMPLE.TEST",
+  "status": ["client transfer prohibited"],
+  "events": [{"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+             {"eventAction": "expiration", "eventDate": "2026-08-13T04:00:00Z"}],
+  "nameservers": [{"objectClassName": "nameserver", "ldhName": "A.IANA-SERVERS.NET"}],
+  "entities": [
+    {"objectClassName": "entity", "handle": "376", "roles": ["registrar"],
+     "publicIds": [{"type": "IANA Registrar ID", "identifier": "376"}],
+     "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "RESERVED-Internet Assigned Numbers Authority"]]],
+     "entities": [{"objectClassName": "entity", "roles": ["abuse"],
+       "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", ""],
+         ["tel", {"type": "voice"}, "uri", "tel:+1.3103015800"], ["email", {}, "text", "Abuse@IANA.test"]]]}]},
+    {"objectClassName": "entity", "roles": ["registrant"],
+     "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "REDACTED FOR PRIVACY"],
+       ["email", {}, "text", "redacted@privacy.test"]]]},
+    {"objectClassName": "entity", "roles": ["technical"],
+     "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Hostmaster"],
+       ["email", {}, "text", "hostmaster@example.test"]]]}
+  ]
+}`
+
+const networkResponse = `{
+  "objectClassName": "ip network", "handle": "NET-192-0-2-0-1", "name": "TEST-NET-1",
+  "startAddress": "192.0.2.0", "endAddress": "192.0.2.255", "ipVersion": "v4", "type": "ASSIGNMENT",
+  "country": "US", "parentHandle": "NET-192-0-0-0-0",
+  "entities": [
+    {"objectClassName": "entity", "handle": "EXAMPLE-ORG", "roles": ["registrant"],
+     "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Hosting LLC"], ["kind", {}, "text", "org"],
+       ["adr", {"cc": "us", "label": "1 Main St"}, "text", ["", "", "", "", "", "", ""]]]]},
+    {"objectClassName": "entity", "handle": "ABUSE-ARIN", "roles": ["abuse"],
+     "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Abuse"], ["kind", {}, "text", "group"],
+       ["email", {}, "text", "abuse@example-hosting.test"], ["tel", {"type": ["work", "voice"]}, "text", "+1-555-010-0199"]]]}
+  ]
+}`
+
+const autnumResponse = `{
+  "objectClassName": "autnum", "handle": "AS64496", "startAutnum": 64496, "endAutnum": 64496, "name": "EXAMPLE-AS",
+  "entities": [{"objectClassName": "entity", "roles": ["registrant"],
+    "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Hosting LLC"], ["kind", {}, "text", "org"]]]}]
+}`
+
+// serveRDAP answers the three object classes and points a bootstrap
+// directory at itself for the .test TLD, 192.0.2.0/24 and AS64496.
+func serveRDAP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rdap+json")
+		switch r.URL.Path {
+		case "/rdap/domain/example.test":
+			fmt.Fprint(w, domainResponse)
+		case "/rdap/ip/192.0.2.10":
+			fmt.Fprint(w, networkResponse)
+		case "/rdap/autnum/64496":
+			fmt.Fprint(w, autnumResponse)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	base := srv.URL + "/rdap/"
+	files := map[string]string{
+		"dns.json":  fmt.Sprintf(`{"services": [[["test"], ["%s"]]]}`, base),
+		"ipv4.json": fmt.Sprintf(`{"services": [[["192.0.0.0/8"], ["http://unused.invalid/", "%s"]]]}`, base),
+		"asn.json":  fmt.Sprintf(`{"services": [[["64496-64511"], ["%s"]]]}`, base),
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+	viper.Set("collectors.rdap.bootstrap_dir", dir)
+	viper.Set("collectors.rdap.fallback", "")
+
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		viper.Set("collectors.rdap.bootstrap_dir", nil)
+		viper.Set("collectors.rdap.fallback", nil)
+	})
+}
+
+func collect(t *testing.T, target string) *core.RDAPResult {
+	evs, err := (&RDAPCollector{}).CollectContext(context.Background(), "case-rdap", target, core.CollectOptions{})
+	if err != nil {
+		t.Fatalf("CollectContext(%s) failed: %v", target, err)
+	}
+	return evs[0].RawData.(*core.RDAPResult)
+}
+
+func TestRDAPCollector_Domain(t *testing.T) {
+	serveRDAP(t)
+
+	res := collect(t, "Example.TEST.")
+	if res.ObjectClass != core.RDAPDomain || res.Name != "example.test" || res.Registrar != "RESERVED-Internet Assigned Numbers Authority" || res.RegistrarID != "376" {
+		t.Errorf("unexpected domain result %+v", res)
+	}
+	if created, ok := res.Event("registration"); !ok || created.Year() != 1995 {
+		t.Errorf("expected registration event, got %v", res.Events)
+	}
+	if !reflect.DeepEqual(res.NameServers, []string{"a.iana-servers.net"}) {
+		t.Errorf("unexpected name servers %v", res.NameServers)
+	}
+
+	byRole := make(map[string]core.RDAPContact)
+	for _, c := range res.Contacts {
+		byRole[c.Roles[0]] = c
+	}
+	if abuse := byRole["abuse"]; !reflect.DeepEqual(abuse.Emails, []string{"abuse@iana.test"}) || !reflect.DeepEqual(abuse.Phones, []string{"+13103015800"}) {
+		t.Errorf("unexpected nested abuse contact %+v", abuse)
+	}
+	if !byRole["registrant"].Redacted || byRole["technical"].Redacted {
+		t.Errorf("unexpected redaction flags %+v", res.Contacts)
+	}
+	if len(res.Raw) == 0 {
+		t.Error("raw response not kept")
+	}
+
+	if _, err := (&RDAPCollector{}).CollectContext(context.Background(), "case-rdap", "missing.test", core.CollectOptions{}); err == nil {
+		t.Error("expected an error for an unknown domain")
+	}
+	if _, err := (&RDAPCollector{}).CollectContext(context.Background(), "case-rdap", "example.nosuchtld", core.CollectOptions{}); err == nil {
+		t.Error("expected an error without a bootstrap entry or fallback")
+	}
+}
+
+func TestRDAPCollector_NetworkAndAutnum(t *testing.T) {
+	serveRDAP(t)
+
+	res := collect(t, "192.0.2.10")
+	if res.ObjectClass != core.RDAPNetwork || res.Name != "TEST-NET-1" || !reflect.DeepEqual(res.CIDRs, []string{"192.0.2.0/24"}) {
+		t.Errorf("unexpected network result %+v", res)
+	}
+	for _, c := range res.Contacts {
+		if c.Roles[0] == "registrant" && (c.Organization != "Example Hosting LLC" || c.Country != "US") {
+			t.Errorf("unexpected registrant %+v", c)
+		}
+	}
+
+	res = collect(t, "as64496")
+	if res.ObjectClass != core.RDAPAutnum || res.Query != "AS64496" || res.Name != "EXAMPLE-AS" {
+		t.Errorf("unexpected autnum result %+v", res)
+	}
+}
+
+func TestBundledBootstrap(t *testing.T) {
+	b, err := LoadBootstrap("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  []string
+		want string
+	}{
+		{"com", b.Domain("www.example.com"), "https://rdap.verisign.com/com/v1/"},
+		{"ARIN v4", b.IP(netip.MustParseAddr("8.8.8.8")), "https://rdap.arin.net/registry/"},
+		{"RIPE v4", b.IP(netip.MustParseAddr("193.0.6.139")), "https://rdap.db.ripe.net/"},
+		{"APNIC v6", b.IP(netip.MustParseAddr("2400:cb00::1")), "https://rdap.apnic.net/"},
+		{"RIPE asn", b.ASN(3333), "https://rdap.db.ripe.net/"},
+		{"ARIN asn", b.ASN(15169), "https://rdap.arin.net/registry/"},
+	}
+	for _, tt := range tests {
+		if len(tt.got) == 0 || tt.got[0] != tt.want {
+			t.Errorf("%s: got %v, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+	if got := b.Domain("example.invalid"); got != nil {
+		t.Errorf("expected no server for .invalid, got %v", got)
+	}
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       []string
+	}{
+		{"192.0.2.0", "192.0.2.255", []string{"192.0.2.0/24"}},
+		{"10.0.0.0", "10.0.2.255", []string{"10.0.0.0/23", "10.0.2.0/24"}},
+		{"10.0.0.1", "10.0.0.4", []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/32"}},
+		{"2001:db8::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff", []string{"2001:db8::/32"}},
+		{"255.255.255.255", "255.255.255.255", []string{"255.255.255.255/32"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range RangeToPrefixes(netip.MustParseAddr(tt.start), netip.MustParseAddr(tt.end)) {
+			got = append(got, p.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RangeToPrefixes(%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/spectre/spectre/internal/core"
@@ -13,6 +17,20 @@ var (
 	mu       sync.RWMutex
 )
 
+// ErrRejected is matched (via errors.Is) by every error Run returns because
+// a policy refused the run, as opposed to the collector itself failing.
+// Retrying such a run cannot succeed.
+var ErrRejected = errors.New("collector run rejected")
+
+type rejection struct{ error }
+
+func (r rejection) Is(target error) bool { return target == ErrRejected }
+func (r rejection) Unwrap() error        { return r.error }
+
+func reject(format string, args ...interface{}) error {
+	return rejection{fmt.Errorf(format, args...)}
+}
+
 // Register adds a collector to the global registry.
 func Register(c core.Collector) {
 	mu.Lock()
@@ -21,29 +39,118 @@ func Register(c core.Collector) {
 }
 
 // Run executes a collector by name with ethics enforcement.
-func Run(name string, caseID string, target string, activeAllowed bool) ([]core.Evidence, error) {
+// The context bounds the whole run, including the rate-limit wait.
+func Run(ctx context.Context, name string, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
 	c, err := Get(name)
 	if err != nil {
-		return nil, err
+		return nil, rejection{err}
 	}
 
 	// 0. Active Consent Check
-	if c.IsActive() && !activeAllowed {
-		return nil, fmt.Errorf("collector '%s' is an ACTIVE probe. You must provide the --active flag to run it", name)
+	if c.IsActive() && !opts.ActiveAllowed {
+		return nil, reject("collector '%s' is an ACTIVE probe. You must provide the --active flag to run it", name)
 	}
 
 	// 1. Scope Control
 	allowed, err := ethics.IsAllowed(target)
 	if !allowed {
-		return nil, fmt.Errorf("safety block: %w", err)
+		return nil, reject("safety block: %w", err)
+	}
+
+	// 2. Applicability
+	entityType := opts.EntityType
+	if entityType == "" {
+		entityType = core.InferEntityType(target)
+		opts.EntityType = entityType
+	}
+	if !Accepts(c, entityType) {
+		return nil, reject("collector '%s' does not accept %s targets (accepts: %s)", name, entityType, strings.Join(c.(core.TypedCollector).Accepts(), ", "))
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// 3. Rate Limiting (not for lookups that never leave the machine)
+	if lc, ok := c.(core.LocalCollector); !ok || !lc.Local() {
+		if err := ethics.Wait(ctx, name); err != nil {
+			return nil, fmt.Errorf("rate limit error: %w", err)
+		}
+	}
+
+	return AsContextCollector(c).CollectContext(ctx, caseID, target, opts)
+}
+
+// Accepts reports whether c can be run against an entity of the given type.
+// Collectors without type metadata accept everything.
+func Accepts(c core.Collector, entityType string) bool {
+	tc, ok := c.(core.TypedCollector)
+	if !ok || len(tc.Accepts()) == 0 {
+		return true
 	}
+	for _, t := range tc.Accepts() {
+		if t == entityType {
+			return true
+		}
+	}
+	return false
+}
 
-	// 2. Rate Limiting
-	if err := ethics.Wait(name); err != nil {
-		return nil, fmt.Errorf("rate limit error: %w", err)
+// Applicable returns the registered collectors that accept entityType,
+// sorted by name.
+func Applicable(entityType string) []core.Collector {
+	var collectors []core.Collector
+	for _, c := range List() {
+		if Accepts(c, entityType) {
+			collectors = append(collectors, c)
+		}
 	}
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].Name() < collectors[j].Name()
+	})
+	return collectors
+}
 
-	return c.Collect(caseID, target)
+// AsContextCollector returns c as a ContextCollector, wrapping legacy
+// collectors in an adapter that stops waiting once the context is done.
+func AsContextCollector(c core.Collector) core.ContextCollector {
+	if cc, ok := c.(core.ContextCollector); ok {
+		return cc
+	}
+	return &legacyAdapter{Collector: c}
+}
+
+// legacyAdapter runs a blocking Collect in the background so that callers
+// are released on cancellation. The underlying call may still finish later;
+// its results are discarded.
+type legacyAdapter struct {
+	core.Collector
+}
+
+func (a *legacyAdapter) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		evidence []core.Evidence
+		err      error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		ev, err := a.Collect(caseID, target)
+		done <- result{ev, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.evidence, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("collector '%s' aborted: %w", a.Name(), ctx.Err())
+	}
 }
 
 // Get retrieves a collector by name.
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// blockingCollector simulates a legacy collector that never returns on its own.
+type blockingCollector struct {
+	release chan struct{}
+}
+
+func (b *blockingCollector) Name() string        { return "test_blocking" }
+func (b *blockingCollector) Description() string { return "blocks until released" }
+func (b *blockingCollector) IsActive() bool      { return false }
+
+func (b *blockingCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	<-b.release
+	return nil, nil
+}
+
+type activeCollector struct{}
+
+func (a *activeCollector) Name() string        { return "test_active" }
+func (a *activeCollector) Description() string { return "active probe" }
+func (a *activeCollector) IsActive() bool      { return true }
+
+func (a *activeCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return nil, nil
+}
+
+func TestRun_CancelsLegacyCollector(t *testing.T) {
+	c := &blockingCollector{release: make(chan struct{})}
+	defer close(c.release)
+	Register(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Run(ctx, c.Name(), "test-case", "example.com", core.CollectOptions{})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestRun_HonoursTimeout(t *testing.T) {
+	c := &blockingCollector{release: make(chan struct{})}
+	defer close(c.release)
+	Register(c)
+
+	start := time.Now()
+	_, err := Run(context.Background(), c.Name(), "test-case", "example.com", core.CollectOptions{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run took too long to honour the deadline: %v", elapsed)
+	}
+}
+
+func TestRun_RejectsActiveWithoutConsent(t *testing.T) {
+	Register(&activeCollector{})
+
+	if _, err := Run(context.Background(), "test_active", "test-case", "example.com", core.CollectOptions{}); err == nil {
+		t.Fatal("Expected active collector to be rejected without consent")
+	}
+}
+
+type ipOnlyCollector struct{}
+
+func (c *ipOnlyCollector) Name() string        { return "test_ip_only" }
+func (c *ipOnlyCollector) Description() string { return "accepts IPs only" }
+func (c *ipOnlyCollector) IsActive() bool      { return false }
+func (c *ipOnlyCollector) Accepts() []string   { return []string{core.EntityIP} }
+func (c *ipOnlyCollector) Produces() []string  { return nil }
+
+func (c *ipOnlyCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return nil, nil
+}
+
+func TestRun_ValidatesEntityType(t *testing.T) {
+	Register(&ipOnlyCollector{})
+
+	if _, err := Run(context.Background(), "test_ip_only", "test-case", "example.com", core.CollectOptions{}); err == nil {
+		t.Error("Expected domain target to be rejected by an IP-only collector")
+	}
+	if _, err := Run(context.Background(), "test_ip_only", "test-case", "192.0.2.10", core.CollectOptions{}); err != nil {
+		t.Errorf("Expected IP target to be accepted, got %v", err)
+	}
+
+	for _, c := range Applicable(core.EntityEmail) {
+		if c.Name() == "test_ip_only" {
+			t.Error("IP-only collector should not be applicable to emails")
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package whois
+
+import (
+	"strings"
+	"time"
+
+	whoisparser "github.com/likexian/whois-parser"
+	"github.com/spectre/spectre/internal/core"
+)
+
+// privacyMarkers appear in the registrant name, organisation or email of
+// records hidden behind a proxy service or redacted by the registry.
+var privacyMarkers = []string{
+	"privacy", "proxy", "redacted", "withheld", "whoisguard", "whois guard", "not disclosed",
+	"data protected", "identity protect", "private registration", "registration private",
+	"domain protection", "contact protection", "gdpr masked", "statutory masking", "perfect privacy",
+}
+
+// Parse turns a raw WHOIS reply into a typed record.
+func Parse(raw string) (*core.WHOISRecord, error) {
+	info, err := whoisparser.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &core.WHOISRecord{}
+	if d := info.Domain; d != nil {
+		record.Domain = strings.ToLower(d.Domain)
+		record.WhoisServer = d.WhoisServer
+		record.CreatedAt = utc(d.CreatedDateInTime)
+		record.UpdatedAt = utc(d.UpdatedDateInTime)
+		record.ExpiresAt = utc(d.ExpirationDateInTime)
+		record.Status = d.Status
+		record.DNSSEC = d.DNSSec
+		for _, ns := range d.NameServers {
+			if ns = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(ns), ".")); ns != "" {
+				record.NameServers = append(record.NameServers, ns)
+			}
+		}
+	}
+	if info.Registrar != nil {
+		record.Registrar = info.Registrar.Name
+	}
+	record.Registrant = contact(info.Registrant)
+	record.Admin = contact(info.Administrative)
+	record.Tech = contact(info.Technical)
+	record.PrivacyService, record.Privacy = privacyService(record.Registrant)
+	return record, nil
+}
+
+// privacyService reports whether the registrant is a privacy proxy or has
+// been redacted, naming the service when the record gives one.
+func privacyService(c *core.WHOISContact) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	for _, field := range []string{c.Organization, c.Name, c.Email} {
+		lower := strings.ToLower(field)
+		for _, marker := range privacyMarkers {
+			if strings.Contains(lower, marker) {
+				if c.Organization != "" && !strings.Contains(strings.ToLower(c.Organization), "redacted") {
+					return c.Organization, true
+				}
+				return "", true
+			}
+		}
+	}
+	return "", false
+}
+
+func contact(c *whoisparser.Contact) *core.WHOISContact {
+	if c == nil {
+		return nil
+	}
+	out := &core.WHOISContact{
+		Name:         strings.TrimSpace(c.Name),
+		Organization: strings.TrimSpace(c.Organization),
+		Email:        strings.ToLower(strings.TrimSpace(c.Email)),
+		Phone:        strings.TrimSpace(c.Phone),
+		Country:      strings.TrimSpace(c.Country),
+	}
+	if *out == (core.WHOISContact{}) {
+		return nil
+	}
+	return out
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
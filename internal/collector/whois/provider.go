@@ -0,0 +1,308 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/http"
+	"github.com/spf13/viper"
+)
+
+// Reverse WHOIS search fields.
+const (
+	FieldEmail        = "email"
+	FieldOrganization = "organization"
+)
+
+// maxProviderResponse bounds a single provider reply.
+const maxProviderResponse = 32 << 20
+
+// ErrUnsupported is returned by providers that do not offer a lookup.
+var ErrUnsupported = errors.New("not supported by this provider")
+
+// Provider answers reverse and historical WHOIS queries, usually from a
+// commercial WHOIS database.
+type Provider interface {
+	Name() string
+	// ReverseWHOIS returns the domains whose registrant field matches value.
+	ReverseWHOIS(ctx context.Context, field, value string) ([]string, error)
+	// History returns past records of domain.
+	History(ctx context.Context, domain string) ([]core.WHOISRecord, error)
+}
+
+// ProviderConfig is one entry of collectors.whois.providers.
+type ProviderConfig struct {
+	Name    string            `mapstructure:"name"`
+	Type    string            `mapstructure:"type"` // registered provider type, "http" by default
+	APIKey  string            `mapstructure:"api_key"`
+	Headers map[string]string `mapstructure:"headers"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+
+	// Reverse WHOIS: URL template with {field}, {query} and {api_key}, and
+	// the dotted path to the list of domains in the JSON reply. List items
+	// are domain strings or objects holding one under DomainField.
+	ReverseURL  string `mapstructure:"reverse_url"`
+	ReversePath string `mapstructure:"reverse_path"`
+	DomainField string `mapstructure:"domain_field"`
+
+	// Historical WHOIS: URL template with {domain} and {api_key}, and the
+	// dotted path to the list of records. Records holding raw WHOIS text
+	// under RawField are parsed like a live reply; others are decoded as
+	// core.WHOISRecord. DateField names the capture date.
+	HistoryURL  string `mapstructure:"history_url"`
+	HistoryPath string `mapstructure:"history_path"`
+	RawField    string `mapstructure:"raw_field"`
+	DateField   string `mapstructure:"date_field"`
+}
+
+// ProviderFactory builds a provider from its configuration.
+type ProviderFactory func(cfg ProviderConfig) (Provider, error)
+
+var (
+	providerMu    sync.RWMutex
+	providerTypes = map[string]ProviderFactory{"http": newHTTPProvider}
+)
+
+// RegisterProviderType makes a provider type available to configuration.
+func RegisterProviderType(name string, factory ProviderFactory) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	providerTypes[name] = factory
+}
+
+// LoadProviders builds the providers configured in collectors.whois.providers.
+func LoadProviders() ([]Provider, error) {
+	var configs []ProviderConfig
+	if err := viper.UnmarshalKey("collectors.whois.providers", &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse WHOIS providers: %w", err)
+	}
+
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	var providers []Provider
+	for i, cfg := range configs {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("provider%d", i+1)
+		}
+		if cfg.Type == "" {
+			cfg.Type = "http"
+		}
+		factory, ok := providerTypes[cfg.Type]
+		if !ok {
+			return nil, fmt.Errorf("WHOIS provider %s: unknown type %q", cfg.Name, cfg.Type)
+		}
+		p, err := factory(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("WHOIS provider %s: %w", cfg.Name, err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// httpProvider queries a JSON HTTP API described entirely by its config.
+type httpProvider struct {
+	cfg    ProviderConfig
+	client *http.Client
+}
+
+func newHTTPProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.ReverseURL == "" && cfg.HistoryURL == "" {
+		return nil, fmt.Errorf("needs reverse_url or history_url")
+	}
+	if cfg.DomainField == "" {
+		cfg.DomainField = "domain"
+	}
+	client := netclient.NewClient()
+	if cfg.Timeout > 0 {
+		client.Timeout = cfg.Timeout
+	}
+	return &httpProvider{cfg: cfg, client: client}, nil
+}
+
+func (p *httpProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *httpProvider) ReverseWHOIS(ctx context.Context, field, value string) ([]string, error) {
+	if p.cfg.ReverseURL == "" {
+		return nil, ErrUnsupported
+	}
+	items, err := p.list(ctx, p.cfg.ReverseURL, map[string]string{"field": field, "query": value}, p.cfg.ReversePath)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var domains []string
+	for _, item := range items {
+		var d string
+		switch v := item.(type) {
+		case string:
+			d = v
+		case map[string]interface{}:
+			d, _ = v[p.cfg.DomainField].(string)
+		}
+		d = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), "."))
+		if d != "" && !seen[d] {
+			seen[d] = true
+			domains = append(domains, d)
+		}
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+func (p *httpProvider) History(ctx context.Context, domain string) ([]core.WHOISRecord, error) {
+	if p.cfg.HistoryURL == "" {
+		return nil, ErrUnsupported
+	}
+	items, err := p.list(ctx, p.cfg.HistoryURL, map[string]string{"domain": domain}, p.cfg.HistoryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []core.WHOISRecord
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var record *core.WHOISRecord
+		if raw, _ := obj[p.cfg.RawField].(string); p.cfg.RawField != "" && raw != "" {
+			if record, err = Parse(raw); err != nil {
+				continue
+			}
+		} else {
+			data, _ := json.Marshal(obj)
+			record = &core.WHOISRecord{}
+			if err := json.Unmarshal(data, record); err != nil {
+				continue
+			}
+			if service, ok := privacyService(record.Registrant); ok {
+				record.Privacy = true
+				if record.PrivacyService == "" {
+					record.PrivacyService = service
+				}
+			}
+		}
+		if record.Domain == "" {
+			record.Domain = domain
+		}
+		if p.cfg.DateField != "" {
+			record.ObservedAt = parseDate(obj[p.cfg.DateField])
+		}
+		record.Source = p.cfg.Name
+		records = append(records, *record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i].ObservedAt, records[j].ObservedAt
+		return a != nil && (b == nil || a.Before(*b))
+	})
+	return records, nil
+}
+
+// list fetches a URL template and returns the JSON array found at path.
+func (p *httpProvider) list(ctx context.Context, tmpl string, vars map[string]string, path string) ([]interface{}, error) {
+	vars["api_key"] = p.cfg.APIKey
+	replace := func(s string, escape bool) string {
+		for k, v := range vars {
+			if escape {
+				v = url.QueryEscape(v)
+			}
+			s = strings.ReplaceAll(s, "{"+k+"}", v)
+		}
+		return s
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", replace(tmpl, true), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s URL: %w", p.cfg.Name, withoutURL(err))
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "spectre")
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, replace(v, false))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", p.cfg.Name, withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s request failed: %s", p.cfg.Name, resp.Status)
+	}
+
+	var body interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProviderResponse)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", p.cfg.Name, err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		obj, ok := body.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s response has no %q", p.cfg.Name, path)
+		}
+		body = obj[key]
+	}
+	if body == nil {
+		return nil, nil
+	}
+	items, ok := body.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s response: %q is not a list", p.cfg.Name, path)
+	}
+	return items, nil
+}
+
+// withoutURL drops the request URL from err, since the URL template may
+// carry the API key and errors end up in evidence.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// parseDate accepts RFC 3339 timestamps, plain dates and Unix seconds.
+func parseDate(v interface{}) *time.Time {
+	var t time.Time
+	switch v := v.(type) {
+	case float64:
+		t = time.Unix(int64(v), 0)
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			t = time.Unix(n, 0)
+			break
+		}
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				t = parsed
+				break
+			}
+		}
+	}
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
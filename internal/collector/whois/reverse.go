@@ -0,0 +1,146 @@
+package whois
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+)
+
+type ReverseWHOISCollector struct{}
+
+func init() {
+	collector.Register(&ReverseWHOISCollector{})
+}
+
+func (r *ReverseWHOISCollector) Name() string {
+	return "reverse_whois"
+}
+
+func (r *ReverseWHOISCollector) Description() string {
+	return "Find domains registered by an email or organisation (reverse WHOIS providers)"
+}
+
+func (r *ReverseWHOISCollector) IsActive() bool {
+	return false
+}
+
+func (r *ReverseWHOISCollector) Accepts() []string {
+	return []string{core.EntityEmail, core.EntityOrganization}
+}
+
+func (r *ReverseWHOISCollector) Produces() []string {
+	return []string{core.EntityDomain}
+}
+
+func (r *ReverseWHOISCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return r.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+// CollectContext asks every configured provider for domains registered by
+// target. Params may set "field" to "email" or "organization"; otherwise
+// targets containing @ are emails and anything else an organisation.
+func (r *ReverseWHOISCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	query := strings.TrimSpace(target)
+	field := opts.Params["field"]
+	switch {
+	case field != "":
+	case opts.EntityType == core.EntityOrganization:
+		field = FieldOrganization
+	case strings.Contains(query, "@"):
+		field = FieldEmail
+	default:
+		field = FieldOrganization
+	}
+	if field != FieldEmail && field != FieldOrganization {
+		return nil, fmt.Errorf("unsupported reverse WHOIS field %q", field)
+	}
+	if field == FieldEmail {
+		query = strings.ToLower(query)
+	}
+
+	providers, err := LoadProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &core.ReverseWHOISResult{Query: query, Field: field, Domains: []string{}}
+	seen := make(map[string]bool)
+	for _, p := range providers {
+		domains, err := p.ReverseWHOIS(ctx, field, query)
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		}
+		result.Providers = append(result.Providers, p.Name())
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("reverse WHOIS aborted: %w", ctx.Err())
+			}
+			log.Warn().Err(err).Str("provider", p.Name()).Msg("Reverse WHOIS lookup failed")
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		for _, d := range domains {
+			if !seen[d] {
+				seen[d] = true
+				result.Domains = append(result.Domains, d)
+			}
+		}
+	}
+	if len(result.Providers) == 0 {
+		return nil, fmt.Errorf("no reverse WHOIS provider configured (collectors.whois.providers)")
+	}
+	if len(result.Errors) == len(result.Providers) {
+		return nil, fmt.Errorf("reverse WHOIS failed: %s", strings.Join(result.Errors, "; "))
+	}
+	sort.Strings(result.Domains)
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	// Store file
+	storageDir := filepath.Join("evidence_storage", caseID)
+	if err := os.MkdirAll(storageDir, 0755); err != nil {
+		return nil, err
+	}
+
+	safe := strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(query)
+	fileName := fmt.Sprintf("reverse_whois_%s_%d.json", safe, time.Now().Unix())
+	filePath := filepath.Join(storageDir, fileName)
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Hash
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	evidence := core.Evidence{
+		CaseID:      caseID,
+		Collector:   "reverse_whois",
+		FilePath:    filePath,
+		FileHash:    hashStr,
+		CollectedAt: time.Now(),
+		Metadata: map[string]interface{}{
+			"target":  target,
+			"field":   field,
+			"domains": len(result.Domains),
+		},
+		RawData: result,
+	}
+
+	return []core.Evidence{evidence}, nil
+}
@@ -1,17 +1,24 @@
 package whois
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/likexian/whois"
-	whoisparser "github.com/likexian/whois-parser"
+	"github.com/rs/zerolog/log"
 	"github.com/spectre/spectre/internal/collector"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
 )
 
 type WHOISCollector struct{}
@@ -25,50 +32,68 @@ func (w *WHOISCollector) Name() string {
 }
 
 func (w *WHOISCollector) Description() string {
-	return "Retrieve domain registration information"
+	return "Retrieve domain registration information (parsed record, privacy detection, historical WHOIS via providers)"
 }
 
 func (w *WHOISCollector) IsActive() bool {
 	return false
 }
 
+func (w *WHOISCollector) Accepts() []string {
+	return []string{core.EntityDomain}
+}
+
+func (w *WHOISCollector) Produces() []string {
+	return []string{core.EntityEmail, core.EntityOrganization, core.EntityDomain}
+}
+
 func (w *WHOISCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
-	raw, err := whois.Whois(target)
+	return w.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+func (w *WHOISCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	raw, err := lookup(ctx, target)
 	if err != nil {
 		return nil, fmt.Errorf("whois lookup failed: %w", err)
 	}
 
-	// Parse to verify it's valid and get metadata
-	result, err := whoisparser.Parse(raw)
+	result := buildResult(ctx, target, raw)
+
+	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
-		// Even if parsing fails, we keep the raw data as evidence
+		return nil, err
 	}
 
-	// Store raw file
+	// Store file
 	storageDir := filepath.Join("evidence_storage", caseID)
 	if err := os.MkdirAll(storageDir, 0755); err != nil {
 		return nil, err
 	}
 
-	fileName := fmt.Sprintf("whois_%s_%d.txt", target, time.Now().Unix())
+	fileName := fmt.Sprintf("whois_%s_%d.json", target, time.Now().Unix())
 	filePath := filepath.Join(storageDir, fileName)
-	if err := os.WriteFile(filePath, []byte(raw), 0644); err != nil {
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
 		return nil, err
 	}
 
 	// Hash
-	hash := sha256.Sum256([]byte(raw))
+	hash := sha256.Sum256(data)
 	hashStr := hex.EncodeToString(hash[:])
 
 	metadata := map[string]interface{}{
 		"target": target,
 	}
-	if result.Registrar != nil {
-		metadata["registrar"] = result.Registrar.Name
+	if r := result.Record; r != nil {
+		metadata["registrar"] = r.Registrar
+		metadata["privacy"] = r.Privacy
+		if r.Registrant != nil {
+			metadata["registrant_email"] = r.Registrant.Email
+			metadata["registrant_name"] = r.Registrant.Name
+			metadata["registrant_org"] = r.Registrant.Organization
+		}
 	}
-	if result.Registrant != nil {
-		metadata["registrant_email"] = result.Registrant.Email
-		metadata["registrant_name"] = result.Registrant.Name
+	if len(result.History) > 0 {
+		metadata["history"] = len(result.History)
 	}
 
 	evidence := core.Evidence{
@@ -78,7 +103,87 @@ func (w *WHOISCollector) Collect(caseID string, target string) ([]core.Evidence,
 		FileHash:    hashStr,
 		CollectedAt: time.Now(),
 		Metadata:    metadata,
+		RawData:     result,
 	}
 
 	return []core.Evidence{evidence}, nil
 }
+
+// buildResult parses the live reply and, when collectors.whois.history is
+// not disabled, adds the records of every provider offering history. The
+// raw reply is kept even when it cannot be parsed.
+func buildResult(ctx context.Context, domain, raw string) *core.WHOISResult {
+	result := &core.WHOISResult{Domain: strings.ToLower(domain), Raw: raw}
+	record, err := Parse(raw)
+	if err != nil {
+		result.ParseError = err.Error()
+	} else {
+		if record.Domain == "" {
+			record.Domain = result.Domain
+		}
+		result.Record = record
+	}
+
+	if viper.IsSet("collectors.whois.history") && !viper.GetBool("collectors.whois.history") {
+		return result
+	}
+	providers, err := LoadProviders()
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	for _, p := range providers {
+		records, err := p.History(ctx, result.Domain)
+		if errors.Is(err, ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("provider", p.Name()).Msg("Historical WHOIS lookup failed")
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		result.History = append(result.History, records...)
+	}
+	sort.SliceStable(result.History, func(i, j int) bool {
+		a, b := result.History[i].ObservedAt, result.History[j].ObservedAt
+		return a != nil && (b == nil || a.Before(*b))
+	})
+	return result
+}
+
+// contextDialer satisfies the whois client's dialer so that connection
+// attempts are aborted together with the collection context.
+type contextDialer struct {
+	ctx context.Context
+}
+
+func (d contextDialer) Dial(network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(d.ctx, network, addr)
+}
+
+// lookup performs the WHOIS query, returning as soon as ctx is done even if
+// a registry server is still holding the connection open.
+func lookup(ctx context.Context, target string) (string, error) {
+	client := whois.NewClient().SetDialer(contextDialer{ctx: ctx})
+	if deadline, ok := ctx.Deadline(); ok {
+		client.SetTimeout(time.Until(deadline))
+	}
+
+	type result struct {
+		raw string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		raw, err := client.Whois(target)
+		done <- result{raw, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.raw, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
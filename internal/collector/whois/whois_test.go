@@ -0,0 +1,178 @@
+package whois
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spf13/viper"
+)
+
+func readTestdata(t *testing.T, name string) string {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParse(t *testing.T) {
+	record, err := Parse(readTestdata(t, "acme.test.txt"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if record.Domain != "acme-widgets.com" || record.Registrar != "Example Registrar, Inc." {
+		t.Errorf("unexpected domain/registrar: %+v", record)
+	}
+	if record.CreatedAt == nil || !record.CreatedAt.Equal(time.Date(2015, 8, 14, 4, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected creation date %v", record.CreatedAt)
+	}
+	if record.ExpiresAt == nil || record.ExpiresAt.Year() != 2026 {
+		t.Errorf("unexpected expiry date %v", record.ExpiresAt)
+	}
+	if want := []string{"ns1.examplehost.net", "ns2.examplehost.net"}; !reflect.DeepEqual(record.NameServers, want) {
+		t.Errorf("name servers = %v, want %v", record.NameServers, want)
+	}
+	if !record.DNSSEC || len(record.Status) != 2 {
+		t.Errorf("unexpected DNSSEC/status: %v %v", record.DNSSEC, record.Status)
+	}
+	r := record.Registrant
+	if r == nil || r.Organization != "Acme Widgets Ltd" || r.Email != "jane.doe@acme-widgets.com" || r.Country != "GB" {
+		t.Errorf("unexpected registrant %+v", r)
+	}
+	if record.Tech == nil || record.Tech.Email != "support@examplehost.net" {
+		t.Errorf("unexpected tech contact %+v", record.Tech)
+	}
+	if record.Privacy {
+		t.Error("registrant wrongly flagged as a privacy service")
+	}
+
+	record, err = Parse(readTestdata(t, "privacy.test.txt"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !record.Privacy || record.PrivacyService != "Domains By Proxy, LLC" {
+		t.Errorf("expected privacy proxy, got %v %q", record.Privacy, record.PrivacyService)
+	}
+}
+
+// fakeProvider serves reverse and historical WHOIS in a made-up API shape.
+func fakeProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/reverse":
+			if r.URL.Query().Get(r.URL.Query().Get("by")) != "jane.doe@acme-widgets.com" {
+				fmt.Fprint(w, `{"result": {"domains": []}}`)
+				return
+			}
+			fmt.Fprint(w, `{"result": {"domains": [{"domain": "Acme-Widgets.com"}, {"domain": "acme-gadgets.net."}, {"domain": "acme-widgets.com"}]}}`)
+		case "/history":
+			fmt.Fprint(w, `{"records": [
+				{"captured": "2021-05-01", "raw": "Domain Name: ACME-WIDGETS.COM\nRegistrar: Old Registrar\nRegistrant Organization: Jane Doe Consulting\nRegistrant Email: jdoe@oldmail.example\nName Server: NS1.OLDHOST.NET\n"},
+				{"captured": "2017-01-01", "domain": "acme-widgets.com", "registrar": "First Registrar", "registrant": {"email": "jane@first.example"}},
+				{"captured": "2019-01-01", "domain": "acme-widgets.com", "registrar": "First Registrar", "registrant": {"organization": "Domains By Proxy, LLC", "email": "acme-widgets.com@domainsbyproxy.com"}}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	viper.Set("collectors.whois.providers", []map[string]interface{}{{
+		"name":         "testdb",
+		"api_key":      "secret",
+		"headers":      map[string]string{"X-Api-Key": "{api_key}"},
+		"reverse_url":  srv.URL + "/reverse?by={field}&{field}={query}",
+		"reverse_path": "result.domains",
+		"history_url":  srv.URL + "/history?domain={domain}",
+		"history_path": "records",
+		"raw_field":    "raw",
+		"date_field":   "captured",
+	}})
+	t.Cleanup(func() { viper.Set("collectors.whois.providers", nil) })
+}
+
+func TestBuildResult_History(t *testing.T) {
+	fakeProvider(t)
+
+	result := buildResult(context.Background(), "acme-widgets.com", readTestdata(t, "acme.test.txt"))
+	if result.Record == nil || result.Raw == "" || len(result.Errors) != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(result.History) != 3 {
+		t.Fatalf("expected 3 historical records, got %+v", result.History)
+	}
+	first, proxied, second := result.History[0], result.History[1], result.History[2]
+	if first.Registrar != "First Registrar" || first.Registrant.Email != "jane@first.example" || first.Source != "testdb" {
+		t.Errorf("expected the decoded 2017 record first, got %+v", first)
+	}
+	if !proxied.Privacy || proxied.PrivacyService != "Domains By Proxy, LLC" {
+		t.Errorf("expected the decoded 2019 record to be flagged as a privacy proxy, got %+v", proxied)
+	}
+	if first.Privacy {
+		t.Errorf("expected the 2017 record not to be flagged, got %+v", first)
+	}
+	if second.Registrar != "Old Registrar" || second.Registrant.Organization != "Jane Doe Consulting" || second.ObservedAt.Year() != 2021 {
+		t.Errorf("expected the parsed 2021 record, got %+v", second)
+	}
+
+	viper.Set("collectors.whois.history", false)
+	defer viper.Set("collectors.whois.history", nil)
+	if result := buildResult(context.Background(), "acme-widgets.com", readTestdata(t, "acme.test.txt")); len(result.History) != 0 {
+		t.Errorf("history disabled but got %d records", len(result.History))
+	}
+}
+
+func TestReverseWHOISCollector_Collect(t *testing.T) {
+	fakeProvider(t)
+	cwd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(cwd)
+
+	evs, err := (&ReverseWHOISCollector{}).CollectContext(context.Background(), "case-whois", "Jane.Doe@acme-widgets.com", core.CollectOptions{})
+	if err != nil {
+		t.Fatalf("CollectContext failed: %v", err)
+	}
+	res := evs[0].RawData.(*core.ReverseWHOISResult)
+	if res.Field != FieldEmail || !reflect.DeepEqual(res.Domains, []string{"acme-gadgets.net", "acme-widgets.com"}) {
+		t.Errorf("unexpected result %+v", res)
+	}
+
+	viper.Set("collectors.whois.providers", nil)
+	if _, err := (&ReverseWHOISCollector{}).CollectContext(context.Background(), "case-whois", "Acme Widgets Ltd", core.CollectOptions{}); err == nil {
+		t.Error("expected an error without providers")
+	}
+}
+
+func TestHTTPProvider_ErrorHidesAPIKey(t *testing.T) {
+	p, err := newHTTPProvider(ProviderConfig{Name: "keyed", APIKey: "s3cr3t-key", ReverseURL: "http://127.0.0.1:1/reverse?key={api_key}&q={query}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.ReverseWHOIS(context.Background(), FieldEmail, "jane@example.test")
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if strings.Contains(err.Error(), "s3cr3t-key") {
+		t.Errorf("API key leaked into the error: %v", err)
+	}
+}
+
+func TestLoadProviders_UnknownType(t *testing.T) {
+	viper.Set("collectors.whois.providers", []map[string]interface{}{{"name": "x", "type": "carrier-pigeon"}})
+	defer viper.Set("collectors.whois.providers", nil)
+	if _, err := LoadProviders(); err == nil {
+		t.Error("expected an error for an unknown provider type")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/spectre/spectre/internal/ethics"
 	"github.com/spf13/viper"
 )
 
@@ -31,3 +32,13 @@ func TestInitConfig(t *testing.T) {
 		t.Errorf("expected test.db, got %s", dbPath)
 	}
 }
+
+func TestApplyEthicsConfig_RateLimits(t *testing.T) {
+	viper.Set("collectors.test_new_collector.rate_limit", 0.5)
+	defer viper.Set("collectors.test_new_collector", nil)
+
+	ApplyEthicsConfig()
+	if got := ethics.Limit("test_new_collector"); got != 0.5 {
+		t.Errorf("expected the configured 0.5 rps, got %v", got)
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import "fmt"
+
+// ASNInfo describes an autonomous system originating a prefix.
+type ASNInfo struct {
+	Number  uint32 `json:"number"`
+	Name    string `json:"name,omitempty"`
+	Country string `json:"country,omitempty"` // where the AS is registered
+	RIR     string `json:"rir,omitempty"`
+}
+
+// Value is the entity value for the AS, e.g. "AS13335".
+func (a ASNInfo) Value() string {
+	return fmt.Sprintf("AS%d", a.Number)
+}
+
+// ASNResult is the routing data for an IP address: the most specific
+// prefix announced for it and the AS (or, for multi-origin prefixes,
+// several) announcing it.
+type ASNResult struct {
+	IP      string    `json:"ip"`
+	Prefix  string    `json:"prefix"`
+	Origins []ASNInfo `json:"origins"`
+	RIR     string    `json:"rir,omitempty"` // registry the address was delegated by
+	Dataset string    `json:"dataset"`       // file the prefix was found in
+}
+
+// ASNCluster is the set of a case's addresses announced by one AS, with
+// the domains resolving to them.
+type ASNCluster struct {
+	ASN       string   `json:"asn"`
+	Name      string   `json:"name,omitempty"`
+	Netblocks []string `json:"netblocks"`
+	IPs       []string `json:"ips"`
+	Domains   []string `json:"domains"`
+}
@@ -0,0 +1,28 @@
+package core
+
+import "time"
+
+// BreachExposure is one breach an identifier appears in. Only the names of
+// the fields present in the leaked record are kept, never their values.
+type BreachExposure struct {
+	Breach     string    `json:"breach"`
+	Title      string    `json:"title,omitempty"`
+	Date       string    `json:"date,omitempty"` // when the breach happened, as given at import
+	ImportedAt time.Time `json:"imported_at"`
+	Fields     []string  `json:"fields"` // e.g. email, password, phone
+}
+
+// Value is the breach entity value, "breach:" and the breach name, so a
+// breach named after a site or handle never merges with that domain or
+// username.
+func (e BreachExposure) Value() string {
+	return "breach:" + e.Breach
+}
+
+// BreachResult is the evidence written by the breach collector.
+type BreachResult struct {
+	Target    string           `json:"target"`
+	Kind      string           `json:"kind"` // EntityEmail or EntityUsername
+	Store     string           `json:"store"`
+	Exposures []BreachExposure `json:"exposures"`
+}
@@ -11,3 +11,9 @@ type Case struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	Status      string    `json:"status"`
 }
+
+// Case states.
+const (
+	CaseActive   = "active"
+	CaseArchived = "archived"
+)
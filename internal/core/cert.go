@@ -0,0 +1,78 @@
+package core
+
+import (
+	"strings"
+	"time"
+)
+
+// Certificate is an X.509 certificate as seen in a CT log or on a server.
+type Certificate struct {
+	Serial     string    `json:"serial"`
+	Issuer     string    `json:"issuer"`
+	CommonName string    `json:"common_name,omitempty"`
+	SANs       []string  `json:"sans"`
+	NotBefore  time.Time `json:"not_before"`
+	NotAfter   time.Time `json:"not_after"`
+	LoggedAt   time.Time `json:"logged_at,omitempty"`
+	LogIDs     []int64   `json:"log_ids,omitempty"` // crt.sh entry IDs (precertificate and final)
+
+	// Set when the certificate itself was seen (tls collector)
+	Subject            string `json:"subject,omitempty"`
+	SHA256             string `json:"sha256,omitempty"`
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+	PublicKey          string `json:"public_key,omitempty"` // e.g. "RSA-2048", "ECDSA-P256"
+	IsCA               bool   `json:"is_ca,omitempty"`
+	SelfSigned         bool   `json:"self_signed,omitempty"`
+}
+
+// CTResult is the evidence written by the ct collector.
+type CTResult struct {
+	Domain       string        `json:"domain"`
+	Source       string        `json:"source"` // endpoint URL or dump path
+	Entries      int           `json:"entries"`
+	Certificates []Certificate `json:"certificates"`
+}
+
+// TLSResult is the evidence written by the tls collector. Chain starts with
+// the leaf certificate.
+type TLSResult struct {
+	Target      string        `json:"target"`
+	Host        string        `json:"host"`
+	Port        int           `json:"port"`
+	Address     string        `json:"address"` // IP actually connected to
+	SNI         string        `json:"sni,omitempty"`
+	Version     string        `json:"version"`
+	CipherSuite string        `json:"cipher_suite"`
+	ALPN        string        `json:"alpn,omitempty"`
+	Chain       []Certificate `json:"chain"`
+	VerifyError string        `json:"verify_error,omitempty"` // empty when the chain is trusted for SNI
+	JA3S        string        `json:"ja3s,omitempty"`
+	JA3SString  string        `json:"ja3s_string,omitempty"`
+	JARM        string        `json:"jarm,omitempty"`
+}
+
+// NormalizeSerial returns a certificate serial number in the form used as
+// the certificate entity value: lowercase hex, no separators or leading
+// zeros.
+func NormalizeSerial(serial string) string {
+	serial = strings.ToLower(strings.NewReplacer(":", "", " ", "", "-", "").Replace(serial))
+	serial = strings.TrimPrefix(serial, "0x")
+	if trimmed := strings.TrimLeft(serial, "0"); trimmed != "" {
+		return trimmed
+	}
+	return serial
+}
+
+// CertificateKey returns the certificate entity value. A certificate seen
+// on a server is keyed by its SHA-256 fingerprint. One known only from CT
+// logs is keyed by issuer and serial, which are only unique together:
+// self-signed appliance certificates often share serials such as 0 or 1.
+func CertificateKey(c *Certificate) string {
+	if c.SHA256 != "" {
+		return strings.ToLower(c.SHA256)
+	}
+	if c.Serial == "" {
+		return ""
+	}
+	return c.Issuer + "|" + NormalizeSerial(c.Serial)
+}
@@ -1,5 +1,10 @@
 package core
 
+import (
+	"context"
+	"time"
+)
+
 // Collector is the interface that all passive collection plugins must implement.
 type Collector interface {
 	Name() string
@@ -7,3 +12,44 @@ type Collector interface {
 	Collect(caseID string, target string) ([]Evidence, error)
 	IsActive() bool
 }
+
+// ProgressFunc receives incremental progress from a running collector.
+// Total is zero when the collector cannot estimate the amount of work.
+type ProgressFunc func(done, total int, message string)
+
+// CollectOptions carries per-run settings for context-aware collectors.
+type CollectOptions struct {
+	ActiveAllowed bool              // Operator consented to active probing
+	EntityType    string            // Type of the target entity ("" = infer from target)
+	Timeout       time.Duration     // Upper bound for the run (0 = no extra deadline)
+	Params        map[string]string // Collector-specific overrides
+	Progress      ProgressFunc      // Optional progress callback
+}
+
+// Report forwards a progress update to the callback, if one is set.
+func (o CollectOptions) Report(done, total int, message string) {
+	if o.Progress != nil {
+		o.Progress(done, total, message)
+	}
+}
+
+// TypedCollector declares which entity types a collector accepts as targets
+// and which types its ingested evidence produces. Collectors that do not
+// implement it, or return an empty Accepts list, take any target.
+type TypedCollector interface {
+	Accepts() []string
+	Produces() []string
+}
+
+// LocalCollector is a collector that may answer from local data only.
+// When Local reports true for the current configuration, the registry does
+// not rate-limit it: no remote service is being queried.
+type LocalCollector interface {
+	Local() bool
+}
+
+// ContextCollector is a collector that honours cancellation and deadlines.
+type ContextCollector interface {
+	Collector
+	CollectContext(ctx context.Context, caseID string, target string, opts CollectOptions) ([]Evidence, error)
+}
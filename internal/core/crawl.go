@@ -0,0 +1,37 @@
+package core
+
+// CrawledPage is one URL fetched by the crawl collector.
+type CrawledPage struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Depth       int    `json:"depth"`
+	Title       string `json:"title,omitempty"`
+	Size        int    `json:"size"`
+}
+
+// SocialLink is a link to a profile on a social platform.
+type SocialLink struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+}
+
+// CrawlResult is the evidence written by the crawl collector. The raw
+// requests and responses are kept in the WARC file it names.
+type CrawlResult struct {
+	Target     string        `json:"target"`
+	StartURL   string        `json:"start_url"`
+	MaxDepth   int           `json:"max_depth"`
+	MaxPages   int           `json:"max_pages"`
+	Pages      []CrawledPage `json:"pages"`
+	Disallowed []string      `json:"disallowed,omitempty"` // skipped because of robots.txt
+	Domains    []string      `json:"outbound_domains,omitempty"`
+	Emails     []string      `json:"emails,omitempty"`
+	Phones     []string      `json:"phones,omitempty"`
+	Social     []SocialLink  `json:"social,omitempty"`
+	Trackers   []TrackerID   `json:"trackers,omitempty"`
+	Endpoints  []string      `json:"endpoints,omitempty"` // URLs referenced from JavaScript
+	WARC       string        `json:"warc"`
+	WARCSHA256 string        `json:"warc_sha256"`
+}
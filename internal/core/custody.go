@@ -0,0 +1,61 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version identifies the SPECTRE build in custody records. Override it at
+// build time with -ldflags "-X github.com/spectre/spectre/internal/core.Version=...".
+var Version = "dev"
+
+// Custody actions.
+const (
+	CustodyCollected = "collected"
+	CustodyIngested  = "ingested"
+	CustodyViewed    = "viewed"
+	CustodyExported  = "exported"
+	CustodyImported  = "imported"
+)
+
+// CustodyEvent is one entry of a case's append-only, hash-chained custody
+// log. Each entry commits to its predecessor through PrevHash.
+type CustodyEvent struct {
+	ID         string            `json:"id"`
+	CaseID     string            `json:"case_id"`
+	Seq        int64             `json:"seq"`
+	EvidenceID string            `json:"evidence_id,omitempty"`
+	Action     string            `json:"action"`
+	Actor      string            `json:"actor"`
+	Tool       string            `json:"tool"`
+	Proxy      string            `json:"proxy,omitempty"`
+	FileHash   string            `json:"file_hash,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	Timestamp  time.Time         `json:"timestamp"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash"`
+}
+
+// ComputeHash returns the SHA-256 over the event's fields and PrevHash.
+func (e *CustodyEvent) ComputeHash() string {
+	details, _ := json.Marshal(e.Details) // Map keys are marshalled in sorted order
+	fields := []string{
+		e.PrevHash,
+		strconv.FormatInt(e.Seq, 10),
+		e.CaseID,
+		e.EvidenceID,
+		e.Action,
+		e.Actor,
+		e.Tool,
+		e.Proxy,
+		e.FileHash,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		string(details),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,74 @@
+package core
+
+// DNSRecord is one resource record from a DNS answer. Value holds the
+// record data in presentation form; type-specific fields are set as well.
+type DNSRecord struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	TTL        uint32 `json:"ttl"`
+	Value      string `json:"value"`
+	Preference uint16 `json:"preference,omitempty"` // MX
+	Priority   uint16 `json:"priority,omitempty"`   // SRV
+	Weight     uint16 `json:"weight,omitempty"`     // SRV
+	Port       uint16 `json:"port,omitempty"`       // SRV
+	Tag        string `json:"tag,omitempty"`        // CAA
+}
+
+// DNSAnswer is the response to a single query, including which server
+// answered and over which transport.
+type DNSAnswer struct {
+	Query     string      `json:"query"`
+	Type      string      `json:"type"`
+	Server    string      `json:"server,omitempty"`
+	Transport string      `json:"transport,omitempty"`
+	RCode     string      `json:"rcode,omitempty"`
+	Records   []DNSRecord `json:"records,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// DNSResult is the evidence written by the dns collector.
+type DNSResult struct {
+	Domain  string       `json:"domain"`
+	Answers []*DNSAnswer `json:"answers"`
+}
+
+// Records returns every record of the given type across all answers.
+func (r *DNSResult) Records(recordType string) []DNSRecord {
+	var out []DNSRecord
+	for _, a := range r.Answers {
+		for _, rec := range a.Records {
+			if rec.Type == recordType {
+				out = append(out, rec)
+			}
+		}
+	}
+	return out
+}
+
+// Subdomain is a name found by the subdomains collector.
+type Subdomain struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses,omitempty"`
+	CNAME     string   `json:"cname,omitempty"`
+	Source    string   `json:"source"` // "bruteforce", "axfr" or "permutation"
+}
+
+// ZoneTransfer records one AXFR attempt against a nameserver.
+type ZoneTransfer struct {
+	Nameserver string `json:"nameserver"`
+	Address    string `json:"address"`
+	Success    bool   `json:"success"`
+	Records    int    `json:"records,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SubdomainResult is the evidence written by the subdomains collector.
+type SubdomainResult struct {
+	Domain         string         `json:"domain"`
+	Wildcard       bool           `json:"wildcard"`
+	WildcardIPs    []string       `json:"wildcard_ips,omitempty"`
+	WildcardCNAMEs []string       `json:"wildcard_cnames,omitempty"`
+	ZoneTransfers  []ZoneTransfer `json:"zone_transfers,omitempty"`
+	Tried          int            `json:"tried"`
+	Subdomains     []Subdomain    `json:"subdomains"`
+}
@@ -0,0 +1,55 @@
+package core
+
+// SMTP verification verdicts.
+const (
+	SMTPDeliverable   = "deliverable"
+	SMTPUndeliverable = "undeliverable"
+	SMTPCatchAll      = "catch_all" // the server accepts any recipient
+	SMTPUnknown       = "unknown"
+)
+
+// SMTPCheck is the outcome of an RCPT TO probe against a mail exchanger.
+// No message is ever sent.
+type SMTPCheck struct {
+	Server  string `json:"server"`
+	Verdict string `json:"verdict"`
+	Code    int    `json:"code,omitempty"` // reply to RCPT TO
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GravatarAccount is an external account listed on a Gravatar profile.
+type GravatarAccount struct {
+	Domain   string `json:"domain"`
+	Username string `json:"username,omitempty"`
+	URL      string `json:"url"`
+}
+
+// GravatarProfile is the public profile attached to an email hash.
+type GravatarProfile struct {
+	ProfileURL  string            `json:"profile_url"`
+	Username    string            `json:"username,omitempty"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Location    string            `json:"location,omitempty"`
+	AvatarURL   string            `json:"avatar_url,omitempty"`
+	Accounts    []GravatarAccount `json:"accounts,omitempty"`
+}
+
+// EmailResult is the evidence written by the email collector.
+type EmailResult struct {
+	Email       string           `json:"email"`
+	Valid       bool             `json:"valid"`
+	SyntaxError string           `json:"syntax_error,omitempty"`
+	LocalPart   string           `json:"local_part,omitempty"`
+	Domain      string           `json:"domain,omitempty"`
+	MX          []DNSRecord      `json:"mx,omitempty"`
+	SPF         string           `json:"spf,omitempty"`
+	AcceptsMail bool             `json:"accepts_mail"` // has MX records, or an address to fall back to
+	Disposable  bool             `json:"disposable"`
+	FreeMail    bool             `json:"free_mail"`
+	Role        bool             `json:"role"` // shared mailbox such as info@ or support@
+	SMTP        *SMTPCheck       `json:"smtp,omitempty"`
+	GravatarMD5 string           `json:"gravatar_md5,omitempty"`
+	Gravatar    *GravatarProfile `json:"gravatar,omitempty"`
+	Usernames   []string         `json:"usernames,omitempty"` // candidates derived from the local part
+}
@@ -1,6 +1,50 @@
 package core
 
-import "time"
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Entity types shared by collectors, ingestion and the pivot engine.
+const (
+	EntityDomain   = "domain"
+	EntityIP       = "ip"
+	EntityEmail    = "email"
+	EntityUsername = "username"
+	EntityURL      = "url"
+	EntityAccount  = "account"
+	EntityRepo     = "repo"
+	EntityService  = "service"
+	// EntityCertificate values come from CertificateKey: the SHA-256
+	// fingerprint, or issuer and serial for certificates known from CT logs.
+	EntityCertificate  = "certificate"
+	EntityTechnology   = "technology"
+	EntityPhone        = "phone" // digits with a leading + when international
+	EntityTracker      = "tracker_id"
+	EntityBreach       = "breach" // "breach:" and the name imported into the local store
+	EntityOrganization = "organization"
+	EntityASN          = "asn"      // "AS" followed by the number, e.g. AS13335
+	EntityNetblock     = "netblock" // CIDR prefix, e.g. 192.0.2.0/24
+)
+
+// EntityTypes lists every entity type, in declaration order.
+var EntityTypes = []string{
+	EntityDomain, EntityIP, EntityEmail, EntityUsername, EntityURL, EntityAccount, EntityRepo,
+	EntityService, EntityCertificate, EntityTechnology, EntityPhone, EntityTracker, EntityBreach,
+	EntityOrganization, EntityASN, EntityNetblock,
+}
+
+// IsEntityType reports whether t is one of the entity types above.
+func IsEntityType(t string) bool {
+	for _, known := range EntityTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
 
 // Entity represents a single intelligence node (e.g., IP, Domain, Person).
 type Entity struct {
@@ -13,3 +57,39 @@ type Entity struct {
 	DiscoveredAt time.Time              `json:"discovered_at"`
 	Metadata     map[string]interface{} `json:"metadata"`
 }
+
+// InferEntityType guesses the entity type of a free-form target string.
+func InferEntityType(target string) string {
+	target = strings.TrimSpace(target)
+
+	if u, err := url.Parse(target); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		return EntityURL
+	}
+	if net.ParseIP(target) != nil {
+		return EntityIP
+	}
+	if host, _, err := net.SplitHostPort(target); err == nil && net.ParseIP(host) != nil {
+		return EntityIP
+	}
+	if _, _, err := net.ParseCIDR(target); err == nil {
+		return EntityNetblock
+	}
+	if IsASN(target) {
+		return EntityASN
+	}
+	if strings.Contains(target, "@") {
+		return EntityEmail
+	}
+	if strings.Contains(target, ".") {
+		return EntityDomain
+	}
+	return EntityUsername
+}
+
+// IsASN reports whether s is an AS number written as "AS64496".
+func IsASN(s string) bool {
+	if len(s) < 3 || !strings.EqualFold(s[:2], "AS") {
+		return false
+	}
+	return strings.Trim(s[2:], "0123456789") == ""
+}
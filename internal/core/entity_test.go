@@ -0,0 +1,25 @@
+package core
+
+import "testing"
+
+func TestInferEntityType(t *testing.T) {
+	cases := map[string]string{
+		"8.8.8.8":             EntityIP,
+		"2001:db8::1":         EntityIP,
+		"127.0.0.1:8080":      EntityIP,
+		"admin@example.com":   EntityEmail,
+		"example.com":         EntityDomain,
+		"https://example.com": EntityURL,
+		"hacker_one":          EntityUsername,
+		"AS13335":             EntityASN,
+		"as64496":             EntityASN,
+		"asdf":                EntityUsername,
+		"192.0.2.0/24":        EntityNetblock,
+		"2001:db8::/32":       EntityNetblock,
+	}
+	for input, want := range cases {
+		if got := InferEntityType(input); got != want {
+			t.Errorf("InferEntityType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package core
+
+// HTTPHop is one response in a redirect chain.
+type HTTPHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location,omitempty"`
+}
+
+// HTTPCookie records a Set-Cookie header without its value.
+type HTTPCookie struct {
+	Name     string `json:"name"`
+	Domain   string `json:"domain,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"http_only"`
+	SameSite string `json:"same_site,omitempty"`
+}
+
+// Favicon is the site icon with the hashes used to search for it elsewhere.
+type Favicon struct {
+	URL    string `json:"url"`
+	Size   int    `json:"size"`
+	MMH3   int32  `json:"mmh3"` // Shodan-style http.favicon.hash
+	SHA256 string `json:"sha256"`
+}
+
+// Technology is a product detected by the HTTP fingerprints.
+type Technology struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	Confidence int      `json:"confidence"`        // 0-100
+	Matched    []string `json:"matched,omitempty"` // what matched, e.g. "header:Server"
+}
+
+// HTTPResult is the evidence written by the http collector. URL, StatusCode,
+// Headers and Title describe the final response after redirects.
+type HTTPResult struct {
+	Target          string            `json:"target"`
+	URL             string            `json:"url"`
+	StatusCode      int               `json:"status_code"`
+	Redirects       []HTTPHop         `json:"redirects,omitempty"`
+	Headers         map[string]string `json:"headers"`
+	Cookies         []HTTPCookie      `json:"cookies,omitempty"`
+	Title           string            `json:"title,omitempty"`
+	BodySize        int               `json:"body_size"`
+	BodyTruncated   bool              `json:"body_truncated,omitempty"`
+	BodySHA256      string            `json:"body_sha256,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	BodyBase64      string            `json:"body_base64,omitempty"` // set instead of Body for non-UTF-8 content
+	MissingHeaders  []string          `json:"missing_security_headers,omitempty"`
+	InsecureCookies []string          `json:"insecure_cookies,omitempty"` // cookies lacking Secure or HttpOnly
+	Favicon         *Favicon          `json:"favicon,omitempty"`
+	Technologies    []Technology      `json:"technologies,omitempty"`
+	Trackers        []TrackerID       `json:"trackers,omitempty"`
+}
@@ -0,0 +1,38 @@
+package core
+
+import "time"
+
+// Job states.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job is a persisted request to run one collector against one target.
+type Job struct {
+	ID            string        `json:"id"`
+	CaseID        string        `json:"case_id"`
+	Collector     string        `json:"collector"`
+	Target        string        `json:"target"`
+	EntityType    string        `json:"entity_type"`
+	ActiveAllowed bool          `json:"active_allowed"`
+	Timeout       time.Duration `json:"timeout"`
+	Status        string        `json:"status"`
+	Attempts      int           `json:"attempts"`
+	MaxAttempts   int           `json:"max_attempts"`
+	LastError     string        `json:"last_error,omitempty"`
+	EvidenceCount int           `json:"evidence_count"`
+	CreatedAt     time.Time     `json:"created_at"`
+	RunAfter      time.Time     `json:"run_after"` // Not claimed before this time (retry backoff)
+	StartedAt     *time.Time    `json:"started_at,omitempty"`
+	FinishedAt    *time.Time    `json:"finished_at,omitempty"`
+	HeartbeatAt   *time.Time    `json:"heartbeat_at,omitempty"` // Refreshed by the worker while running
+}
+
+// Done reports whether the job has reached a terminal state.
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
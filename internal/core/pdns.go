@@ -0,0 +1,27 @@
+package core
+
+import "time"
+
+// PDNSRecord is one passive DNS observation: RRName answered with RData
+// between FirstSeen and LastSeen.
+type PDNSRecord struct {
+	RRName    string    `json:"rrname"`
+	RRType    string    `json:"rrtype"`
+	RData     string    `json:"rdata"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int64     `json:"count,omitempty"`
+	Source    string    `json:"source"` // provider that reported it
+}
+
+// ReverseDNSResult is the evidence written by the reverse collector: the
+// PTR names of an address and the names passive DNS has seen on it.
+type ReverseDNSResult struct {
+	IP          string       `json:"ip"`
+	PTR         []string     `json:"ptr,omitempty"`
+	PTRError    string       `json:"ptr_error,omitempty"`
+	Providers   []string     `json:"providers,omitempty"`
+	Resolutions []PDNSRecord `json:"resolutions,omitempty"`
+	Truncated   bool         `json:"truncated,omitempty"` // more names than collectors.reverse.max_domains
+	Errors      []string     `json:"errors,omitempty"`
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// OpenPort is a responsive port found by the ports collector, with whatever
+// the service probes identified.
+type OpenPort struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"` // "tcp" or "udp"
+	Service  string `json:"service,omitempty"`
+	Product  string `json:"product,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Info     string `json:"info,omitempty"`
+	TLS      bool   `json:"tls,omitempty"`
+	Banner   string `json:"banner,omitempty"`
+	Method   string `json:"method,omitempty"` // matching probe, or "port" when guessed from the number
+}
+
+// ServiceValue is the entity value of the service on this host and port,
+// e.g. "192.0.2.1:443/tcp", so services are never shared between hosts.
+func (p OpenPort) ServiceValue() string {
+	return ServiceValue(p.Host, p.Port, p.Protocol)
+}
+
+// ServiceValue formats a per-host service entity value.
+func ServiceValue(host string, port int, protocol string) string {
+	return fmt.Sprintf("%s/%s", net.JoinHostPort(host, strconv.Itoa(port)), protocol)
+}
+
+// PortScanResult is the evidence written by the ports collector.
+type PortScanResult struct {
+	Target   string     `json:"target"`
+	Mode     string     `json:"mode"`
+	Hosts    []string   `json:"hosts"`
+	Skipped  []string   `json:"skipped,omitempty"` // hosts outside the ethics scope
+	TCPPorts int        `json:"tcp_ports"`         // ports tried per host
+	UDPPorts int        `json:"udp_ports,omitempty"`
+	Open     []OpenPort `json:"open"`
+}
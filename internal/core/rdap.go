@@ -0,0 +1,74 @@
+package core
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RDAP object classes queried by the rdap collector.
+const (
+	RDAPDomain  = "domain"
+	RDAPNetwork = "ip network"
+	RDAPAutnum  = "autnum"
+)
+
+// RDAPContact is an RDAP entity with the roles it holds for the object,
+// e.g. registrant, technical or abuse.
+type RDAPContact struct {
+	Handle       string   `json:"handle,omitempty"`
+	Roles        []string `json:"roles"`
+	Kind         string   `json:"kind,omitempty"` // vCard kind: individual, org, ...
+	Name         string   `json:"name,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	Emails       []string `json:"emails,omitempty"`
+	Phones       []string `json:"phones,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	Redacted     bool     `json:"redacted"` // details withheld for privacy
+}
+
+// RDAPEvent is a dated lifecycle event such as "registration" or
+// "expiration".
+type RDAPEvent struct {
+	Action string    `json:"action"`
+	Date   time.Time `json:"date"`
+}
+
+// RDAPResult is the evidence written by the rdap collector. Raw is the
+// server's reply exactly as received.
+type RDAPResult struct {
+	Query       string        `json:"query"`
+	ObjectClass string        `json:"object_class"`
+	URL         string        `json:"url"` // the URL queried, picked from the bootstrap registry
+	Handle      string        `json:"handle,omitempty"`
+	Name        string        `json:"name,omitempty"` // LDH name, or the network/AS name
+	Status      []string      `json:"status,omitempty"`
+	Events      []RDAPEvent   `json:"events,omitempty"`
+	Registrar   string        `json:"registrar,omitempty"`
+	RegistrarID string        `json:"registrar_iana_id,omitempty"`
+	NameServers []string      `json:"name_servers,omitempty"`
+	Contacts    []RDAPContact `json:"contacts,omitempty"`
+
+	// IP networks
+	StartAddress string   `json:"start_address,omitempty"`
+	EndAddress   string   `json:"end_address,omitempty"`
+	CIDRs        []string `json:"cidrs,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	NetworkType  string   `json:"network_type,omitempty"`
+	ParentHandle string   `json:"parent_handle,omitempty"`
+
+	// Autonomous systems
+	StartAutnum uint32 `json:"start_autnum,omitempty"`
+	EndAutnum   uint32 `json:"end_autnum,omitempty"`
+
+	Raw json.RawMessage `json:"raw"`
+}
+
+// Event returns the date of the first event with the given action.
+func (r *RDAPResult) Event(action string) (time.Time, bool) {
+	for _, e := range r.Events {
+		if e.Action == action {
+			return e.Date, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,15 @@
+package core
+
+import "time"
+
+// APIToken is a credential for the API server. The secret itself is never
+// stored; Hash holds its SHA-256 and Prefix a short, non-secret hint.
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hash       string     `json:"-"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TrackerID is an analytics or advertising account identifier embedded in a
+// page, e.g. {"google_analytics", "UA-12345-1"}. Sites sharing one are
+// usually run by the same operator.
+type TrackerID struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Page string `json:"page,omitempty"` // first page it was seen on
+}
+
+// Value is the tracker_id entity value: the ID itself when its prefix
+// already names the service (UA-, G-, GTM-, ca-pub-, AW-), otherwise
+// "type:id" so bare numbers from different services never merge.
+func (t TrackerID) Value() string {
+	if strings.Trim(t.ID, "0123456789") == "" {
+		return t.Type + ":" + t.ID
+	}
+	return t.ID
+}
+
+// TrackerCluster is a group of sites connected by shared tracker IDs.
+type TrackerCluster struct {
+	Sites    []string `json:"sites"`
+	Trackers []string `json:"trackers"` // the shared IDs that join them
+}
+
+// trackerPatterns pull analytics and advertising account IDs out of HTML
+// and JavaScript. The ID is the last capture group.
+var trackerPatterns = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"google_analytics", regexp.MustCompile(`\b(UA-\d{4,10}-\d{1,4})\b`)},
+	{"google_analytics_4", regexp.MustCompile(`(?:gtag\(\s*['"]config['"]\s*,\s*['"]|[?&]id=)(G-[A-Z0-9]{6,12})\b`)},
+	{"google_tag_manager", regexp.MustCompile(`\b(GTM-[A-Z0-9]{4,9})\b`)},
+	{"google_adsense", regexp.MustCompile(`\b(ca-pub-\d{10,20})\b`)},
+	{"google_ads", regexp.MustCompile(`\b(AW-\d{9,12})\b`)},
+	{"facebook_pixel", regexp.MustCompile(`(?:fbq\(\s*['"]init['"]\s*,\s*['"]|facebook\.com/tr\?id=)(\d{10,20})`)},
+	{"hotjar", regexp.MustCompile(`\bhjid\s*:\s*(\d{5,10})`)},
+	{"yandex_metrika", regexp.MustCompile(`\bym\(\s*(\d{6,10})\s*,\s*['"]init['"]`)},
+}
+
+// ExtractTrackers finds tracker IDs in HTML or JavaScript, deduplicated.
+func ExtractTrackers(s string) []TrackerID {
+	var out []TrackerID
+	seen := make(map[string]bool)
+	for _, p := range trackerPatterns {
+		for _, m := range p.re.FindAllStringSubmatch(s, -1) {
+			id := m[len(m)-1]
+			if !seen[p.kind+id] {
+				seen[p.kind+id] = true
+				out = append(out, TrackerID{Type: p.kind, ID: id})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package core
+
+import "testing"
+
+func TestExtractTrackers(t *testing.T) {
+	page := `<script async src="https://www.googletagmanager.com/gtag/js?id=G-ABCDEF1234"></script>
+<script>gtag('config', 'G-ABCDEF1234'); ga('create', 'UA-1234567-2', 'auto');</script>
+<noscript><iframe src="https://www.googletagmanager.com/ns.html?id=GTM-K9X2QZ"></iframe></noscript>
+<ins class="adsbygoogle" data-ad-client="ca-pub-1234567890123456"></ins>
+<img src="https://www.facebook.com/tr?id=123456789012345&ev=PageView">
+<script>(function(h){h.hjSettings={hjid:3141592,hjsv:6};})(window); ym(87654321, "init", {});</script>
+<p>Our G-Series widgets ship in G-CLASSIC colours.</p>`
+
+	got := make(map[string]string)
+	for _, tr := range ExtractTrackers(page) {
+		if _, dup := got[tr.Type]; dup {
+			t.Errorf("Duplicate %s tracker %s", tr.Type, tr.ID)
+		}
+		got[tr.Type] = tr.Value()
+	}
+	want := map[string]string{
+		"google_analytics":   "UA-1234567-2",
+		"google_analytics_4": "G-ABCDEF1234",
+		"google_tag_manager": "GTM-K9X2QZ",
+		"google_adsense":     "ca-pub-1234567890123456",
+		"facebook_pixel":     "facebook_pixel:123456789012345",
+		"hotjar":             "hotjar:3141592",
+		"yandex_metrika":     "yandex_metrika:87654321",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %q, want %q", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("Unexpected trackers: %v", got)
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import "time"
+
+// WHOISContact is one contact block of a WHOIS record.
+type WHOISContact struct {
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Country      string `json:"country,omitempty"`
+}
+
+// WHOISRecord is a parsed WHOIS record, either the live one or a snapshot
+// from a historical WHOIS provider.
+type WHOISRecord struct {
+	Domain         string        `json:"domain"`
+	Registrar      string        `json:"registrar,omitempty"`
+	WhoisServer    string        `json:"whois_server,omitempty"`
+	CreatedAt      *time.Time    `json:"created_at,omitempty"`
+	UpdatedAt      *time.Time    `json:"updated_at,omitempty"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	NameServers    []string      `json:"name_servers,omitempty"`
+	Status         []string      `json:"status,omitempty"`
+	DNSSEC         bool          `json:"dnssec"`
+	Registrant     *WHOISContact `json:"registrant,omitempty"`
+	Admin          *WHOISContact `json:"admin,omitempty"`
+	Tech           *WHOISContact `json:"tech,omitempty"`
+	Privacy        bool          `json:"privacy"`                   // registrant hidden by a proxy service or redacted
+	PrivacyService string        `json:"privacy_service,omitempty"` // e.g. "Domains By Proxy, LLC"
+	ObservedAt     *time.Time    `json:"observed_at,omitempty"`     // historical records: when it was captured
+	Source         string        `json:"source,omitempty"`          // historical records: provider name
+}
+
+// WHOISResult is the evidence written by the whois collector. Raw is the
+// server's reply exactly as received.
+type WHOISResult struct {
+	Domain     string        `json:"domain"`
+	Raw        string        `json:"raw"`
+	Record     *WHOISRecord  `json:"record,omitempty"`
+	ParseError string        `json:"parse_error,omitempty"`
+	History    []WHOISRecord `json:"history,omitempty"` // oldest first
+	Errors     []string      `json:"errors,omitempty"`  // provider failures
+}
+
+// ReverseWHOISResult is the evidence written by the reverse_whois collector.
+type ReverseWHOISResult struct {
+	Query     string   `json:"query"`
+	Field     string   `json:"field"` // "email" or "organization"
+	Providers []string `json:"providers"`
+	Domains   []string `json:"domains"`
+	Errors    []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,178 @@
+package custody
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+// Root is the directory collectors write evidence files to.
+var Root = "evidence_storage"
+
+// Issue kinds. Chain, modified and missing are integrity failures; the rest
+// are reported as warnings.
+const (
+	IssueChain     = "chain"     // Custody log entry altered, reordered or removed
+	IssueModified  = "modified"  // File hash differs from evidence.file_hash
+	IssueMissing   = "missing"   // Evidence file no longer exists
+	IssueUnlogged  = "unlogged"  // Evidence without a matching collection entry
+	IssueUntracked = "untracked" // File in the case folder with no evidence record
+)
+
+// Issue is a single verification finding.
+type Issue struct {
+	Kind       string `json:"kind"`
+	EvidenceID string `json:"evidence_id,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+// Result summarises the verification of one case.
+type Result struct {
+	CaseID       string    `json:"case_id"`
+	CheckedAt    time.Time `json:"checked_at"`
+	Events       int       `json:"events"`
+	FilesChecked int       `json:"files_checked"`
+	Issues       []Issue   `json:"issues,omitempty"`
+	Warnings     []Issue   `json:"warnings,omitempty"`
+}
+
+// OK reports whether the chain and every evidence file verified.
+func (r *Result) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Verify checks a case's custody chain and rehashes its evidence files.
+func Verify(caseID string) (*Result, error) {
+	res := &Result{CaseID: caseID, CheckedAt: time.Now()}
+
+	events, err := storage.ListCustodyEvents(caseID)
+	if err != nil {
+		return nil, err
+	}
+	res.Events = len(events)
+	res.Issues = append(res.Issues, verifyChain(events)...)
+
+	// Files the log accounts for, and the hash each evidence item was logged with
+	known := make(map[string]bool)
+	collected := make(map[string]string)
+	for _, e := range events {
+		if e.Action == core.CustodyCollected && e.EvidenceID != "" {
+			collected[e.EvidenceID] = e.FileHash
+		}
+		if path := e.Details["path"]; e.Action == core.CustodyExported && path != "" {
+			known[filepath.Clean(path)] = true
+		}
+	}
+
+	evidence, err := storage.ListEvidenceByCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range evidence {
+		if ev.FilePath == "" {
+			continue
+		}
+		known[filepath.Clean(ev.FilePath)] = true
+		res.FilesChecked++
+
+		hash, err := HashFile(ev.FilePath)
+		switch {
+		case os.IsNotExist(err):
+			res.Issues = append(res.Issues, Issue{Kind: IssueMissing, EvidenceID: ev.ID, Path: ev.FilePath, Detail: "file not found"})
+			continue
+		case err != nil:
+			return nil, err
+		case hash != ev.FileHash:
+			res.Issues = append(res.Issues, Issue{Kind: IssueModified, EvidenceID: ev.ID, Path: ev.FilePath,
+				Detail: fmt.Sprintf("expected sha256 %s, found %s", ev.FileHash, hash)})
+		}
+
+		logged, ok := collected[ev.ID]
+		switch {
+		case !ok:
+			res.Warnings = append(res.Warnings, Issue{Kind: IssueUnlogged, EvidenceID: ev.ID, Path: ev.FilePath, Detail: "no collection entry in the custody log"})
+		case logged != ev.FileHash:
+			res.Issues = append(res.Issues, Issue{Kind: IssueModified, EvidenceID: ev.ID, Path: ev.FilePath,
+				Detail: fmt.Sprintf("evidence record hash %s differs from the logged %s", ev.FileHash, logged)})
+		}
+	}
+
+	dir := filepath.Join(Root, caseID)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !known[filepath.Clean(path)] {
+			res.Warnings = append(res.Warnings, Issue{Kind: IssueUntracked, Path: path, Detail: "no evidence record"})
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// verifyChain recomputes every hash and link of a case's log.
+func verifyChain(events []*core.CustodyEvent) []Issue {
+	var issues []Issue
+	prev := ""
+	for i, e := range events {
+		if want := int64(i + 1); e.Seq != want {
+			issues = append(issues, Issue{Kind: IssueChain, Detail: fmt.Sprintf("entry %d has sequence %d; entries are missing", want, e.Seq)})
+		}
+		if e.PrevHash != prev {
+			issues = append(issues, Issue{Kind: IssueChain, EvidenceID: e.EvidenceID, Detail: fmt.Sprintf("entry %d does not link to its predecessor", e.Seq)})
+		}
+		if e.ComputeHash() != e.Hash {
+			issues = append(issues, Issue{Kind: IssueChain, EvidenceID: e.EvidenceID, Detail: fmt.Sprintf("entry %d (%s) was altered", e.Seq, e.Action)})
+		}
+		prev = e.Hash
+	}
+	return issues
+}
+
+// HashFile returns the hex SHA-256 of a file, as stored in evidence.file_hash.
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RecordExport logs that a file derived from the case (a report, an export
+// bundle) left SPECTRE.
+func RecordExport(caseID, format, path string) error {
+	hash, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	return storage.RecordCustody(caseID, "", core.CustodyExported, hash, map[string]string{"format": format, "path": path})
+}
+
+// RecordView logs that an evidence file was opened. Paths that do not belong
+// to an evidence record are ignored.
+func RecordView(path string, details map[string]string) error {
+	ev, err := storage.GetEvidenceByPath(path)
+	if err != nil || ev == nil {
+		return err
+	}
+	return storage.RecordCustody(ev.CaseID, ev.ID, core.CustodyViewed, ev.FileHash, details)
+}
@@ -0,0 +1,111 @@
+package custody
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+func setupCase(t *testing.T) string {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB, oldRoot := storage.DB, Root
+	storage.DB = db
+	Root = t.TempDir()
+	t.Cleanup(func() {
+		storage.DB, Root = oldDB, oldRoot
+		db.Close()
+	})
+	if err := storage.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreateCase(&core.Case{ID: "case-verify", Name: "Verify"}); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(Root, "case-verify")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestVerify(t *testing.T) {
+	dir := setupCase(t)
+
+	path := filepath.Join(dir, "dns_example.com.json")
+	if err := os.WriteFile(path, []byte(`{"a": ["192.0.2.1"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := HashFile(path)
+	ev := &core.Evidence{CaseID: "case-verify", Collector: "dns", FilePath: path, FileHash: hash}
+	if err := storage.CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Verify("case-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.OK() || res.FilesChecked != 1 || len(res.Warnings) != 0 {
+		t.Fatalf("Expected a clean verification, got %+v", res)
+	}
+
+	// A report exported into the case folder is accounted for by its export entry
+	reportPath := filepath.Join(dir, "investigation_report.md")
+	os.WriteFile(reportPath, []byte("# Report"), 0644)
+	if err := RecordExport("case-verify", "markdown", reportPath); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "stray.txt"), []byte("?"), 0644)
+
+	if err := os.WriteFile(path, []byte(`{"a": ["198.51.100.1"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res, err = Verify("case-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.OK() || len(res.Issues) != 1 || res.Issues[0].Kind != IssueModified {
+		t.Errorf("Expected one modified-file issue, got %+v", res.Issues)
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].Kind != IssueUntracked {
+		t.Errorf("Expected only the stray file to be untracked, got %+v", res.Warnings)
+	}
+
+	os.Remove(path)
+	res, _ = Verify("case-verify")
+	if len(res.Issues) != 1 || res.Issues[0].Kind != IssueMissing {
+		t.Errorf("Expected one missing-file issue, got %+v", res.Issues)
+	}
+}
+
+func TestVerifyChainDetectsTampering(t *testing.T) {
+	var events []*core.CustodyEvent
+	prev := ""
+	for i, action := range []string{core.CustodyCollected, core.CustodyIngested, core.CustodyViewed} {
+		e := &core.CustodyEvent{CaseID: "c", Seq: int64(i + 1), EvidenceID: "ev", Action: action, Actor: "analyst", PrevHash: prev}
+		e.Hash = e.ComputeHash()
+		prev = e.Hash
+		events = append(events, e)
+	}
+	if issues := verifyChain(events); len(issues) != 0 {
+		t.Fatalf("Expected an intact chain, got %+v", issues)
+	}
+
+	events[1].Actor = "mallory"
+	if issues := verifyChain(events); len(issues) != 1 {
+		t.Errorf("Expected the altered entry to be reported, got %+v", issues)
+	}
+	events[1].Actor = "analyst"
+
+	if issues := verifyChain([]*core.CustodyEvent{events[0], events[2]}); len(issues) != 2 {
+		t.Errorf("Expected a removed entry to break sequence and link, got %+v", issues)
+	}
+}
@@ -2,6 +2,7 @@ package ethics
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"golang.org/x/time/rate"
@@ -20,9 +21,21 @@ var defaultLimits = map[string]rate.Limit{
 }
 
 // Wait blocks until the collector is allowed to proceed based on its rate limit.
-func Wait(collectorName string) error {
+// It returns early with the context's error if ctx is cancelled or its deadline
+// would expire before a token becomes available.
+func Wait(ctx context.Context, collectorName string) error {
 	l := getLimiter(collectorName)
-	return l.Wait(context.Background())
+	if err := l.Wait(ctx); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// The limiter refuses up front when the deadline is too close
+		if _, ok := ctx.Deadline(); ok {
+			return fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+		}
+		return err
+	}
+	return nil
 }
 
 func getLimiter(name string) *rate.Limiter {
@@ -59,3 +72,8 @@ func SetLimit(name string, r float64) {
 	defer mu.Unlock()
 	limiters[name] = rate.NewLimiter(rate.Limit(r), 1)
 }
+
+// Limit returns the rate currently enforced for a collector.
+func Limit(name string) float64 {
+	return float64(getLimiter(name).Limit())
+}
@@ -1,12 +1,18 @@
 package netclient
 
 import (
+	"context"
 	"crypto/tls"
+	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"golang.org/x/net/proxy"
 )
 
 // NewClient returns a new http.Client configured with optional proxy settings.
@@ -42,3 +48,85 @@ func NewClient() *http.Client {
 		Timeout:   30 * time.Second,
 	}
 }
+
+// DialContext opens a raw connection for collectors that do not speak HTTP.
+// In ghost mode it goes through the Tor SOCKS proxy; otherwise a SOCKS5
+// http.proxy is honoured and anything else dials directly.
+func DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var direct net.Dialer
+	proxyURL, err := socksProxy()
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return direct.DialContext(ctx, network, address)
+	}
+	dialer, err := proxy.FromURL(proxyURL, &direct)
+	if err != nil {
+		return nil, err
+	}
+	if cd, ok := dialer.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, network, address)
+	}
+	return dialer.Dial(network, address)
+}
+
+// DialsDirect reports whether DialContext connects without a proxy.
+func DialsDirect() bool {
+	proxyURL, err := socksProxy()
+	return err == nil && proxyURL == nil
+}
+
+// socksProxy returns the SOCKS proxy raw connections use, or nil to dial
+// directly. Ghost mode refuses anything but SOCKS5.
+func socksProxy() (*url.URL, error) {
+	proxyURLStr := viper.GetString("http.proxy")
+	if viper.GetBool("ghost_mode") {
+		proxyURLStr = viper.GetString("http.tor_proxy")
+		if proxyURLStr == "" {
+			proxyURLStr = "socks5://127.0.0.1:9050"
+		}
+	}
+	if proxyURLStr == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(proxyURLStr)
+	if err != nil || !strings.HasPrefix(proxyURL.Scheme, "socks5") {
+		if viper.GetBool("ghost_mode") {
+			return nil, fmt.Errorf("ghost mode needs a socks5 proxy, got %q", redact(proxyURLStr))
+		}
+		return nil, nil
+	}
+	return proxyURL, nil
+}
+
+// ProxyDescription names the proxy outbound requests currently go through,
+// for audit records. It returns "direct" when no proxy is configured.
+func ProxyDescription() string {
+	if viper.GetBool("ghost_mode") {
+		proxyURLStr := viper.GetString("http.tor_proxy")
+		if proxyURLStr == "" {
+			proxyURLStr = "socks5://127.0.0.1:9050"
+		}
+		return "ghost:" + redact(proxyURLStr)
+	}
+	if proxyURLStr := viper.GetString("http.proxy"); proxyURLStr != "" {
+		return redact(proxyURLStr)
+	}
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if v := os.Getenv(env); v != "" {
+			return "env:" + redact(v)
+		}
+	}
+	return "direct"
+}
+
+// redact strips credentials from a proxy URL.
+func redact(proxyURLStr string) string {
+	u, err := url.Parse(proxyURLStr)
+	if err != nil || u.User == nil {
+		return proxyURLStr
+	}
+	u.User = nil
+	return u.String()
+}
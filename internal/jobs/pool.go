@@ -0,0 +1,304 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// Config controls worker concurrency and retry behaviour.
+type Config struct {
+	Workers      int           // Jobs executed concurrently
+	MaxAttempts  int           // Attempts per job before it is marked failed
+	Backoff      time.Duration // Delay before the first retry; doubles per attempt
+	MaxBackoff   time.Duration // Upper bound for the retry delay
+	PollInterval time.Duration // How often idle workers look for new jobs
+	StaleAfter   time.Duration // Running jobs without a heartbeat this long are requeued
+}
+
+// LoadConfig reads the jobs section from viper, with defaults.
+func LoadConfig() Config {
+	cfg := Config{
+		Workers:      4,
+		MaxAttempts:  3,
+		Backoff:      5 * time.Second,
+		MaxBackoff:   5 * time.Minute,
+		PollInterval: time.Second,
+		StaleAfter:   time.Minute,
+	}
+	if viper.IsSet("jobs.workers") {
+		cfg.Workers = viper.GetInt("jobs.workers")
+	}
+	if viper.IsSet("jobs.max_attempts") {
+		cfg.MaxAttempts = viper.GetInt("jobs.max_attempts")
+	}
+	if viper.IsSet("jobs.backoff") {
+		cfg.Backoff = viper.GetDuration("jobs.backoff")
+	}
+	if viper.IsSet("jobs.max_backoff") {
+		cfg.MaxBackoff = viper.GetDuration("jobs.max_backoff")
+	}
+	if viper.IsSet("jobs.poll_interval") {
+		cfg.PollInterval = viper.GetDuration("jobs.poll_interval")
+	}
+	if viper.IsSet("jobs.stale_after") {
+		cfg.StaleAfter = viper.GetDuration("jobs.stale_after")
+	}
+	return cfg
+}
+
+// Enqueue persists a job for the named collector. The entity type is inferred
+// from the target when opts does not set one.
+func Enqueue(caseID, name, target string, opts core.CollectOptions, maxAttempts int) (*core.Job, error) {
+	if _, err := collector.Get(name); err != nil {
+		return nil, err
+	}
+
+	entityType := opts.EntityType
+	if entityType == "" {
+		entityType = core.InferEntityType(target)
+	}
+
+	job := &core.Job{
+		CaseID:        caseID,
+		Collector:     name,
+		Target:        target,
+		EntityType:    entityType,
+		ActiveAllowed: opts.ActiveAllowed,
+		Timeout:       opts.Timeout,
+		MaxAttempts:   maxAttempts,
+	}
+	if err := storage.CreateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Pool executes queued jobs with a fixed number of workers.
+type Pool struct {
+	cfg    Config
+	jobIDs []string
+
+	// Optional hooks. They may be called from several workers at once.
+	OnProgress func(job *core.Job, done, total int, message string)
+	OnFinish   func(job *core.Job) // After every attempt, terminal or requeued
+}
+
+// NewPool creates a worker pool. When jobIDs are given the pool only claims
+// those jobs, which lets a foreground command run its own work without
+// draining the shared queue.
+func NewPool(cfg Config, jobIDs ...string) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = time.Minute
+	}
+	return &Pool{cfg: cfg, jobIDs: jobIDs}
+}
+
+// Run starts the workers and blocks until ctx is cancelled. Jobs interrupted
+// by the shutdown go back to the queue.
+func (p *Pool) Run(ctx context.Context) error {
+	if n, err := storage.RequeueStaleJobs(p.cfg.StaleAfter); err != nil {
+		return err
+	} else if n > 0 {
+		log.Info().Int("jobs", n).Msg("Requeued interrupted jobs")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (p *Pool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := storage.ClaimJob(p.jobIDs...)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to claim job")
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.cfg.PollInterval):
+			}
+			continue
+		}
+		p.execute(ctx, job)
+	}
+}
+
+// execute runs one attempt of a claimed job and records the outcome.
+func (p *Pool) execute(ctx context.Context, job *core.Job) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Heartbeat and watch for cancellation requested from another process
+	var cancelled atomic.Bool
+	stopWatch := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(p.heartbeatInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopWatch:
+				return
+			case <-ticker.C:
+				status, err := storage.TouchJob(job.ID)
+				if err == nil && status == core.JobCancelled {
+					cancelled.Store(true)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	opts := core.CollectOptions{
+		ActiveAllowed: job.ActiveAllowed,
+		EntityType:    job.EntityType,
+		Timeout:       job.Timeout,
+		Progress: func(done, total int, message string) {
+			if p.OnProgress != nil {
+				p.OnProgress(job, done, total, message)
+			}
+		},
+	}
+	evidenceList, err := collector.Run(runCtx, job.Collector, job.CaseID, job.Target, opts)
+	close(stopWatch)
+
+	if err == nil {
+		for _, ev := range evidenceList {
+			if err := storage.CreateEvidence(&ev); err != nil {
+				log.Warn().Err(err).Str("job", job.ID).Msg("Failed to save evidence")
+				continue
+			}
+			if err := storage.IngestEvidence(&ev); err != nil {
+				log.Warn().Err(err).Str("job", job.ID).Msg("Ingestion failed")
+			}
+		}
+		job.EvidenceCount = len(evidenceList)
+	}
+
+	p.settle(ctx, job, err, cancelled.Load())
+
+	// A cancellation may have landed after the last heartbeat; the guarded
+	// write leaves it in place and the job is reported as it now stands
+	if ok, err := storage.FinishJob(job); err != nil {
+		log.Error().Err(err).Str("job", job.ID).Msg("Failed to record job result")
+	} else if !ok {
+		if current, getErr := storage.GetJob(job.ID); getErr == nil && current != nil {
+			*job = *current
+		} else {
+			job.Status = core.JobCancelled
+		}
+	}
+	if p.OnFinish != nil {
+		p.OnFinish(job)
+	}
+}
+
+// settle decides the job's next state from the outcome of an attempt.
+func (p *Pool) settle(ctx context.Context, job *core.Job, err error, cancelled bool) {
+	now := time.Now()
+	finish := func(status string) {
+		job.Status = status
+		job.FinishedAt = &now
+	}
+
+	switch {
+	case cancelled:
+		finish(core.JobCancelled)
+		if err != nil {
+			job.LastError = err.Error()
+		}
+	case err == nil:
+		finish(core.JobSucceeded)
+		job.LastError = ""
+	case ctx.Err() != nil:
+		// The pool is shutting down; this attempt does not count
+		job.Status = core.JobQueued
+		job.Attempts--
+		job.RunAfter = now
+		job.LastError = err.Error()
+	case errors.Is(err, collector.ErrRejected) || job.Attempts >= job.MaxAttempts:
+		finish(core.JobFailed)
+		job.LastError = err.Error()
+	default:
+		job.Status = core.JobQueued
+		job.RunAfter = now.Add(p.backoff(job.Attempts))
+		job.LastError = fmt.Sprintf("attempt %d: %v", job.Attempts, err)
+	}
+}
+
+// backoff returns the delay before the retry that follows the given attempt.
+func (p *Pool) backoff(attempt int) time.Duration {
+	delay := p.cfg.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.cfg.MaxBackoff > 0 && delay >= p.cfg.MaxBackoff {
+			return p.cfg.MaxBackoff
+		}
+	}
+	return delay
+}
+
+func (p *Pool) heartbeatInterval() time.Duration {
+	interval := p.cfg.StaleAfter / 3
+	if interval > p.cfg.PollInterval {
+		interval = p.cfg.PollInterval
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return interval
+}
+
+// Wait blocks until every listed job has reached a terminal state and
+// returns their final records.
+func Wait(ctx context.Context, ids []string, interval time.Duration) ([]*core.Job, error) {
+	for {
+		var finished []*core.Job
+		for _, id := range ids {
+			job, err := storage.GetJob(id)
+			if err != nil {
+				return nil, err
+			}
+			if job == nil {
+				return nil, fmt.Errorf("job %s not found", id)
+			}
+			if !job.Done() {
+				break
+			}
+			finished = append(finished, job)
+		}
+		if len(finished) == len(ids) {
+			return finished, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+// flakyCollector fails a fixed number of times before succeeding.
+type flakyCollector struct {
+	name     string
+	failures int32
+	calls    atomic.Int32
+}
+
+func (f *flakyCollector) Name() string        { return f.name }
+func (f *flakyCollector) Description() string { return "fails before succeeding" }
+func (f *flakyCollector) IsActive() bool      { return false }
+
+func (f *flakyCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	if f.calls.Add(1) <= f.failures {
+		return nil, errors.New("temporary failure")
+	}
+	return nil, nil
+}
+
+// stuckCollector blocks until its context is cancelled.
+type stuckCollector struct{}
+
+func (s *stuckCollector) Name() string        { return "test_stuck" }
+func (s *stuckCollector) Description() string { return "never finishes" }
+func (s *stuckCollector) IsActive() bool      { return false }
+
+func (s *stuckCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return s.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+func (s *stuckCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+var testConfig = Config{
+	Workers:      2,
+	MaxAttempts:  3,
+	Backoff:      10 * time.Millisecond,
+	PollInterval: 10 * time.Millisecond,
+	StaleAfter:   time.Minute,
+}
+
+func setupDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+
+	oldDB := storage.DB
+	storage.DB = db
+	t.Cleanup(func() {
+		storage.DB = oldDB
+		db.Close()
+	})
+
+	if err := storage.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreateCase(&core.Case{ID: "case-jobs", Name: "Jobs"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runPool runs a pool until the given jobs finish and returns their records.
+func runPool(t *testing.T, pool *Pool, ids ...string) []*core.Job {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Run(ctx)
+	}()
+
+	finished, err := Wait(ctx, ids, 10*time.Millisecond)
+	cancel()
+	<-done
+	if err != nil {
+		t.Fatalf("Jobs did not finish: %v", err)
+	}
+	return finished
+}
+
+func TestPool_RetriesTransientFailures(t *testing.T) {
+	setupDB(t)
+
+	c := &flakyCollector{name: "test_flaky", failures: 2}
+	collector.Register(c)
+
+	job, err := Enqueue("case-jobs", c.Name(), "example.com", core.CollectOptions{}, testConfig.MaxAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := runPool(t, NewPool(testConfig), job.ID)
+	if finished[0].Status != core.JobSucceeded {
+		t.Errorf("Expected job to succeed after retries, got %s (%s)", finished[0].Status, finished[0].LastError)
+	}
+	if finished[0].Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", finished[0].Attempts)
+	}
+}
+
+func TestPool_GivesUpAfterMaxAttempts(t *testing.T) {
+	setupDB(t)
+
+	c := &flakyCollector{name: "test_broken", failures: 100}
+	collector.Register(c)
+
+	job, err := Enqueue("case-jobs", c.Name(), "example.com", core.CollectOptions{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := runPool(t, NewPool(testConfig), job.ID)
+	if finished[0].Status != core.JobFailed || c.calls.Load() != 2 {
+		t.Errorf("Expected failure after 2 calls, got %s after %d", finished[0].Status, c.calls.Load())
+	}
+}
+
+func TestPool_DoesNotRetryRejectedRuns(t *testing.T) {
+	setupDB(t)
+
+	c := &flakyCollector{name: "test_rejected"}
+	collector.Register(c)
+
+	// Targets outside the accepted scope are rejected by collector.Run
+	job, err := Enqueue("case-jobs", c.Name(), "127.0.0.1", core.CollectOptions{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finished := runPool(t, NewPool(testConfig), job.ID)
+	if finished[0].Status != core.JobFailed || finished[0].Attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %s after %d", finished[0].Status, finished[0].Attempts)
+	}
+}
+
+func TestPool_CancelsRunningJob(t *testing.T) {
+	setupDB(t)
+	collector.Register(&stuckCollector{})
+
+	job, err := Enqueue("case-jobs", "test_stuck", "example.com", core.CollectOptions{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			if j, _ := storage.GetJob(job.ID); j != nil && j.Status == core.JobRunning {
+				storage.CancelJob(job.ID)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	finished := runPool(t, NewPool(testConfig), job.ID)
+	if finished[0].Status != core.JobCancelled {
+		t.Errorf("Expected job to be cancelled, got %s", finished[0].Status)
+	}
+}
@@ -0,0 +1,270 @@
+package pivot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// DefaultRules maps an entity type to the collectors that expand it.
+var DefaultRules = map[string][]string{
+	core.EntityDomain:   {"dns", "whois"},
+	core.EntityIP:       {"geo", "ports", "rdap", "reverse"},
+	core.EntityASN:      {"rdap"},
+	core.EntityEmail:    {"email", "github"},
+	core.EntityUsername: {"github", "social"},
+}
+
+// Config controls how far the engine is allowed to pivot.
+type Config struct {
+	MaxDepth      int                 // Hops away from the seed entity (0 = seed only)
+	MaxRuns       int                 // Collector runs allowed per case (0 = unlimited)
+	ActiveAllowed bool                // Permit active collectors during pivots
+	Rules         map[string][]string // Entity type -> collectors
+}
+
+// LoadConfig reads pivot settings from viper, falling back to DefaultRules.
+func LoadConfig() Config {
+	cfg := Config{
+		MaxDepth: 2,
+		MaxRuns:  50,
+		Rules:    DefaultRules,
+	}
+	if viper.IsSet("pivot.max_depth") {
+		cfg.MaxDepth = viper.GetInt("pivot.max_depth")
+	}
+	if viper.IsSet("pivot.max_runs") {
+		cfg.MaxRuns = viper.GetInt("pivot.max_runs")
+	}
+	if viper.IsSet("pivot.rules") {
+		rules := make(map[string][]string)
+		for entityType := range viper.GetStringMap("pivot.rules") {
+			rules[entityType] = viper.GetStringSlice("pivot.rules." + entityType)
+		}
+		cfg.Rules = rules
+	}
+	return cfg
+}
+
+// Step describes a single collector run scheduled by the engine.
+type Step struct {
+	Depth       int
+	Collector   string
+	EntityType  string
+	Target      string
+	Evidence    int
+	NewEntities []*core.Entity
+	Err         error
+}
+
+// Summary is returned once the pivot queue is drained or the budget runs out.
+type Summary struct {
+	Runs            int
+	NewEntities     int
+	BudgetExhausted bool
+	Steps           []Step
+}
+
+// Engine expands entities by running the collectors configured for their type
+// and feeding every newly ingested entity back into the queue.
+type Engine struct {
+	cfg    Config
+	OnStep func(Step) // Optional hook invoked after each collector run
+
+	mu      sync.Mutex
+	runs    map[string]int             // case ID -> collector runs spent
+	visited map[string]map[string]bool // case ID -> type|value|collector
+}
+
+// NewEngine creates a pivot engine with the given configuration.
+func NewEngine(cfg Config) *Engine {
+	if cfg.Rules == nil {
+		cfg.Rules = DefaultRules
+	}
+	return &Engine{
+		cfg:     cfg,
+		runs:    make(map[string]int),
+		visited: make(map[string]map[string]bool),
+	}
+}
+
+type queued struct {
+	entity *core.Entity
+	depth  int
+}
+
+// Run pivots outward from the seed entity in breadth-first order.
+func (e *Engine) Run(ctx context.Context, caseID string, seed *core.Entity) (*Summary, error) {
+	summary := &Summary{}
+	queue := []queued{{entity: seed, depth: 0}}
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, name := range e.cfg.Rules[current.entity.Type] {
+			if err := ctx.Err(); err != nil {
+				return summary, err
+			}
+			if !e.shouldRun(caseID, current.entity, name) {
+				continue
+			}
+			if !e.reserve(caseID) {
+				summary.BudgetExhausted = true
+				return summary, nil
+			}
+
+			step := e.runCollector(ctx, caseID, current.entity, name)
+			step.Depth = current.depth
+			summary.Runs++
+			summary.NewEntities += len(step.NewEntities)
+			summary.Steps = append(summary.Steps, step)
+			if e.OnStep != nil {
+				e.OnStep(step)
+			}
+
+			if current.depth+1 > e.cfg.MaxDepth {
+				continue
+			}
+			for _, ent := range step.NewEntities {
+				queue = append(queue, queued{entity: ent, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+// shouldRun applies loop detection, the active-probe gate and the
+// collector's declared entity types.
+func (e *Engine) shouldRun(caseID string, ent *core.Entity, name string) bool {
+	c, err := collector.Get(name)
+	if err != nil {
+		log.Debug().Str("collector", name).Msg("Pivot rule references unknown collector")
+		return false
+	}
+	if c.IsActive() && !e.cfg.ActiveAllowed {
+		return false
+	}
+	if !collector.Accepts(c, ent.Type) {
+		return false
+	}
+
+	key := strings.ToLower(ent.Type + "|" + ent.Value + "|" + name)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.visited[caseID] == nil {
+		e.visited[caseID] = make(map[string]bool)
+	}
+	if e.visited[caseID][key] {
+		return false
+	}
+	e.visited[caseID][key] = true
+	return true
+}
+
+// reserve consumes one unit of the per-case run budget.
+func (e *Engine) reserve(caseID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cfg.MaxRuns > 0 && e.runs[caseID] >= e.cfg.MaxRuns {
+		return false
+	}
+	e.runs[caseID]++
+	return true
+}
+
+// runCollector executes one collector, persists its evidence and reports the
+// entities that ingestion added to the case.
+func (e *Engine) runCollector(ctx context.Context, caseID string, ent *core.Entity, name string) Step {
+	step := Step{Collector: name, EntityType: ent.Type, Target: ent.Value}
+
+	before, err := entityIDs(caseID)
+	if err != nil {
+		step.Err = err
+		return step
+	}
+
+	evidenceList, err := collector.Run(ctx, name, caseID, ent.Value, core.CollectOptions{
+		ActiveAllowed: e.cfg.ActiveAllowed,
+		EntityType:    ent.Type,
+	})
+	if err != nil {
+		step.Err = err
+		return step
+	}
+	step.Evidence = len(evidenceList)
+
+	for _, ev := range evidenceList {
+		if ev.EntityID == "" {
+			ev.EntityID = ent.ID
+		}
+		if err := storage.CreateEvidence(&ev); err != nil {
+			log.Warn().Err(err).Str("collector", name).Msg("Failed to save pivot evidence")
+			continue
+		}
+		if err := storage.IngestEvidence(&ev); err != nil {
+			log.Warn().Err(err).Str("collector", name).Msg("Pivot ingestion failed")
+		}
+	}
+
+	after, err := storage.ListEntitiesByCase(caseID)
+	if err != nil {
+		step.Err = err
+		return step
+	}
+	for _, candidate := range after {
+		if !before[candidate.ID] {
+			step.NewEntities = append(step.NewEntities, candidate)
+		}
+	}
+
+	return step
+}
+
+func entityIDs(caseID string) (map[string]bool, error) {
+	entities, err := storage.ListEntitiesByCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(entities))
+	for _, ent := range entities {
+		ids[ent.ID] = true
+	}
+	return ids, nil
+}
+
+// EnsureSeed returns the case entity for target, creating it if necessary.
+func EnsureSeed(caseID string, target string) (*core.Entity, error) {
+	existing, err := storage.GetEntityByValue(caseID, target)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	seed := &core.Entity{
+		CaseID:     caseID,
+		Type:       core.InferEntityType(target),
+		Value:      target,
+		Source:     "manual",
+		Confidence: 1.0,
+	}
+	if err := storage.CreateEntity(seed); err != nil {
+		return nil, fmt.Errorf("failed to create seed entity: %w", err)
+	}
+	return seed, nil
+}
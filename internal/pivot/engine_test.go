@@ -0,0 +1,126 @@
+package pivot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+// fakeCollector simulates ingestion by creating a fixed entity for every run.
+type fakeCollector struct {
+	name     string
+	produces func(target string) (string, string)
+	calls    int
+}
+
+func (f *fakeCollector) Name() string        { return f.name }
+func (f *fakeCollector) Description() string { return "fake pivot collector" }
+func (f *fakeCollector) IsActive() bool      { return false }
+
+func (f *fakeCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	f.calls++
+	entityType, value := f.produces(target)
+	existing, _ := storage.GetEntityByValue(caseID, value)
+	if existing == nil {
+		if err := storage.CreateEntity(&core.Entity{CaseID: caseID, Type: entityType, Value: value, Source: f.name}); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func setupDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB := storage.DB
+	storage.DB = db
+	t.Cleanup(func() {
+		storage.DB = oldDB
+		db.Close()
+	})
+
+	if err := storage.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.CreateCase(&core.Case{ID: "case-pivot", Name: "Pivot"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEngine_PivotsAndDetectsLoops(t *testing.T) {
+	setupDB(t)
+
+	// domain -> ip -> the same domain again (a resolution loop)
+	resolve := &fakeCollector{name: "test_resolve", produces: func(string) (string, string) { return "ip", "192.0.2.1" }}
+	reverse := &fakeCollector{name: "test_reverse", produces: func(string) (string, string) { return "domain", "example.com" }}
+	collector.Register(resolve)
+	collector.Register(reverse)
+
+	engine := NewEngine(Config{
+		MaxDepth: 5,
+		Rules: map[string][]string{
+			"domain": {"test_resolve"},
+			"ip":     {"test_reverse"},
+		},
+	})
+
+	seed, err := EnsureSeed("case-pivot", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := engine.Run(context.Background(), "case-pivot", seed)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if resolve.calls != 1 || reverse.calls != 1 {
+		t.Errorf("Expected each collector to run once, got resolve=%d reverse=%d", resolve.calls, reverse.calls)
+	}
+	if summary.NewEntities != 1 {
+		t.Errorf("Expected 1 new entity, got %d", summary.NewEntities)
+	}
+}
+
+func TestEngine_RespectsDepthAndBudget(t *testing.T) {
+	setupDB(t)
+
+	// Every run yields a brand new domain, so only depth/budget stop the chain
+	n := 0
+	chain := &fakeCollector{name: "test_chain", produces: func(string) (string, string) {
+		n++
+		return "domain", fmt.Sprintf("hop%d.example.com", n)
+	}}
+	collector.Register(chain)
+
+	rules := map[string][]string{"domain": {"test_chain"}}
+
+	seed, err := EnsureSeed("case-pivot", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := NewEngine(Config{MaxDepth: 2, Rules: rules}).Run(context.Background(), "case-pivot", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Runs != 3 {
+		t.Errorf("Expected 3 runs for depth 0..2, got %d", summary.Runs)
+	}
+
+	summary, err = NewEngine(Config{MaxDepth: 10, MaxRuns: 4, Rules: rules}).Run(context.Background(), "case-pivot", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Runs != 4 || !summary.BudgetExhausted {
+		t.Errorf("Expected budget to stop after 4 runs, got runs=%d exhausted=%v", summary.Runs, summary.BudgetExhausted)
+	}
+}
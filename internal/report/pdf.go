@@ -6,6 +6,7 @@ import (
 
 	"github.com/jung-kurt/gofpdf"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/custody"
 	"github.com/spectre/spectre/internal/storage"
 )
 
@@ -184,6 +185,55 @@ func GeneratePDFReport(caseID string) (string, error) {
 		pdf.Ln(4)
 	}
 
+	// --- Chain of Custody ---
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Chain of Custody")
+	pdf.Ln(15)
+
+	verification, err := custody.Verify(caseID)
+	if err != nil {
+		pdf.SetFont("Arial", "", 11)
+		pdf.MultiCell(0, 6, fmt.Sprintf("Verification could not be performed: %v", err), "", "L", false)
+	} else {
+		status := "VERIFIED"
+		if !verification.OK() {
+			status = "FAILED"
+		}
+		pdf.SetFont("Arial", "B", 12)
+		pdf.Cell(0, 8, fmt.Sprintf("Status: %s", status))
+		pdf.Ln(8)
+		pdf.SetFont("Arial", "", 10)
+		pdf.Cell(0, 6, fmt.Sprintf("Checked at: %s", verification.CheckedAt.Format("2006-01-02 15:04:05 MST")))
+		pdf.Ln(6)
+		pdf.Cell(0, 6, fmt.Sprintf("Custody log entries: %d  |  Evidence files rehashed: %d", verification.Events, verification.FilesChecked))
+		pdf.Ln(10)
+
+		writeIssues := func(title string, issues []custody.Issue) {
+			if len(issues) == 0 {
+				return
+			}
+			pdf.SetFont("Arial", "B", 11)
+			pdf.Cell(0, 8, fmt.Sprintf("%s (%d)", title, len(issues)))
+			pdf.Ln(8)
+			pdf.SetFont("Arial", "", 9)
+			for i, is := range issues {
+				if i == 25 {
+					pdf.MultiCell(0, 5, fmt.Sprintf("... and %d more; run 'spectre evidence verify' for the full list.", len(issues)-i), "", "L", false)
+					break
+				}
+				line := fmt.Sprintf("- [%s] %s", is.Kind, is.Detail)
+				if is.Path != "" {
+					line += fmt.Sprintf(" (%s)", is.Path)
+				}
+				pdf.MultiCell(0, 5, line, "", "L", false)
+			}
+			pdf.Ln(4)
+		}
+		writeIssues("Integrity Failures", verification.Issues)
+		writeIssues("Warnings", verification.Warnings)
+	}
+
 	outfile := fmt.Sprintf("report_%s.pdf", caseID)
 	err = pdf.OutputFileAndClose(outfile)
 	if err != nil {
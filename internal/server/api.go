@@ -0,0 +1,591 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/analysis"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/custody"
+	"github.com/spectre/spectre/internal/ethics"
+	"github.com/spectre/spectre/internal/jobs"
+	"github.com/spectre/spectre/internal/report"
+	"github.com/spectre/spectre/internal/stix"
+	"github.com/spectre/spectre/internal/storage"
+	"github.com/spf13/viper"
+)
+
+// maxBodyBytes bounds the size of JSON request bodies.
+const maxBodyBytes = 1 << 20
+
+// maxBundleBytes bounds the size of uploaded STIX bundles.
+const maxBundleBytes = 32 << 20
+
+type caseHandler func(w http.ResponseWriter, r *http.Request, c *core.Case)
+
+// caseRoutes maps /api/cases/{id}/{resource} and method to a handler.
+// The empty resource is the case itself.
+var caseRoutes = map[string]map[string]caseHandler{
+	"": {
+		http.MethodGet: handleGetCase,
+	},
+	"graph": {
+		http.MethodGet: handleGraph,
+	},
+	"trackers": {
+		http.MethodGet: handleTrackerClusters,
+	},
+	"asns": {
+		http.MethodGet: handleASNClusters,
+	},
+	"stix": {
+		http.MethodGet:  handleExportSTIX,
+		http.MethodPost: writable(handleImportSTIX),
+	},
+	"archive": {
+		http.MethodPost: handleArchiveCase,
+	},
+	"entities": {
+		http.MethodGet:  handleListEntities,
+		http.MethodPost: writable(handleCreateEntity),
+	},
+	"links": {
+		http.MethodGet:  handleListLinks,
+		http.MethodPost: writable(handleCreateLink),
+	},
+	"collect": {
+		http.MethodPost: writable(handleCollect),
+	},
+	"jobs": {
+		http.MethodGet: handleListJobs,
+	},
+	"analysis": {
+		http.MethodGet: handleGetAnalysis,
+	},
+	"analyze": {
+		http.MethodPost: writable(handleAnalyze),
+	},
+	"reports": {
+		http.MethodPost: handleCreateReport,
+	},
+}
+
+// writable rejects changes to archived cases.
+func writable(next caseHandler) caseHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *core.Case) {
+		if c.Status == core.CaseArchived {
+			writeError(w, http.StatusConflict, "Case is archived")
+			return
+		}
+		next(w, r, c)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// decodeJSON strictly decodes a single JSON object into v. An empty body
+// is allowed when optional is true.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}, optional bool) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		if optional && errors.Is(err, io.EOF) {
+			return true
+		}
+		writeError(w, http.StatusBadRequest, "Invalid JSON body: "+err.Error())
+		return false
+	}
+	if dec.More() {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body: unexpected data after object")
+		return false
+	}
+	return true
+}
+
+type createCaseRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func handleCreateCase(w http.ResponseWriter, r *http.Request) {
+	var req createCaseRequest
+	if !decodeJSON(w, r, &req, false) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	c := &core.Case{Name: req.Name, Description: req.Description}
+	if err := storage.CreateCase(c); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	Broadcast(map[string]interface{}{"type": "case_created", "data": c})
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func handleGetCase(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	writeJSON(w, http.StatusOK, c)
+}
+
+func handleGraph(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	data, err := analysis.ExportCaseForViz(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func handleTrackerClusters(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	clusters, err := storage.TrackerClusters(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, clusters)
+}
+
+func handleASNClusters(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	clusters, err := storage.ASNClusters(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, clusters)
+}
+
+func handleExportSTIX(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	b, err := stix.ExportCase(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, b)
+}
+
+func handleImportSTIX(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	b, err := stix.ReadBundle(http.MaxBytesReader(w, r.Body, maxBundleBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := stix.ImportBundle(c.ID, b)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	Broadcast(map[string]interface{}{"type": "stix_imported", "data": map[string]interface{}{"case_id": c.ID, "result": res}})
+	writeJSON(w, http.StatusOK, res)
+}
+
+func handleArchiveCase(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	if err := storage.UpdateCaseStatus(c.ID, core.CaseArchived); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	updated, err := storage.GetCase(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	Broadcast(map[string]interface{}{"type": "case_archived", "data": updated})
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func handleListEntities(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	entities, err := storage.ListEntitiesByCase(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entities == nil {
+		entities = []*core.Entity{}
+	}
+	writeJSON(w, http.StatusOK, entities)
+}
+
+type createEntityRequest struct {
+	Type       string                 `json:"type"`
+	Value      string                 `json:"value"`
+	Confidence float64                `json:"confidence"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+func handleCreateEntity(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	var req createEntityRequest
+	if !decodeJSON(w, r, &req, false) {
+		return
+	}
+	req.Value = strings.TrimSpace(req.Value)
+	if req.Value == "" {
+		writeError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	if req.Confidence < 0 || req.Confidence > 1 {
+		writeError(w, http.StatusBadRequest, "confidence must be between 0 and 1")
+		return
+	}
+	if req.Type == "" {
+		req.Type = core.InferEntityType(req.Value)
+	}
+	if !core.IsEntityType(req.Type) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown entity type %q (valid: %s)", req.Type, strings.Join(core.EntityTypes, ", ")))
+		return
+	}
+	if req.Confidence == 0 {
+		req.Confidence = 1.0
+	}
+
+	existing, err := storage.GetEntityByValue(c.ID, req.Value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Entities are looked up by value alone, so a value may exist only once
+	if existing != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("entity %s already exists as %s (ID: %s)", req.Value, existing.Type, existing.ID))
+		return
+	}
+
+	e := &core.Entity{
+		CaseID:     c.ID,
+		Type:       req.Type,
+		Value:      req.Value,
+		Source:     "manual",
+		Confidence: req.Confidence,
+		Metadata:   req.Metadata,
+	}
+	if err := storage.CreateEntity(e); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, e)
+}
+
+func handleListLinks(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	rels, err := storage.ListRelationshipsByCase(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if rels == nil {
+		rels = []*core.Relationship{}
+	}
+	writeJSON(w, http.StatusOK, rels)
+}
+
+type createLinkRequest struct {
+	From       string  `json:"from"` // Entity ID or value
+	To         string  `json:"to"`   // Entity ID or value
+	Type       string  `json:"type"`
+	Confidence float64 `json:"confidence"`
+}
+
+func handleCreateLink(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	var req createLinkRequest
+	if !decodeJSON(w, r, &req, false) {
+		return
+	}
+	if req.From == "" || req.To == "" || strings.TrimSpace(req.Type) == "" {
+		writeError(w, http.StatusBadRequest, "from, to and type are required")
+		return
+	}
+	if req.Confidence < 0 || req.Confidence > 1 {
+		writeError(w, http.StatusBadRequest, "confidence must be between 0 and 1")
+		return
+	}
+	if req.Confidence == 0 {
+		req.Confidence = 1.0
+	}
+
+	from, err := resolveEntity(c.ID, req.From)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	to, err := resolveEntity(c.ID, req.To)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if from == nil || to == nil {
+		writeError(w, http.StatusNotFound, "from and to must reference entities in this case")
+		return
+	}
+
+	rel := &core.Relationship{
+		CaseID:       c.ID,
+		FromEntityID: from.ID,
+		ToEntityID:   to.ID,
+		Type:         strings.TrimSpace(req.Type),
+		Confidence:   req.Confidence,
+	}
+	if err := storage.CreateRelationship(rel); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	Broadcast(map[string]interface{}{"type": "link_created", "data": rel})
+	writeJSON(w, http.StatusCreated, rel)
+}
+
+// resolveEntity finds an entity of the case by ID, falling back to value.
+func resolveEntity(caseID, ref string) (*core.Entity, error) {
+	e, err := storage.GetEntity(ref)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil && e.CaseID == caseID {
+		return e, nil
+	}
+	return storage.GetEntityByValue(caseID, ref)
+}
+
+type collectRequest struct {
+	Collector  string `json:"collector"` // Collector name or "all"
+	Target     string `json:"target"`
+	EntityType string `json:"entity_type"`
+	Active     bool   `json:"active"`
+	Timeout    string `json:"timeout"` // Go duration, e.g. "30s"
+}
+
+// handleCollect queues collection jobs; the server's worker pool runs them.
+func handleCollect(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	var req collectRequest
+	if !decodeJSON(w, r, &req, false) {
+		return
+	}
+	req.Target = strings.TrimSpace(req.Target)
+	if req.Collector == "" || req.Target == "" {
+		writeError(w, http.StatusBadRequest, "collector and target are required")
+		return
+	}
+
+	opts := core.CollectOptions{ActiveAllowed: req.Active, EntityType: req.EntityType}
+	if opts.EntityType == "" {
+		opts.EntityType = core.InferEntityType(req.Target)
+	}
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d < 0 {
+			writeError(w, http.StatusBadRequest, "timeout must be a positive duration such as 30s")
+			return
+		}
+		opts.Timeout = d
+	}
+
+	if allowed, err := ethics.IsAllowed(req.Target); !allowed {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("safety block: %v", err))
+		return
+	}
+
+	var names []string
+	if req.Collector == "all" {
+		for _, col := range collector.Applicable(opts.EntityType) {
+			if col.IsActive() && !req.Active {
+				continue
+			}
+			names = append(names, col.Name())
+		}
+	} else {
+		col, err := collector.Get(req.Collector)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if col.IsActive() && !req.Active {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("collector '%s' is an active probe; set \"active\": true to run it", col.Name()))
+			return
+		}
+		if !collector.Accepts(col, opts.EntityType) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("collector '%s' does not accept %s targets", col.Name(), opts.EntityType))
+			return
+		}
+		names = []string{col.Name()}
+	}
+	if len(names) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("no collectors accept %s targets", opts.EntityType))
+		return
+	}
+
+	maxAttempts := jobs.LoadConfig().MaxAttempts
+	queued := []*core.Job{}
+	for _, name := range names {
+		job, err := jobs.Enqueue(c.ID, name, req.Target, opts, maxAttempts)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		queued = append(queued, job)
+		Broadcast(map[string]interface{}{"type": "job_queued", "data": job})
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"jobs": queued})
+}
+
+func handleListJobs(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	list, err := storage.ListJobs(c.ID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if list == nil {
+		list = []*core.Job{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func handleGetAnalysis(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	a, err := storage.GetLatestAnalysis(c.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if a == nil {
+		writeError(w, http.StatusNotFound, "No analysis for this case yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+var (
+	analysesMu sync.Mutex
+	analyses   = make(map[string]bool) // case ID -> analysis in progress
+)
+
+type analyzeRequest struct {
+	Model string `json:"model"`
+}
+
+// handleAnalyze starts an AI analysis in the background. The result is
+// broadcast to SSE clients and available from GET .../analysis.
+func handleAnalyze(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	var req analyzeRequest
+	if !decodeJSON(w, r, &req, true) {
+		return
+	}
+	if req.Model == "" {
+		req.Model = viper.GetString("llm.model")
+	}
+	if req.Model == "" {
+		req.Model = "llama3"
+	}
+
+	analysesMu.Lock()
+	if analyses[c.ID] {
+		analysesMu.Unlock()
+		writeError(w, http.StatusConflict, "Analysis already running for this case")
+		return
+	}
+	analyses[c.ID] = true
+	analysesMu.Unlock()
+
+	go func(caseID, model string) {
+		defer func() {
+			analysesMu.Lock()
+			delete(analyses, caseID)
+			analysesMu.Unlock()
+		}()
+
+		result, err := analysis.AnalyzeCase(caseID, model)
+		if err != nil {
+			log.Error().Err(err).Str("case", caseID).Msg("Analysis failed")
+			Broadcast(map[string]interface{}{
+				"type": "analysis_failed",
+				"data": map[string]string{"case_id": caseID, "error": err.Error()},
+			})
+			return
+		}
+		Broadcast(map[string]interface{}{"type": "analysis_completed", "data": result})
+	}(c.ID, req.Model)
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "started", "model": req.Model})
+}
+
+type reportRequest struct {
+	Format string `json:"format"` // markdown (default) or pdf
+}
+
+// handleCreateReport renders a report into the case's evidence folder so it
+// can be downloaded from /evidence/.
+func handleCreateReport(w http.ResponseWriter, r *http.Request, c *core.Case) {
+	var req reportRequest
+	if !decodeJSON(w, r, &req, true) {
+		return
+	}
+	if req.Format == "" || req.Format == "md" {
+		req.Format = "markdown"
+	}
+	if req.Format != "markdown" && req.Format != "pdf" {
+		writeError(w, http.StatusBadRequest, "format must be markdown or pdf")
+		return
+	}
+
+	outputDir := filepath.Join("evidence_storage", c.ID)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var fileName string
+	switch req.Format {
+	case "markdown":
+		md, err := report.GenerateMarkdownReport(c.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		fileName = "investigation_report.md"
+		if err := os.WriteFile(filepath.Join(outputDir, fileName), []byte(md), 0644); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save report: %v", err))
+			return
+		}
+	case "pdf":
+		generated, err := report.GeneratePDFReport(c.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		fileName = "investigation_report.pdf"
+		if err := os.Rename(generated, filepath.Join(outputDir, fileName)); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save report: %v", err))
+			return
+		}
+	}
+
+	if err := custody.RecordExport(c.ID, req.Format, filepath.Join(outputDir, fileName)); err != nil {
+		log.Warn().Err(err).Str("case", c.ID).Msg("Failed to record report export")
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"format": req.Format,
+		"path":   filepath.Join(outputDir, fileName),
+		"url":    "/evidence/" + c.ID + "/" + fileName,
+	})
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+type passiveCollector struct{}
+
+func (p *passiveCollector) Name() string        { return "test_api_passive" }
+func (p *passiveCollector) Description() string { return "passive test collector" }
+func (p *passiveCollector) IsActive() bool      { return false }
+func (p *passiveCollector) Accepts() []string   { return []string{core.EntityDomain} }
+func (p *passiveCollector) Produces() []string  { return nil }
+
+func (p *passiveCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return nil, nil
+}
+
+func setupServer(t *testing.T, opts Options) *httptest.Server {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	oldDB := storage.DB
+	storage.DB = db
+	t.Cleanup(func() {
+		storage.DB = oldDB
+		db.Close()
+	})
+	if err := storage.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(NewHandler(opts))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, method, url, body string, out interface{}) int {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: invalid JSON response: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestAPI_CaseEntityAndLinkLifecycle(t *testing.T) {
+	srv := setupServer(t, Options{AuthDisabled: true})
+
+	if code := do(t, "POST", srv.URL+"/api/cases", `{"name": ""}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for empty name, got %d", code)
+	}
+	if code := do(t, "POST", srv.URL+"/api/cases", `{"name": "x", "bogus": 1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown field, got %d", code)
+	}
+
+	var c core.Case
+	if code := do(t, "POST", srv.URL+"/api/cases", `{"name": "API Case"}`, &c); code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", code)
+	}
+	base := srv.URL + "/api/cases/" + c.ID
+
+	var domain, ip core.Entity
+	if code := do(t, "POST", base+"/entities", `{"value": "example.com"}`, &domain); code != http.StatusCreated {
+		t.Fatalf("Expected 201 for entity, got %d", code)
+	}
+	if domain.Type != core.EntityDomain {
+		t.Errorf("Expected inferred type domain, got %s", domain.Type)
+	}
+	if code := do(t, "POST", base+"/entities", `{"value": "example.com"}`, nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 for duplicate entity, got %d", code)
+	}
+	if code := do(t, "POST", base+"/entities", `{"type": "username", "value": "example.com"}`, nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 for an existing value under another type, got %d", code)
+	}
+	if code := do(t, "POST", base+"/entities", `{"type": "person", "value": "Jane Doe"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown entity type, got %d", code)
+	}
+	do(t, "POST", base+"/entities", `{"type": "ip", "value": "192.0.2.1"}`, &ip)
+
+	var rel core.Relationship
+	if code := do(t, "POST", base+"/links", `{"from": "example.com", "to": "`+ip.ID+`", "type": "resolves_to"}`, &rel); code != http.StatusCreated {
+		t.Fatalf("Expected 201 for link, got %d", code)
+	}
+	if rel.FromEntityID != domain.ID || rel.ToEntityID != ip.ID {
+		t.Errorf("Link resolved to wrong entities: %+v", rel)
+	}
+	if code := do(t, "POST", base+"/links", `{"from": "missing", "to": "example.com", "type": "x"}`, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown entity, got %d", code)
+	}
+
+	var archived core.Case
+	if code := do(t, "POST", base+"/archive", ``, &archived); code != http.StatusOK || archived.Status != core.CaseArchived {
+		t.Fatalf("Expected case to be archived, got %d %+v", code, archived)
+	}
+	if code := do(t, "POST", base+"/entities", `{"value": "other.com"}`, nil); code != http.StatusConflict {
+		t.Errorf("Expected 409 when writing to an archived case, got %d", code)
+	}
+
+	if code := do(t, "GET", srv.URL+"/api/cases/nope/entities", ``, nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown case, got %d", code)
+	}
+	if code := do(t, "DELETE", base, ``, nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", code)
+	}
+}
+
+func TestAPI_CollectQueuesJobs(t *testing.T) {
+	srv := setupServer(t, Options{AuthDisabled: true})
+	collector.Register(&passiveCollector{})
+
+	var c core.Case
+	do(t, "POST", srv.URL+"/api/cases", `{"name": "Collect"}`, &c)
+	base := srv.URL + "/api/cases/" + c.ID
+
+	if code := do(t, "POST", base+"/collect", `{"collector": "test_api_passive", "target": "192.0.2.1"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported target type, got %d", code)
+	}
+	if code := do(t, "POST", base+"/collect", `{"collector": "test_api_passive", "target": "localhost"}`, nil); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a blocked target, got %d", code)
+	}
+
+	var resp struct {
+		Jobs []*core.Job `json:"jobs"`
+	}
+	if code := do(t, "POST", base+"/collect", `{"collector": "test_api_passive", "target": "example.com", "timeout": "30s"}`, &resp); code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", code)
+	}
+	if len(resp.Jobs) != 1 || resp.Jobs[0].Status != core.JobQueued {
+		t.Fatalf("Expected one queued job, got %+v", resp.Jobs)
+	}
+
+	var list []*core.Job
+	if code := do(t, "GET", base+"/jobs", ``, &list); code != http.StatusOK || len(list) != 1 {
+		t.Errorf("Expected the queued job to be listed, got %d %d", code, len(list))
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+const (
+	tokenPrefix = "spt_"
+	cookieName  = "spectre_token"
+)
+
+// GenerateToken creates and stores a new API token. The returned secret is
+// shown once; only its hash is persisted.
+func GenerateToken(name string) (string, *core.APIToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(buf)
+
+	t := &core.APIToken{
+		Name:   name,
+		Hash:   HashToken(secret),
+		Prefix: secret[:len(tokenPrefix)+8],
+	}
+	if err := storage.CreateAPIToken(t); err != nil {
+		return "", nil, err
+	}
+	return secret, t, nil
+}
+
+// NewSessionToken returns a random token that is valid only for the lifetime
+// of one server process (see Options.SessionToken).
+func NewSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return tokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// HashToken returns the hex SHA-256 of a token secret.
+func HashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestToken extracts a token from the Authorization header or the
+// session cookie set by the dashboard login.
+func requestToken(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if scheme, value, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(value)
+		}
+		return ""
+	}
+	if c, err := r.Cookie(cookieName); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// validToken checks a secret against the session token and the token store.
+func (s *apiServer) validToken(secret string) bool {
+	if secret == "" {
+		return false
+	}
+	if s.opts.SessionToken != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(s.opts.SessionToken)) == 1 {
+		return true
+	}
+
+	t, err := storage.GetActiveAPITokenByHash(HashToken(secret))
+	if err != nil {
+		log.Error().Err(err).Msg("Token lookup failed")
+		return false
+	}
+	if t == nil {
+		return false
+	}
+	if err := storage.TouchAPIToken(t.ID); err != nil {
+		log.Warn().Err(err).Msg("Failed to record token use")
+	}
+	return true
+}
+
+// requireAuth rejects requests without a valid token.
+func (s *apiServer) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.opts.AuthDisabled && !s.validToken(requestToken(r)) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="spectre"`)
+			writeError(w, http.StatusUnauthorized, "Missing or invalid API token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleLogin exchanges ?token=... for an HttpOnly cookie so the dashboard
+// (including its EventSource stream) is authenticated without scripts ever
+// seeing the secret again.
+func (s *apiServer) handleLogin(w http.ResponseWriter, r *http.Request) bool {
+	secret := r.URL.Query().Get("token")
+	if secret == "" {
+		return false
+	}
+	if !s.validToken(secret) {
+		writeError(w, http.StatusUnauthorized, "Invalid API token")
+		return true
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName,
+		Value:    secret,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+	return true
+}
+
+// cors only lets same-origin pages and explicitly configured origins use the
+// API from a browser.
+func (s *apiServer) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || sameOrigin(r, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.originAllowed(origin) {
+			writeError(w, http.StatusForbidden, "Origin not allowed")
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Add("Vary", "Origin")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *apiServer) originAllowed(origin string) bool {
+	for _, allowed := range s.opts.AllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameOrigin(r *http.Request, origin string) bool {
+	scheme := "http://"
+	if r.TLS != nil {
+		scheme = "https://"
+	}
+	return strings.EqualFold(origin, scheme+r.Host)
+}
+
+// isLoopback reports whether a bind address only accepts local connections.
+func isLoopback(bind string) bool {
+	if bind == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(bind)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spectre/spectre/internal/storage"
+)
+
+func request(t *testing.T, method, url string, header http.Header) *http.Response {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestAuth_RequiresValidToken(t *testing.T) {
+	srv := setupServer(t, Options{})
+
+	secret, token, err := GenerateToken("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored string
+	storage.DB.QueryRow(`SELECT token_hash FROM api_tokens WHERE id = ?`, token.ID).Scan(&stored)
+	if stored == secret || stored != HashToken(secret) {
+		t.Fatal("Expected only the token hash to be stored")
+	}
+
+	if resp := request(t, "GET", srv.URL+"/api/cases", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", resp.StatusCode)
+	}
+	if resp := request(t, "GET", srv.URL+"/api/cases", http.Header{"Authorization": {"Bearer spt_wrong"}}); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 with a bad token, got %d", resp.StatusCode)
+	}
+	if resp := request(t, "GET", srv.URL+"/api/cases", http.Header{"Authorization": {"Bearer " + secret}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 with a valid token, got %d", resp.StatusCode)
+	}
+
+	// Dashboard login trades the query token for a cookie
+	resp := request(t, "GET", srv.URL+"/?token="+secret, nil)
+	if resp.StatusCode != http.StatusSeeOther || len(resp.Cookies()) != 1 || !resp.Cookies()[0].HttpOnly {
+		t.Fatalf("Expected redirect with an HttpOnly cookie, got %d %v", resp.StatusCode, resp.Cookies())
+	}
+	cookie := resp.Cookies()[0].String()
+	if resp := request(t, "GET", srv.URL+"/api/cases", http.Header{"Cookie": {cookie}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected cookie to authenticate, got %d", resp.StatusCode)
+	}
+
+	if err := storage.RevokeAPIToken(token.Prefix); err != nil {
+		t.Fatal(err)
+	}
+	if resp := request(t, "GET", srv.URL+"/api/cases", http.Header{"Authorization": {"Bearer " + secret}}); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after revocation, got %d", resp.StatusCode)
+	}
+}
+
+func TestCORS_RejectsForeignOrigins(t *testing.T) {
+	srv := setupServer(t, Options{AuthDisabled: true, AllowedOrigins: []string{"https://dashboard.example"}})
+
+	if resp := request(t, "GET", srv.URL+"/api/cases", http.Header{"Origin": {"https://evil.example"}}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a foreign origin, got %d", resp.StatusCode)
+	}
+
+	resp := request(t, "OPTIONS", srv.URL+"/api/cases", http.Header{"Origin": {"https://dashboard.example"}})
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != "https://dashboard.example" {
+		t.Errorf("Expected preflight to allow the configured origin, got %d %q", resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+
+	if resp := request(t, "GET", srv.URL+"/api/cases", http.Header{"Origin": {srv.URL}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected same-origin requests to pass, got %d", resp.StatusCode)
+	}
+}
+
+func TestStart_RefusesUnauthenticatedPublicBind(t *testing.T) {
+	if err := Start(Options{Bind: "0.0.0.0", Port: 0, AuthDisabled: true}); err == nil {
+		t.Error("Expected Start to refuse a public bind without authentication")
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/spectre/spectre/internal/analysis"
+	"github.com/rs/zerolog/log"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/custody"
 	"github.com/spectre/spectre/internal/storage"
 	"github.com/spf13/viper"
 )
@@ -25,18 +30,109 @@ func SetAssets(assets embed.FS) {
 	webAssets = assets
 }
 
+// Options configures the listener, authentication and CORS policy.
+type Options struct {
+	Bind           string // Interface to listen on; loopback by default
+	Port           int
+	TLS            bool // Serve HTTPS, generating a local certificate if needed
+	CertFile       string
+	KeyFile        string
+	AllowedOrigins []string // Extra browser origins allowed to call the API
+	AuthDisabled   bool     // Only permitted on loopback addresses
+	SessionToken   string   // Optional token valid for this process only
+}
+
+// LoadOptions reads the server section of the config.
+func LoadOptions() Options {
+	opts := Options{
+		Bind:           "127.0.0.1",
+		Port:           8080,
+		TLS:            viper.GetBool("server.tls.enabled"),
+		CertFile:       viper.GetString("server.tls.cert"),
+		KeyFile:        viper.GetString("server.tls.key"),
+		AllowedOrigins: viper.GetStringSlice("server.cors.allowed_origins"),
+	}
+	if viper.IsSet("server.bind") {
+		opts.Bind = viper.GetString("server.bind")
+	}
+	if viper.IsSet("server.port") {
+		opts.Port = viper.GetInt("server.port")
+	}
+	if viper.IsSet("server.auth.enabled") {
+		opts.AuthDisabled = !viper.GetBool("server.auth.enabled")
+	}
+	if opts.CertFile == "" {
+		opts.CertFile = "spectre-cert.pem"
+	}
+	if opts.KeyFile == "" {
+		opts.KeyFile = "spectre-key.pem"
+	}
+	return opts
+}
+
+// URL returns the base URL the server is reachable at.
+func (o Options) URL() string {
+	scheme := "http"
+	if o.TLS {
+		scheme = "https"
+	}
+	host := o.Bind
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(o.Port)))
+}
+
+type apiServer struct {
+	opts Options
+}
+
 // Start starts the API server
-func Start(port int) error {
+func Start(opts Options) error {
+	if opts.AuthDisabled && !isLoopback(opts.Bind) {
+		return fmt.Errorf("refusing to listen on %s without authentication; enable server.auth or bind to 127.0.0.1", opts.Bind)
+	}
+
+	// Hook into storage events
+	storage.OnEntityCreated = func(e *core.Entity) {
+		Broadcast(map[string]interface{}{
+			"type": "entity_created",
+			"data": e,
+		})
+	}
+
+	addr := net.JoinHostPort(opts.Bind, strconv.Itoa(opts.Port))
+	srv := &http.Server{Addr: addr, Handler: NewHandler(opts)}
+
+	if opts.TLS {
+		if err := ensureCertificate(opts.CertFile, opts.KeyFile, opts.Bind); err != nil {
+			return err
+		}
+		fmt.Printf("SPECTRE API Server starting on https://%s...\n", addr)
+		return srv.ListenAndServeTLS(opts.CertFile, opts.KeyFile)
+	}
+
+	fmt.Printf("SPECTRE API Server starting on http://%s...\n", addr)
+	return srv.ListenAndServe()
+}
+
+// NewHandler builds the router for the API, evidence files and web UI.
+// Everything except the dashboard page itself requires a token.
+func NewHandler(opts Options) http.Handler {
+	s := &apiServer{opts: opts}
 	mux := http.NewServeMux()
 
 	// API Routes
-	mux.HandleFunc("/api/cases", handleCases)
-	mux.HandleFunc("/api/cases/", handleCaseDetail) // /api/cases/{id} and /api/cases/{id}/graph
-	mux.HandleFunc("/api/events", handleEvents)
-	mux.HandleFunc("/api/settings", handleSettings)
+	mux.Handle("/api/cases", s.requireAuth(http.HandlerFunc(handleCases)))
+	mux.Handle("/api/cases/", s.requireAuth(http.HandlerFunc(handleCaseDetail))) // /api/cases/{id}[/{resource}]
+	mux.Handle("/api/events", s.requireAuth(http.HandlerFunc(handleEvents)))
+	mux.Handle("/api/settings", s.requireAuth(http.HandlerFunc(handleSettings)))
 
 	// Static Assets
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" && s.handleLogin(w, r) {
+			return
+		}
 		if !strings.HasPrefix(r.URL.Path, "/api") && !strings.HasPrefix(r.URL.Path, "/evidence") {
 			data, err := webAssets.ReadFile("web/index.html")
 			if err != nil {
@@ -54,25 +150,29 @@ func Start(port int) error {
 
 	// Serve Evidence Files
 	fs := http.FileServer(http.Dir("evidence_storage"))
-	mux.Handle("/evidence/", http.StripPrefix("/evidence/", fs))
+	mux.Handle("/evidence/", s.requireAuth(http.StripPrefix("/evidence/", recordViews(fs))))
 
-	// Hook into storage events
-	storage.OnEntityCreated = func(e *core.Entity) {
-		Broadcast(map[string]interface{}{
-			"type": "entity_created",
-			"data": e,
-		})
-	}
+	return s.cors(mux)
+}
 
-	fmt.Printf("SPECTRE API Server starting on :%d...\n", port)
-	return http.ListenAndServe(fmt.Sprintf(":%d", port), mux)
+// recordViews adds a custody entry whenever an evidence file is served.
+func recordViews(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if r.Method != http.MethodGet {
+			return
+		}
+		file := filepath.Join("evidence_storage", filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		if err := custody.RecordView(file, map[string]string{"via": "api", "remote_addr": r.RemoteAddr}); err != nil {
+			log.Warn().Err(err).Str("path", file).Msg("Failed to record evidence view")
+		}
+	})
 }
 
 func handleEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	messageChan := make(chan interface{})
 	clientsMu.Lock()
@@ -119,55 +219,55 @@ func Broadcast(msg interface{}) {
 }
 
 func handleCases(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	cases, err := storage.ListCases()
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	switch r.Method {
+	case http.MethodGet:
+		cases, err := storage.ListCases()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, cases)
+	case http.MethodPost:
+		handleCreateCase(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cases)
 }
 
 func handleCaseDetail(w http.ResponseWriter, r *http.Request) {
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 4 {
-		http.Error(w, "Invalid path", http.StatusBadRequest)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[2] == "" || len(parts) > 4 {
+		writeError(w, http.StatusBadRequest, "Invalid path")
 		return
 	}
 
-	caseID := parts[3]
-
-	// Check if it's a graph request
-	if len(parts) > 4 && parts[4] == "graph" {
-		data, err := analysis.ExportCaseForViz(caseID)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(data)
-		return
+	caseID := parts[2]
+	resource := ""
+	if len(parts) == 4 {
+		resource = parts[3]
 	}
 
-	// Normal case detail
 	c, err := storage.GetCase(caseID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	if c == nil {
-		http.Error(w, "Case not found", http.StatusNotFound)
+		writeError(w, http.StatusNotFound, "Case not found")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(c)
+	route := caseRoutes[resource]
+	if route == nil {
+		writeError(w, http.StatusNotFound, "Unknown case resource")
+		return
+	}
+	handler, ok := route[r.Method]
+	if !ok {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	handler(w, r, c)
 }
 
 func handleSettings(w http.ResponseWriter, r *http.Request) {
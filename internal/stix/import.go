@@ -0,0 +1,186 @@
+package stix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+// ImportResult counts what an import added to a case.
+type ImportResult struct {
+	Entities      int `json:"entities"`      // Newly created entities
+	Relationships int `json:"relationships"` // Newly created relationships
+	Skipped       int `json:"skipped"`       // Objects with no entity or relationship mapping
+}
+
+// ReadBundle decodes a STIX bundle.
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("invalid STIX bundle: %w", err)
+	}
+	if b.Type != "bundle" {
+		return nil, fmt.Errorf("invalid STIX bundle: type is %q, expected \"bundle\"", b.Type)
+	}
+	return &b, nil
+}
+
+// ImportBundle adds the observables and relationships of b to an existing
+// case. Entities already in the case are reused, so importing the same
+// bundle twice changes nothing.
+func ImportBundle(caseID string, b *Bundle) (*ImportResult, error) {
+	c, err := storage.GetCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("case not found: %s", caseID)
+	}
+
+	res := &ImportResult{}
+	entityIDs := make(map[string]string) // STIX ID -> entity ID
+	var sros, embedded []Object
+
+	for _, obj := range b.Objects {
+		switch obj.Type() {
+		case TypeRelationship:
+			sros = append(sros, obj)
+			continue
+		case TypeIdentity, TypeGrouping:
+			continue
+		}
+
+		e := scoToEntity(obj)
+		if e == nil {
+			res.Skipped++
+			continue
+		}
+		e.CaseID = caseID
+
+		existing, err := storage.GetEntityByValue(caseID, e.Value)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			if err := storage.CreateEntity(e); err != nil {
+				return nil, err
+			}
+			res.Entities++
+		} else {
+			e = existing
+		}
+		entityIDs[obj.ID()] = e.ID
+
+		if _, ok := obj["resolves_to_refs"]; ok {
+			embedded = append(embedded, obj)
+		}
+	}
+
+	for _, sro := range sros {
+		from, to := entityIDs[sro.str("source_ref")], entityIDs[sro.str("target_ref")]
+		relType := strings.ReplaceAll(sro.str("relationship_type"), "-", "_")
+		if from == "" || to == "" || relType == "" {
+			res.Skipped++
+			continue
+		}
+		confidence := 0.5
+		if v, ok := sro["confidence"].(float64); ok && v >= 0 && v <= 100 {
+			confidence = v / 100
+		}
+		created, err := link(caseID, from, to, relType, confidence)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			res.Relationships++
+		}
+	}
+
+	// Producers following the STIX 2.1 examples embed resolutions in the
+	// domain-name object instead of emitting SROs.
+	for _, obj := range embedded {
+		refs, _ := obj["resolves_to_refs"].([]interface{})
+		for _, ref := range refs {
+			s, _ := ref.(string)
+			to := entityIDs[s]
+			if to == "" {
+				continue
+			}
+			created, err := link(caseID, entityIDs[obj.ID()], to, "resolves_to", 1.0)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				res.Relationships++
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// link creates a relationship, reporting false if it already exists.
+func link(caseID, from, to, relType string, confidence float64) (bool, error) {
+	rel := &core.Relationship{
+		CaseID:       caseID,
+		FromEntityID: from,
+		ToEntityID:   to,
+		Type:         relType,
+		Confidence:   confidence,
+	}
+	if err := storage.CreateRelationship(rel); err != nil {
+		if storage.IsDuplicate(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// scoToEntity maps a Cyber-observable back to an entity, or returns nil for
+// objects SPECTRE has no entity type for.
+func scoToEntity(obj Object) *core.Entity {
+	e := &core.Entity{Source: "stix", Confidence: 0.5}
+
+	switch obj.Type() {
+	case TypeDomainName:
+		e.Type, e.Value = core.EntityDomain, obj.str("value")
+	case TypeIPv4, TypeIPv6:
+		e.Type, e.Value = core.EntityIP, obj.str("value")
+	case TypeEmailAddr:
+		e.Type, e.Value = core.EntityEmail, obj.str("value")
+	case TypeURL:
+		e.Type, e.Value = core.EntityURL, obj.str("value")
+	case TypeUserAccount:
+		e.Type, e.Value = core.EntityAccount, obj.str("account_login")
+		if e.Value == "" {
+			e.Value = obj.str("user_id")
+		}
+		if platform := obj.str("account_type"); platform != "" {
+			e.Metadata = map[string]interface{}{"platform": platform}
+		}
+	case TypeCustomEntity:
+		e.Type, e.Value = obj.str("x_spectre_type"), obj.str("value")
+	default:
+		return nil
+	}
+	if e.Value == "" || !core.IsEntityType(e.Type) {
+		return nil
+	}
+
+	// Types the rest of spectre does not know keep the one derived above
+	if t := obj.str("x_spectre_type"); core.IsEntityType(t) {
+		e.Type = t
+	}
+	if v, ok := obj["x_spectre_confidence"].(float64); ok && v > 0 && v <= 1 {
+		e.Confidence = v
+	}
+	if s := obj.str("x_spectre_source"); s != "" {
+		e.Source = s
+	}
+	return e
+}
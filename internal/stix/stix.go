@@ -0,0 +1,217 @@
+// Package stix converts case graphs to and from STIX 2.1 bundles.
+//
+// Entities become STIX Cyber-observable Objects with deterministic IDs, so
+// the same domain or address exported from two cases gets the same STIX ID.
+// Relationships become SROs. Entity types without a STIX equivalent are
+// exported as x-spectre-entity custom objects so they survive a round trip.
+package stix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+// SpecVersion is the STIX version written to every object.
+const SpecVersion = "2.1"
+
+// scoNamespace is the UUIDv5 namespace STIX 2.1 defines for deterministic
+// Cyber-observable IDs.
+var scoNamespace = uuid.MustParse("00abedb4-aa42-466c-9c01-fed23315a9b7")
+
+// STIX object types used by the mapping.
+const (
+	TypeDomainName   = "domain-name"
+	TypeIPv4         = "ipv4-addr"
+	TypeIPv6         = "ipv6-addr"
+	TypeEmailAddr    = "email-addr"
+	TypeUserAccount  = "user-account"
+	TypeURL          = "url"
+	TypeRelationship = "relationship"
+	TypeGrouping     = "grouping"
+	TypeIdentity     = "identity"
+	TypeCustomEntity = "x-spectre-entity"
+)
+
+// Object is a STIX object. Objects are heterogeneous, so they are kept as
+// property maps.
+type Object map[string]interface{}
+
+// Type returns the object's STIX type.
+func (o Object) Type() string { return o.str("type") }
+
+// ID returns the object's STIX identifier.
+func (o Object) ID() string { return o.str("id") }
+
+func (o Object) str(key string) string {
+	s, _ := o[key].(string)
+	return s
+}
+
+// Bundle is a STIX 2.1 bundle.
+type Bundle struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Objects []Object `json:"objects"`
+}
+
+// ExportCase builds a STIX bundle from a case's entities and relationships.
+// A grouping object named after the case references every exported object.
+func ExportCase(caseID string) (*Bundle, error) {
+	c, err := storage.GetCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("case not found: %s", caseID)
+	}
+
+	entities, err := storage.ListEntitiesByCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+	rels, err := storage.ListRelationshipsByCase(caseID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timestamp(time.Now())
+	identity := Object{
+		"type":           TypeIdentity,
+		"spec_version":   SpecVersion,
+		"id":             "identity--" + uuid.NewSHA1(scoNamespace, []byte("spectre")).String(),
+		"created":        timestamp(time.Unix(0, 0)),
+		"modified":       timestamp(time.Unix(0, 0)),
+		"name":           "SPECTRE",
+		"identity_class": "system",
+	}
+	b := &Bundle{Type: "bundle", ID: "bundle--" + uuid.New().String(), Objects: []Object{identity}}
+
+	stixIDs := make(map[string]string)
+	var refs []string
+	for _, e := range entities {
+		obj, err := entityToSCO(e)
+		if err != nil {
+			return nil, err
+		}
+		stixIDs[e.ID] = obj.ID()
+		b.Objects = append(b.Objects, obj)
+		refs = append(refs, obj.ID())
+	}
+
+	for _, r := range rels {
+		from, to := stixIDs[r.FromEntityID], stixIDs[r.ToEntityID]
+		if from == "" || to == "" {
+			continue
+		}
+		sro := Object{
+			"type":              TypeRelationship,
+			"spec_version":      SpecVersion,
+			"id":                "relationship--" + uuid.NewSHA1(scoNamespace, []byte(r.ID)).String(),
+			"created":           timestamp(r.DiscoveredAt),
+			"modified":          timestamp(r.DiscoveredAt),
+			"created_by_ref":    identity.ID(),
+			"relationship_type": strings.ReplaceAll(r.Type, "_", "-"),
+			"source_ref":        from,
+			"target_ref":        to,
+			"confidence":        int(math.Round(r.Confidence * 100)),
+		}
+		b.Objects = append(b.Objects, sro)
+		refs = append(refs, sro.ID())
+	}
+
+	if len(refs) > 0 {
+		b.Objects = append(b.Objects, Object{
+			"type":           TypeGrouping,
+			"spec_version":   SpecVersion,
+			"id":             "grouping--" + uuid.NewSHA1(scoNamespace, []byte(c.ID)).String(),
+			"created":        timestamp(c.CreatedAt),
+			"modified":       now,
+			"created_by_ref": identity.ID(),
+			"name":           c.Name,
+			"description":    c.Description,
+			"context":        "unspecified",
+			"object_refs":    refs,
+		})
+	}
+
+	return b, nil
+}
+
+// entityToSCO maps an entity to a Cyber-observable with a deterministic ID
+// derived from its ID-contributing properties.
+func entityToSCO(e *core.Entity) (Object, error) {
+	obj := Object{"spec_version": SpecVersion}
+	idProps := map[string]interface{}{}
+
+	switch e.Type {
+	case core.EntityDomain:
+		obj["type"], obj["value"] = TypeDomainName, e.Value
+		idProps["value"] = e.Value
+	case core.EntityIP:
+		obj["type"] = TypeIPv4
+		if ip := net.ParseIP(e.Value); ip != nil && ip.To4() == nil {
+			obj["type"] = TypeIPv6
+		}
+		obj["value"] = e.Value
+		idProps["value"] = e.Value
+	case core.EntityEmail:
+		obj["type"], obj["value"] = TypeEmailAddr, e.Value
+		idProps["value"] = e.Value
+	case core.EntityURL:
+		obj["type"], obj["value"] = TypeURL, e.Value
+		idProps["value"] = e.Value
+	case core.EntityAccount, core.EntityUsername:
+		obj["type"], obj["account_login"] = TypeUserAccount, e.Value
+		idProps["account_login"] = e.Value
+		if platform, ok := e.Metadata["platform"].(string); ok && platform != "" {
+			obj["account_type"] = strings.ToLower(platform)
+			idProps["account_type"] = obj["account_type"]
+		}
+	default:
+		obj["type"], obj["value"] = TypeCustomEntity, e.Value
+		idProps["entity_type"], idProps["value"] = e.Type, e.Value
+	}
+
+	// Keep the original type and scoring so imports restore them exactly
+	obj["x_spectre_type"] = e.Type
+	obj["x_spectre_confidence"] = e.Confidence
+	if e.Source != "" {
+		obj["x_spectre_source"] = e.Source
+	}
+
+	canonical, err := canonicalJSON(idProps)
+	if err != nil {
+		return nil, err
+	}
+	obj["id"] = obj.Type() + "--" + uuid.NewSHA1(scoNamespace, canonical).String()
+	return obj, nil
+}
+
+// canonicalJSON serialises flat string maps the way RFC 8785 does: sorted
+// keys, no insignificant whitespace and no HTML escaping.
+func canonicalJSON(v map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode STIX ID properties: %w", err)
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// timestamp formats t as a STIX timestamp (UTC, millisecond precision).
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,176 @@
+package stix
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
+)
+
+func setupDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB := storage.DB
+	storage.DB = db
+	t.Cleanup(func() {
+		storage.DB = oldDB
+		db.Close()
+	})
+	if err := storage.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEntityToSCO_DeterministicIDs(t *testing.T) {
+	// UUIDv5 over {"value":"john@example.com"} in the STIX SCO namespace
+	obj, err := entityToSCO(&core.Entity{Type: core.EntityEmail, Value: "john@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.ID() != "email-addr--7165e2a9-671f-585d-b1e1-ca59c671d934" {
+		t.Errorf("Unexpected email-addr ID %s", obj.ID())
+	}
+
+	v6, _ := entityToSCO(&core.Entity{Type: core.EntityIP, Value: "2001:db8::1"})
+	if v6.Type() != TypeIPv6 {
+		t.Errorf("Expected ipv6-addr, got %s", v6.Type())
+	}
+	svc, _ := entityToSCO(&core.Entity{Type: core.EntityService, Value: "192.0.2.1:22/ssh"})
+	if svc.Type() != TypeCustomEntity || svc["x_spectre_type"] != core.EntityService {
+		t.Errorf("Expected an x-spectre-entity for services, got %+v", svc)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	setupDB(t)
+
+	src := &core.Case{Name: "Source"}
+	storage.CreateCase(src)
+	domain := &core.Entity{CaseID: src.ID, Type: core.EntityDomain, Value: "example.com", Confidence: 0.9}
+	ip := &core.Entity{CaseID: src.ID, Type: core.EntityIP, Value: "192.0.2.1"}
+	svc := &core.Entity{CaseID: src.ID, Type: core.EntityService, Value: "192.0.2.1:443/https"}
+	for _, e := range []*core.Entity{domain, ip, svc} {
+		storage.CreateEntity(e)
+	}
+	storage.CreateRelationship(&core.Relationship{CaseID: src.ID, FromEntityID: domain.ID, ToEntityID: ip.ID, Type: "resolves_to", Confidence: 0.8})
+
+	b, err := ExportCase(src.ID)
+	if err != nil {
+		t.Fatalf("ExportCase failed: %v", err)
+	}
+	// identity, three observables, one relationship, one grouping
+	if len(b.Objects) != 6 {
+		t.Fatalf("Expected 6 objects, got %d", len(b.Objects))
+	}
+	var sro Object
+	for _, obj := range b.Objects {
+		if obj.Type() == TypeRelationship {
+			sro = obj
+		}
+	}
+	if sro["relationship_type"] != "resolves-to" || sro["confidence"] != 80 {
+		t.Errorf("Unexpected SRO: %+v", sro)
+	}
+
+	data, _ := json.Marshal(b)
+	parsed, err := ReadBundle(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := &core.Case{Name: "Destination"}
+	storage.CreateCase(dst)
+	res, err := ImportBundle(dst.ID, parsed)
+	if err != nil {
+		t.Fatalf("ImportBundle failed: %v", err)
+	}
+	if res.Entities != 3 || res.Relationships != 1 || res.Skipped != 0 {
+		t.Errorf("Unexpected import result: %+v", res)
+	}
+
+	imported, _ := storage.GetEntityByValue(dst.ID, "example.com")
+	if imported == nil || imported.Type != core.EntityDomain || imported.Confidence != 0.9 {
+		t.Errorf("Domain not restored: %+v", imported)
+	}
+	if s, _ := storage.GetEntityByValue(dst.ID, svc.Value); s == nil || s.Type != core.EntityService {
+		t.Errorf("Service not restored from custom object: %+v", s)
+	}
+
+	again, err := ImportBundle(dst.ID, parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Entities != 0 || again.Relationships != 0 {
+		t.Errorf("Re-importing should not add anything, got %+v", again)
+	}
+}
+
+func TestImportEmbeddedResolutions(t *testing.T) {
+	setupDB(t)
+	c := &core.Case{Name: "Foreign"}
+	storage.CreateCase(c)
+
+	bundle := `{"type": "bundle", "id": "bundle--1", "objects": [
+		{"type": "ipv4-addr", "spec_version": "2.1", "id": "ipv4-addr--a", "value": "198.51.100.3"},
+		{"type": "domain-name", "spec_version": "2.1", "id": "domain-name--b", "value": "example.org", "resolves_to_refs": ["ipv4-addr--a"]},
+		{"type": "malware", "spec_version": "2.1", "id": "malware--c", "name": "x", "is_family": false}
+	]}`
+	b, err := ReadBundle(strings.NewReader(bundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ImportBundle(c.ID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Entities != 2 || res.Relationships != 1 || res.Skipped != 1 {
+		t.Errorf("Unexpected import result: %+v", res)
+	}
+
+	// Failures other than duplicates are reported, not counted as existing
+	if _, err := storage.DB.Exec(`CREATE TRIGGER links_down BEFORE INSERT ON relationships BEGIN SELECT RAISE(ABORT, 'disk I/O error'); END`); err != nil {
+		t.Fatal(err)
+	}
+	storage.DB.Exec(`DELETE FROM relationships`)
+	if _, err := ImportBundle(c.ID, b); err == nil {
+		t.Error("Expected a failed relationship insert to fail the import")
+	}
+
+	if _, err := ReadBundle(strings.NewReader(`{"type": "indicator"}`)); err == nil {
+		t.Error("Expected a non-bundle document to be rejected")
+	}
+}
+
+func TestImportIgnoresUnknownSpectreTypes(t *testing.T) {
+	setupDB(t)
+	c := &core.Case{Name: "Crafted"}
+	storage.CreateCase(c)
+
+	bundle := `{"type": "bundle", "id": "bundle--1", "objects": [
+		{"type": "domain-name", "spec_version": "2.1", "id": "domain-name--a", "value": "example.org", "x_spectre_type": "<script>"},
+		{"type": "x-spectre-entity", "spec_version": "2.1", "id": "x-spectre-entity--b", "value": "anything", "x_spectre_type": "bogus"}
+	]}`
+	b, err := ReadBundle(strings.NewReader(bundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ImportBundle(c.ID, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Entities != 1 || res.Skipped != 1 {
+		t.Errorf("Unexpected import result: %+v", res)
+	}
+	if e, _ := storage.GetEntityByValue(c.ID, "example.org"); e == nil || e.Type != core.EntityDomain {
+		t.Errorf("Expected the SCO's own type, got %+v", e)
+	}
+	if e, _ := storage.GetEntityByValue(c.ID, "anything"); e != nil {
+		t.Errorf("Expected the unknown custom type to be skipped, got %+v", e)
+	}
+}
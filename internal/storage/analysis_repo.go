@@ -15,7 +15,10 @@ func SaveAnalysis(a *core.Analysis) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
+	return saveAnalysis(DB, a)
+}
 
+func saveAnalysis(w dbWriter, a *core.Analysis) error {
 	if a.ID == "" {
 		a.ID = uuid.New().String()
 	}
@@ -30,7 +33,7 @@ func SaveAnalysis(a *core.Analysis) error {
 
 	query := `INSERT INTO analyses (id, case_id, context_hash, findings, risks, connections, next_steps, confidence, analyzed_at) 
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := DB.Exec(query, a.ID, a.CaseID, a.ContextHash, string(findingsJSON), string(risksJSON), string(connectionsJSON), string(nextStepsJSON), a.Confidence, a.AnalyzedAt)
+	_, err := w.Exec(query, a.ID, a.CaseID, a.ContextHash, string(findingsJSON), string(risksJSON), string(connectionsJSON), string(nextStepsJSON), a.Confidence, a.AnalyzedAt)
 	if err != nil {
 		return fmt.Errorf("failed to save analysis: %w", err)
 	}
@@ -104,4 +107,38 @@ func GetAnalysisByHash(caseID string, hash string) (*core.Analysis, error) {
 	json.Unmarshal([]byte(nextStepsStr), &a.NextSteps)
 
 	return &a, nil
-}
\ No newline at end of file
+}
+// ListAnalysesByCase retrieves every analysis of a case, oldest first.
+func ListAnalysesByCase(caseID string) ([]*core.Analysis, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT id, case_id, context_hash, findings, risks, connections, next_steps, confidence, analyzed_at 
+	          FROM analyses WHERE case_id = ? ORDER BY analyzed_at`
+	rows, err := DB.Query(query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analyses: %w", err)
+	}
+	defer rows.Close()
+
+	var analyses []*core.Analysis
+	for rows.Next() {
+		var a core.Analysis
+		var contextHash sql.NullString
+		var findingsStr, risksStr, connStr, nextStepsStr string
+
+		if err := rows.Scan(&a.ID, &a.CaseID, &contextHash, &findingsStr, &risksStr, &connStr, &nextStepsStr, &a.Confidence, &a.AnalyzedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan analysis: %w", err)
+		}
+		a.ContextHash = contextHash.String
+
+		json.Unmarshal([]byte(findingsStr), &a.Findings)
+		json.Unmarshal([]byte(risksStr), &a.Risks)
+		json.Unmarshal([]byte(connStr), &a.Connections)
+		json.Unmarshal([]byte(nextStepsStr), &a.NextSteps)
+		analyses = append(analyses, &a)
+	}
+
+	return analyses, nil
+}
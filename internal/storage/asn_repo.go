@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// ASNClusters groups the addresses of a case by the AS announcing them,
+// following ip -> netblock (contained_in) -> asn (announced_by), and adds
+// the domains resolving to each address. It shows which hosting providers
+// a target's infrastructure sits on. ASes with the most addresses come
+// first.
+func ASNClusters(caseID string) ([]core.ASNCluster, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT a.value, a.metadata, n.value, i.value FROM relationships c
+	          JOIN relationships b ON b.from_entity = c.to_entity AND b.rel_type = ?
+	          JOIN entities i ON i.id = c.from_entity
+	          JOIN entities n ON n.id = c.to_entity
+	          JOIN entities a ON a.id = b.to_entity
+	          WHERE c.case_id = ? AND c.rel_type = ? AND i.type = ? AND a.type = ?`
+	rows, err := DB.Query(query, RelAnnouncedBy, caseID, RelContainedIn, core.EntityIP, core.EntityASN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ASNs: %w", err)
+	}
+	defer rows.Close()
+
+	byASN := make(map[string]*core.ASNCluster)
+	netblocks := make(map[string]map[string]bool)
+	ips := make(map[string]map[string]bool)
+	asnsByIP := make(map[string][]string)
+	for rows.Next() {
+		var asn, metaJSON, block, ip string
+		if err := rows.Scan(&asn, &metaJSON, &block, &ip); err != nil {
+			return nil, fmt.Errorf("failed to scan ASN: %w", err)
+		}
+		if byASN[asn] == nil {
+			c := &core.ASNCluster{ASN: asn}
+			var meta map[string]interface{}
+			if json.Unmarshal([]byte(metaJSON), &meta) == nil {
+				c.Name, _ = meta["as_name"].(string)
+			}
+			byASN[asn] = c
+			netblocks[asn] = make(map[string]bool)
+			ips[asn] = make(map[string]bool)
+		}
+		netblocks[asn][block] = true
+		if !ips[asn][ip] {
+			ips[asn][ip] = true
+			asnsByIP[ip] = append(asnsByIP[ip], asn)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query ASNs: %w", err)
+	}
+	rows, err = DB.Query(`SELECT d.value, i.value FROM relationships r
+	          JOIN entities d ON d.id = r.from_entity
+	          JOIN entities i ON i.id = r.to_entity
+	          WHERE r.case_id = ? AND r.rel_type = ?`, caseID, RelResolvesTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resolutions: %w", err)
+	}
+	defer rows.Close()
+
+	domains := make(map[string]map[string]bool)
+	for rows.Next() {
+		var domain, ip string
+		if err := rows.Scan(&domain, &ip); err != nil {
+			return nil, fmt.Errorf("failed to scan resolution: %w", err)
+		}
+		for _, asn := range asnsByIP[ip] {
+			if domains[asn] == nil {
+				domains[asn] = make(map[string]bool)
+			}
+			domains[asn][domain] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query resolutions: %w", err)
+	}
+
+	clusters := make([]core.ASNCluster, 0, len(byASN))
+	for asn, c := range byASN {
+		c.Netblocks = sortedKeys(netblocks[asn])
+		c.IPs = sortedKeys(ips[asn])
+		c.Domains = sortedKeys(domains[asn])
+		clusters = append(clusters, *c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].IPs) != len(clusters[j].IPs) {
+			return len(clusters[i].IPs) > len(clusters[j].IPs)
+		}
+		return clusters[i].ASN < clusters[j].ASN
+	})
+	return clusters, nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
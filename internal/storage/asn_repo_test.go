@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestASNClusters(t *testing.T) {
+	setupIngestDB(t)
+
+	hosting := core.ASNInfo{Number: 64497, Name: "EXAMPLE-HOSTING"}
+	ingestResult(t, "asn", &core.ASNResult{IP: "198.51.100.10", Prefix: "198.51.100.0/24", Origins: []core.ASNInfo{hosting}})
+	ingestResult(t, "asn", &core.ASNResult{IP: "198.51.101.20", Prefix: "198.51.101.0/24", Origins: []core.ASNInfo{hosting}})
+	ingestResult(t, "asn", &core.ASNResult{IP: "192.0.2.5", Prefix: "192.0.2.0/24", Origins: []core.ASNInfo{{Number: 64496}}})
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "dns", Metadata: map[string]interface{}{}}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	for domain, addr := range map[string]string{"a.example": "198.51.100.10", "b.example": "198.51.101.20"} {
+		d, _ := ensureEntity("case-ingest", core.EntityDomain, domain, "dns", nil)
+		ip, _ := GetEntityByValue("case-ingest", addr)
+		link(ev, d, ip, RelResolvesTo, 1.0)
+	}
+
+	clusters, err := ASNClusters("case-ingest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(clusters)
+	want := "[{AS64497 EXAMPLE-HOSTING [198.51.100.0/24 198.51.101.0/24] [198.51.100.10 198.51.101.20] [a.example b.example]} {AS64496  [192.0.2.0/24] [192.0.2.5] []}]"
+	if got != want {
+		t.Errorf("ASNClusters =\n%s\nwant\n%s", got, want)
+	}
+}
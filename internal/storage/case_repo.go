@@ -14,7 +14,10 @@ func CreateCase(c *core.Case) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
+	return createCase(DB, c)
+}
 
+func createCase(w dbWriter, c *core.Case) error {
 	if c.ID == "" {
 		c.ID = uuid.New().String()
 	}
@@ -25,11 +28,11 @@ func CreateCase(c *core.Case) error {
 		c.UpdatedAt = time.Now()
 	}
 	if c.Status == "" {
-		c.Status = "active"
+		c.Status = core.CaseActive
 	}
 
 	query := `INSERT INTO cases (id, name, description, created_at, updated_at, status) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := DB.Exec(query, c.ID, c.Name, c.Description, c.CreatedAt, c.UpdatedAt, c.Status)
+	_, err := w.Exec(query, c.ID, c.Name, c.Description, c.CreatedAt, c.UpdatedAt, c.Status)
 	if err != nil {
 		return fmt.Errorf("failed to create case: %w", err)
 	}
@@ -82,3 +85,21 @@ func ListCases() ([]*core.Case, error) {
 
 	return cases, nil
 }
+
+// UpdateCaseStatus changes the status of a case (e.g. to archived).
+func UpdateCaseStatus(id string, status string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	query := `UPDATE cases SET status = ?, updated_at = ? WHERE id = ?`
+	res, err := DB.Exec(query, status, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update case: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("case %s not found", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spectre/spectre/internal/core"
+	netclient "github.com/spectre/spectre/internal/http"
+)
+
+// custodyMu serialises appends within this process so concurrent collectors
+// do not race for the same sequence number.
+var custodyMu sync.Mutex
+
+// RecordCustody appends a custody event for the current user, build and
+// outbound proxy.
+func RecordCustody(caseID, evidenceID, action, fileHash string, details map[string]string) error {
+	return AppendCustodyEvent(newCustodyEvent(caseID, evidenceID, action, fileHash, details))
+}
+
+func newCustodyEvent(caseID, evidenceID, action, fileHash string, details map[string]string) *core.CustodyEvent {
+	return &core.CustodyEvent{
+		CaseID:     caseID,
+		EvidenceID: evidenceID,
+		Action:     action,
+		Actor:      currentActor(),
+		Tool:       "spectre/" + core.Version,
+		Proxy:      netclient.ProxyDescription(),
+		FileHash:   fileHash,
+		Details:    details,
+	}
+}
+
+// dbWriter is the part of *sql.DB and *sql.Tx used to store rows, so the
+// same code writes directly or inside a transaction.
+type dbWriter interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// AppendCustodyEvent links e to the last event of its case and stores it.
+// Seq, PrevHash, Hash and (if unset) Timestamp are filled in.
+func AppendCustodyEvent(e *core.CustodyEvent) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	custodyMu.Lock()
+	defer custodyMu.Unlock()
+	return appendCustodyEvent(DB, e)
+}
+
+// appendCustodyEvent does the work of AppendCustodyEvent through w. The
+// caller holds custodyMu.
+func appendCustodyEvent(w dbWriter, e *core.CustodyEvent) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+
+	detailsJSON, err := json.Marshal(e.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal custody details: %w", err)
+	}
+
+	// Another process may append between our read and insert; the
+	// UNIQUE(case_id, seq) constraint catches that and we retry.
+	for attempt := 0; ; attempt++ {
+		var lastSeq int64
+		var lastHash string
+		err := w.QueryRow(`SELECT seq, hash FROM custody_events WHERE case_id = ? ORDER BY seq DESC LIMIT 1`, e.CaseID).Scan(&lastSeq, &lastHash)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to read custody chain: %w", err)
+		}
+
+		e.Seq = lastSeq + 1
+		e.PrevHash = lastHash
+		e.Hash = e.ComputeHash()
+
+		query := `INSERT INTO custody_events (id, case_id, seq, evidence_id, action, actor, tool, proxy, file_hash, details, timestamp, prev_hash, hash)
+		          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = w.Exec(query, e.ID, e.CaseID, e.Seq, e.EvidenceID, e.Action, e.Actor, e.Tool, e.Proxy, e.FileHash,
+			string(detailsJSON), e.Timestamp.UTC().Format(time.RFC3339Nano), e.PrevHash, e.Hash)
+		if err == nil {
+			return nil
+		}
+		if attempt < 3 && strings.Contains(err.Error(), "UNIQUE") {
+			continue
+		}
+		return fmt.Errorf("failed to append custody event: %w", err)
+	}
+}
+
+// ListCustodyEvents returns a case's custody log in chain order.
+func ListCustodyEvents(caseID string) ([]*core.CustodyEvent, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT id, case_id, seq, evidence_id, action, actor, tool, proxy, file_hash, details, timestamp, prev_hash, hash
+	          FROM custody_events WHERE case_id = ? ORDER BY seq`
+	rows, err := DB.Query(query, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custody events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*core.CustodyEvent
+	for rows.Next() {
+		var e core.CustodyEvent
+		var evidenceID, actor, tool, proxy, fileHash, details sql.NullString
+		var ts string
+		if err := rows.Scan(&e.ID, &e.CaseID, &e.Seq, &evidenceID, &e.Action, &actor, &tool, &proxy, &fileHash, &details, &ts, &e.PrevHash, &e.Hash); err != nil {
+			return nil, fmt.Errorf("failed to scan custody event: %w", err)
+		}
+		e.EvidenceID, e.Actor, e.Tool, e.Proxy, e.FileHash = evidenceID.String, actor.String, tool.String, proxy.String, fileHash.String
+
+		if details.Valid && details.String != "" && details.String != "null" {
+			if err := json.Unmarshal([]byte(details.String), &e.Details); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal custody details: %w", err)
+			}
+		}
+		if e.Timestamp, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+			return nil, fmt.Errorf("invalid custody timestamp %q: %w", ts, err)
+		}
+		events = append(events, &e)
+	}
+
+	return events, nil
+}
+
+func currentActor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestCustodyChain(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB := DB
+	DB = db
+	defer func() {
+		DB = oldDB
+		db.Close()
+	}()
+	if err := InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateCase(&core.Case{ID: "case-custody", Name: "Custody"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := &core.Evidence{CaseID: "case-custody", Collector: "dns", FilePath: "evidence_storage/case-custody/dns.json", FileHash: "abc"}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatalf("CreateEvidence failed: %v", err)
+	}
+	if err := RecordCustody("case-custody", ev.ID, core.CustodyViewed, ev.FileHash, map[string]string{"via": "test"}); err != nil {
+		t.Fatalf("RecordCustody failed: %v", err)
+	}
+
+	events, err := ListCustodyEvents("case-custody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 custody events, got %d", len(events))
+	}
+	if events[0].Action != core.CustodyCollected || events[0].Seq != 1 || events[0].PrevHash != "" {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if events[1].PrevHash != events[0].Hash || events[1].Details["via"] != "test" {
+		t.Errorf("Second event is not linked to the first: %+v", events[1])
+	}
+	for _, e := range events {
+		if e.ComputeHash() != e.Hash {
+			t.Errorf("Event %d hash does not round-trip through the database", e.Seq)
+		}
+	}
+
+	if _, err := DB.Exec(`UPDATE custody_events SET actor = 'mallory'`); err == nil {
+		t.Error("Expected the custody log to reject updates")
+	}
+	if _, err := DB.Exec(`DELETE FROM custody_events`); err == nil {
+		t.Error("Expected the custody log to reject deletes")
+	}
+}
+
+func TestCreateEvidence_RollsBackWithoutCustody(t *testing.T) {
+	setupIngestDB(t)
+	if _, err := DB.Exec(`CREATE TRIGGER custody_down BEFORE INSERT ON custody_events BEGIN SELECT RAISE(ABORT, 'custody unavailable'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "dns", FilePath: "evidence_storage/case-ingest/dns.json", FileHash: "abc"}
+	if err := CreateEvidence(ev); err == nil {
+		t.Fatal("Expected CreateEvidence to fail when custody cannot be recorded")
+	}
+	if list, _ := ListEvidenceByCase("case-ingest"); len(list) != 0 {
+		t.Errorf("Expected no evidence without a custody event, got %d", len(list))
+	}
+}
@@ -18,7 +18,18 @@ func CreateEntity(e *core.Entity) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
+	if err := createEntity(DB, e); err != nil {
+		return err
+	}
 
+	if OnEntityCreated != nil {
+		OnEntityCreated(e)
+	}
+
+	return nil
+}
+
+func createEntity(w dbWriter, e *core.Entity) error {
 	if e.ID == "" {
 		e.ID = uuid.New().String()
 	}
@@ -36,15 +47,10 @@ func CreateEntity(e *core.Entity) error {
 
 	query := `INSERT INTO entities (id, case_id, type, value, source, confidence, discovered_at, metadata) 
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err = DB.Exec(query, e.ID, e.CaseID, e.Type, e.Value, e.Source, e.Confidence, e.DiscoveredAt, string(metadataJSON))
+	_, err = w.Exec(query, e.ID, e.CaseID, e.Type, e.Value, e.Source, e.Confidence, e.DiscoveredAt, string(metadataJSON))
 	if err != nil {
 		return fmt.Errorf("failed to create entity: %w", err)
 	}
-
-	if OnEntityCreated != nil {
-		OnEntityCreated(e)
-	}
-
 	return nil
 }
 
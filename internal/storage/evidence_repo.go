@@ -16,6 +16,29 @@ func CreateEvidence(ev *core.Evidence) error {
 		return fmt.Errorf("database not initialized")
 	}
 
+	// The row and its "collected" event are stored together, so evidence
+	// never exists without custody and a failed call leaves nothing behind
+	custodyMu.Lock()
+	defer custodyMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := createEvidence(tx, ev); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit evidence: %w", err)
+	}
+	return nil
+}
+
+// createEvidence stores ev and its "collected" custody event through w. The
+// caller holds custodyMu.
+func createEvidence(w dbWriter, ev *core.Evidence) error {
 	if ev.ID == "" {
 		ev.ID = uuid.New().String()
 	}
@@ -28,13 +51,21 @@ func CreateEvidence(ev *core.Evidence) error {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
+	details := map[string]string{"collector": ev.Collector, "file_path": ev.FilePath}
+	if target, ok := ev.Metadata["target"].(string); ok {
+		details["target"] = target
+	}
+
 	query := `INSERT INTO evidence (id, case_id, entity_id, collector, file_path, file_hash, collected_at, metadata) 
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err = DB.Exec(query, ev.ID, ev.CaseID, ev.EntityID, ev.Collector, ev.FilePath, ev.FileHash, ev.CollectedAt, string(metadataJSON))
+	_, err = w.Exec(query, ev.ID, ev.CaseID, ev.EntityID, ev.Collector, ev.FilePath, ev.FileHash, ev.CollectedAt, string(metadataJSON))
 	if err != nil {
 		return fmt.Errorf("failed to create evidence: %w", err)
 	}
 
+	if err := appendCustodyEvent(w, newCustodyEvent(ev.CaseID, ev.ID, core.CustodyCollected, ev.FileHash, details)); err != nil {
+		return fmt.Errorf("failed to record custody: %w", err)
+	}
 	return nil
 }
 
@@ -72,3 +103,23 @@ func ListEvidenceByCase(caseID string) ([]*core.Evidence, error) {
 
 	return evidenceList, nil
 }
+
+// GetEvidenceByPath finds the evidence record for a stored file.
+func GetEvidenceByPath(filePath string) (*core.Evidence, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	var ev core.Evidence
+	var entityID sql.NullString
+	query := `SELECT id, case_id, entity_id, collector, file_path, file_hash, collected_at FROM evidence WHERE file_path = ?`
+	err := DB.QueryRow(query, filePath).Scan(&ev.ID, &ev.CaseID, &entityID, &ev.Collector, &ev.FilePath, &ev.FileHash, &ev.CollectedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get evidence: %w", err)
+	}
+	ev.EntityID = entityID.String
+	return &ev, nil
+}
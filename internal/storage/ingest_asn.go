@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// RelAnnouncedBy links a netblock to the AS originating it in BGP.
+const RelAnnouncedBy = "announced_by"
+
+// ingestASN places the address in its announced netblock and links the
+// netblock to each origin AS. The first origin is also copied onto the
+// address so it can be read without following links.
+func ingestASN(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.ASNResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.ASNResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse ASN evidence: %w", err)
+		}
+	}
+
+	ipMeta := map[string]interface{}{"announced_prefix": result.Prefix}
+	if result.RIR != "" {
+		ipMeta["rir"] = result.RIR
+	}
+	if len(result.Origins) > 0 {
+		ipMeta["asn"] = result.Origins[0].Value()
+		if result.Origins[0].Name != "" {
+			ipMeta["as_name"] = result.Origins[0].Name
+		}
+	}
+	ip, err := ensureEntity(ev.CaseID, core.EntityIP, result.IP, "asn", ipMeta)
+	if err != nil {
+		return err
+	}
+
+	block, err := ensureEntity(ev.CaseID, core.EntityNetblock, result.Prefix, "asn", map[string]interface{}{"announced": true})
+	if err != nil {
+		return err
+	}
+	link(ev, ip, block, RelContainedIn, 1.0)
+
+	for _, origin := range result.Origins {
+		meta := make(map[string]interface{})
+		if origin.Name != "" {
+			meta["as_name"] = origin.Name
+		}
+		if origin.Country != "" {
+			meta["country"] = origin.Country
+		}
+		if origin.RIR != "" {
+			meta["rir"] = origin.RIR
+		}
+		as, err := ensureEntity(ev.CaseID, core.EntityASN, origin.Value(), "asn", meta)
+		if err != nil {
+			return err
+		}
+		link(ev, block, as, RelAnnouncedBy, 1.0)
+	}
+	return nil
+}
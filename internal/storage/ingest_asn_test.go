@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestASN(t *testing.T) {
+	setupIngestDB(t)
+
+	ingestResult(t, "asn", &core.ASNResult{
+		IP:     "198.51.101.1",
+		Prefix: "198.51.100.0/22",
+		RIR:    "ARIN",
+		Origins: []core.ASNInfo{
+			{Number: 64497, Name: "EXAMPLE-HOSTING", Country: "US"},
+			{Number: 64498},
+		},
+		Dataset: "sample.pfx2as",
+	})
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"198.51.101.1 -> 198.51.100.0/22": RelContainedIn,
+		"198.51.100.0/22 -> AS64497":      RelAnnouncedBy,
+		"198.51.100.0/22 -> AS64498":      RelAnnouncedBy,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	ip, _ := GetEntityByValue("case-ingest", "198.51.101.1")
+	if ip.Metadata["asn"] != "AS64497" || ip.Metadata["as_name"] != "EXAMPLE-HOSTING" || ip.Metadata["rir"] != "ARIN" || ip.Metadata["announced_prefix"] != "198.51.100.0/22" {
+		t.Errorf("Expected routing metadata on the IP, got %+v", ip.Metadata)
+	}
+	as, _ := GetEntityByValue("case-ingest", "AS64497")
+	if as == nil || as.Type != core.EntityASN || as.Metadata["as_name"] != "EXAMPLE-HOSTING" || as.Metadata["country"] != "US" {
+		t.Errorf("Expected an asn entity, got %+v", as)
+	}
+	block, _ := GetEntityByValue("case-ingest", "198.51.100.0/22")
+	if block == nil || block.Type != core.EntityNetblock {
+		t.Errorf("Expected a netblock entity, got %+v", block)
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// RelExposedIn links an email or username to a breach it appears in.
+const RelExposedIn = "exposed_in"
+
+// ingestBreach links the identifier to each breach it was found in. The
+// fields exposed differ per identifier, so they are kept on the identifier
+// under "breaches" (breach name -> field names) rather than on the breach.
+func ingestBreach(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.BreachResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.BreachResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse breach evidence: %w", err)
+		}
+	}
+	if len(result.Exposures) == 0 {
+		return nil
+	}
+
+	exposed := make(map[string]interface{})
+	if existing, err := GetEntityByValue(ev.CaseID, result.Target); err != nil {
+		return err
+	} else if existing != nil {
+		if prev, ok := existing.Metadata["breaches"].(map[string]interface{}); ok {
+			for k, v := range prev {
+				exposed[k] = v
+			}
+		}
+	}
+	for _, e := range result.Exposures {
+		exposed[e.Breach] = e.Fields
+	}
+	target, err := ensureEntity(ev.CaseID, result.Kind, result.Target, "breach", map[string]interface{}{"breaches": exposed})
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Exposures {
+		meta := map[string]interface{}{}
+		if e.Title != "" {
+			meta["title"] = e.Title
+		}
+		if e.Date != "" {
+			meta["breach_date"] = e.Date
+		}
+		breach, err := ensureEntity(ev.CaseID, core.EntityBreach, e.Value(), "breach", meta)
+		if err != nil {
+			return err
+		}
+		link(ev, target, breach, RelExposedIn, 1.0)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestBreach(t *testing.T) {
+	setupIngestDB(t)
+
+	ingestResult := func(r *core.BreachResult) {
+		ev := &core.Evidence{CaseID: "case-ingest", Collector: "breach", Metadata: map[string]interface{}{"target": r.Target}, RawData: r}
+		if err := CreateEvidence(ev); err != nil {
+			t.Fatal(err)
+		}
+		if err := IngestEvidence(ev); err != nil {
+			t.Fatalf("IngestEvidence failed: %v", err)
+		}
+	}
+	ingestResult(&core.BreachResult{
+		Target: "jane@acme.test",
+		Kind:   core.EntityEmail,
+		Exposures: []core.BreachExposure{
+			{Breach: "acme-2019", Title: "Acme Forum", Date: "2019-03-01", Fields: []string{"email", "password"}},
+		},
+	})
+	ingestResult(&core.BreachResult{
+		Target: "jane@acme.test",
+		Kind:   core.EntityEmail,
+		Exposures: []core.BreachExposure{
+			{Breach: "shop-2021", Date: "2021-07-15", Fields: []string{"email", "phone"}},
+		},
+	})
+
+	rels := relTypes(t, "case-ingest")
+	for _, k := range []string{"jane@acme.test -> breach:acme-2019", "jane@acme.test -> breach:shop-2021"} {
+		if rels[k] != RelExposedIn {
+			t.Errorf("Expected %s to be %q, got %q", k, RelExposedIn, rels[k])
+		}
+	}
+
+	b, _ := GetEntityByValue("case-ingest", "breach:acme-2019")
+	if b == nil || b.Type != core.EntityBreach || b.Metadata["breach_date"] != "2019-03-01" || b.Metadata["title"] != "Acme Forum" {
+		t.Errorf("Expected a breach entity, got %+v", b)
+	}
+	email, _ := GetEntityByValue("case-ingest", "jane@acme.test")
+	breaches, _ := email.Metadata["breaches"].(map[string]interface{})
+	if len(breaches) != 2 {
+		t.Errorf("Expected exposures from both runs on the email, got %+v", email.Metadata)
+	}
+}
+
+func TestIngestBreach_NamedAfterDomain(t *testing.T) {
+	setupIngestDB(t)
+	domain, err := ensureEntity("case-ingest", core.EntityDomain, "acme.test", "dns", map[string]interface{}{"registrar": "Example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingestResult(t, "breach", &core.BreachResult{
+		Target:    "jane@acme.test",
+		Kind:      core.EntityEmail,
+		Exposures: []core.BreachExposure{{Breach: "acme.test", Fields: []string{"email"}}},
+	})
+
+	got, _ := GetEntityByValue("case-ingest", "acme.test")
+	if got.ID != domain.ID || got.Type != core.EntityDomain || got.Metadata["registrar"] != "Example" {
+		t.Errorf("Breach merged into the domain entity: %+v", got)
+	}
+	if b, _ := GetEntityByValue("case-ingest", "breach:acme.test"); b == nil || b.Type != core.EntityBreach {
+		t.Errorf("Expected a separate breach entity, got %+v", b)
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Certificate relationship types.
+const (
+	RelCovers       = "covers"             // certificate -> each SAN
+	RelServesCert   = "serves_certificate" // host or IP -> leaf certificate
+	RelCertIssuedBy = "issued_by"          // certificate -> issuing certificate in the chain
+)
+
+func ingestCT(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.CTResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.CTResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to unmarshal CT results: %w", err)
+		}
+	}
+
+	for i := range result.Certificates {
+		if _, err := ingestCertificate(ev, &result.Certificates[i], "ct"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingestTLS links the probed host and the address it connected to with the
+// leaf certificate. Certificates are keyed by fingerprint, so hosts
+// presenting the same certificate end up sharing one certificate entity.
+func ingestTLS(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.TLSResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.TLSResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to unmarshal TLS results: %w", err)
+		}
+	}
+
+	var chain []*core.Entity
+	for i := range result.Chain {
+		ent, err := ingestCertificate(ev, &result.Chain[i], "tls")
+		if err != nil {
+			return err
+		}
+		if ent == nil {
+			break
+		}
+		if len(chain) > 0 {
+			link(ev, chain[len(chain)-1], ent, RelCertIssuedBy, 1.0)
+		}
+		chain = append(chain, ent)
+	}
+
+	tlsMeta := map[string]interface{}{
+		"port":    result.Port,
+		"version": result.Version,
+		"cipher":  result.CipherSuite,
+		"trusted": result.VerifyError == "",
+	}
+	if result.ALPN != "" {
+		tlsMeta["alpn"] = result.ALPN
+	}
+	if result.JA3S != "" {
+		tlsMeta["ja3s"] = result.JA3S
+	}
+	if result.JARM != "" {
+		tlsMeta["jarm"] = result.JARM
+	}
+	meta := map[string]interface{}{"tls": tlsMeta}
+
+	hostType := core.EntityDomain
+	if net.ParseIP(result.Host) != nil {
+		hostType = core.EntityIP
+	}
+	host, err := ensureEntity(ev.CaseID, hostType, result.Host, "tls", meta)
+	if err != nil {
+		return err
+	}
+	var addr *core.Entity
+	if result.Address != "" && result.Address != result.Host {
+		if addr, err = ensureEntity(ev.CaseID, core.EntityIP, result.Address, "tls", meta); err != nil {
+			return err
+		}
+		link(ev, host, addr, RelResolvesTo, 1.0)
+	}
+
+	if len(chain) > 0 {
+		link(ev, host, chain[0], RelServesCert, 1.0)
+		link(ev, addr, chain[0], RelServesCert, 1.0)
+	}
+	return nil
+}
+
+// ingestCertificate creates the certificate entity and links it to every
+// SAN. Wildcard names cover their parent domain at lower confidence.
+func ingestCertificate(ev *core.Evidence, cert *core.Certificate, source string) (*core.Entity, error) {
+	key := core.CertificateKey(cert)
+	if key == "" {
+		return nil, nil
+	}
+
+	var wildcards []string
+	for _, san := range cert.SANs {
+		if strings.HasPrefix(san, "*.") {
+			wildcards = append(wildcards, san)
+		}
+	}
+	meta := map[string]interface{}{
+		"issuer": cert.Issuer,
+		"serial": core.NormalizeSerial(cert.Serial),
+		"sans":   cert.SANs,
+	}
+	if cert.CommonName != "" {
+		meta["common_name"] = cert.CommonName
+	}
+	if !cert.NotBefore.IsZero() {
+		meta["not_before"] = cert.NotBefore.Format(time.RFC3339)
+	}
+	if !cert.NotAfter.IsZero() {
+		meta["not_after"] = cert.NotAfter.Format(time.RFC3339)
+		meta["expired"] = cert.NotAfter.Before(time.Now())
+	}
+	if len(wildcards) > 0 {
+		meta["wildcards"] = wildcards
+	}
+	if cert.SHA256 != "" {
+		meta["sha256"] = cert.SHA256
+		meta["subject"] = cert.Subject
+		meta["public_key"] = cert.PublicKey
+		meta["signature_algorithm"] = cert.SignatureAlgorithm
+		meta["self_signed"] = cert.SelfSigned
+		meta["is_ca"] = cert.IsCA
+	}
+	certEnt, err := ensureEntity(ev.CaseID, core.EntityCertificate, key, source, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, san := range cert.SANs {
+		name := strings.TrimSuffix(strings.ToLower(san), ".")
+		confidence := 1.0
+		if strings.HasPrefix(name, "*.") {
+			name, confidence = name[2:], 0.8
+		}
+		entityType := core.EntityDomain
+		if net.ParseIP(name) != nil {
+			entityType = core.EntityIP
+		} else if name == "" || strings.Contains(name, "@") || !strings.Contains(name, ".") {
+			continue
+		}
+		target, err := ensureEntity(ev.CaseID, entityType, name, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		link(ev, certEnt, target, RelCovers, confidence)
+	}
+	return certEnt, nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestCT(t *testing.T) {
+	setupIngestDB(t)
+
+	result := &core.CTResult{Domain: "example.com", Certificates: []core.Certificate{
+		{Serial: "4a1b", Issuer: "CN=R3", CommonName: "example.com", SANs: []string{"example.com", "www.example.com"},
+			NotBefore: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), NotAfter: time.Date(2024, 5, 30, 0, 0, 0, 0, time.UTC)},
+		{Serial: "0FFE99", Issuer: "CN=DigiCert", SANs: []string{"*.example.net", "192.0.2.1"}},
+	}}
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "ct", Metadata: map[string]interface{}{"target": "example.com"}, RawData: result}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	for _, k := range []string{"CN=R3|4a1b -> example.com", "CN=R3|4a1b -> www.example.com", "CN=DigiCert|ffe99 -> example.net", "CN=DigiCert|ffe99 -> 192.0.2.1"} {
+		if rels[k] != RelCovers {
+			t.Errorf("Expected %s to be %q, got %q", k, RelCovers, rels[k])
+		}
+	}
+
+	cert, _ := GetEntityByValue("case-ingest", "CN=R3|4a1b")
+	if cert == nil || cert.Type != core.EntityCertificate {
+		t.Fatalf("Expected a certificate entity, got %+v", cert)
+	}
+	if cert.Metadata["issuer"] != "CN=R3" || cert.Metadata["serial"] != "4a1b" || cert.Metadata["not_after"] != "2024-05-30T00:00:00Z" || cert.Metadata["expired"] != true {
+		t.Errorf("Unexpected certificate metadata: %v", cert.Metadata)
+	}
+	if ip, _ := GetEntityByValue("case-ingest", "192.0.2.1"); ip == nil || ip.Type != core.EntityIP {
+		t.Errorf("Expected an IP SAN to become an ip entity, got %+v", ip)
+	}
+}
+
+func TestIngestTLS_SharedCertificate(t *testing.T) {
+	setupIngestDB(t)
+
+	leaf := core.Certificate{Serial: "abc123", Issuer: "CN=Test CA", SANs: []string{"example.com"}, SHA256: "00ff", PublicKey: "RSA-2048"}
+	ca := core.Certificate{Serial: "01", Issuer: "CN=Test Root", Subject: "CN=Test CA", SHA256: "11ee", IsCA: true}
+	for _, host := range []string{"example.com", "192.0.2.7"} {
+		result := &core.TLSResult{Host: host, Port: 443, Address: "192.0.2.7", Version: "TLS 1.3", JARM: "27d40d", Chain: []core.Certificate{leaf, ca}}
+		ev := &core.Evidence{CaseID: "case-ingest", Collector: "tls", Metadata: map[string]interface{}{"target": host}, RawData: result}
+		if err := CreateEvidence(ev); err != nil {
+			t.Fatal(err)
+		}
+		if err := IngestEvidence(ev); err != nil {
+			t.Fatalf("IngestEvidence failed: %v", err)
+		}
+	}
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"example.com -> 00ff":      RelServesCert,
+		"192.0.2.7 -> 00ff":        RelServesCert,
+		"example.com -> 192.0.2.7": RelResolvesTo,
+		"00ff -> 11ee":             RelCertIssuedBy,
+		"00ff -> example.com":      RelCovers,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	entities, _ := ListEntitiesByCase("case-ingest")
+	certs := 0
+	for _, e := range entities {
+		if e.Type == core.EntityCertificate {
+			certs++
+		}
+	}
+	if certs != 2 {
+		t.Errorf("Expected the leaf and CA certificates once each, got %d certificate entities", certs)
+	}
+	host, _ := GetEntityByValue("case-ingest", "example.com")
+	if tls, _ := host.Metadata["tls"].(map[string]interface{}); tls == nil || tls["jarm"] != "27d40d" {
+		t.Errorf("Expected TLS details on the host, got %v", host.Metadata)
+	}
+}
+
+func TestIngestTLS_SharedSerialIsNotSharedCertificate(t *testing.T) {
+	setupIngestDB(t)
+
+	// Two unrelated self-signed appliances, both with serial 0
+	for _, tc := range []struct{ host, sha string }{{"192.0.2.20", "aa01"}, {"192.0.2.21", "bb02"}} {
+		cert := core.Certificate{Serial: "0", Issuer: "CN=localhost", Subject: "CN=localhost", SHA256: tc.sha, SelfSigned: true}
+		ingestResult(t, "tls", &core.TLSResult{Host: tc.host, Port: 443, Address: tc.host, Chain: []core.Certificate{cert}})
+	}
+
+	rels := relTypes(t, "case-ingest")
+	if rels["192.0.2.20 -> aa01"] != RelServesCert || rels["192.0.2.21 -> bb02"] != RelServesCert {
+		t.Errorf("Expected each host to serve its own certificate, got %v", rels)
+	}
+	if rels["192.0.2.20 -> bb02"] != "" || rels["192.0.2.21 -> aa01"] != "" {
+		t.Errorf("Certificates sharing a serial were merged: %v", rels)
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Crawl relationship types.
+const (
+	RelLinksTo         = "links_to"         // site -> domain it links out to
+	RelMentions        = "mentions"         // site -> email or phone found on its pages
+	RelLinksToProfile  = "links_to_profile" // site -> social account
+	RelExposesEndpoint = "exposes_endpoint" // site -> url referenced from its JavaScript
+	RelHasAccount      = "has_account"      // username -> account
+)
+
+// ingestCrawl turns what the crawler extracted into entities around the
+// crawled site.
+func ingestCrawl(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.CrawlResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.CrawlResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse crawl evidence: %w", err)
+		}
+	}
+
+	meta := map[string]interface{}{"crawled_pages": len(result.Pages)}
+	site, err := ensureEntity(ev.CaseID, core.InferEntityType(result.Target), result.Target, "crawl", meta)
+	if err != nil {
+		return err
+	}
+	if err := linkTrackers(ev, site, result.Trackers, "crawl"); err != nil {
+		return err
+	}
+
+	for _, domain := range result.Domains {
+		ent, err := ensureEntity(ev.CaseID, core.EntityDomain, domain, "crawl", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, site, ent, RelLinksTo, 1.0)
+	}
+	for _, email := range result.Emails {
+		ent, err := ensureEntity(ev.CaseID, core.EntityEmail, email, "crawl", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, site, ent, RelMentions, 1.0)
+	}
+	for _, phone := range result.Phones {
+		ent, err := ensureEntity(ev.CaseID, core.EntityPhone, phone, "crawl", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, site, ent, RelMentions, 0.8)
+	}
+	for _, s := range result.Social {
+		account, err := ensureEntity(ev.CaseID, core.EntityAccount, s.URL, "crawl", map[string]interface{}{"platform": s.Platform})
+		if err != nil {
+			return err
+		}
+		link(ev, site, account, RelLinksToProfile, 1.0)
+		if s.Username != "" {
+			user, err := ensureEntity(ev.CaseID, core.EntityUsername, s.Username, "crawl", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, user, account, RelHasAccount, 0.9)
+		}
+	}
+	for _, endpoint := range result.Endpoints {
+		ent, err := ensureEntity(ev.CaseID, core.EntityURL, endpoint, "crawl", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, site, ent, RelExposesEndpoint, 0.7)
+	}
+	return nil
+}
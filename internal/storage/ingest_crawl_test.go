@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestCrawl(t *testing.T) {
+	setupIngestDB(t)
+
+	result := &core.CrawlResult{
+		Target:    "acme.test",
+		Pages:     []core.CrawledPage{{URL: "https://acme.test/", StatusCode: 200}},
+		Domains:   []string{"partner.example.org"},
+		Emails:    []string{"sales@acme.test"},
+		Phones:    []string{"+442079460958"},
+		Social:    []core.SocialLink{{Platform: "Twitter", URL: "https://twitter.com/acmecorp", Username: "acmecorp"}},
+		Trackers:  []core.TrackerID{{Type: "google_analytics", ID: "UA-1234567-1"}},
+		Endpoints: []string{"https://acme.test/api/v1/users"},
+	}
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "crawl", Metadata: map[string]interface{}{"target": "acme.test"}, RawData: result}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"acme.test -> partner.example.org":            RelLinksTo,
+		"acme.test -> sales@acme.test":                RelMentions,
+		"acme.test -> +442079460958":                  RelMentions,
+		"acme.test -> https://twitter.com/acmecorp":   RelLinksToProfile,
+		"acmecorp -> https://twitter.com/acmecorp":    RelHasAccount,
+		"acme.test -> https://acme.test/api/v1/users": RelExposesEndpoint,
+		"acme.test -> UA-1234567-1":                   RelUsesTracker,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	phone, _ := GetEntityByValue("case-ingest", "+442079460958")
+	if phone == nil || phone.Type != core.EntityPhone {
+		t.Errorf("Expected a phone entity, got %+v", phone)
+	}
+	tracker, _ := GetEntityByValue("case-ingest", "UA-1234567-1")
+	if tracker == nil || tracker.Type != core.EntityTracker || tracker.Metadata["tracker_type"] != "google_analytics" {
+		t.Errorf("Expected a tracker_id entity, got %+v", tracker)
+	}
+}
@@ -0,0 +1,366 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// DNS relationship types.
+const (
+	RelResolvesTo     = "resolves_to"
+	RelCNAME          = "cname_to"
+	RelNameserver     = "uses_nameserver"
+	RelMailserver     = "uses_mailserver"
+	RelSOAContact     = "soa_contact"
+	RelSPFInclude     = "spf_includes"
+	RelSPFAuthorizes  = "spf_authorizes"
+	RelDMARCReporting = "dmarc_reports_to"
+	RelSRVTarget      = "srv_target"
+	RelSubdomainOf    = "subdomain_of"
+)
+
+func ingestDNS(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.DNSResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+
+		// Evidence collected before direct resolver queries is a map of
+		// record type to values.
+		var legacy map[string][]string
+		if json.Unmarshal(data, &legacy) == nil {
+			return ingestLegacyDNS(ev, legacy)
+		}
+		result = &core.DNSResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to unmarshal DNS results: %w", err)
+		}
+	}
+
+	domain, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Domain, "dns", nil)
+	if err != nil {
+		return err
+	}
+	apexMeta := map[string]interface{}{}
+
+	for _, rec := range result.Records("CNAME") {
+		owner, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.Name, "dns", nil)
+		if err != nil {
+			return err
+		}
+		canonical, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.Value, "dns", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, owner, canonical, RelCNAME, 1.0)
+	}
+
+	// Addresses hang off the name that owns them, which is the end of the
+	// CNAME chain rather than the queried domain.
+	for _, recType := range []string{"A", "AAAA"} {
+		for _, rec := range result.Records(recType) {
+			owner, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.Name, "dns", nil)
+			if err != nil {
+				return err
+			}
+			ip, err := ensureEntity(ev.CaseID, core.EntityIP, rec.Value, "dns", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, owner, ip, RelResolvesTo, 1.0)
+		}
+	}
+
+	for _, rec := range result.Records("NS") {
+		ns, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.Value, "dns", map[string]interface{}{"role": "nameserver"})
+		if err != nil {
+			return err
+		}
+		link(ev, domain, ns, RelNameserver, 1.0)
+	}
+
+	for _, rec := range result.Records("MX") {
+		if rec.Value == "" { // Null MX (RFC 7505): the domain accepts no mail
+			apexMeta["null_mx"] = true
+			continue
+		}
+		mx, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.Value, "dns",
+			map[string]interface{}{"role": "mailserver", "mx_preference": rec.Preference})
+		if err != nil {
+			return err
+		}
+		link(ev, domain, mx, RelMailserver, 1.0)
+	}
+
+	for _, rec := range result.Records("SOA") {
+		fields := strings.Fields(rec.Value)
+		if len(fields) < 2 {
+			continue
+		}
+		apexMeta["soa_serial"] = fieldAt(fields, 2)
+		if email := soaMailbox(fields[1]); email != "" {
+			contact, err := ensureEntity(ev.CaseID, core.EntityEmail, email, "dns", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, domain, contact, RelSOAContact, 0.8)
+		}
+	}
+
+	for _, rec := range result.Records("SRV") {
+		if rec.Value == "" {
+			continue
+		}
+		service := strings.TrimSuffix(rec.Name, "."+result.Domain)
+		host, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.Value, "dns",
+			map[string]interface{}{"srv_service": service, "srv_port": rec.Port})
+		if err != nil {
+			return err
+		}
+		link(ev, domain, host, RelSRVTarget, 1.0)
+	}
+
+	var caa, verifications, dkim []string
+	for _, rec := range result.Records("CAA") {
+		caa = append(caa, rec.Value)
+	}
+	for _, rec := range result.Records("TXT") {
+		txt := strings.TrimSpace(rec.Value)
+		switch {
+		case rec.Name == result.Domain && strings.HasPrefix(strings.ToLower(txt), "v=spf1"):
+			apexMeta["spf"] = txt
+			if err := ingestSPF(ev, domain, txt); err != nil {
+				return err
+			}
+		case rec.Name == "_dmarc."+result.Domain && strings.HasPrefix(strings.ToUpper(txt), "V=DMARC1"):
+			apexMeta["dmarc"] = txt
+			if err := ingestDMARC(ev, domain, txt); err != nil {
+				return err
+			}
+		case strings.Contains(rec.Name, "._domainkey."):
+			dkim = append(dkim, strings.SplitN(rec.Name, ".", 2)[0])
+		case rec.Name == result.Domain:
+			if provider := verificationProvider(txt); provider != "" {
+				verifications = append(verifications, provider)
+			}
+		}
+	}
+	if len(caa) > 0 {
+		apexMeta["caa"] = caa
+	}
+	if len(verifications) > 0 {
+		apexMeta["txt_verifications"] = verifications
+	}
+	if len(dkim) > 0 {
+		apexMeta["dkim_selectors"] = dkim
+	}
+
+	if len(apexMeta) > 0 {
+		if _, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Domain, "dns", apexMeta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ingestSubdomains(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.SubdomainResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.SubdomainResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to unmarshal subdomain results: %w", err)
+		}
+	}
+
+	meta := map[string]interface{}{"wildcard_dns": result.Wildcard}
+	if result.Wildcard {
+		meta["wildcard_ips"] = result.WildcardIPs
+	}
+	if len(result.ZoneTransfers) > 0 {
+		open := false
+		for _, zt := range result.ZoneTransfers {
+			open = open || zt.Success
+		}
+		meta["axfr_open"] = open
+	}
+	apex, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Domain, "subdomains", meta)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range result.Subdomains {
+		entity, err := ensureEntity(ev.CaseID, core.EntityDomain, sub.Name, "subdomains", map[string]interface{}{"discovered_by": sub.Source})
+		if err != nil {
+			return err
+		}
+		// Permutation hits are real answers, but a catch-all record the
+		// wildcard probe missed is more likely to produce them.
+		confidence := 1.0
+		if sub.Source == "permutation" {
+			confidence = 0.9
+		}
+		link(ev, entity, apex, RelSubdomainOf, confidence)
+
+		if sub.CNAME != "" {
+			canonical, err := ensureEntity(ev.CaseID, core.EntityDomain, sub.CNAME, "subdomains", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, entity, canonical, RelCNAME, 1.0)
+		}
+		for _, addr := range sub.Addresses {
+			ip, err := ensureEntity(ev.CaseID, core.EntityIP, addr, "subdomains", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, entity, ip, RelResolvesTo, confidence)
+		}
+	}
+	return nil
+}
+
+// ingestSPF links a domain to the domains its SPF policy includes and the
+// single hosts it authorises. Networks are left in the policy text.
+func ingestSPF(ev *core.Evidence, domain *core.Entity, spf string) error {
+	for _, term := range strings.Fields(spf)[1:] {
+		term = strings.TrimLeft(term, "+-~?")
+		mech, arg, ok := strings.Cut(term, ":")
+		if !ok {
+			mech, arg, ok = strings.Cut(term, "=")
+		}
+		if !ok || arg == "" {
+			continue
+		}
+
+		switch strings.ToLower(mech) {
+		case "include", "redirect":
+			if strings.Contains(arg, "%") { // Macro, not a literal domain
+				continue
+			}
+			inc, err := ensureEntity(ev.CaseID, core.EntityDomain, strings.ToLower(arg), "dns", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, domain, inc, RelSPFInclude, 0.9)
+		case "ip4", "ip6":
+			addr := strings.TrimSuffix(strings.TrimSuffix(arg, "/32"), "/128")
+			if net.ParseIP(addr) == nil {
+				continue
+			}
+			ip, err := ensureEntity(ev.CaseID, core.EntityIP, addr, "dns", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, domain, ip, RelSPFAuthorizes, 0.9)
+		}
+	}
+	return nil
+}
+
+// ingestDMARC links a domain to the addresses its aggregate and forensic
+// reports are sent to.
+func ingestDMARC(ev *core.Evidence, domain *core.Entity, dmarc string) error {
+	for _, tag := range strings.Split(dmarc, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if !ok || (key != "rua" && key != "ruf") {
+			continue
+		}
+		for _, uri := range strings.Split(value, ",") {
+			addr, ok := strings.CutPrefix(strings.TrimSpace(uri), "mailto:")
+			if !ok {
+				continue
+			}
+			addr, _, _ = strings.Cut(addr, "!") // Size limit suffix
+			if !strings.Contains(addr, "@") {
+				continue
+			}
+			email, err := ensureEntity(ev.CaseID, core.EntityEmail, strings.ToLower(addr), "dns", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, domain, email, RelDMARCReporting, 0.9)
+		}
+	}
+	return nil
+}
+
+// verificationProvider names the service a domain-verification TXT record
+// belongs to ("google-site-verification=..." gives "google-site").
+func verificationProvider(txt string) string {
+	key, _, ok := strings.Cut(txt, "=")
+	if !ok {
+		return ""
+	}
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "ms" {
+		return "microsoft"
+	}
+	if strings.HasSuffix(key, "-verification") || strings.HasSuffix(key, "-domain-verification") {
+		return strings.TrimSuffix(strings.TrimSuffix(key, "-verification"), "-domain")
+	}
+	return ""
+}
+
+// soaMailbox turns an SOA RNAME ("hostmaster.example.com") into an address.
+func soaMailbox(rname string) string {
+	local, domain, ok := strings.Cut(strings.ReplaceAll(rname, `\.`, "\x00"), ".")
+	if !ok || domain == "" || !strings.Contains(domain, ".") {
+		return ""
+	}
+	return strings.ToLower(strings.ReplaceAll(local, "\x00", ".") + "@" + domain)
+}
+
+func fieldAt(fields []string, i int) string {
+	if i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
+
+// ingestLegacyDNS handles evidence from the original collector, which stored
+// only record values keyed by type.
+func ingestLegacyDNS(ev *core.Evidence, results map[string][]string) error {
+	target, _ := ev.Metadata["target"].(string)
+	domain, err := ensureEntity(ev.CaseID, core.EntityDomain, target, "dns", nil)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range results["A"] {
+		ip, err := ensureEntity(ev.CaseID, core.EntityIP, value, "dns", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, domain, ip, RelResolvesTo, 1.0)
+	}
+	for _, value := range results["NS"] {
+		ns, err := ensureEntity(ev.CaseID, core.EntityDomain, strings.TrimSuffix(value, "."), "dns", map[string]interface{}{"role": "nameserver"})
+		if err != nil {
+			return err
+		}
+		link(ev, domain, ns, RelNameserver, 1.0)
+	}
+	for _, value := range results["MX"] {
+		mx, err := ensureEntity(ev.CaseID, core.EntityDomain, strings.TrimSuffix(value, "."), "dns", map[string]interface{}{"role": "mailserver"})
+		if err != nil {
+			return err
+		}
+		link(ev, domain, mx, RelMailserver, 1.0)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+)
+
+func setupIngestDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = oldDB
+		db.Close()
+	})
+	if err := InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateCase(&core.Case{ID: "case-ingest", Name: "Ingest"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// relTypes maps "from -> to" to the relationship type linking them.
+func relTypes(t *testing.T, caseID string) map[string]string {
+	entities, _ := ListEntitiesByCase(caseID)
+	values := make(map[string]string)
+	for _, e := range entities {
+		values[e.ID] = e.Value
+	}
+	rels, err := ListRelationshipsByCase(caseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]string)
+	for _, r := range rels {
+		out[values[r.FromEntityID]+" -> "+values[r.ToEntityID]] = r.Type
+	}
+	return out
+}
+
+func TestIngestDNS(t *testing.T) {
+	setupIngestDB(t)
+
+	result := &core.DNSResult{Domain: "example.com", Answers: []*core.DNSAnswer{
+		{Query: "example.com", Type: "CNAME", Records: []core.DNSRecord{{Name: "example.com", Type: "CNAME", Value: "edge.example.net"}}},
+		{Query: "example.com", Type: "A", Records: []core.DNSRecord{{Name: "edge.example.net", Type: "A", TTL: 60, Value: "192.0.2.10"}}},
+		{Query: "example.com", Type: "NS", Records: []core.DNSRecord{{Name: "example.com", Type: "NS", Value: "ns1.example.org"}}},
+		{Query: "example.com", Type: "MX", Records: []core.DNSRecord{{Name: "example.com", Type: "MX", Value: "mx.example.com", Preference: 10}}},
+		{Query: "example.com", Type: "SOA", Records: []core.DNSRecord{{Name: "example.com", Type: "SOA", Value: "ns1.example.org host\\.master.example.com 2024010101 7200 3600 1209600 300"}}},
+		{Query: "example.com", Type: "TXT", Records: []core.DNSRecord{
+			{Name: "example.com", Type: "TXT", Value: "v=spf1 include:_spf.google.com ip4:198.51.100.7 ip4:203.0.113.0/24 ~all"},
+			{Name: "example.com", Type: "TXT", Value: "google-site-verification=abc123"},
+		}},
+		{Query: "_dmarc.example.com", Type: "TXT", Records: []core.DNSRecord{{Name: "_dmarc.example.com", Type: "TXT", Value: "v=DMARC1; p=reject; rua=mailto:dmarc@example.com!10m"}}},
+		{Query: "selector1._domainkey.example.com", Type: "TXT", Records: []core.DNSRecord{{Name: "selector1._domainkey.example.com", Type: "TXT", Value: "v=DKIM1; k=rsa; p=MIGf"}}},
+		{Query: "example.com", Type: "CAA", Records: []core.DNSRecord{{Name: "example.com", Type: "CAA", Tag: "issue", Value: `0 issue "letsencrypt.org"`}}},
+	}}
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "dns", Metadata: map[string]interface{}{"target": "example.com"}, RawData: result}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"example.com -> edge.example.net":        RelCNAME,
+		"edge.example.net -> 192.0.2.10":         RelResolvesTo,
+		"example.com -> ns1.example.org":         RelNameserver,
+		"example.com -> mx.example.com":          RelMailserver,
+		"example.com -> host.master@example.com": RelSOAContact,
+		"example.com -> _spf.google.com":         RelSPFInclude,
+		"example.com -> 198.51.100.7":            RelSPFAuthorizes,
+		"example.com -> dmarc@example.com":       RelDMARCReporting,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+	if len(rels) != len(want) {
+		t.Errorf("Expected %d relationships, got %v", len(want), rels)
+	}
+
+	domain, _ := GetEntityByValue("case-ingest", "example.com")
+	if domain.Metadata["dmarc"] == nil || domain.Metadata["caa"] == nil {
+		t.Errorf("Expected DMARC and CAA on the domain, got %v", domain.Metadata)
+	}
+	if sel, _ := domain.Metadata["dkim_selectors"].([]interface{}); len(sel) != 1 || sel[0] != "selector1" {
+		t.Errorf("Expected DKIM selector1, got %v", domain.Metadata["dkim_selectors"])
+	}
+	if v, _ := domain.Metadata["txt_verifications"].([]interface{}); len(v) != 1 || v[0] != "google-site" {
+		t.Errorf("Expected a google-site verification, got %v", domain.Metadata["txt_verifications"])
+	}
+	if mx, _ := GetEntityByValue("case-ingest", "mx.example.com"); mx.Metadata["role"] != "mailserver" {
+		t.Errorf("Expected the MX host to be marked as a mailserver, got %v", mx.Metadata)
+	}
+}
+
+func TestIngestDNS_LegacyEvidence(t *testing.T) {
+	setupIngestDB(t)
+
+	path := filepath.Join(t.TempDir(), "dns_example.org.json")
+	os.WriteFile(path, []byte(`{"A": ["192.0.2.20"], "MX": ["mail.example.org."], "NS": null}`), 0644)
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "dns", FilePath: path, Metadata: map[string]interface{}{"target": "example.org"}}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	if rels["example.org -> 192.0.2.20"] != RelResolvesTo || rels["example.org -> mail.example.org"] != RelMailserver {
+		t.Errorf("Unexpected relationships from legacy evidence: %v", rels)
+	}
+}
+
+func TestIngestSubdomains(t *testing.T) {
+	setupIngestDB(t)
+
+	result := &core.SubdomainResult{
+		Domain:        "example.com",
+		Wildcard:      true,
+		WildcardIPs:   []string{"192.0.2.99"},
+		ZoneTransfers: []core.ZoneTransfer{{Nameserver: "ns1.example.com", Address: "192.0.2.53", Error: "transfer refused (Refused)"}},
+		Subdomains: []core.Subdomain{
+			{Name: "www.example.com", CNAME: "edge.example.net", Addresses: []string{"192.0.2.1"}, Source: "bruteforce"},
+			{Name: "api-dev.example.com", Addresses: []string{"192.0.2.2"}, Source: "permutation"},
+		},
+	}
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "subdomains", Metadata: map[string]interface{}{"target": "example.com"}, RawData: result}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"www.example.com -> example.com":      RelSubdomainOf,
+		"api-dev.example.com -> example.com":  RelSubdomainOf,
+		"www.example.com -> edge.example.net": RelCNAME,
+		"www.example.com -> 192.0.2.1":        RelResolvesTo,
+		"api-dev.example.com -> 192.0.2.2":    RelResolvesTo,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	apex, _ := GetEntityByValue("case-ingest", "example.com")
+	if apex.Metadata["wildcard_dns"] != true || apex.Metadata["axfr_open"] != false {
+		t.Errorf("Expected wildcard and closed AXFR on the apex, got %v", apex.Metadata)
+	}
+	if sub, _ := GetEntityByValue("case-ingest", "api-dev.example.com"); sub.Metadata["discovered_by"] != "permutation" {
+		t.Errorf("Expected discovered_by on the subdomain, got %v", sub.Metadata)
+	}
+}
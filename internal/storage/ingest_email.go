@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Email relationship types.
+const (
+	RelAtDomain         = "at_domain"         // email -> its domain
+	RelPossibleUsername = "possible_username" // email -> handle derived from it
+)
+
+// ingestEmail records the checks on the email entity and links it to its
+// domain, Gravatar accounts and candidate usernames. Candidates are weak
+// guesses until the social collector confirms them, so they are linked
+// with low confidence.
+func ingestEmail(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.EmailResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.EmailResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse email evidence: %w", err)
+		}
+	}
+
+	meta := map[string]interface{}{"valid": result.Valid}
+	if !result.Valid {
+		meta["syntax_error"] = result.SyntaxError
+		_, err := ensureEntity(ev.CaseID, core.EntityEmail, result.Email, "email", meta)
+		return err
+	}
+	meta["disposable"] = result.Disposable
+	meta["free_mail"] = result.FreeMail
+	meta["role"] = result.Role
+	meta["accepts_mail"] = result.AcceptsMail
+	meta["gravatar_md5"] = result.GravatarMD5
+	if result.SMTP != nil {
+		meta["smtp"] = result.SMTP.Verdict
+	}
+	email, err := ensureEntity(ev.CaseID, core.EntityEmail, result.Email, "email", meta)
+	if err != nil {
+		return err
+	}
+
+	domainMeta := map[string]interface{}{}
+	if result.SPF != "" {
+		domainMeta["spf"] = result.SPF
+	}
+	if result.FreeMail {
+		domainMeta["free_mail"] = true
+	}
+	if result.Disposable {
+		domainMeta["disposable_mail"] = true
+	}
+	domain, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Domain, "email", domainMeta)
+	if err != nil {
+		return err
+	}
+	link(ev, email, domain, RelAtDomain, 1.0)
+	for _, mx := range result.MX {
+		if mx.Value == "" {
+			continue
+		}
+		host, err := ensureEntity(ev.CaseID, core.EntityDomain, mx.Value, "email", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, domain, host, RelMailserver, 1.0)
+	}
+
+	if g := result.Gravatar; g != nil && g.ProfileURL != "" {
+		profile, err := ensureEntity(ev.CaseID, core.EntityAccount, g.ProfileURL, "email", map[string]interface{}{
+			"platform": "Gravatar", "display_name": g.DisplayName, "location": g.Location, "avatar_url": g.AvatarURL,
+		})
+		if err != nil {
+			return err
+		}
+		link(ev, email, profile, RelHasAccount, 1.0)
+		if g.Username != "" {
+			if err := linkUsername(ev, email, g.Username, 0.9); err != nil {
+				return err
+			}
+		}
+		// Accounts the owner chose to list on their profile
+		for _, a := range g.Accounts {
+			if a.URL == "" {
+				continue
+			}
+			account, err := ensureEntity(ev.CaseID, core.EntityAccount, a.URL, "email", map[string]interface{}{"platform": a.Domain})
+			if err != nil {
+				return err
+			}
+			link(ev, email, account, RelHasAccount, 0.9)
+		}
+	}
+
+	for _, u := range result.Usernames {
+		if err := linkUsername(ev, email, u, 0.3); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func linkUsername(ev *core.Evidence, email *core.Entity, username string, confidence float64) error {
+	user, err := ensureEntity(ev.CaseID, core.EntityUsername, username, "email", nil)
+	if err != nil {
+		return err
+	}
+	link(ev, email, user, RelPossibleUsername, confidence)
+	return nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestEmail(t *testing.T) {
+	setupIngestDB(t)
+
+	result := &core.EmailResult{
+		Email:       "jane.doe@acme.test",
+		Valid:       true,
+		LocalPart:   "jane.doe",
+		Domain:      "acme.test",
+		MX:          []core.DNSRecord{{Type: "MX", Value: "mx1.acme.test", Preference: 10}},
+		SPF:         "v=spf1 mx -all",
+		AcceptsMail: true,
+		SMTP:        &core.SMTPCheck{Server: "mx1.acme.test", Verdict: core.SMTPDeliverable, Code: 250},
+		GravatarMD5: "0cba00ca3da1b283a57287bcceb17e35",
+		Gravatar: &core.GravatarProfile{
+			ProfileURL: "https://gravatar.com/janedoe",
+			Username:   "janedoe",
+			Accounts:   []core.GravatarAccount{{Domain: "github.com", Username: "jdoe", URL: "https://github.com/jdoe"}},
+		},
+		Usernames: []string{"jane.doe", "janedoe", "jdoe"},
+	}
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "email", Metadata: map[string]interface{}{"target": result.Email}, RawData: result}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"jane.doe@acme.test -> acme.test":                    RelAtDomain,
+		"acme.test -> mx1.acme.test":                         RelMailserver,
+		"jane.doe@acme.test -> https://gravatar.com/janedoe": RelHasAccount,
+		"jane.doe@acme.test -> https://github.com/jdoe":      RelHasAccount,
+		"jane.doe@acme.test -> janedoe":                      RelPossibleUsername,
+		"jane.doe@acme.test -> jdoe":                         RelPossibleUsername,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	email, _ := GetEntityByValue("case-ingest", "jane.doe@acme.test")
+	if email == nil || email.Metadata["smtp"] != core.SMTPDeliverable || email.Metadata["gravatar_md5"] != result.GravatarMD5 {
+		t.Errorf("Expected email metadata, got %+v", email)
+	}
+	user, _ := GetEntityByValue("case-ingest", "jdoe")
+	if user == nil || user.Type != core.EntityUsername {
+		t.Errorf("Expected a username entity, got %+v", user)
+	}
+	domain, _ := GetEntityByValue("case-ingest", "acme.test")
+	if domain == nil || domain.Metadata["spf"] != "v=spf1 mx -all" {
+		t.Errorf("Expected SPF on the domain, got %+v", domain)
+	}
+}
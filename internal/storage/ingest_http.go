@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// HTTP relationship types.
+const (
+	RelUsesTechnology = "uses_technology" // domain/ip/url -> technology
+)
+
+// ingestHTTP records the response summary on the target and links it to
+// each detected technology. Technologies are shared across hosts so a
+// search for "WordPress" finds every site running it.
+func ingestHTTP(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.HTTPResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.HTTPResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse http evidence: %w", err)
+		}
+	}
+	target := result.Target
+	if target == "" {
+		target, _ = ev.Metadata["target"].(string)
+	}
+	if target == "" {
+		return nil
+	}
+
+	meta := map[string]interface{}{"http_status": result.StatusCode}
+	if server := result.Headers["Server"]; server != "" {
+		meta["http_server"] = server
+	}
+	if result.Title != "" {
+		meta["http_title"] = result.Title
+	}
+	if result.Favicon != nil {
+		meta["favicon_mmh3"] = result.Favicon.MMH3
+	}
+	if len(result.Technologies) > 0 {
+		techs := make(map[string]interface{}, len(result.Technologies))
+		for _, t := range result.Technologies {
+			techs[t.Name] = t.Version
+		}
+		meta["technologies"] = techs
+	}
+	host, err := ensureEntity(ev.CaseID, core.InferEntityType(target), target, "http", meta)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range result.Technologies {
+		tech, err := ensureTechnology(ev.CaseID, t)
+		if err != nil {
+			return err
+		}
+		link(ev, host, tech, RelUsesTechnology, float64(t.Confidence)/100)
+	}
+
+	trackers := result.Trackers
+	if trackers == nil && result.Body != "" {
+		// Evidence from before tracker extraction still has the body
+		trackers = core.ExtractTrackers(result.Body)
+	}
+	return linkTrackers(ev, host, trackers, "http")
+}
+
+// ensureTechnology gets or creates the entity for t, adding its version to
+// the list of versions seen.
+func ensureTechnology(caseID string, t core.Technology) (*core.Entity, error) {
+	existing, err := GetEntityByValue(caseID, t.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	if existing != nil {
+		if vs, ok := existing.Metadata["versions"].([]interface{}); ok {
+			for _, v := range vs {
+				if s, ok := v.(string); ok {
+					versions = append(versions, s)
+				}
+			}
+		}
+	}
+	if t.Version != "" && !slices.Contains(versions, t.Version) {
+		versions = append(versions, t.Version)
+		sort.Strings(versions)
+	}
+
+	meta := map[string]interface{}{"categories": t.Categories}
+	if len(versions) > 0 {
+		meta["versions"] = versions
+	}
+	return ensureEntity(caseID, core.EntityTechnology, t.Name, "http", meta)
+}
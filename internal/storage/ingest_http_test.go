@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestHTTP_Technologies(t *testing.T) {
+	setupIngestDB(t)
+
+	ingest := func(target, wpVersion string) {
+		result := &core.HTTPResult{Target: target, URL: "https://" + target + "/", StatusCode: 200,
+			Headers: map[string]string{"Server": "nginx"}, Title: "Blog",
+			Technologies: []core.Technology{
+				{Name: "WordPress", Version: wpVersion, Categories: []string{"CMS"}, Confidence: 100},
+				{Name: "PHP", Categories: []string{"Programming languages"}, Confidence: 50},
+			}}
+		ev := &core.Evidence{CaseID: "case-ingest", Collector: "http", Metadata: map[string]interface{}{"target": target}, RawData: result}
+		if err := CreateEvidence(ev); err != nil {
+			t.Fatal(err)
+		}
+		if err := IngestEvidence(ev); err != nil {
+			t.Fatalf("IngestEvidence failed: %v", err)
+		}
+	}
+	ingest("example.com", "6.4.2")
+	ingest("192.0.2.1", "5.9")
+
+	rels := relTypes(t, "case-ingest")
+	for _, k := range []string{"example.com -> WordPress", "example.com -> PHP", "192.0.2.1 -> WordPress"} {
+		if rels[k] != RelUsesTechnology {
+			t.Errorf("Expected %s to be %q, got %q", k, RelUsesTechnology, rels[k])
+		}
+	}
+
+	wp, _ := GetEntityByValue("case-ingest", "WordPress")
+	if wp == nil || wp.Type != core.EntityTechnology {
+		t.Fatalf("Expected a technology entity, got %+v", wp)
+	}
+	if versions, _ := wp.Metadata["versions"].([]interface{}); len(versions) != 2 || versions[0] != "5.9" || versions[1] != "6.4.2" {
+		t.Errorf("Expected both WordPress versions, got %v", wp.Metadata["versions"])
+	}
+
+	ip, _ := GetEntityByValue("case-ingest", "192.0.2.1")
+	if ip == nil || ip.Type != core.EntityIP || ip.Metadata["http_server"] != "nginx" {
+		t.Errorf("Expected the ip target to carry the server header, got %+v", ip)
+	}
+	if techs, _ := ip.Metadata["technologies"].(map[string]interface{}); techs["WordPress"] != "5.9" {
+		t.Errorf("Expected the host's technology map, got %v", ip.Metadata["technologies"])
+	}
+}
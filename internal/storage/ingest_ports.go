@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Port scan relationship types.
+const (
+	RelHasPort = "has_port" // ip -> service on that ip
+)
+
+// ingestPorts creates one service entity per host, port and protocol, so
+// port 443 on two hosts are two services rather than a shared "TCP/443".
+func ingestPorts(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.PortScanResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.PortScanResult{}
+		if err := json.Unmarshal(data, result); err != nil || result.Target == "" {
+			// Evidence from before service detection: {"22": "open"}
+			result, err = legacyPortScan(ev, data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var domain *core.Entity
+	if net.ParseIP(result.Target) == nil {
+		if _, _, err := net.ParseCIDR(result.Target); err != nil {
+			ent, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Target, "ports", nil)
+			if err != nil {
+				return err
+			}
+			domain = ent
+		}
+	}
+
+	hosts := make(map[string]*core.Entity)
+	for _, open := range result.Open {
+		host, ok := hosts[open.Host]
+		if !ok {
+			hostType := core.EntityIP
+			if net.ParseIP(open.Host) == nil {
+				hostType = core.EntityDomain
+			}
+			ent, err := ensureEntity(ev.CaseID, hostType, open.Host, "ports", nil)
+			if err != nil {
+				return err
+			}
+			host, hosts[open.Host] = ent, ent
+			if hostType == core.EntityIP {
+				link(ev, domain, host, RelResolvesTo, 1.0)
+			}
+		}
+
+		meta := map[string]interface{}{
+			"host":     open.Host,
+			"port":     open.Port,
+			"protocol": open.Protocol,
+		}
+		for k, v := range map[string]string{"service": open.Service, "product": open.Product, "version": open.Version, "info": open.Info, "banner": open.Banner, "detected_by": open.Method} {
+			if v != "" {
+				meta[k] = v
+			}
+		}
+		if open.TLS {
+			meta["tls"] = true
+		}
+		svc, err := ensureEntity(ev.CaseID, core.EntityService, open.ServiceValue(), "ports", meta)
+		if err != nil {
+			return err
+		}
+		link(ev, host, svc, RelHasPort, 1.0)
+	}
+	return nil
+}
+
+func legacyPortScan(ev *core.Evidence, data []byte) (*core.PortScanResult, error) {
+	var legacy map[string]string
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal port scan results: %w", err)
+	}
+	target, _ := ev.Metadata["target"].(string)
+	result := &core.PortScanResult{Target: target}
+	for port, status := range legacy {
+		n, err := strconv.Atoi(port)
+		if err != nil || status != "open" {
+			continue
+		}
+		result.Open = append(result.Open, core.OpenPort{Host: target, Port: n, Protocol: "tcp"})
+	}
+	return result, nil
+}
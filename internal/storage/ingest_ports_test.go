@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestPorts_ServicesPerHost(t *testing.T) {
+	setupIngestDB(t)
+
+	result := &core.PortScanResult{Target: "example.com", Hosts: []string{"192.0.2.1", "192.0.2.2"}, Open: []core.OpenPort{
+		{Host: "192.0.2.1", Port: 443, Protocol: "tcp", Service: "https", Product: "nginx", Version: "1.25.3", TLS: true, Method: "TLSGetRequest"},
+		{Host: "192.0.2.2", Port: 443, Protocol: "tcp", Service: "https", Method: "port"},
+		{Host: "192.0.2.2", Port: 53, Protocol: "udp", Service: "domain"},
+	}}
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "ports", Metadata: map[string]interface{}{"target": "example.com"}, RawData: result}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"example.com -> 192.0.2.1":       RelResolvesTo,
+		"example.com -> 192.0.2.2":       RelResolvesTo,
+		"192.0.2.1 -> 192.0.2.1:443/tcp": RelHasPort,
+		"192.0.2.2 -> 192.0.2.2:443/tcp": RelHasPort,
+		"192.0.2.2 -> 192.0.2.2:53/udp":  RelHasPort,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	svc, _ := GetEntityByValue("case-ingest", "192.0.2.1:443/tcp")
+	if svc == nil || svc.Type != core.EntityService || svc.Metadata["product"] != "nginx" || svc.Metadata["tls"] != true {
+		t.Errorf("Unexpected service entity: %+v", svc)
+	}
+}
+
+func TestIngestPorts_LegacyEvidence(t *testing.T) {
+	setupIngestDB(t)
+
+	path := filepath.Join(t.TempDir(), "ports_192.0.2.9.json")
+	os.WriteFile(path, []byte(`{"22": "open", "80": "open"}`), 0644)
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: "ports", FilePath: path, Metadata: map[string]interface{}{"target": "192.0.2.9"}}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+
+	rels := relTypes(t, "case-ingest")
+	if rels["192.0.2.9 -> 192.0.2.9:22/tcp"] != RelHasPort || rels["192.0.2.9 -> 192.0.2.9:80/tcp"] != RelHasPort {
+		t.Errorf("Unexpected relationships from legacy evidence: %v", rels)
+	}
+	if e, _ := GetEntityByValue("case-ingest", "TCP/22"); e != nil {
+		t.Error("Legacy evidence must not create shared TCP/<port> services")
+	}
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// RDAP relationship types.
+const (
+	RelRegisteredWith   = "registered_with"   // domain -> registrar organization
+	RelAllocatedTo      = "allocated_to"      // netblock or asn -> holder organization
+	RelContainedIn      = "contained_in"      // ip -> netblock
+	RelAbuseContact     = "abuse_contact"     // domain, netblock or asn -> abuse email/phone
+	RelTechnicalContact = "technical_contact" // domain, netblock or asn -> technical/NOC email/phone
+	RelAdminContact     = "admin_contact"     // domain, netblock or asn -> administrative email/phone
+)
+
+// ingestRDAP links the queried object to its registrar, network ranges and
+// role contacts. For an IP the contacts belong to the network holding it,
+// so they hang off the netblock rather than the address. Redacted contacts
+// are skipped.
+func ingestRDAP(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.RDAPResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.RDAPResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse RDAP evidence: %w", err)
+		}
+	}
+
+	meta := map[string]interface{}{"rdap_handle": result.Handle}
+	if len(result.Status) > 0 {
+		meta["rdap_status"] = result.Status
+	}
+	for action, key := range map[string]string{"registration": "registered_at", "expiration": "expires_at", "last changed": "updated_at"} {
+		if t, ok := result.Event(action); ok {
+			meta[key] = t.Format(time.RFC3339)
+		}
+	}
+
+	subjectType := core.EntityDomain
+	switch result.ObjectClass {
+	case core.RDAPNetwork:
+		subjectType = core.EntityIP
+		if strings.Contains(result.Query, "/") {
+			subjectType = core.EntityNetblock
+		}
+	case core.RDAPAutnum:
+		subjectType = core.EntityASN
+		meta["as_name"] = result.Name
+	default:
+		if result.Registrar != "" {
+			meta["registrar"] = result.Registrar
+		}
+	}
+	subject, err := ensureEntity(ev.CaseID, subjectType, result.Query, "rdap", meta)
+	if err != nil {
+		return err
+	}
+
+	// Contacts of a network belong to its (most specific) netblock
+	holder := subject
+	if result.ObjectClass == core.RDAPNetwork {
+		netMeta := map[string]interface{}{
+			"network_name": result.Name, "rdap_handle": result.Handle, "country": result.Country,
+			"network_type": result.NetworkType, "start_address": result.StartAddress, "end_address": result.EndAddress,
+		}
+		for i, cidr := range result.CIDRs {
+			block, err := ensureEntity(ev.CaseID, core.EntityNetblock, cidr, "rdap", netMeta)
+			if err != nil {
+				return err
+			}
+			link(ev, subject, block, RelContainedIn, 1.0)
+			if i == 0 {
+				holder = block
+			}
+		}
+	}
+
+	if result.Registrar != "" && result.ObjectClass != core.RDAPNetwork && result.ObjectClass != core.RDAPAutnum {
+		regMeta := map[string]interface{}{"registrar": true}
+		if result.RegistrarID != "" {
+			regMeta["iana_id"] = result.RegistrarID
+		}
+		registrar, err := ensureEntity(ev.CaseID, core.EntityOrganization, result.Registrar, "rdap", regMeta)
+		if err != nil {
+			return err
+		}
+		link(ev, subject, registrar, RelRegisteredWith, 1.0)
+	}
+	for _, ns := range result.NameServers {
+		ent, err := ensureEntity(ev.CaseID, core.EntityDomain, ns, "rdap", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, subject, ent, RelNameserver, 1.0)
+	}
+
+	for _, c := range result.Contacts {
+		if c.Redacted {
+			continue
+		}
+		for _, role := range c.Roles {
+			if err := linkRDAPContact(ev, holder, result.ObjectClass, strings.ToLower(role), c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// linkRDAPContact links one role of a contact. Registrants become the
+// owning organization; the other roles link their emails and phones.
+func linkRDAPContact(ev *core.Evidence, holder *core.Entity, objectClass, role string, c core.RDAPContact) error {
+	var relType string
+	switch role {
+	case "registrant":
+		orgRel, emailRel := RelRegisteredTo, RelRegisteredBy
+		if objectClass == core.RDAPNetwork || objectClass == core.RDAPAutnum {
+			orgRel, emailRel = RelAllocatedTo, RelAllocatedTo
+		}
+		if c.Organization != "" {
+			org, err := ensureEntity(ev.CaseID, core.EntityOrganization, c.Organization, "rdap", map[string]interface{}{"rdap_handle": c.Handle})
+			if err != nil {
+				return err
+			}
+			link(ev, holder, org, orgRel, 1.0)
+		}
+		for _, email := range c.Emails {
+			ent, err := ensureEntity(ev.CaseID, core.EntityEmail, email, "rdap", nil)
+			if err != nil {
+				return err
+			}
+			link(ev, holder, ent, emailRel, 1.0)
+		}
+		return nil
+	case "abuse":
+		relType = RelAbuseContact
+	case "technical", "noc":
+		relType = RelTechnicalContact
+	case "administrative":
+		relType = RelAdminContact
+	default:
+		return nil // registrar is handled separately; billing, reseller etc. say little
+	}
+
+	for _, email := range c.Emails {
+		ent, err := ensureEntity(ev.CaseID, core.EntityEmail, email, "rdap", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, holder, ent, relType, 1.0)
+	}
+	for _, phone := range c.Phones {
+		ent, err := ensureEntity(ev.CaseID, core.EntityPhone, phone, "rdap", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, holder, ent, relType, 1.0)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestRDAPNetwork(t *testing.T) {
+	setupIngestDB(t)
+
+	ingestResult(t, "rdap", &core.RDAPResult{
+		Query:       "192.0.2.10",
+		ObjectClass: core.RDAPNetwork,
+		Handle:      "NET-192-0-2-0-1",
+		Name:        "TEST-NET-1",
+		CIDRs:       []string{"192.0.2.0/24"},
+		Country:     "US",
+		Contacts: []core.RDAPContact{
+			{Handle: "EXAMPLE-ORG", Roles: []string{"registrant"}, Organization: "Example Hosting LLC"},
+			{Roles: []string{"abuse"}, Emails: []string{"abuse@example-hosting.test"}, Phones: []string{"+15550100199"}},
+			{Roles: []string{"technical"}, Emails: []string{"redacted@example-hosting.test"}, Redacted: true},
+		},
+	})
+	ingestResult(t, "rdap", &core.RDAPResult{
+		Query:       "AS64496",
+		ObjectClass: core.RDAPAutnum,
+		Name:        "EXAMPLE-AS",
+		Contacts:    []core.RDAPContact{{Roles: []string{"registrant"}, Organization: "Example Hosting LLC"}},
+	})
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"192.0.2.10 -> 192.0.2.0/24":                 RelContainedIn,
+		"192.0.2.0/24 -> Example Hosting LLC":        RelAllocatedTo,
+		"192.0.2.0/24 -> abuse@example-hosting.test": RelAbuseContact,
+		"192.0.2.0/24 -> +15550100199":               RelAbuseContact,
+		"AS64496 -> Example Hosting LLC":             RelAllocatedTo,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+	if rel, ok := rels["192.0.2.0/24 -> redacted@example-hosting.test"]; ok {
+		t.Errorf("Redacted contact should not be linked, got %q", rel)
+	}
+
+	block, _ := GetEntityByValue("case-ingest", "192.0.2.0/24")
+	if block == nil || block.Type != core.EntityNetblock || block.Metadata["network_name"] != "TEST-NET-1" {
+		t.Errorf("Expected a netblock entity, got %+v", block)
+	}
+	asn, _ := GetEntityByValue("case-ingest", "AS64496")
+	if asn == nil || asn.Type != core.EntityASN || asn.Metadata["as_name"] != "EXAMPLE-AS" {
+		t.Errorf("Expected an asn entity, got %+v", asn)
+	}
+}
+
+func TestIngestRDAPDomain(t *testing.T) {
+	setupIngestDB(t)
+
+	ingestResult(t, "rdap", &core.RDAPResult{
+		Query:       "example.test",
+		ObjectClass: core.RDAPDomain,
+		Registrar:   "Example Registrar, Inc.",
+		RegistrarID: "9999",
+		Events:      []core.RDAPEvent{{Action: "registration", Date: time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC)}},
+		NameServers: []string{"a.iana-servers.net"},
+		Contacts: []core.RDAPContact{
+			{Roles: []string{"registrar"}, Name: "Example Registrar, Inc."},
+			{Roles: []string{"registrant"}, Organization: "Acme Widgets Ltd", Emails: []string{"jane@acme.test"}},
+			{Roles: []string{"administrative"}, Emails: []string{"admin@acme.test"}},
+		},
+	})
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"example.test -> Example Registrar, Inc.": RelRegisteredWith,
+		"example.test -> a.iana-servers.net":      RelNameserver,
+		"example.test -> Acme Widgets Ltd":        RelRegisteredTo,
+		"example.test -> jane@acme.test":          RelRegisteredBy,
+		"example.test -> admin@acme.test":         RelAdminContact,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+
+	domain, _ := GetEntityByValue("case-ingest", "example.test")
+	if domain.Metadata["registered_at"] != "1995-08-14T04:00:00Z" || domain.Metadata["registrar"] != "Example Registrar, Inc." {
+		t.Errorf("Expected registration metadata, got %+v", domain.Metadata)
+	}
+	registrar, _ := GetEntityByValue("case-ingest", "Example Registrar, Inc.")
+	if registrar == nil || registrar.Metadata["iana_id"] != "9999" {
+		t.Errorf("Expected registrar organization, got %+v", registrar)
+	}
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Reverse DNS relationship types.
+const (
+	RelHasPTR       = "has_ptr"        // ip -> hostname in its PTR record
+	RelCoHostedWith = "co_hosted_with" // domain -> other domain seen on the same ip
+)
+
+// maxCoHostedAnchors caps the domains linked to every other name on an
+// address with co_hosted_with.
+const maxCoHostedAnchors = 5
+
+// ingestReverse links the address to its PTR names and passive DNS names.
+// Names seen in passive DNS resolve to the address at lower confidence,
+// since the sighting may be old. The case's own domains on the address
+// (those already resolving to it) are linked to each of them with
+// co_hosted_with. When there are none, as when the address is the seed,
+// the most recently seen names stand in for them. Either way at most
+// maxCoHostedAnchors are used; linking every pair would explode on shared
+// hosting.
+func ingestReverse(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.ReverseDNSResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.ReverseDNSResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse reverse DNS evidence: %w", err)
+		}
+	}
+
+	var meta map[string]interface{}
+	if len(result.PTR) > 0 {
+		meta = map[string]interface{}{"ptr": result.PTR}
+	}
+	ip, err := ensureEntity(ev.CaseID, core.EntityIP, result.IP, "reverse", meta)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range result.PTR {
+		host, err := ensureEntity(ev.CaseID, core.EntityDomain, name, "reverse", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, ip, host, RelHasPTR, 1.0)
+	}
+
+	anchors, err := domainsResolvingTo(ev.CaseID, ip.ID)
+	if err != nil {
+		return err
+	}
+	var domains []*core.Entity
+	for _, rec := range result.Resolutions {
+		domain, err := ensureEntity(ev.CaseID, core.EntityDomain, rec.RRName, "reverse", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, domain, ip, RelResolvesTo, 0.8)
+		domains = append(domains, domain)
+	}
+
+	if len(anchors) == 0 {
+		anchors = domains // Most recently seen first
+	}
+	if len(anchors) > maxCoHostedAnchors {
+		anchors = anchors[:maxCoHostedAnchors]
+	}
+	linked := make(map[string]bool)
+	for _, anchor := range anchors {
+		linked[anchor.ID] = true
+		for _, domain := range domains {
+			if !linked[domain.ID] {
+				link(ev, anchor, domain, RelCoHostedWith, 0.7)
+			}
+		}
+	}
+	return nil
+}
+
+// domainsResolvingTo returns the domains of a case with a resolves_to link
+// to the entity ipID.
+func domainsResolvingTo(caseID, ipID string) ([]*core.Entity, error) {
+	rows, err := DB.Query(`SELECT e.id, e.value FROM relationships r
+	          JOIN entities e ON e.id = r.from_entity
+	          WHERE r.case_id = ? AND r.to_entity = ? AND r.rel_type = ? AND e.type = ?`,
+		caseID, ipID, RelResolvesTo, core.EntityDomain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query resolutions: %w", err)
+	}
+	defer rows.Close()
+
+	var domains []*core.Entity
+	for rows.Next() {
+		e := &core.Entity{CaseID: caseID, Type: core.EntityDomain}
+		if err := rows.Scan(&e.ID, &e.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan resolution: %w", err)
+		}
+		domains = append(domains, e)
+	}
+	return domains, rows.Err()
+}
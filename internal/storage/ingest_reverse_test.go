@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestIngestReverse(t *testing.T) {
+	setupIngestDB(t)
+
+	// The case's own domain already resolves to the address
+	ingestResult(t, "dns", &core.DNSResult{Domain: "example.com", Answers: []*core.DNSAnswer{
+		{Query: "example.com", Type: "A", Records: []core.DNSRecord{{Name: "example.com", Type: "A", Value: "192.0.2.10"}}},
+	}})
+
+	seen := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	ingestResult(t, "reverse", &core.ReverseDNSResult{
+		IP:  "192.0.2.10",
+		PTR: []string{"host-10.provider.test"},
+		Resolutions: []core.PDNSRecord{
+			{RRName: "example.com", RRType: "A", RData: "192.0.2.10", FirstSeen: seen, LastSeen: seen, Source: "local"},
+			{RRName: "shop.test", RRType: "A", RData: "192.0.2.10", FirstSeen: seen, LastSeen: seen, Source: "local"},
+			{RRName: "blog.test", RRType: "A", RData: "192.0.2.10", FirstSeen: seen, LastSeen: seen, Source: "local"},
+		},
+	})
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"192.0.2.10 -> host-10.provider.test": RelHasPTR,
+		"example.com -> 192.0.2.10":           RelResolvesTo,
+		"shop.test -> 192.0.2.10":             RelResolvesTo,
+		"blog.test -> 192.0.2.10":             RelResolvesTo,
+		"example.com -> shop.test":            RelCoHostedWith,
+		"example.com -> blog.test":            RelCoHostedWith,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+	for _, k := range []string{"example.com -> example.com", "shop.test -> blog.test", "blog.test -> shop.test"} {
+		if rels[k] != "" {
+			t.Errorf("Unexpected relationship %s (%s)", k, rels[k])
+		}
+	}
+
+	ip, _ := GetEntityByValue("case-ingest", "192.0.2.10")
+	if ptr, _ := ip.Metadata["ptr"].([]interface{}); len(ptr) != 1 || ptr[0] != "host-10.provider.test" {
+		t.Errorf("Expected PTR metadata on the IP, got %+v", ip.Metadata)
+	}
+}
+
+func TestIngestReverse_SeedIP(t *testing.T) {
+	setupIngestDB(t)
+
+	// No case domain resolves to the address: the most recent names anchor
+	seen := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	res := &core.ReverseDNSResult{IP: "192.0.2.20"}
+	for i := 0; i <= maxCoHostedAnchors+1; i++ {
+		res.Resolutions = append(res.Resolutions, core.PDNSRecord{
+			RRName: fmt.Sprintf("site%d.test", i), RRType: "A", RData: "192.0.2.20", FirstSeen: seen, LastSeen: seen, Source: "local",
+		})
+	}
+	ingestResult(t, "reverse", res)
+
+	rels := relTypes(t, "case-ingest")
+	last := fmt.Sprintf("site%d.test", maxCoHostedAnchors+1)
+	for _, k := range []string{"site0.test -> site1.test", "site0.test -> " + last, fmt.Sprintf("site%d.test -> %s", maxCoHostedAnchors-1, last)} {
+		if rels[k] != RelCoHostedWith {
+			t.Errorf("Expected %s to be %q, got %q", k, RelCoHostedWith, rels[k])
+		}
+	}
+	for _, k := range []string{"site1.test -> site0.test", fmt.Sprintf("site%d.test -> %s", maxCoHostedAnchors, last)} {
+		if rels[k] != "" {
+			t.Errorf("Unexpected relationship %s (%s)", k, rels[k])
+		}
+	}
+}
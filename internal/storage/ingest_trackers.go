@@ -0,0 +1,22 @@
+package storage
+
+import "github.com/spectre/spectre/internal/core"
+
+// Tracker relationship types.
+const (
+	RelUsesTracker = "uses_tracker" // site -> tracker_id embedded in its pages
+)
+
+// linkTrackers creates a tracker_id entity for each ID and links site to
+// it. Sites sharing an ID end up on the same node, which is what
+// TrackerClusters looks for.
+func linkTrackers(ev *core.Evidence, site *core.Entity, trackers []core.TrackerID, source string) error {
+	for _, t := range trackers {
+		tracker, err := ensureEntity(ev.CaseID, core.EntityTracker, t.Value(), source, map[string]interface{}{"tracker_type": t.Type, "tracker_id": t.ID})
+		if err != nil {
+			return err
+		}
+		link(ev, site, tracker, RelUsesTracker, 1.0)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// WHOIS relationship types.
+const (
+	RelRegisteredBy           = "registered_by"            // domain -> registrant email
+	RelRegisteredTo           = "registered_to"            // domain -> registrant organization
+	RelPreviouslyRegisteredBy = "previously_registered_by" // domain -> registrant email or organization in historical WHOIS
+)
+
+// ingestWHOIS records the registration details on the domain and links it
+// to its registrant and nameservers. Privacy-proxy contacts are shared by
+// thousands of unrelated domains, so they are noted but never linked.
+func ingestWHOIS(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.WHOISResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.WHOISResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			// Older evidence is the raw reply; only its metadata was parsed
+			return ingestLegacyWHOIS(ev)
+		}
+	}
+
+	record := result.Record
+	if record == nil {
+		_, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Domain, "whois", nil)
+		return err
+	}
+
+	meta := map[string]interface{}{
+		"registrar": record.Registrar,
+		"privacy":   record.Privacy,
+		"dnssec":    record.DNSSEC,
+	}
+	for key, t := range map[string]*time.Time{"registered_at": record.CreatedAt, "updated_at": record.UpdatedAt, "expires_at": record.ExpiresAt} {
+		if t != nil {
+			meta[key] = t.Format(time.RFC3339)
+		}
+	}
+	if len(record.Status) > 0 {
+		meta["whois_status"] = record.Status
+	}
+	if record.PrivacyService != "" {
+		meta["privacy_service"] = record.PrivacyService
+	}
+	if r := record.Registrant; r != nil && r.Country != "" {
+		meta["registrant_country"] = r.Country
+	}
+	if len(result.History) > 0 {
+		meta["whois_history"] = len(result.History)
+	}
+	domain, err := ensureEntity(ev.CaseID, core.EntityDomain, result.Domain, "whois", meta)
+	if err != nil {
+		return err
+	}
+
+	for _, ns := range record.NameServers {
+		ent, err := ensureEntity(ev.CaseID, core.EntityDomain, ns, "whois", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, domain, ent, RelNameserver, 1.0)
+	}
+
+	current := make(map[string]bool)
+	if !record.Privacy && record.Registrant != nil {
+		if email := record.Registrant.Email; email != "" {
+			current[email] = true
+			if err := linkRegistrant(ev, domain, core.EntityEmail, email, RelRegisteredBy, 1.0); err != nil {
+				return err
+			}
+		}
+		if org := record.Registrant.Organization; org != "" {
+			current[strings.ToLower(org)] = true
+			if err := linkRegistrant(ev, domain, core.EntityOrganization, org, RelRegisteredTo, 1.0); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Earlier registrants, often from before a privacy service was enabled
+	for _, h := range result.History {
+		if h.Privacy || h.Registrant == nil {
+			continue
+		}
+		if email := h.Registrant.Email; email != "" && !current[email] {
+			current[email] = true
+			if err := linkRegistrant(ev, domain, core.EntityEmail, email, RelPreviouslyRegisteredBy, 0.8); err != nil {
+				return err
+			}
+		}
+		if org := h.Registrant.Organization; org != "" && !current[strings.ToLower(org)] {
+			current[strings.ToLower(org)] = true
+			if err := linkRegistrant(ev, domain, core.EntityOrganization, org, RelPreviouslyRegisteredBy, 0.8); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ingestLegacyWHOIS handles evidence from before records were parsed into
+// the evidence file, using the registrant email kept in its metadata.
+func ingestLegacyWHOIS(ev *core.Evidence) error {
+	target, _ := ev.Metadata["target"].(string)
+	domain, err := ensureEntity(ev.CaseID, core.EntityDomain, target, "whois", nil)
+	if err != nil {
+		return err
+	}
+	if email, ok := ev.Metadata["registrant_email"].(string); ok && email != "" {
+		return linkRegistrant(ev, domain, core.EntityEmail, email, RelRegisteredBy, 1.0)
+	}
+	return nil
+}
+
+// ingestReverseWHOIS links every domain a provider attributed to the
+// queried email or organisation. Provider databases lag behind the live
+// registries, hence the lower confidence.
+func ingestReverseWHOIS(ev *core.Evidence) error {
+	result, _ := ev.RawData.(*core.ReverseWHOISResult)
+
+	// Fallback to disk
+	if result == nil {
+		data, err := os.ReadFile(ev.FilePath)
+		if err != nil {
+			return fmt.Errorf("failed to read evidence file: %w", err)
+		}
+		result = &core.ReverseWHOISResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("failed to parse reverse WHOIS evidence: %w", err)
+		}
+	}
+
+	entityType, relType := core.EntityEmail, RelRegisteredBy
+	if result.Field == "organization" {
+		entityType, relType = core.EntityOrganization, RelRegisteredTo
+	}
+	registrant, err := ensureEntity(ev.CaseID, entityType, result.Query, "reverse_whois", nil)
+	if err != nil {
+		return err
+	}
+	for _, d := range result.Domains {
+		domain, err := ensureEntity(ev.CaseID, core.EntityDomain, d, "reverse_whois", nil)
+		if err != nil {
+			return err
+		}
+		link(ev, domain, registrant, relType, 0.9)
+	}
+	return nil
+}
+
+func linkRegistrant(ev *core.Evidence, domain *core.Entity, entityType, value, relType string, confidence float64) error {
+	ent, err := ensureEntity(ev.CaseID, entityType, value, "whois", nil)
+	if err != nil {
+		return err
+	}
+	link(ev, domain, ent, relType, confidence)
+	return nil
+}
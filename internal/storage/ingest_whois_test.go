@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func ingestResult(t *testing.T, collector string, raw interface{}) {
+	t.Helper()
+	ev := &core.Evidence{CaseID: "case-ingest", Collector: collector, Metadata: map[string]interface{}{}, RawData: raw}
+	if err := CreateEvidence(ev); err != nil {
+		t.Fatal(err)
+	}
+	if err := IngestEvidence(ev); err != nil {
+		t.Fatalf("IngestEvidence failed: %v", err)
+	}
+}
+
+func TestIngestWHOIS(t *testing.T) {
+	setupIngestDB(t)
+
+	created := time.Date(2015, 8, 14, 4, 0, 0, 0, time.UTC)
+	ingestResult(t, "whois", &core.WHOISResult{
+		Domain: "acme.test",
+		Record: &core.WHOISRecord{
+			Domain:      "acme.test",
+			Registrar:   "Example Registrar, Inc.",
+			CreatedAt:   &created,
+			NameServers: []string{"ns1.host.test"},
+			Registrant:  &core.WHOISContact{Organization: "Acme Widgets Ltd", Email: "jane@acme.test", Country: "GB"},
+		},
+		History: []core.WHOISRecord{
+			{Domain: "acme.test", Registrant: &core.WHOISContact{Email: "jdoe@oldmail.test"}},
+			{Domain: "acme.test", Registrant: &core.WHOISContact{Email: "jane@acme.test"}},
+		},
+	})
+	ingestResult(t, "whois", &core.WHOISResult{
+		Domain: "hidden.test",
+		Record: &core.WHOISRecord{
+			Domain:         "hidden.test",
+			Registrant:     &core.WHOISContact{Organization: "Domains By Proxy, LLC", Email: "hidden.test@domainsbyproxy.test"},
+			Privacy:        true,
+			PrivacyService: "Domains By Proxy, LLC",
+		},
+	})
+
+	rels := relTypes(t, "case-ingest")
+	want := map[string]string{
+		"acme.test -> jane@acme.test":    RelRegisteredBy,
+		"acme.test -> Acme Widgets Ltd":  RelRegisteredTo,
+		"acme.test -> ns1.host.test":     RelNameserver,
+		"acme.test -> jdoe@oldmail.test": RelPreviouslyRegisteredBy,
+	}
+	for k, v := range want {
+		if rels[k] != v {
+			t.Errorf("Expected %s to be %q, got %q", k, v, rels[k])
+		}
+	}
+	if rel, ok := rels["hidden.test -> hidden.test@domainsbyproxy.test"]; ok {
+		t.Errorf("Privacy proxy email should not be linked, got %q", rel)
+	}
+
+	domain, _ := GetEntityByValue("case-ingest", "acme.test")
+	if domain.Metadata["registrar"] != "Example Registrar, Inc." || domain.Metadata["registered_at"] != "2015-08-14T04:00:00Z" {
+		t.Errorf("Expected registration metadata, got %+v", domain.Metadata)
+	}
+	org, _ := GetEntityByValue("case-ingest", "Acme Widgets Ltd")
+	if org == nil || org.Type != core.EntityOrganization {
+		t.Errorf("Expected an organization entity, got %+v", org)
+	}
+	hidden, _ := GetEntityByValue("case-ingest", "hidden.test")
+	if hidden.Metadata["privacy"] != true || hidden.Metadata["privacy_service"] != "Domains By Proxy, LLC" {
+		t.Errorf("Expected privacy metadata, got %+v", hidden.Metadata)
+	}
+}
+
+func TestIngestReverseWHOIS(t *testing.T) {
+	setupIngestDB(t)
+
+	ingestResult(t, "reverse_whois", &core.ReverseWHOISResult{
+		Query: "jane@acme.test", Field: "email", Providers: []string{"testdb"},
+		Domains: []string{"acme.test", "acme-gadgets.test"},
+	})
+
+	rels := relTypes(t, "case-ingest")
+	for _, k := range []string{"acme.test -> jane@acme.test", "acme-gadgets.test -> jane@acme.test"} {
+		if rels[k] != RelRegisteredBy {
+			t.Errorf("Expected %s to be %q, got %q", k, RelRegisteredBy, rels[k])
+		}
+	}
+}
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 
 	"github.com/spectre/spectre/internal/core"
@@ -10,11 +9,30 @@ import (
 
 // IngestEvidence parses evidence data and populates the graph (entities/relationships).
 func IngestEvidence(ev *core.Evidence) error {
+	if err := ingest(ev); err != nil {
+		return err
+	}
+	return RecordCustody(ev.CaseID, ev.ID, core.CustodyIngested, ev.FileHash, map[string]string{"collector": ev.Collector})
+}
+
+func ingest(ev *core.Evidence) error {
 	switch ev.Collector {
 	case "dns":
 		return ingestDNS(ev)
+	case "subdomains":
+		return ingestSubdomains(ev)
+	case "ct":
+		return ingestCT(ev)
 	case "whois":
 		return ingestWHOIS(ev)
+	case "reverse_whois":
+		return ingestReverseWHOIS(ev)
+	case "rdap":
+		return ingestRDAP(ev)
+	case "asn":
+		return ingestASN(ev)
+	case "reverse":
+		return ingestReverse(ev)
 	case "github":
 		return ingestGitHub(ev)
 	case "geo":
@@ -23,6 +41,14 @@ func IngestEvidence(ev *core.Evidence) error {
 		return ingestPorts(ev)
 	case "http":
 		return ingestHTTP(ev)
+	case "tls":
+		return ingestTLS(ev)
+	case "crawl":
+		return ingestCrawl(ev)
+	case "email":
+		return ingestEmail(ev)
+	case "breach":
+		return ingestBreach(ev)
 	case "screenshot":
 		return ingestScreenshot(ev)
 	case "social":
@@ -79,7 +105,7 @@ func ingestSocial(ev *core.Evidence) error {
 			CaseID:       ev.CaseID,
 			FromEntityID: userEnt.ID,
 			ToEntityID:   siteEnt.ID,
-			Type:         "has_account",
+			Type:         RelHasAccount,
 			EvidenceID:   ev.ID,
 		}
 		CreateRelationship(rel)
@@ -116,99 +142,6 @@ func ingestScreenshot(ev *core.Evidence) error {
 	return CreateRelationship(rel)
 }
 
-func ingestPorts(ev *core.Evidence) error {
-	targetIP := ev.Metadata["target"].(string)
-	
-	data, err := os.ReadFile(ev.FilePath)
-	if err != nil {
-		return err
-	}
-
-	var results map[string]string
-	if err := json.Unmarshal(data, &results); err != nil {
-		return err
-	}
-
-	// Ensure IP entity exists
-	ipEnt, _ := GetEntityByValue(ev.CaseID, targetIP)
-	if ipEnt == nil {
-		ipEnt = &core.Entity{CaseID: ev.CaseID, Type: "ip", Value: targetIP, Source: "ports"}
-		CreateEntity(ipEnt)
-	}
-
-	for port, status := range results {
-		if status == "open" {
-			svcName := fmt.Sprintf("TCP/%s", port)
-			svcEnt := &core.Entity{
-				CaseID: ev.CaseID,
-				Type:   "service",
-				Value:  svcName,
-				Source: "ports",
-			}
-			
-			existing, _ := GetEntityByValue(ev.CaseID, svcName)
-			if existing == nil {
-				CreateEntity(svcEnt)
-			} else {
-				svcEnt = existing
-			}
-
-			// Link IP -> has -> Service
-			rel := &core.Relationship{
-				CaseID:       ev.CaseID,
-				FromEntityID: ipEnt.ID,
-				ToEntityID:   svcEnt.ID,
-				Type:         "has_port",
-				EvidenceID:   ev.ID,
-			}
-			CreateRelationship(rel)
-		}
-	}
-	return nil
-}
-
-func ingestHTTP(ev *core.Evidence) error {
-	target := ev.Metadata["target"].(string)
-	server := ""
-	if s, ok := ev.Metadata["server"].(string); ok {
-		server = s
-	}
-
-	// Ensure target entity exists
-	targetEnt, _ := GetEntityByValue(ev.CaseID, target)
-	if targetEnt == nil {
-		targetEnt = &core.Entity{CaseID: ev.CaseID, Type: "domain", Value: target, Source: "http"}
-		CreateEntity(targetEnt)
-	}
-
-	if server != "" {
-		svcEnt := &core.Entity{
-			CaseID: ev.CaseID,
-			Type:   "service",
-			Value:  server,
-			Source: "http",
-		}
-		
-		existing, _ := GetEntityByValue(ev.CaseID, server)
-		if existing == nil {
-			CreateEntity(svcEnt)
-		} else {
-			svcEnt = existing
-		}
-
-		// Link Target -> runs -> Service
-		rel := &core.Relationship{
-			CaseID:       ev.CaseID,
-			FromEntityID: targetEnt.ID,
-			ToEntityID:   svcEnt.ID,
-			Type:         "runs_service",
-			EvidenceID:   ev.ID,
-		}
-		CreateRelationship(rel)
-	}
-	return nil
-}
-
 func ingestGeo(ev *core.Evidence) error {
 	targetIP := ev.Metadata["target"].(string)
 	
@@ -318,129 +251,47 @@ func ingestGitHub(ev *core.Evidence) error {
 	return nil
 }
 
-func ingestWHOIS(ev *core.Evidence) error {
-	targetDomain := ev.Metadata["target"].(string)
-	
-	// Ensure domain entity exists
-	domainEnt, _ := GetEntityByValue(ev.CaseID, targetDomain)
-	if domainEnt == nil {
-		domainEnt = &core.Entity{
-			CaseID: ev.CaseID,
-			Type:   "domain",
-			Value:  targetDomain,
-			Source: "whois",
-		}
-		if err := CreateEntity(domainEnt); err != nil {
-			return err
-		}
-	}
-
-	// If we have a registrant email, create it and link it
-	if email, ok := ev.Metadata["registrant_email"].(string); ok && email != "" {
-		emailEnt := &core.Entity{
-			CaseID: ev.CaseID,
-			Type:   "email",
-			Value:  email,
-			Source: "whois",
-		}
-		
-		existingEmail, _ := GetEntityByValue(ev.CaseID, email)
-		if existingEmail == nil {
-			if err := CreateEntity(emailEnt); err != nil {
-				return err
-			}
-		} else {
-			emailEnt = existingEmail
-		}
-
-		// Link Domain -> owns -> Email (or registered_by)
-		rel := &core.Relationship{
-			CaseID:       ev.CaseID,
-			FromEntityID: domainEnt.ID,
-			ToEntityID:   emailEnt.ID,
-			Type:         "registered_by",
-			EvidenceID:   ev.ID,
-			Confidence:   1.0,
-		}
-		CreateRelationship(rel)
-	}
-
-	return nil
-}
-
-func ingestDNS(ev *core.Evidence) error {
-	var results map[string][]string
-
-	// Try in-memory first
-	if ev.RawData != nil {
-		if r, ok := ev.RawData.(map[string][]string); ok {
-			results = r
-		}
-	}
-
-	// Fallback to disk
-	if results == nil {
-		data, err := os.ReadFile(ev.FilePath)
-		if err != nil {
-			return fmt.Errorf("failed to read evidence file: %w", err)
-		}
-
-		if err := json.Unmarshal(data, &results); err != nil {
-			return fmt.Errorf("failed to unmarshal DNS results: %w", err)
-		}
-	}
-
-	targetDomain := ev.Metadata["target"].(string)
-
-	// Ensure target domain entity exists
-	domainEnt := &core.Entity{
-		CaseID: ev.CaseID,
-		Type:   "domain",
-		Value:  targetDomain,
-		Source: "dns",
+// ensureEntity returns the case's entity with this value, creating it if
+// needed. Metadata keys are merged into an existing entity.
+func ensureEntity(caseID, entityType, value, source string, metadata map[string]interface{}) (*core.Entity, error) {
+	existing, err := GetEntityByValue(caseID, value)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Check if already exists to avoid errors (or use GetEntityByValue)
-	existing, _ := GetEntityByValue(ev.CaseID, targetDomain)
 	if existing == nil {
-		if err := CreateEntity(domainEnt); err != nil {
-			return err
+		e := &core.Entity{CaseID: caseID, Type: entityType, Value: value, Source: source, Metadata: metadata}
+		if err := CreateEntity(e); err != nil {
+			return nil, err
 		}
-	} else {
-		domainEnt = existing
+		return e, nil
 	}
 
-	// Process A records
-	for _, ip := range results["A"] {
-		ipEnt := &core.Entity{
-			CaseID: ev.CaseID,
-			Type:   "ip",
-			Value:  ip,
-			Source: "dns",
+	if len(metadata) > 0 {
+		if existing.Metadata == nil {
+			existing.Metadata = make(map[string]interface{})
 		}
-		
-		existingIP, _ := GetEntityByValue(ev.CaseID, ip)
-		if existingIP == nil {
-			if err := CreateEntity(ipEnt); err != nil {
-				return err
-			}
-		} else {
-			ipEnt = existingIP
-		}
-
-		// Create relationship
-		rel := &core.Relationship{
-			CaseID:       ev.CaseID,
-			FromEntityID: domainEnt.ID,
-			ToEntityID:   ipEnt.ID,
-			Type:         "resolves_to",
-			EvidenceID:   ev.ID,
-			Confidence:   1.0,
+		for k, v := range metadata {
+			existing.Metadata[k] = v
 		}
-		if err := CreateRelationship(rel); err != nil {
-			// Might already exist due to unique constraint, ignore error
+		if err := UpdateEntity(existing); err != nil {
+			return nil, err
 		}
 	}
+	return existing, nil
+}
 
-	return nil
+// link records a relationship backed by ev. Existing links (the unique
+// constraint) are not an error.
+func link(ev *core.Evidence, from, to *core.Entity, relType string, confidence float64) {
+	if from == nil || to == nil || from.ID == to.ID {
+		return
+	}
+	CreateRelationship(&core.Relationship{
+		CaseID:       ev.CaseID,
+		FromEntityID: from.ID,
+		ToEntityID:   to.ID,
+		Type:         relType,
+		EvidenceID:   ev.ID,
+		Confidence:   confidence,
+	})
 }
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Job timestamps are stored in UTC so that run_after and heartbeat_at
+// compare correctly as text inside SQLite.
+
+const jobColumns = `id, case_id, collector, target, entity_type, active_allowed, timeout_ns, status, attempts, max_attempts,
+	last_error, evidence_count, created_at, run_after, started_at, finished_at, heartbeat_at`
+
+// CreateJob queues a new collection job.
+func CreateJob(j *core.Job) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	now := time.Now().UTC()
+	if j.ID == "" {
+		j.ID = uuid.New().String()
+	}
+	if j.Status == "" {
+		j.Status = core.JobQueued
+	}
+	if j.MaxAttempts <= 0 {
+		j.MaxAttempts = 1
+	}
+	if j.CreatedAt.IsZero() {
+		j.CreatedAt = now
+	}
+	if j.RunAfter.IsZero() {
+		j.RunAfter = now
+	}
+
+	query := `INSERT INTO jobs (id, case_id, collector, target, entity_type, active_allowed, timeout_ns, status, attempts, max_attempts, created_at, run_after)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := DB.Exec(query, j.ID, j.CaseID, j.Collector, j.Target, j.EntityType, j.ActiveAllowed, int64(j.Timeout),
+		j.Status, j.Attempts, j.MaxAttempts, j.CreatedAt.UTC(), j.RunAfter.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob retrieves a job by its ID. It returns nil if the job does not exist.
+func GetJob(id string) (*core.Job, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	row := DB.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id)
+	j, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return j, nil
+}
+
+// ListJobs returns jobs newest first, optionally filtered by case and status.
+func ListJobs(caseID string, status string) ([]*core.Job, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE 1=1`
+	var args []interface{}
+	if caseID != "" {
+		query += ` AND case_id = ?`
+		args = append(args, caseID)
+	}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*core.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, nil
+}
+
+// ClaimJob atomically moves the oldest runnable queued job to running and
+// returns it. When ids are given only those jobs are considered. It returns
+// nil if nothing is ready.
+func ClaimJob(ids ...string) (*core.Job, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	now := time.Now().UTC()
+	query := `SELECT id FROM jobs WHERE status = ? AND run_after <= ?`
+	args := []interface{}{core.JobQueued, now}
+	if len(ids) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(`, ?`, len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	query += ` ORDER BY run_after, created_at LIMIT 1`
+
+	var id string
+	if err := DB.QueryRow(query, args...).Scan(&id); err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to find queued job: %w", err)
+	}
+
+	// The status guard makes the claim safe against other workers
+	res, err := DB.Exec(`UPDATE jobs SET status = ?, attempts = attempts + 1, started_at = ?, heartbeat_at = ?, finished_at = NULL
+	                     WHERE id = ? AND status = ?`, core.JobRunning, now, now, id, core.JobQueued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+
+	return GetJob(id)
+}
+
+// FinishJob records the outcome of an attempt on a running job. The status
+// guard keeps a cancellation that landed during the attempt; it reports
+// false when the job was no longer running and nothing was written.
+func FinishJob(j *core.Job) (bool, error) {
+	if DB == nil {
+		return false, fmt.Errorf("database not initialized")
+	}
+
+	query := `UPDATE jobs SET status = ?, attempts = ?, last_error = ?, evidence_count = ?, run_after = ?, finished_at = ?
+	          WHERE id = ? AND status = ?`
+	res, err := DB.Exec(query, j.Status, j.Attempts, j.LastError, j.EvidenceCount, j.RunAfter.UTC(), utcOrNil(j.FinishedAt), j.ID, core.JobRunning)
+	if err != nil {
+		return false, fmt.Errorf("failed to update job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update job: %w", err)
+	}
+	return n > 0, nil
+}
+
+// TouchJob refreshes the heartbeat of a running job and returns its current
+// status, which lets workers notice cancellations made by other processes.
+func TouchJob(id string) (string, error) {
+	if DB == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+
+	if _, err := DB.Exec(`UPDATE jobs SET heartbeat_at = ? WHERE id = ? AND status = ?`, time.Now().UTC(), id, core.JobRunning); err != nil {
+		return "", fmt.Errorf("failed to touch job: %w", err)
+	}
+
+	var status string
+	if err := DB.QueryRow(`SELECT status FROM jobs WHERE id = ?`, id).Scan(&status); err != nil {
+		return "", fmt.Errorf("failed to read job status: %w", err)
+	}
+	return status, nil
+}
+
+// CancelJob marks a queued or running job as cancelled.
+func CancelJob(id string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	res, err := DB.Exec(`UPDATE jobs SET status = ?, finished_at = ? WHERE id = ? AND status IN (?, ?)`,
+		core.JobCancelled, time.Now().UTC(), id, core.JobQueued, core.JobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("job %s not found or already finished", id)
+	}
+	return nil
+}
+
+// RetryJob puts a failed or cancelled job back on the queue with a fresh
+// attempt budget.
+func RetryJob(id string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	res, err := DB.Exec(`UPDATE jobs SET status = ?, attempts = 0, last_error = '', run_after = ?, finished_at = NULL
+	                     WHERE id = ? AND status IN (?, ?)`,
+		core.JobQueued, time.Now().UTC(), id, core.JobFailed, core.JobCancelled)
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("job %s not found or not in a failed/cancelled state", id)
+	}
+	return nil
+}
+
+// RequeueStaleJobs returns running jobs whose worker stopped sending
+// heartbeats (e.g. the process was killed) to the queue.
+func RequeueStaleJobs(staleAfter time.Duration) (int, error) {
+	if DB == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	cutoff := time.Now().UTC().Add(-staleAfter)
+	res, err := DB.Exec(`UPDATE jobs SET status = ?, run_after = ? WHERE status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)`,
+		core.JobQueued, time.Now().UTC(), core.JobRunning, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (*core.Job, error) {
+	var j core.Job
+	var entityType, lastError sql.NullString
+	var timeout int64
+	var startedAt, finishedAt, heartbeatAt sql.NullTime
+
+	err := row.Scan(&j.ID, &j.CaseID, &j.Collector, &j.Target, &entityType, &j.ActiveAllowed, &timeout, &j.Status,
+		&j.Attempts, &j.MaxAttempts, &lastError, &j.EvidenceCount, &j.CreatedAt, &j.RunAfter, &startedAt, &finishedAt, &heartbeatAt)
+	if err != nil {
+		return nil, err
+	}
+
+	j.EntityType = entityType.String
+	j.LastError = lastError.String
+	j.Timeout = time.Duration(timeout)
+	if startedAt.Valid {
+		j.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		j.FinishedAt = &finishedAt.Time
+	}
+	if heartbeatAt.Valid {
+		j.HeartbeatAt = &heartbeatAt.Time
+	}
+	return &j, nil
+}
+
+func utcOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
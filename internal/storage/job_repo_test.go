@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+)
+
+func setupJobsDB(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDB := DB
+	DB = db
+	t.Cleanup(func() {
+		DB = oldDB
+		db.Close()
+	})
+
+	if err := InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateCase(&core.Case{ID: "case-jobs", Name: "Jobs"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	setupJobsDB(t)
+
+	job := &core.Job{CaseID: "case-jobs", Collector: "dns", Target: "example.com", MaxAttempts: 3, Timeout: 30 * time.Second}
+	if err := CreateJob(job); err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	// Jobs scheduled in the future are not claimable yet
+	later := &core.Job{CaseID: "case-jobs", Collector: "whois", Target: "example.com", RunAfter: time.Now().Add(time.Hour)}
+	if err := CreateJob(later); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := ClaimJob()
+	if err != nil {
+		t.Fatalf("ClaimJob failed: %v", err)
+	}
+	if claimed == nil || claimed.ID != job.ID {
+		t.Fatalf("Expected to claim %s, got %+v", job.ID, claimed)
+	}
+	if claimed.Status != core.JobRunning || claimed.Attempts != 1 || claimed.Timeout != 30*time.Second {
+		t.Errorf("Unexpected claimed job state: %+v", claimed)
+	}
+
+	if next, err := ClaimJob(); err != nil || next != nil {
+		t.Errorf("Expected nothing else to be claimable, got %+v (err %v)", next, err)
+	}
+
+	if err := CancelJob(job.ID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+
+	// The worker finishing afterwards must not overwrite the cancellation
+	claimed.Status = core.JobSucceeded
+	if ok, err := FinishJob(claimed); err != nil || ok {
+		t.Errorf("Expected FinishJob to skip a cancelled job, got %v (err %v)", ok, err)
+	}
+	if got, _ := GetJob(job.ID); got.Status != core.JobCancelled {
+		t.Errorf("Expected the cancellation to stick, got %s", got.Status)
+	}
+	if err := CancelJob(job.ID); err == nil {
+		t.Error("Expected cancelling a finished job to fail")
+	}
+
+	if err := RetryJob(job.ID); err != nil {
+		t.Fatalf("RetryJob failed: %v", err)
+	}
+	got, _ := GetJob(job.ID)
+	if got.Status != core.JobQueued || got.Attempts != 0 {
+		t.Errorf("Expected retried job to be queued with no attempts, got %+v", got)
+	}
+
+	list, err := ListJobs("case-jobs", core.JobQueued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("Expected 2 queued jobs, got %d", len(list))
+	}
+}
+
+func TestRequeueStaleJobs(t *testing.T) {
+	setupJobsDB(t)
+
+	job := &core.Job{CaseID: "case-jobs", Collector: "dns", Target: "example.com"}
+	if err := CreateJob(job); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ClaimJob(job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh heartbeat keeps the job running
+	if n, err := RequeueStaleJobs(time.Minute); err != nil || n != 0 {
+		t.Fatalf("Expected no stale jobs, got %d (err %v)", n, err)
+	}
+
+	// Simulate a worker that died an hour ago
+	if _, err := DB.Exec(`UPDATE jobs SET heartbeat_at = ? WHERE id = ?`, time.Now().UTC().Add(-time.Hour), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := RequeueStaleJobs(time.Minute); err != nil || n != 1 {
+		t.Fatalf("Expected 1 requeued job, got %d (err %v)", n, err)
+	}
+	if got, _ := GetJob(job.ID); got.Status != core.JobQueued {
+		t.Errorf("Expected job to be queued again, got %s", got.Status)
+	}
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestMigrate_IsIdempotent(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	oldDB := DB
+	DB = db
+	defer func() { DB = oldDB }()
+
+	applied, err := Migrate(false)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if len(applied) == 0 {
+		t.Fatal("Expected migrations to be applied on an empty database")
+	}
+
+	again, err := Migrate(false)
+	if err != nil {
+		t.Fatalf("Second Migrate failed: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("Expected no pending migrations, got %d", len(again))
+	}
+
+	status, err := MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range status {
+		if m.AppliedAt == nil {
+			t.Errorf("Migration %04d_%s still pending", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrate_AdoptsLegacyDatabase(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	oldDB := DB
+	DB = db
+	defer func() { DB = oldDB }()
+
+	// A database created before migrations existed has the tables but no
+	// schema_migrations bookkeeping.
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec(migrations[0].SQL); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateCase(&core.Case{ID: "legacy", Name: "Legacy"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Migrate(false); err != nil {
+		t.Fatalf("Migrate failed on legacy database: %v", err)
+	}
+
+	c, err := GetCase("legacy")
+	if err != nil || c == nil {
+		t.Fatalf("Legacy case lost during migration: %v", err)
+	}
+}
+
+func TestMigrate_BacksUpFileDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spectre.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	oldDB, oldPath := DB, dbPath
+	DB, dbPath = db, path
+	defer func() { DB, dbPath = oldDB, oldPath }()
+
+	// Fresh database: nothing worth backing up yet
+	if _, err := Migrate(true); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ := filepath.Glob(path + ".bak-*"); len(matches) != 0 {
+		t.Errorf("Expected no backup for an empty database, got %v", matches)
+	}
+
+	// Forget the (idempotent) initial migration so the next run has work to do
+	if _, err := DB.Exec(`DELETE FROM schema_migrations WHERE version = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Migrate(true); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, _ := filepath.Glob(path + ".bak-*")
+	if len(matches) != 1 {
+		t.Fatalf("Expected one backup file, got %v", matches)
+	}
+	if info, err := os.Stat(matches[0]); err != nil || info.Size() == 0 {
+		t.Errorf("Backup file is missing or empty: %v", err)
+	}
+}
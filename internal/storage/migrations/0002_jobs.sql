@@ -0,0 +1,25 @@
+-- Persistent collection job queue.
+
+CREATE TABLE jobs (
+    id TEXT PRIMARY KEY,
+    case_id TEXT NOT NULL,
+    collector TEXT NOT NULL,
+    target TEXT NOT NULL,
+    entity_type TEXT,
+    active_allowed BOOLEAN DEFAULT 0,
+    timeout_ns INTEGER DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'queued',
+    attempts INTEGER DEFAULT 0,
+    max_attempts INTEGER DEFAULT 3,
+    last_error TEXT,
+    evidence_count INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    run_after DATETIME DEFAULT CURRENT_TIMESTAMP,
+    started_at DATETIME,
+    finished_at DATETIME,
+    heartbeat_at DATETIME,
+    FOREIGN KEY (case_id) REFERENCES cases(id)
+);
+
+CREATE INDEX idx_jobs_status ON jobs(status, run_after);
+CREATE INDEX idx_jobs_case ON jobs(case_id);
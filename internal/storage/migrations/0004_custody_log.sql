@@ -0,0 +1,32 @@
+-- Append-only, hash-chained chain-of-custody log. Timestamps are kept as
+-- RFC 3339 text so they hash identically after a round trip.
+
+CREATE TABLE custody_events (
+    id TEXT PRIMARY KEY,
+    case_id TEXT NOT NULL,
+    seq INTEGER NOT NULL,
+    evidence_id TEXT,
+    action TEXT NOT NULL,
+    actor TEXT,
+    tool TEXT,
+    proxy TEXT,
+    file_hash TEXT,
+    details JSON,
+    timestamp TEXT NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL,
+    FOREIGN KEY (case_id) REFERENCES cases(id),
+    UNIQUE(case_id, seq)
+);
+
+CREATE INDEX idx_custody_evidence ON custody_events(evidence_id);
+
+CREATE TRIGGER custody_events_no_update BEFORE UPDATE ON custody_events
+BEGIN
+    SELECT RAISE(ABORT, 'custody log is append-only');
+END;
+
+CREATE TRIGGER custody_events_no_delete BEFORE DELETE ON custody_events
+BEGIN
+    SELECT RAISE(ABORT, 'custody log is append-only');
+END;
@@ -2,10 +2,12 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
 	"github.com/spectre/spectre/internal/core"
 )
 
@@ -14,7 +16,10 @@ func CreateRelationship(r *core.Relationship) error {
 	if DB == nil {
 		return fmt.Errorf("database not initialized")
 	}
+	return createRelationship(DB, r)
+}
 
+func createRelationship(w dbWriter, r *core.Relationship) error {
 	if r.ID == "" {
 		r.ID = uuid.New().String()
 	}
@@ -27,7 +32,7 @@ func CreateRelationship(r *core.Relationship) error {
 
 	query := `INSERT INTO relationships (id, case_id, from_entity, to_entity, rel_type, confidence, evidence_id, discovered_at) 
 	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := DB.Exec(query, r.ID, r.CaseID, r.FromEntityID, r.ToEntityID, r.Type, r.Confidence, r.EvidenceID, r.DiscoveredAt)
+	_, err := w.Exec(query, r.ID, r.CaseID, r.FromEntityID, r.ToEntityID, r.Type, r.Confidence, r.EvidenceID, r.DiscoveredAt)
 	if err != nil {
 		return fmt.Errorf("failed to create relationship: %w", err)
 	}
@@ -35,6 +40,13 @@ func CreateRelationship(r *core.Relationship) error {
 	return nil
 }
 
+// IsDuplicate reports whether err comes from a uniqueness constraint, such
+// as creating a relationship that already exists.
+func IsDuplicate(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
 // GetRelationship retrieves a relationship by its ID.
 func GetRelationship(id string) (*core.Relationship, error) {
 	if DB == nil {
@@ -11,9 +11,28 @@ import (
 
 var DB *sql.DB
 
-// InitDB initializes the SQLite database connection.
+// dbPath is the file backing DB, used for pre-migration backups.
+var dbPath string
+
+// InitDB opens the SQLite database and, unless database.auto_migrate is
+// disabled, applies pending schema migrations.
 func InitDB() error {
-	dbPath := viper.GetString("database.path")
+	if err := OpenDB(); err != nil {
+		return err
+	}
+
+	if viper.IsSet("database.auto_migrate") && !viper.GetBool("database.auto_migrate") {
+		return nil
+	}
+	if _, err := Migrate(true); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+// OpenDB opens the SQLite database connection without touching the schema.
+func OpenDB() error {
+	dbPath = viper.GetString("database.path")
 	if dbPath == "" {
 		dbPath = "spectre.db"
 	}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spectre/spectre/internal/core"
+)
+
+const tokenColumns = `id, name, token_hash, prefix, created_at, last_used_at, revoked_at`
+
+// CreateAPIToken stores a new API token. t.Hash must already be set.
+func CreateAPIToken(t *core.APIToken) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if t.Hash == "" {
+		return fmt.Errorf("token hash is required")
+	}
+
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+
+	query := `INSERT INTO api_tokens (id, name, token_hash, prefix, created_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := DB.Exec(query, t.ID, t.Name, t.Hash, t.Prefix, t.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+	return nil
+}
+
+// GetActiveAPITokenByHash returns the unrevoked token with the given hash,
+// or nil if there is none.
+func GetActiveAPITokenByHash(hash string) (*core.APIToken, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	row := DB.QueryRow(`SELECT `+tokenColumns+` FROM api_tokens WHERE token_hash = ? AND revoked_at IS NULL`, hash)
+	t, err := scanToken(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return t, nil
+}
+
+// ListAPITokens returns every token, newest first.
+func ListAPITokens() ([]*core.APIToken, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	rows, err := DB.Query(`SELECT ` + tokenColumns + ` FROM api_tokens ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*core.APIToken
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes a token by ID or by its prefix.
+func RevokeAPIToken(ref string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	res, err := DB.Exec(`UPDATE api_tokens SET revoked_at = ? WHERE (id = ? OR prefix = ?) AND revoked_at IS NULL`, time.Now(), ref, ref)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("no active token matches %s", ref)
+	}
+	return nil
+}
+
+// TouchAPIToken records that a token was just used.
+func TouchAPIToken(id string) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	if _, err := DB.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		return fmt.Errorf("failed to touch token: %w", err)
+	}
+	return nil
+}
+
+func scanToken(row rowScanner) (*core.APIToken, error) {
+	var t core.APIToken
+	var lastUsed, revoked sql.NullTime
+	if err := row.Scan(&t.ID, &t.Name, &t.Hash, &t.Prefix, &t.CreatedAt, &lastUsed, &revoked); err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		t.RevokedAt = &revoked.Time
+	}
+	return &t, nil
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// TrackerClusters groups the sites of a case that share tracker IDs. Two
+// sites sharing any ID are in the same cluster, transitively, so A and C
+// end up together when A shares an Analytics ID with B and B shares a Pixel
+// with C. Largest clusters come first.
+func TrackerClusters(caseID string) ([]core.TrackerCluster, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	query := `SELECT s.value, t.value FROM relationships r
+	          JOIN entities s ON s.id = r.from_entity
+	          JOIN entities t ON t.id = r.to_entity
+	          WHERE r.case_id = ? AND r.rel_type = ? AND t.type = ?`
+	rows, err := DB.Query(query, caseID, RelUsesTracker, core.EntityTracker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trackers: %w", err)
+	}
+	defer rows.Close()
+
+	sitesByTracker := make(map[string]map[string]bool)
+	for rows.Next() {
+		var site, tracker string
+		if err := rows.Scan(&site, &tracker); err != nil {
+			return nil, fmt.Errorf("failed to scan tracker: %w", err)
+		}
+		if sitesByTracker[tracker] == nil {
+			sitesByTracker[tracker] = make(map[string]bool)
+		}
+		sitesByTracker[tracker][site] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query trackers: %w", err)
+	}
+
+	// Union-find over sites joined by each shared tracker
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(s string) string {
+		if parent[s] != s {
+			parent[s] = find(parent[s])
+		}
+		return parent[s]
+	}
+	var shared []string
+	for tracker, sites := range sitesByTracker {
+		if len(sites) < 2 {
+			continue
+		}
+		shared = append(shared, tracker)
+		var first string
+		for site := range sites {
+			if _, ok := parent[site]; !ok {
+				parent[site] = site
+			}
+			if first == "" {
+				first = site
+			} else {
+				parent[find(site)] = find(first)
+			}
+		}
+	}
+
+	byRoot := make(map[string]*core.TrackerCluster)
+	for site := range parent {
+		root := find(site)
+		if byRoot[root] == nil {
+			byRoot[root] = &core.TrackerCluster{}
+		}
+		byRoot[root].Sites = append(byRoot[root].Sites, site)
+	}
+	for _, tracker := range shared {
+		for site := range sitesByTracker[tracker] {
+			c := byRoot[find(site)]
+			c.Trackers = append(c.Trackers, tracker)
+			break
+		}
+	}
+
+	clusters := make([]core.TrackerCluster, 0, len(byRoot))
+	for _, c := range byRoot {
+		sort.Strings(c.Sites)
+		sort.Strings(c.Trackers)
+		clusters = append(clusters, *c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Sites) != len(clusters[j].Sites) {
+			return len(clusters[i].Sites) > len(clusters[j].Sites)
+		}
+		return clusters[i].Sites[0] < clusters[j].Sites[0]
+	})
+	return clusters, nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+func TestTrackerClusters(t *testing.T) {
+	setupIngestDB(t)
+
+	ingest := func(target string, trackers ...core.TrackerID) {
+		result := &core.HTTPResult{Target: target, URL: "https://" + target + "/", StatusCode: 200, Trackers: trackers}
+		ev := &core.Evidence{CaseID: "case-ingest", Collector: "http", Metadata: map[string]interface{}{"target": target}, RawData: result}
+		if err := CreateEvidence(ev); err != nil {
+			t.Fatal(err)
+		}
+		if err := IngestEvidence(ev); err != nil {
+			t.Fatalf("IngestEvidence failed: %v", err)
+		}
+	}
+	ga := core.TrackerID{Type: "google_analytics", ID: "UA-1234567-1"}
+	pixel := core.TrackerID{Type: "facebook_pixel", ID: "123456789012345"}
+	ingest("a.example", ga)
+	ingest("b.example", ga, pixel)
+	ingest("c.example", pixel, core.TrackerID{Type: "google_tag_manager", ID: "GTM-ONLYC"})
+	ingest("d.example", core.TrackerID{Type: "google_tag_manager", ID: "GTM-ALONE"})
+	ingest("e.example", core.TrackerID{Type: "hotjar", ID: "998877"})
+	ingest("f.example", core.TrackerID{Type: "hotjar", ID: "998877"})
+
+	if rels := relTypes(t, "case-ingest"); rels["a.example -> UA-1234567-1"] != RelUsesTracker || rels["b.example -> facebook_pixel:123456789012345"] != RelUsesTracker {
+		t.Errorf("Expected uses_tracker links, got %v", rels)
+	}
+
+	clusters, err := TrackerClusters("case-ingest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprint(clusters)
+	want := "[{[a.example b.example c.example] [UA-1234567-1 facebook_pixel:123456789012345]} {[e.example f.example] [hotjar:998877]}]"
+	if got != want {
+		t.Errorf("TrackerClusters =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/spectre/spectre/internal/core"
+)
+
+// Tx groups writes that must be stored together or not at all, such as a
+// case imported from a bundle.
+type Tx struct {
+	tx       *sql.Tx
+	entities []*core.Entity // announced through OnEntityCreated on commit
+}
+
+// WithTx runs fn in a transaction, committing when it returns nil and
+// rolling everything back otherwise. Custody appends from other callers
+// wait until it finishes.
+func WithTx(fn func(tx *Tx) error) error {
+	if DB == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	custodyMu.Lock()
+	defer custodyMu.Unlock()
+
+	sqlTx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	tx := &Tx{tx: sqlTx}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if OnEntityCreated != nil {
+		for _, e := range tx.entities {
+			OnEntityCreated(e)
+		}
+	}
+	return nil
+}
+
+// CreateCase is CreateCase within the transaction.
+func (tx *Tx) CreateCase(c *core.Case) error {
+	return createCase(tx.tx, c)
+}
+
+// CreateEntity is CreateEntity within the transaction.
+func (tx *Tx) CreateEntity(e *core.Entity) error {
+	if err := createEntity(tx.tx, e); err != nil {
+		return err
+	}
+	tx.entities = append(tx.entities, e)
+	return nil
+}
+
+// CreateEvidence is CreateEvidence within the transaction.
+func (tx *Tx) CreateEvidence(ev *core.Evidence) error {
+	return createEvidence(tx.tx, ev)
+}
+
+// CreateRelationship is CreateRelationship within the transaction.
+func (tx *Tx) CreateRelationship(r *core.Relationship) error {
+	return createRelationship(tx.tx, r)
+}
+
+// SaveAnalysis is SaveAnalysis within the transaction.
+func (tx *Tx) SaveAnalysis(a *core.Analysis) error {
+	return saveAnalysis(tx.tx, a)
+}
+
+// RecordCustody is RecordCustody within the transaction.
+func (tx *Tx) RecordCustody(caseID, evidenceID, action, fileHash string, details map[string]string) error {
+	return appendCustodyEvent(tx.tx, newCustodyEvent(caseID, evidenceID, action, fileHash, details))
+}
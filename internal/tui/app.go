@@ -12,10 +12,15 @@ import (
 	"github.com/spectre/spectre/internal/analysis"
 	"github.com/spectre/spectre/internal/config"
 	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/custody"
 	"github.com/spectre/spectre/internal/report"
 	"github.com/spf13/viper"
 )
 
+// DashboardURL is opened by the Web Dashboard view. The console sets it to a
+// link that logs the browser in with a per-session token.
+var DashboardURL = "http://127.0.0.1:8080"
+
 type sessionState int
 
 const (
@@ -26,6 +31,7 @@ const (
 	ViewTimeline
 	ViewReports
 	ViewSettings
+	ViewCollect
 	ViewDashboard
 )
 
@@ -107,7 +113,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		
 		m.caseList.SetSize(w, h)
 		m.runner.caseList.SetSize(w, h)
-		m.runner.collList.SetSize(w, h)
+		m.runner.entityList.SetSize(w, h)
+		m.runner.collList.SetSize(w, h-2)
 
 	case []list.Item:
 		m.caseList.SetItems(msg)
@@ -160,7 +167,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.analysisStatus = AnalysisError
 		m.analysisError = string(msg)
 
+	case collectionDoneMsg:
+		m.runner, cmd = m.runner.Update(msg)
+		return m, cmd
+
 	case tea.KeyMsg:
+		// While typing a target, every key but ctrl+c belongs to the runner
+		if m.state == ViewCollect && !m.focusNav && m.runner.capturesInput() && msg.String() != "ctrl+c" {
+			m.runner, cmd = m.runner.Update(msg)
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			m.quitting = true
@@ -197,7 +214,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, StartAnalysis(m.selectedCaseID, m.modelName)
 				}
 				if m.state == ViewDashboard {
-					openBrowser("http://localhost:8080")
+					openBrowser(DashboardURL)
 				}
 			}
 			return m, nil
@@ -232,6 +249,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd2
 		}
 
+		// The runner gets everything except the view shortcuts
+		if m.state == ViewCollect && !isViewKey(msg.String()) {
+			m.runner, cmd = m.runner.Update(msg)
+			return m, cmd
+		}
+
 		// Global navigation if not in a list
 		switch msg.String() {
 		case "1": m.state = ViewCases
@@ -247,6 +270,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "5": m.state = ViewTimeline
 		case "6": m.state = ViewReports
 		case "7": m.state = ViewSettings
+		case "8": m.state = ViewCollect
 		}
 
 		// Specific View Keybindings
@@ -309,6 +333,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// Save to file
 					filename := fmt.Sprintf("report_%s.md", m.selectedCaseID)
 					os.WriteFile(filename, []byte(content), 0644)
+					custody.RecordExport(m.selectedCaseID, "markdown", filename)
 					return AnalysisFinishedMsg{nil} // Signal success (nil result means just update status)
 				}
 			}
@@ -318,6 +343,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if err != nil {
 						return AnalysisErrorMsg(err.Error())
 					}
+					custody.RecordExport(m.selectedCaseID, "pdf", filename)
 					return AnalysisFinishedMsg{&core.Analysis{
 						Findings: []string{fmt.Sprintf("PDF Report generated: %s", filename)},
 					}}
@@ -380,6 +406,7 @@ func (m model) renderNav() string {
 		{ViewTimeline, "Timeline"},
 		{ViewReports, "Reports"},
 		{ViewSettings, "Settings"},
+		{ViewCollect, "Collect"},
 		{ViewDashboard, "Web Dashboard"},
 	}
 
@@ -504,8 +531,11 @@ func (m model) renderContent() string {
 		s.WriteString("\n(Press 'Enter' or 'Space' to toggle/change)\n")
 		content = s.String()
 
+	case ViewCollect:
+		content = "COLLECT\n───────\n\n" + m.runner.View()
+
 	case ViewDashboard:
-		content = "Opening Web Dashboard in your default browser...\n\nURL: http://localhost:8080"
+		content = "Opening Web Dashboard in your default browser...\n\nURL: " + strings.SplitN(DashboardURL, "?", 2)[0]
 	
 	default:
 		content = "View not implemented yet."
@@ -550,6 +580,12 @@ func (m model) renderFooter() string {
 
 }
 
+// isViewKey reports whether key is one of the number shortcuts that switch
+// views.
+func isViewKey(key string) bool {
+	return len(key) == 1 && key[0] >= '1' && key[0] <= '8'
+}
+
 func formatBool(b bool) string {
 	if b {
 		return "[ON]"
@@ -1,32 +1,70 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rs/zerolog/log"
 	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+	"github.com/spectre/spectre/internal/storage"
 )
 
 type runnerState int
 
 const (
 	selectCase runnerState = iota
-	selectCollector
+	selectEntity
 	inputTarget
+	selectCollector
 	executing
 	result
 )
 
+// collectionDoneMsg reports the end of a collection started by the runner.
+type collectionDoneMsg struct {
+	evidence int
+	err      error
+}
+
+// entityItem is a collection target offered by the runner.
+type entityItem struct {
+	entityType, value string
+	manual            bool // Placeholder for typing a new target
+}
+
+func (i entityItem) Title() string {
+	if i.manual {
+		return "+ New target..."
+	}
+	return i.value
+}
+
+func (i entityItem) Description() string {
+	if i.manual {
+		return "Type a target; its entity type is inferred"
+	}
+	return i.entityType
+}
+
+func (i entityItem) FilterValue() string { return i.value }
+
 type runnerModel struct {
 	state          runnerState
 	caseList       list.Model
+	entityList     list.Model
 	collList       list.Model
 	textInput      textinput.Model
 	selectedCaseID string
 	selectedColl   string
+	target         string
+	targetType     string
 	activeAllowed  bool
+	cancel         context.CancelFunc // Aborts the running collection
 	err            error
 	message        string
 }
@@ -36,55 +74,160 @@ func NewRunnerModel() runnerModel {
 	ti.Placeholder = "example.com"
 	ti.Focus()
 
-	// Dynamic Collector list
-	var collectors []list.Item
-	for _, c := range collector.List() {
-		collectors = append(collectors, item{
-			title: c.Name(),
-			desc:  c.Description(),
-		})
-	}
-
-	cl := list.New(collectors, list.NewDefaultDelegate(), 0, 0)
+	// Collector list is filled once the target's entity type is known
+	cl := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	cl.Title = "Select Collector (Space to toggle Active Mode)"
 	cl.SetShowHelp(false)
 
+	el := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	el.Title = "Select Target Entity"
+	el.SetShowHelp(false)
+
 	// Case list (initialized empty, will be populated by Update)
 	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	l.Title = "Select Case for Collection"
 	l.SetShowHelp(false)
 
 	return runnerModel{
-		state:     selectCase,
-		caseList:  l,
-		collList:  cl,
-		textInput: ti,
+		state:      selectCase,
+		caseList:   l,
+		entityList: el,
+		collList:   cl,
+		textInput:  ti,
 	}
 }
 
+// loadEntities lists the case's entities as collection targets.
+func (m *runnerModel) loadEntities() error {
+	entities, err := storage.ListEntitiesByCase(m.selectedCaseID)
+	if err != nil {
+		return err
+	}
+
+	items := []list.Item{entityItem{manual: true}}
+	for _, e := range entities {
+		items = append(items, entityItem{entityType: e.Type, value: e.Value})
+	}
+	m.entityList.SetItems(items)
+	m.entityList.Select(0)
+	return nil
+}
+
+// filterCollectors shows only the collectors that accept the target's type.
+func (m *runnerModel) filterCollectors() {
+	var items []list.Item
+	for _, c := range collector.Applicable(m.targetType) {
+		desc := c.Description()
+		if c.IsActive() {
+			desc = "[ACTIVE] " + desc
+		}
+		items = append(items, item{
+			title: c.Name(),
+			desc:  desc,
+		})
+	}
+	m.collList.SetItems(items)
+	m.collList.Select(0)
+}
+
+// capturesInput reports whether the runner needs every key, including the
+// ones the app uses for navigation, because the user is typing.
+func (m runnerModel) capturesInput() bool {
+	switch m.state {
+	case selectCase:
+		return m.caseList.SettingFilter()
+	case selectEntity:
+		return m.entityList.SettingFilter()
+	case inputTarget:
+		return true
+	case selectCollector:
+		return m.collList.SettingFilter()
+	}
+	return false
+}
+
 func (m runnerModel) Update(msg tea.Msg) (runnerModel, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if done, ok := msg.(collectionDoneMsg); ok {
+		m.cancel = nil
+		m.state = result
+		m.err = done.err
+		switch {
+		case errors.Is(done.err, context.Canceled):
+			m.err = nil
+			m.message = "Collection cancelled."
+		case done.err == nil:
+			m.message = fmt.Sprintf("Collection complete! %d evidence item(s) saved.", done.evidence)
+		}
+		return m, nil
+	}
+
 	switch m.state {
 	case selectCase:
 		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "enter" {
 			if i := m.caseList.SelectedItem(); i != nil {
 				m.selectedCaseID = i.(item).id
-				m.state = selectCollector
+				if err := m.loadEntities(); err != nil {
+					m.err = err
+					m.state = result
+					return m, nil
+				}
+				m.state = selectEntity
 				return m, nil
 			}
 		}
 		m.caseList, cmd = m.caseList.Update(msg)
 		return m, cmd
 
+	case selectEntity:
+		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "esc" && !m.entityList.SettingFilter() {
+			m.state = selectCase
+			return m, nil
+		}
+		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "enter" {
+			if i, ok := m.entityList.SelectedItem().(entityItem); ok {
+				if i.manual {
+					m.state = inputTarget
+					return m, nil
+				}
+				m.target, m.targetType = i.value, i.entityType
+				m.filterCollectors()
+				m.state = selectCollector
+				return m, nil
+			}
+		}
+		m.entityList, cmd = m.entityList.Update(msg)
+		return m, cmd
+
+	case inputTarget:
+		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "esc" {
+			m.state = selectEntity
+			return m, nil
+		}
+		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "enter" {
+			target := m.textInput.Value()
+			if target == "" {
+				return m, nil
+			}
+			m.target, m.targetType = target, core.InferEntityType(target)
+			m.filterCollectors()
+			m.state = selectCollector
+			return m, nil
+		}
+		m.textInput, cmd = m.textInput.Update(msg)
+		return m, cmd
+
 	case selectCollector:
-		if km, ok := msg.(tea.KeyMsg); ok {
+		if km, ok := msg.(tea.KeyMsg); ok && !m.collList.SettingFilter() {
 			switch km.String() {
+			case "esc":
+				m.state = selectEntity
+				return m, nil
 			case "enter":
 				if i := m.collList.SelectedItem(); i != nil {
 					m.selectedColl = i.(item).title
-					m.state = inputTarget
-					return m, nil
+					return m.execute()
 				}
 			case " ":
 				m.activeAllowed = !m.activeAllowed
@@ -99,25 +242,14 @@ func (m runnerModel) Update(msg tea.Msg) (runnerModel, tea.Cmd) {
 		m.collList, cmd = m.collList.Update(msg)
 		return m, cmd
 
-	case inputTarget:
-		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "enter" {
-			target := m.textInput.Value()
-			if target == "" {
-				return m, nil
-			}
-			m.state = executing
-			return m, func() tea.Msg {
-				_, err := collector.Run(m.selectedColl, m.selectedCaseID, target, m.activeAllowed)
-				if err != nil {
-					return err
-				}
-				return "Collection complete!"
-			}
+	case executing:
+		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "esc" && m.cancel != nil {
+			m.cancel()
+			m.cancel = nil
+			return m, nil
 		}
-		m.textInput, cmd = m.textInput.Update(msg)
-		return m, cmd
 
-	case result, executing:
+	case result:
 		if km, ok := msg.(tea.KeyMsg); ok && km.String() == "enter" {
 			m.state = selectCase
 			m.message = ""
@@ -126,35 +258,54 @@ func (m runnerModel) Update(msg tea.Msg) (runnerModel, tea.Cmd) {
 		}
 	}
 
-	switch msg := msg.(type) {
-	case error:
-		m.err = msg
-		m.state = result
-	case string:
-		m.message = msg
-		m.state = result
-	}
-
 	return m, nil
 }
 
+// execute starts the selected collector in the background and stores the
+// evidence it returns.
+func (m runnerModel) execute() (runnerModel, tea.Cmd) {
+	m.state = executing
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	name, caseID, target := m.selectedColl, m.selectedCaseID, m.target
+	opts := core.CollectOptions{
+		ActiveAllowed: m.activeAllowed,
+		EntityType:    m.targetType,
+	}
+	return m, func() tea.Msg {
+		defer cancel()
+		evidenceList, err := collector.Run(ctx, name, caseID, target, opts)
+		if err != nil {
+			return collectionDoneMsg{err: err}
+		}
+		for _, ev := range evidenceList {
+			if err := storage.CreateEvidence(&ev); err != nil {
+				return collectionDoneMsg{err: fmt.Errorf("failed to save evidence: %w", err)}
+			}
+			if err := storage.IngestEvidence(&ev); err != nil {
+				log.Warn().Err(err).Str("collector", name).Msg("Ingestion failed")
+			}
+		}
+		return collectionDoneMsg{evidence: len(evidenceList)}
+	}
+}
+
 func (m runnerModel) View() string {
 	switch m.state {
 	case selectCase:
 		return m.caseList.View()
-	case selectCollector:
-		return m.collList.View()
+	case selectEntity:
+		return m.entityList.View() + "\n(esc: back)"
 	case inputTarget:
-		status := "SAFE"
-		if m.activeAllowed {
-			status = "ACTIVE/DANGEROUS"
-		}
 		return fmt.Sprintf(
-			"Running %s for case %s\nMode: %s\n\nEnter target:\n\n%s\n\n(enter: run • esc: cancel)",
-			m.selectedColl, m.selectedCaseID, status, m.textInput.View(),
+			"New target for case %s\n\nEnter target:\n\n%s\n\n(enter: continue • esc: cancel)",
+			m.selectedCaseID, m.textInput.View(),
 		)
+	case selectCollector:
+		return fmt.Sprintf("Target: %s (%s)\n\n%s\n(esc: back)", m.target, m.targetType, m.collList.View())
 	case executing:
-		return fmt.Sprintf("Running %s against %s... Please wait.", m.selectedColl, m.textInput.Value())
+		return fmt.Sprintf("Running %s against %s... Please wait.\n\n(esc: cancel)", m.selectedColl, m.target)
 	case result:
 		if m.err != nil {
 			return fmt.Errorf("Error: %v\n\n(enter: continue)", m.err).Error()
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spectre/spectre/internal/collector"
+	"github.com/spectre/spectre/internal/core"
+)
+
+// blockingCollector runs until its context is cancelled.
+type blockingCollector struct{}
+
+func (c *blockingCollector) Name() string        { return "test_tui_blocking" }
+func (c *blockingCollector) Description() string { return "blocks until cancelled" }
+func (c *blockingCollector) IsActive() bool      { return false }
+func (c *blockingCollector) Accepts() []string   { return []string{core.EntityDomain} }
+func (c *blockingCollector) Produces() []string  { return nil }
+
+func (c *blockingCollector) Collect(caseID string, target string) ([]core.Evidence, error) {
+	return c.CollectContext(context.Background(), caseID, target, core.CollectOptions{})
+}
+
+func (c *blockingCollector) CollectContext(ctx context.Context, caseID string, target string, opts core.CollectOptions) ([]core.Evidence, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func init() {
+	collector.Register(&blockingCollector{})
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func send(t *testing.T, m model, msg tea.Msg) (model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return next.(model), cmd
+}
+
+func collectorNames(m runnerModel) []string {
+	var names []string
+	for _, i := range m.collList.Items() {
+		names = append(names, i.(item).title)
+	}
+	return names
+}
+
+func TestRunner_FiltersCollectorsByEntityType(t *testing.T) {
+	r := NewRunnerModel()
+
+	r.targetType = core.EntityIP
+	r.filterCollectors()
+	if names := strings.Join(collectorNames(r), ","); strings.Contains(names, "test_tui_blocking") {
+		t.Errorf("Domain-only collector offered for an IP: %s", names)
+	}
+
+	r.targetType = core.EntityDomain
+	r.filterCollectors()
+	if names := strings.Join(collectorNames(r), ","); !strings.Contains(names, "test_tui_blocking") {
+		t.Errorf("Expected the domain collector to be offered, got %s", names)
+	}
+}
+
+func TestApp_RunnerCancel(t *testing.T) {
+	m := InitialModel()
+	m, _ = send(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyLeft})
+	for i := 0; i < int(ViewCollect); i++ {
+		m, _ = send(t, m, key("j"))
+	}
+	m, _ = send(t, m, key("enter"))
+	m, _ = send(t, m, tea.KeyMsg{Type: tea.KeyRight})
+	if m.state != ViewCollect {
+		t.Fatalf("Expected the sidebar to open the collect view, got %v", m.state)
+	}
+
+	// Skip case and entity selection, which need a database
+	m.runner.selectedCaseID = "case-tui"
+	m.runner.state = inputTarget
+
+	// View shortcuts and q are typed into the target, not acted on
+	m, _ = send(t, m, key("q1.example.test"))
+	if m.quitting || m.state != ViewCollect {
+		t.Fatal("Typing a target triggered app shortcuts")
+	}
+	m, _ = send(t, m, key("enter"))
+	if m.runner.state != selectCollector || m.runner.target != "q1.example.test" || m.runner.targetType != core.EntityDomain {
+		t.Fatalf("Expected the collector list for the typed domain, got state %v target %q (%s)", m.runner.state, m.runner.target, m.runner.targetType)
+	}
+	for i, name := range collectorNames(m.runner) {
+		if name == "test_tui_blocking" {
+			m.runner.collList.Select(i)
+		}
+	}
+
+	m, cmd := send(t, m, key("enter"))
+	if m.runner.state != executing || cmd == nil {
+		t.Fatalf("Expected the collection to start, got state %v", m.runner.state)
+	}
+	done := make(chan tea.Msg, 1)
+	go func() { done <- cmd() }()
+
+	m, _ = send(t, m, key("esc"))
+	var msg tea.Msg
+	select {
+	case msg = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("esc did not cancel the running collector")
+	}
+
+	m, _ = send(t, m, msg)
+	if m.runner.state != result || m.runner.err != nil || !strings.Contains(m.View(), "Collection cancelled") {
+		t.Errorf("Expected a cancelled result, got state %v err %v message %q", m.runner.state, m.runner.err, m.runner.message)
+	}
+}